@@ -0,0 +1,58 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ivch/dynasty/server/handlers/auth/token"
+)
+
+const jwtKeyCmd = "jwt-key"
+
+// generateJWTKey writes the new signing key of the access tokens to the keys dir and prints its ID, e.g.
+//
+//	app jwt-key -dir /etc/dynasty/jwt -alg EdDSA
+func generateJWTKey(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet(jwtKeyCmd, flag.ContinueOnError)
+	fs.SetOutput(out)
+
+	dir := fs.String("dir", ".", "directory of the keys, AUTH_JWT_KEYS_DIR")
+	alg := fs.String("alg", token.AlgEdDSA, "signing algorithm, EdDSA or RS256")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	key, err := token.GenerateKey(*alg)
+	if err != nil {
+		return err
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	kid := time.Now().UTC().Format("20060102") + "-" + hex.EncodeToString(suffix)
+
+	f, err := os.OpenFile(filepath.Join(*dir, kid+".pem"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(key); err != nil {
+		f.Close() // nolint: errcheck,gosec
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(out, kid)
+	return err
+}
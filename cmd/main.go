@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
@@ -22,26 +23,73 @@ import (
 	clientUsers "github.com/ivch/dynasty/common/clients/users"
 	"github.com/ivch/dynasty/common/email"
 	"github.com/ivch/dynasty/common/logger"
+	"github.com/ivch/dynasty/common/sms"
+	"github.com/ivch/dynasty/common/storage"
+	"github.com/ivch/dynasty/common/telegram"
+	"github.com/ivch/dynasty/common/webpush"
 	"github.com/ivch/dynasty/config"
 	"github.com/ivch/dynasty/server"
 	svcAuth "github.com/ivch/dynasty/server/handlers/auth"
 	repoAuth "github.com/ivch/dynasty/server/handlers/auth/repo"
+	"github.com/ivch/dynasty/server/handlers/auth/token"
 	transportAuth "github.com/ivch/dynasty/server/handlers/auth/transport"
+	svcBot "github.com/ivch/dynasty/server/handlers/bot"
+	repoBot "github.com/ivch/dynasty/server/handlers/bot/repo"
+	transportBot "github.com/ivch/dynasty/server/handlers/bot/transport"
 	svcDict "github.com/ivch/dynasty/server/handlers/dictionaries"
 	repoDict "github.com/ivch/dynasty/server/handlers/dictionaries/repo"
 	transportDict "github.com/ivch/dynasty/server/handlers/dictionaries/transport"
 	"github.com/ivch/dynasty/server/handlers/health"
+	svcNotif "github.com/ivch/dynasty/server/handlers/notifications"
+	repoNotif "github.com/ivch/dynasty/server/handlers/notifications/repo"
+	transportNotif "github.com/ivch/dynasty/server/handlers/notifications/transport"
+	svcOutbox "github.com/ivch/dynasty/server/handlers/outbox"
+	repoOutbox "github.com/ivch/dynasty/server/handlers/outbox/repo"
+	transportOutbox "github.com/ivch/dynasty/server/handlers/outbox/transport"
 	svcReqs "github.com/ivch/dynasty/server/handlers/requests"
 	repoReqs "github.com/ivch/dynasty/server/handlers/requests/repo"
 	transportReqs "github.com/ivch/dynasty/server/handlers/requests/transport"
 	transportUI "github.com/ivch/dynasty/server/handlers/ui/transport"
 	svcUsers "github.com/ivch/dynasty/server/handlers/users"
+	"github.com/ivch/dynasty/server/handlers/users/password"
 	repoUsers "github.com/ivch/dynasty/server/handlers/users/repo"
 	transportUsers "github.com/ivch/dynasty/server/handlers/users/transport"
 )
 
+const (
+	approvalsExpiryInterval = 10 * time.Second
+	imageJobsPollInterval   = 5 * time.Second
+	outboxPollInterval      = 2 * time.Second
+	outboxPruneInterval     = time.Hour
+	loginAttemptsCleanup    = 10 * time.Minute
+	sessionsCleanupInterval = time.Hour
+	telegramPollTimeout     = 25 * time.Second
+	defaultImageWorkers     = 2
+)
+
 // nolint: funlen
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == smsGatewayCmd {
+		if err := runSMSGateway(os.Args[2:], os.Stderr); err != nil {
+			stdLog.Fatalf("sms gateway failed: %s", err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == jwtKeyCmd {
+		if err := generateJWTKey(os.Args[2:], os.Stdout); err != nil {
+			stdLog.Fatalf("failed to generate jwt key: %s", err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == vapidKeysCmd {
+		if err := printVAPIDKeys(os.Stdout); err != nil {
+			stdLog.Fatalf("failed to generate vapid keys: %s", err)
+		}
+		return
+	}
+
 	if _, err := os.Stat(".env"); !os.IsNotExist(err) {
 		if err := godotenv.Load(".env"); err != nil {
 			stdLog.Fatal("error loading .env file:" + err.Error())
@@ -59,38 +107,109 @@ func main() {
 	}
 
 	log := logger.NewStdLog(logger.WithLevel(lvl))
-	db, err := gorm.Open("postgres", fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		cfg.DB.Host, cfg.DB.Port, cfg.User, cfg.Password, cfg.Database, cfg.SSL))
+	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		cfg.DB.Host, cfg.DB.Port, cfg.User, cfg.Password, cfg.Database, cfg.SSL)
+	db, err := gorm.Open("postgres", dsn)
 	if err != nil {
 		stdLog.Fatalf("cannot connect to db: %s", err.Error())
 	}
 
-	s3Config := &aws.Config{
-		Credentials: credentials.NewStaticCredentials(cfg.Key, cfg.Secret, ""),
-		Endpoint:    aws.String(cfg.Endpoint),
-		Region:      aws.String(cfg.Region),
+	store, storageHandler, err := newStorage(cfg)
+	if err != nil {
+		stdLog.Fatalf("cannot init storage: %s", err)
 	}
+	p := bluemonday.StrictPolicy()
 
-	newSession, err := session.NewSession(s3Config)
+	emailTemplates, err := email.LoadTemplates(cfg.TplPath)
 	if err != nil {
-		stdLog.Fatalf("cannot start DO session: %s", err)
+		stdLog.Fatalf("failed to load email templates: %s", err)
 	}
-	s3Client := s3.New(newSession)
-	p := bluemonday.StrictPolicy()
+	mailOpts, mailHandler, err := newMailTransport(cfg)
+	if err != nil {
+		stdLog.Fatalf("cannot init mail transport: %s", err)
+	}
+	mailSender := email.New(emailTemplates, cfg.SMTP.Host, cfg.SMTP.Port, cfg.Pass, cfg.From, mailOpts...)
 
-	mailSender := email.New(cfg.TplPath, cfg.SMTP.Host, cfg.SMTP.Port, cfg.Pass, cfg.From)
+	notifSvc := svcNotif.New(log, repoNotif.New(db), notificationChannels(cfg, mailSender)...)
+	notifTransport := transportNotif.NewHTTPTransport(log, notifSvc)
 
 	healthChecker := health.NewMultiChecker()
 	healthTransport := health.NewHTTPTransport(healthChecker)
 
-	userService := svcUsers.New(log, repoUsers.New(db), cfg.VerifyRegCode, cfg.MembersLimit, mailSender)
-	usersTransport := transportUsers.NewHTTPTransport(log, userService, p)
-	authService := svcAuth.New(log, repoAuth.New(db), clientUsers.New(userService), cfg.JWTSecret)
-	authTransport := transportAuth.NewHTTPTransport(log, authService)
 	dictService := svcDict.New(log, repoDict.New(db))
 	dictTransport := transportDict.NewHTTPTransport(log, dictService)
-	reqsSvc := svcReqs.New(log, repoReqs.New(db), s3Client, cfg.S3SpaceName, cfg.CDNHost)
+	imageVariants, err := svcReqs.ParseImageVariants(cfg.ImageVariants)
+	if err != nil {
+		stdLog.Fatalf("failed to parse image variants: %s", err)
+	}
+	reqsOpts := []svcReqs.Option{
+		svcReqs.WithApprovalTTL(cfg.ApprovalTTL),
+		svcReqs.WithAttachmentLimits(cfg.AttachmentLimits),
+		svcReqs.WithMaxImageDimension(cfg.MaxImageDimension),
+		svcReqs.WithImageVariants(imageVariants),
+		svcReqs.WithNotifier(notifSvc),
+		svcReqs.WithApprovalEvents(repoReqs.NewApprovalEvents(db, dsn)),
+	}
+	if cfg.PrivateImages {
+		reqsOpts = append(reqsOpts, svcReqs.WithPrivateImages(cfg.ImageURLTTL))
+	}
+	avatars := svcReqs.NewAvatars(log, store, cfg.CDNHost, reqsOpts...)
+	loginGuard := svcAuth.NewLoginGuard(log, loginAttempts(cfg, db), svcAuth.LoginLimits{
+		MaxFailures:   cfg.LoginMaxFailures,
+		IPMaxFailures: cfg.LoginIPMaxFailures,
+		LockoutTTL:    cfg.LoginLockoutTTL,
+	})
+	passwords, err := password.New(password.Params{
+		Algorithm:     cfg.PasswordHash,
+		BcryptCost:    cfg.PasswordBcryptCost,
+		Argon2Time:    cfg.PasswordArgon2Time,
+		Argon2Memory:  cfg.PasswordArgon2Memory,
+		Argon2Threads: uint8(cfg.PasswordArgon2Threads), // #nosec G115 -- validated by the config
+	})
+	if err != nil {
+		stdLog.Fatalf("failed to init password hashing: %s", err)
+	}
+	userService := svcUsers.New(log, repoUsers.New(db), cfg.VerifyRegCode, cfg.MembersLimit, mailSender,
+		svcUsers.WithAvatars(avatars), svcUsers.WithNotifier(notifSvc), svcUsers.WithLoginUnlocker(loginGuard),
+		svcUsers.WithPasswordHasher(passwords))
+	usersTransport := transportUsers.NewHTTPTransport(log, userService, p)
+	authOpts, err := authKeys(cfg)
+	if err != nil {
+		stdLog.Fatalf("failed to load jwt keys: %s", err)
+	}
+	authService := svcAuth.New(log, repoAuth.New(db), clientUsers.New(userService), cfg.JWTSecret,
+		append(authOpts, svcAuth.WithNotifier(notifSvc),
+			svcAuth.WithLoginGuard(loginGuard),
+			svcAuth.WithAccessTokenTTL(cfg.AccessTokenTTL),
+			svcAuth.WithLegacyClients(svcAuth.LegacyClients{
+				UserAgents: cfg.LegacyUserAgents,
+				MinVersion: cfg.LegacyMinVersion,
+				TokenTTL:   cfg.LegacyTokenTTL,
+			}))...)
+	authTransport := transportAuth.NewHTTPTransport(log, authService)
+	reqsSvc := svcReqs.New(log, repoReqs.New(db), userService, store, cfg.CDNHost, reqsOpts...)
 	reqsTransport := transportReqs.NewHTTPTransport(log, reqsSvc, p)
+	outboxSvc := svcOutbox.New(log, repoOutbox.New(db), userService,
+		svcOutbox.WithHandler(svcUsers.TopicRecoveryEmail, userService.SendRecoveryEmail),
+		svcOutbox.WithHandler(svcNotif.TopicEvent, notifSvc.HandleEvent),
+		svcOutbox.WithHandler(svcNotif.TopicDelivery, notifSvc.HandleDelivery),
+		svcOutbox.WithHandler(svcNotif.TopicGuardEvent, notifSvc.HandleGuardEvent),
+	)
+	outboxTransport := transportOutbox.NewHTTPTransport(log, outboxSvc)
+
+	// only one instance may poll the bot updates, so the bot is enabled separately from the channel
+	var botSvc *svcBot.Service
+	if cfg.TelegramBotToken != "" && cfg.TelegramBotPolling {
+		bot := telegram.New(cfg.TelegramAPIURL, cfg.TelegramBotToken)
+		botSvc = svcBot.New(log, repoBot.New(db), bot, reqsSvc, notifSvc, p)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == reconcileImagesCmd {
+		if err := reconcileImages(context.Background(), reqsSvc, os.Args[2:], os.Stdout); err != nil {
+			stdLog.Fatalf("failed to reconcile images: %s", err)
+		}
+		return
+	}
 	uiTransport := transportUI.NewHTTPHandler(cfg.APIHost, cfg.PageURI, cfg.PagerLimit)
 
 	signals := make(chan os.Signal, 1)
@@ -102,17 +221,64 @@ func main() {
 		log.Info("shutdown signal '%s' received! Bye!", sig)
 	}()
 
-	srv, err := server.New(
-		":"+cfg.HTTPPort,
-		log,
-		map[string]http.Handler{
-			"/health":     healthTransport,
-			"/users":      usersTransport,
-			"/auth":       authTransport,
-			"/dictionary": dictTransport,
-			"/requests":   reqsTransport,
-			"/ui":         uiTransport,
+	go reqsSvc.RunApprovalsExpiry(ctx, approvalsExpiryInterval)
+	go reqsSvc.RunApprovalEvents(ctx)
+	go loginGuard.RunCleanup(ctx, loginAttemptsCleanup)
+	go outboxSvc.RunPruning(ctx, outboxPruneInterval)
+	go authService.RunSessionsCleanup(ctx, sessionsCleanupInterval)
+
+	outboxDone := make(chan struct{})
+	go func() {
+		outboxSvc.Run(ctx, outboxPollInterval)
+		close(outboxDone)
+	}()
+
+	if botSvc != nil {
+		go botSvc.Run(ctx, telegramPollTimeout)
+	}
+
+	imageWorkers := cfg.ImageWorkers
+	if imageWorkers == 0 {
+		imageWorkers = defaultImageWorkers
+	}
+	if cfg.ImageGCInterval > 0 {
+		minAge := cfg.ImageGCMinAge
+		if minAge == 0 {
+			minAge = svcReqs.DefaultOrphanMinAge
+		}
+		go reqsSvc.RunImageReconciliation(ctx, cfg.ImageGCInterval, svcReqs.ReconcileOptions{
+			DeleteOrphans: cfg.ImageGCDelete,
+			MinAge:        minAge,
 		})
+	}
+
+	imageWorkersDone := make(chan struct{})
+	go func() {
+		reqsSvc.RunImageWorkers(ctx, imageWorkers, imageJobsPollInterval)
+		close(imageWorkersDone)
+	}()
+
+	handlers := map[string]http.Handler{
+		"/health":        healthTransport,
+		"/users":         usersTransport,
+		"/auth":          authTransport,
+		"/dictionary":    dictTransport,
+		"/requests":      reqsTransport,
+		"/ui":            uiTransport,
+		"/notifications": notifTransport,
+		"/outbox":        outboxTransport,
+	}
+	if storageHandler != nil {
+		handlers["/storage"] = storageHandler
+	}
+	if botSvc != nil {
+		handlers["/telegram"] = transportBot.NewHTTPTransport(log, botSvc)
+	}
+	if mailHandler != nil {
+		handlers["/debug/mail"] = mailHandler
+	}
+
+	srv, err := server.New(":"+cfg.HTTPPort, log, handlers)
 	if err != nil {
 		stdLog.Fatal(fmt.Errorf("failed to create server: %w", err))
 	}
@@ -121,4 +287,97 @@ func main() {
 	if err := srv.Serve(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		stdLog.Fatal(fmt.Errorf("server failed: %w", err))
 	}
+
+	log.Info("waiting for image jobs and outbox messages in progress")
+	<-imageWorkersDone
+	<-outboxDone
+}
+
+// notificationChannels enables email and the configured optional channels.
+func notificationChannels(cfg *config.Config, mailer svcNotif.Mailer) []svcNotif.Option {
+	opts := []svcNotif.Option{svcNotif.WithChannel(svcNotif.ChannelEmail, svcNotif.NewEmailChannel(mailer))}
+	if cfg.SMSGatewayURL != "" {
+		opts = append(opts, svcNotif.WithChannel(svcNotif.ChannelSMS, svcNotif.NewTextChannel(sms.New(cfg.SMSGatewayURL))))
+	}
+	if cfg.TelegramBotToken != "" {
+		bot := telegram.New(cfg.TelegramAPIURL, cfg.TelegramBotToken)
+		opts = append(opts, svcNotif.WithChannel(svcNotif.ChannelTelegram, svcNotif.NewTextChannel(bot)))
+	}
+	if cfg.WebPushPublicKey != "" && cfg.WebPushPrivateKey != "" {
+		subscriber := cfg.WebPushSubscriber
+		if subscriber == "" {
+			subscriber = cfg.From
+		}
+		push := webpush.New(cfg.WebPushPublicKey, cfg.WebPushPrivateKey, subscriber)
+		opts = append(opts,
+			svcNotif.WithChannel(svcNotif.ChannelWebPush, svcNotif.NewWebPushChannel(push)),
+			svcNotif.WithWebPushKey(cfg.WebPushPublicKey),
+		)
+	}
+	return opts
+}
+
+// authKeys loads the asymmetric keys of the tokens, without them the tokens are signed with the secret.
+func authKeys(cfg *config.Config) ([]svcAuth.Option, error) {
+	if cfg.JWTKeysDir == "" {
+		return nil, nil
+	}
+
+	keys, err := token.LoadKeys(cfg.JWTKeysDir, cfg.JWTSigningKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return []svcAuth.Option{svcAuth.WithKeys(keys)}, nil
+}
+
+// loginAttempts keeps the failed logins in the database, so the lockouts hold across the instances.
+func loginAttempts(cfg *config.Config, db *gorm.DB) svcAuth.LoginAttempts {
+	if cfg.LoginAttemptsStore == svcAuth.LoginAttemptsMemory {
+		return repoAuth.NewMemoryLoginAttempts()
+	}
+	return repoAuth.NewLoginAttempts(db)
+}
+
+// newMailTransport configures where the mail goes. The memory transport comes with
+// the handler listing the captured messages, which is mounted to the backend.
+func newMailTransport(cfg *config.Config) ([]email.Option, http.Handler, error) {
+	opts := []email.Option{email.WithSecurity(cfg.Security), email.WithFromName(cfg.FromName)}
+
+	switch cfg.SMTP.Transport {
+	case email.TransportDir:
+		dir, err := email.NewDir(cfg.CaptureDir)
+		if err != nil {
+			return nil, nil, err
+		}
+		return append(opts, email.WithTransport(dir)), nil, nil
+	case email.TransportMemory:
+		memory := email.NewMemory(cfg.CaptureLimit)
+		return append(opts, email.WithTransport(memory)), memory.Handler(), nil
+	}
+
+	return opts, nil, nil
+}
+
+// newStorage creates the configured blob storage. The local one comes with
+// the handler serving its files, which is mounted to the backend.
+func newStorage(cfg *config.Config) (svcReqs.Storage, http.Handler, error) {
+	if cfg.Driver == storage.DriverLocal {
+		local, err := storage.NewLocal(cfg.LocalPath, cfg.LocalURL, []byte(cfg.LocalSecret))
+		if err != nil {
+			return nil, nil, err
+		}
+		return local, local.Handler(), nil
+	}
+
+	newSession, err := session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials(cfg.Key, cfg.Secret, ""),
+		Endpoint:    aws.String(cfg.Endpoint),
+		Region:      aws.String(cfg.Region),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot start DO session: %w", err)
+	}
+
+	return storage.NewS3(s3.New(newSession), cfg.S3SpaceName), nil, nil
 }
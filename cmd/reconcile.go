@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	svcReqs "github.com/ivch/dynasty/server/handlers/requests"
+)
+
+const reconcileImagesCmd = "reconcile-images"
+
+// reconcileImages runs the images reconciliation once and prints the report, e.g.
+//
+//	app reconcile-images -delete -min-age 48h
+func reconcileImages(ctx context.Context, svc *svcReqs.Service, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet(reconcileImagesCmd, flag.ContinueOnError)
+	fs.SetOutput(out)
+
+	var opts svcReqs.ReconcileOptions
+	fs.BoolVar(&opts.DeleteOrphans, "delete", false, "delete the orphaned files, otherwise they are only reported")
+	fs.DurationVar(&opts.MinAge, "min-age", svcReqs.DefaultOrphanMinAge, "min age of the orphaned files to be deleted")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	report, err := svc.ReconcileImages(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range report.Orphans {
+		fmt.Fprintln(out, "orphan:", key) // nolint: errcheck
+	}
+	for _, key := range report.MissingVariants {
+		fmt.Fprintln(out, "missing variant:", key) // nolint: errcheck
+	}
+	for _, key := range report.MissingImages {
+		fmt.Fprintln(out, "missing image:", key) // nolint: errcheck
+	}
+	fmt.Fprintln(out, report) // nolint: errcheck
+
+	return nil
+}
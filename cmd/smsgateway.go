@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ivch/dynasty/common/logger"
+	"github.com/ivch/dynasty/common/sms"
+)
+
+const smsGatewayCmd = "sms-gateway"
+
+// runSMSGateway serves the stand-in SMS gateway which logs the messages instead of sending them, e.g.
+//
+//	app sms-gateway -addr :9002
+func runSMSGateway(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet(smsGatewayCmd, flag.ContinueOnError)
+	fs.SetOutput(out)
+
+	addr := fs.String("addr", ":9002", "address to listen on")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	log := logger.NewStdLog()
+	log.Info("sms gateway stand-in listens on %s", *addr)
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           sms.StandIn(log),
+		ReadHeaderTimeout: 2 * time.Second,
+	}
+
+	return srv.ListenAndServe()
+}
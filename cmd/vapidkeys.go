@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ivch/dynasty/common/webpush"
+)
+
+const vapidKeysCmd = "vapid-keys"
+
+// printVAPIDKeys prints the new key pair for the Web Push in the env format, e.g.
+//
+//	app vapid-keys >> .env
+func printVAPIDKeys(out io.Writer) error {
+	public, private, err := webpush.GenerateKeys()
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(out, "NOTIFY_WEBPUSH_PUBLIC_KEY=%s\nNOTIFY_WEBPUSH_PRIVATE_KEY=%s\n", public, private)
+	return err
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/ivch/dynasty/common/errs"
 	"github.com/ivch/dynasty/server/handlers/users"
 	"github.com/ivch/dynasty/server/handlers/users/transport"
 )
@@ -56,7 +57,10 @@ func (c *Client) UserByPhoneAndPassword(ctx context.Context, phone, password str
 
 	res, err := c.svc.UserByPhoneAndPassword(ctx, phone, password)
 	if err != nil {
-		return nil, errors.New("user with given credentials not found")
+		if errors.Is(err, errs.InvalidCredentials) || errors.Is(err, errs.UserNotFound) {
+			return nil, errs.InvalidCredentials
+		}
+		return nil, fmt.Errorf("failed to get user by phone: %w", err)
 	}
 
 	return &transport.UserByIDResponse{
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	usersClient "github.com/ivch/dynasty/common/clients/users"
+	"github.com/ivch/dynasty/common/errs"
 	"github.com/ivch/dynasty/server/handlers/users"
 	"github.com/ivch/dynasty/server/handlers/users/transport"
 )
@@ -106,6 +107,17 @@ func TestClient_UserByPhoneAndPassword(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name:     "error wrong password",
+			phone:    "1",
+			password: "1",
+			usrv: &usersClient.UserServiceMock{
+				UserByPhoneAndPasswordFunc: func(_ context.Context, _ string, _ string) (*users.User, error) {
+					return nil, errs.InvalidCredentials
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name:     "ok",
 			phone:    "1",
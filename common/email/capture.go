@@ -0,0 +1,270 @@
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/http"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// DefaultCaptureLimit is how many recent messages Memory keeps by default.
+	DefaultCaptureLimit = 100
+
+	captureDirPermission = 0o750
+)
+
+// Dir captures the mail instead of sending it: every message is written to the
+// directory as the .eml file, which opens in any mail client.
+type Dir struct {
+	dir string
+}
+
+func NewDir(dir string) (*Dir, error) {
+	if err := os.MkdirAll(dir, captureDirPermission); err != nil {
+		return nil, fmt.Errorf("failed to create mail capture dir: %w", err)
+	}
+
+	return &Dir{dir: dir}, nil
+}
+
+// Send writes the message to the file named by the time it was sent, so the files sort
+// chronologically. The file is renamed into place when it is complete.
+func (d *Dir) Send(_ string, _ []string, msg []byte) error {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+
+	name := time.Now().UTC().Format("20060102T150405.000000000") + "-" + hex.EncodeToString(suffix) + ".eml"
+
+	tmp, err := os.CreateTemp(d.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // nolint: errcheck
+
+	if _, err := tmp.Write(msg); err != nil {
+		tmp.Close() // nolint: errcheck
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(d.dir, name))
+}
+
+// Captured is the message kept by Memory.
+type Captured struct {
+	ID      string    `json:"id"`
+	From    string    `json:"from"`
+	To      []string  `json:"to"`
+	Subject string    `json:"subject"`
+	Date    time.Time `json:"date"`
+	Text    string    `json:"text,omitempty"`
+	HTML    string    `json:"html,omitempty"`
+
+	raw []byte
+}
+
+// Memory captures the mail instead of sending it and keeps the recent messages,
+// which are listed by Handler, e.g. to get the recovery code in the integration tests.
+type Memory struct {
+	mu       sync.Mutex
+	limit    int
+	seq      int
+	messages []*Captured
+}
+
+// NewMemory returns the transport keeping up to limit recent messages, DefaultCaptureLimit if it is not positive.
+func NewMemory(limit int) *Memory {
+	if limit <= 0 {
+		limit = DefaultCaptureLimit
+	}
+
+	return &Memory{limit: limit}
+}
+
+func (m *Memory) Send(from string, to []string, msg []byte) error {
+	c, err := parseCaptured(msg)
+	if err != nil {
+		return err
+	}
+	c.From = from
+	c.To = append([]string(nil), to...)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.seq++
+	c.ID = strconv.Itoa(m.seq)
+	m.messages = append(m.messages, c)
+	if len(m.messages) > m.limit {
+		m.messages = append([]*Captured(nil), m.messages[len(m.messages)-m.limit:]...)
+	}
+
+	return nil
+}
+
+// Messages returns the captured messages, the newest first. With to only the ones sent to the address.
+func (m *Memory) Messages(to string) []*Captured {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	res := make([]*Captured, 0, len(m.messages))
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if to == "" || contains(m.messages[i].To, to) {
+			res = append(res, m.messages[i])
+		}
+	}
+
+	return res
+}
+
+// Reset drops the captured messages.
+func (m *Memory) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = nil
+}
+
+func (m *Memory) message(id string) *Captured {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, c := range m.messages {
+		if c.ID == id {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// Handler serves the captured messages: GET / lists them, optionally ?to=address,
+// GET /{id} returns the raw message and DELETE / drops them all.
+// It exposes whatever was sent, so it must not be reachable in production.
+func (m *Memory) Handler() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", m.list)
+	r.Delete("/", m.reset)
+	r.Get("/{id}", m.raw)
+	return r
+}
+
+func (m *Memory) list(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m.Messages(r.URL.Query().Get("to"))) // nolint: errcheck
+}
+
+func (m *Memory) reset(w http.ResponseWriter, _ *http.Request) {
+	m.Reset()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (m *Memory) raw(w http.ResponseWriter, r *http.Request) {
+	c := m.message(chi.URLParam(r, "id"))
+	if c == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "message/rfc822")
+	w.Write(c.raw) // nolint: errcheck
+}
+
+// parseCaptured reads the subject, the date and the text and HTML parts of the message.
+func parseCaptured(raw []byte) (*Captured, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		return nil, err
+	}
+
+	c := Captured{Subject: subject, raw: raw}
+	if date, err := msg.Header.Date(); err == nil {
+		c.Date = date
+	}
+
+	body := msg.Body
+	if strings.EqualFold(msg.Header.Get("Content-Transfer-Encoding"), "quoted-printable") {
+		body = quotedprintable.NewReader(body)
+	}
+
+	if err := c.readPart(msg.Header.Get("Content-Type"), body); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// readPart keeps the first text and HTML parts found in the entity, the nested multiparts included.
+func (c *Captured) readPart(contentType string, body io.Reader) error {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/"):
+		r := multipart.NewReader(body, params["boundary"])
+		for {
+			p, err := r.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			if strings.HasPrefix(p.Header.Get("Content-Disposition"), "attachment") {
+				continue
+			}
+
+			if err := c.readPart(p.Header.Get("Content-Type"), p); err != nil {
+				return err
+			}
+		}
+	case mediaType == "text/plain" && c.Text == "":
+		b, err := io.ReadAll(body)
+		c.Text = string(b)
+		return err
+	case mediaType == "text/html" && c.HTML == "":
+		b, err := io.ReadAll(body)
+		c.HTML = string(b)
+		return err
+	}
+
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package email_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ivch/dynasty/common/email"
+)
+
+func TestDir_Send(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	transport, err := email.NewDir(dir)
+	if err != nil {
+		t.Fatalf("NewDir() error = %v", err)
+	}
+
+	e := email.New(nil, "", "", "", "noreply@example.com", email.WithTransport(transport))
+	for _, to := range []string{"john@example.com", "jane@example.com"} {
+		if err := e.SendEmail(to, "Hi", "text", ""); err != nil {
+			t.Fatalf("SendEmail() error = %v", err)
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("files = %v, want 2 .eml files", files)
+	}
+
+	for i, want := range []string{"<john@example.com>", "<jane@example.com>"} {
+		if filepath.Ext(files[i]) != ".eml" {
+			t.Errorf("file = %s, want .eml", files[i])
+		}
+
+		f, err := os.Open(files[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg, err := mail.ReadMessage(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("ReadMessage() error = %v", err)
+		}
+
+		if to := msg.Header.Get("To"); to != want {
+			t.Errorf("To = %q, want %q", to, want)
+		}
+	}
+}
+
+func TestMemory_Send(t *testing.T) {
+	tpls, err := email.LoadTemplates("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	memory := email.NewMemory(2)
+	e := email.New(tpls, "", "", "", "noreply@example.com", email.WithTransport(memory))
+
+	for i, to := range []string{"john@example.com", "jane@example.com", "john@example.com"} {
+		if err := e.SendRecoveryCodeEmail(to, "John", "code"+strconv.Itoa(i), email.LangEN); err != nil {
+			t.Fatalf("SendRecoveryCodeEmail() error = %v", err)
+		}
+	}
+
+	all := memory.Messages("")
+	if len(all) != 2 || all[0].ID != "3" || all[1].ID != "2" {
+		t.Fatalf("Messages() = %+v, want the last 2 newest first", all)
+	}
+
+	got := memory.Messages("JOHN@example.com")
+	if len(got) != 1 {
+		t.Fatalf("Messages(john) = %+v, want 1", got)
+	}
+
+	m := got[0]
+	if m.From != "noreply@example.com" || m.Subject == "" || m.Date.IsZero() {
+		t.Errorf("message = %+v", m)
+	}
+	if !strings.Contains(m.Text, "code2") || !strings.Contains(m.HTML, "code2") {
+		t.Errorf("message does not contain the code: %q, %q", m.Text, m.HTML)
+	}
+
+	memory.Reset()
+	if got := memory.Messages(""); len(got) != 0 {
+		t.Errorf("Messages() after Reset() = %+v", got)
+	}
+}
+
+func TestMemory_Handler(t *testing.T) {
+	memory := email.NewMemory(0)
+	e := email.New(nil, "", "", "", "noreply@example.com", email.WithTransport(memory))
+	if err := e.SendEmail("john@example.com", "Hi", "text", "<p>html</p>"); err != nil {
+		t.Fatal(err)
+	}
+
+	h := memory.Handler()
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "list", method: http.MethodGet, path: "/?to=john@example.com", wantStatus: http.StatusOK, wantBody: `"subject":"Hi"`},
+		{name: "list other", method: http.MethodGet, path: "/?to=jane@example.com", wantStatus: http.StatusOK, wantBody: "[]"},
+		{name: "raw", method: http.MethodGet, path: "/1", wantStatus: http.StatusOK, wantBody: "MIME-Version: 1.0"},
+		{name: "raw not found", method: http.MethodGet, path: "/2", wantStatus: http.StatusNotFound},
+		{name: "reset", method: http.MethodDelete, path: "/", wantStatus: http.StatusNoContent},
+		{name: "list after reset", method: http.MethodGet, path: "/", wantStatus: http.StatusOK, wantBody: "[]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want %s", w.Body.String(), tt.wantBody)
+			}
+			if strings.HasPrefix(tt.path, "/?") {
+				var list []*email.Captured
+				if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+					t.Errorf("bad list: %v", err)
+				}
+			}
+		})
+	}
+}
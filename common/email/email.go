@@ -1,74 +1,110 @@
 package email
 
 import (
-	"bytes"
-	"fmt"
 	"html"
-	"html/template"
-	"net/smtp"
+	"net/mail"
+	"time"
 )
 
-const (
-	mime = "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"
+type Email struct {
+	Templates *Templates
+	From      string
+	FromName  string
+	Transport Transport
+}
 
-	subjPasswordRecovery = "Восстановление пароля" // nolint: gosec
-	tplPasswordRecovery  = "passwordRecovery.html" // nolint: gosec
-)
+// Option configures optional Email parameters.
+type Option func(e *Email)
 
-type Email struct {
-	TplPath string
-	Host    string
-	Port    string
-	From    string
-	Pass    string
+// WithSecurity sets how the connection to the SMTP server is secured, SecurityStartTLS by default.
+func WithSecurity(security string) Option {
+	return func(e *Email) {
+		if t, ok := e.Transport.(*SMTP); ok && security != "" {
+			t.Security = security
+		}
+	}
 }
 
-func New(tplPath, host, port, pass, from string) *Email {
-	return &Email{
-		TplPath: tplPath,
-		Host:    host,
-		Port:    port,
-		From:    from,
-		Pass:    pass,
+// WithTimeout limits the whole session with the SMTP server, one minute by default.
+func WithTimeout(timeout time.Duration) Option {
+	return func(e *Email) {
+		if t, ok := e.Transport.(*SMTP); ok && timeout > 0 {
+			t.Timeout = timeout
+		}
 	}
 }
 
-func (e *Email) SendRecoveryCodeEmail(to, username, code string) error {
-	templateData := struct {
-		Username     string
-		RecoveryCode string
-	}{
-		Username:     html.UnescapeString(username),
-		RecoveryCode: code,
+// WithFromName sets the display name of the sender.
+func WithFromName(name string) Option {
+	return func(e *Email) {
+		e.FromName = name
+	}
+}
+
+// WithTransport replaces the SMTP server, e.g. with the capturing Dir or Memory.
+func WithTransport(t Transport) Option {
+	return func(e *Email) {
+		e.Transport = t
 	}
+}
 
-	tpl, err := e.parseTemplate(fmt.Sprintf("%s/%s", e.TplPath, tplPasswordRecovery), templateData)
+// New returns the sender of the mail through the SMTP server, where it authenticates
+// as from with pass. With the empty pass the mail is sent without authentication.
+func New(templates *Templates, host, port, pass, from string, opts ...Option) *Email {
+	e := Email{
+		Templates: templates,
+		From:      from,
+		Transport: &SMTP{
+			Host:     host,
+			Port:     port,
+			Username: from,
+			Pass:     pass,
+			Security: SecurityStartTLS,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(&e)
+	}
+
+	return &e
+}
+
+func (e *Email) SendRecoveryCodeEmail(to, username, code, lang string) error {
+	msg, err := e.Templates.Render(MessagePasswordRecovery, lang, PasswordRecoveryData{
+		Username:     html.UnescapeString(username),
+		RecoveryCode: code,
+	})
 	if err != nil {
 		return err
 	}
 
-	body := "To: " + to + "\r\nSubject: " + subjPasswordRecovery + "\r\n" + mime + "\r\n" + tpl
-
-	return e.send(to, body)
+	return e.SendEmail(to, msg.Subject, msg.Text, msg.HTML)
 }
 
-func (e *Email) send(to, body string) error {
-	var (
-		auth   = smtp.PlainAuth("", e.From, e.Pass, e.Host)
-		server = fmt.Sprintf("%s:%s", e.Host, e.Port)
-	)
-	return smtp.SendMail(server, auth, e.From, []string{to}, []byte(body))
+// SendEmail sends the message with the plain text and HTML alternatives, either of them may be empty.
+func (e *Email) SendEmail(to, subject, text, html string) error {
+	return e.Send(&Mail{
+		To:      []mail.Address{{Address: to}},
+		Subject: subject,
+		Text:    text,
+		HTML:    html,
+	})
 }
 
-func (e *Email) parseTemplate(filename string, data interface{}) (string, error) {
-	t, err := template.ParseFiles(filename)
+// Send sends the mail from the configured sender.
+func (e *Email) Send(m *Mail) error {
+	m.From = mail.Address{Name: e.FromName, Address: e.From}
+
+	data, err := m.Bytes()
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	buf := new(bytes.Buffer)
-	if err = t.Execute(buf, data); err != nil {
-		return "", err
+	to := make([]string, len(m.To))
+	for i := range m.To {
+		to[i] = m.To[i].Address
 	}
-	return buf.String(), nil
+
+	return e.Transport.Send(e.From, to, data)
 }
@@ -0,0 +1,159 @@
+package email_test
+
+import (
+	"bufio"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivch/dynasty/common/email"
+)
+
+// fakeSMTP accepts a single session without TLS and authentication and returns
+// the commands and the data it got.
+func fakeSMTP(t *testing.T) (host, port string, session <-chan []string) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	ch := make(chan []string, 1)
+	go func() {
+		var got []string
+		defer func() { ch <- got }()
+
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) } // nolint: errcheck
+
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			got = append(got, line)
+
+			switch cmd := strings.ToUpper(strings.Fields(line + " ")[0]); cmd {
+			case "EHLO":
+				reply("250 localhost")
+			case "DATA":
+				reply("354 go ahead")
+				var data []string
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if l == ".\r\n" {
+						break
+					}
+					data = append(data, l)
+				}
+				got = append(got, strings.Join(data, ""))
+				reply("250 queued")
+			case "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	host, port, _ = net.SplitHostPort(l.Addr().String())
+	return host, port, ch
+}
+
+func TestEmail_Send(t *testing.T) {
+	host, port, session := fakeSMTP(t)
+
+	e := email.New(nil, host, port, "", "noreply@example.com",
+		email.WithSecurity(email.SecurityNone),
+		email.WithFromName("Dynasty"),
+	)
+
+	if err := e.SendEmail("john@example.com", "Hi", "text", "<p>html</p>"); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+
+	got := <-session
+	if len(got) != 6 {
+		t.Fatalf("session = %q", got)
+	}
+
+	for i, want := range []string{"EHLO", "MAIL FROM:<noreply@example.com>", "RCPT TO:<john@example.com>", "DATA"} {
+		if !strings.HasPrefix(got[i], want) {
+			t.Errorf("command %d = %q, want %q", i, got[i], want)
+		}
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(got[4]))
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+
+	if from := msg.Header.Get("From"); from != `"Dynasty" <noreply@example.com>` {
+		t.Errorf("From = %q", from)
+	}
+
+	if ct := msg.Header.Get("Content-Type"); !strings.HasPrefix(ct, "multipart/alternative") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+}
+
+func TestEmail_Send_StartTLSUnsupported(t *testing.T) {
+	host, port, _ := fakeSMTP(t)
+
+	e := email.New(nil, host, port, "", "noreply@example.com")
+
+	err := e.SendEmail("john@example.com", "Hi", "text", "")
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Errorf("SendEmail() error = %v, want STARTTLS error", err)
+	}
+}
+
+func TestEmail_Send_ServerStalls(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	// accept the connection and never greet
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			t.Cleanup(func() { conn.Close() })
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+	e := email.New(nil, host, port, "", "noreply@example.com",
+		email.WithSecurity(email.SecurityNone),
+		email.WithTimeout(100*time.Millisecond),
+	)
+
+	done := make(chan error, 1)
+	go func() { done <- e.SendEmail("john@example.com", "Hi", "text", "") }()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("SendEmail() expected timeout error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("SendEmail() blocked on the stalled server")
+	}
+}
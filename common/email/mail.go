@@ -0,0 +1,221 @@
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+const base64LineLength = 76
+
+// Attachment is the file attached to the mail. With ContentID it is inline,
+// e.g. the image referenced from the HTML part as <img src="cid:ContentID">.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+	ContentID   string
+}
+
+// Mail is the message composed as multipart/alternative of the plain text and HTML parts,
+// wrapped in multipart/related with the inline files and multipart/mixed with the attachments.
+type Mail struct {
+	From        mail.Address
+	To          []mail.Address
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+	// Date and MessageID are set when the mail is composed if they are empty.
+	Date      time.Time
+	MessageID string
+}
+
+// entity is the MIME entity: the part of the message or the message itself.
+type entity struct {
+	header textproto.MIMEHeader
+	body   []byte
+}
+
+// Bytes composes the mail in the RFC 5322 format.
+func (m *Mail) Bytes() ([]byte, error) {
+	if len(m.To) == 0 {
+		return nil, errors.New("no recipients")
+	}
+
+	if m.Text == "" && m.HTML == "" {
+		return nil, errors.New("empty mail")
+	}
+
+	if m.Date.IsZero() {
+		m.Date = time.Now()
+	}
+
+	if m.MessageID == "" {
+		id, err := newMessageID(m.From.Address)
+		if err != nil {
+			return nil, err
+		}
+		m.MessageID = id
+	}
+
+	root := m.root()
+
+	to := make([]string, len(m.To))
+	for i := range m.To {
+		to[i] = m.To[i].String()
+	}
+
+	var buf bytes.Buffer
+	writeHeader(&buf, "From", m.From.String())
+	writeHeader(&buf, "To", strings.Join(to, ", "))
+	writeHeader(&buf, "Subject", mime.QEncoding.Encode("utf-8", singleLine(m.Subject)))
+	writeHeader(&buf, "Date", m.Date.Format(time.RFC1123Z))
+	writeHeader(&buf, "Message-ID", m.MessageID)
+	writeHeader(&buf, "MIME-Version", "1.0")
+	for _, k := range []string{"Content-Type", "Content-Transfer-Encoding"} {
+		if v := root.header.Get(k); v != "" {
+			writeHeader(&buf, k, v)
+		}
+	}
+	buf.WriteString("\r\n")
+	buf.Write(root.body)
+
+	return buf.Bytes(), nil
+}
+
+func (m *Mail) root() *entity {
+	var parts []*entity
+	if m.Text != "" {
+		parts = append(parts, textEntity("text/plain", m.Text))
+	}
+	if m.HTML != "" {
+		parts = append(parts, textEntity("text/html", m.HTML))
+	}
+
+	root := parts[0]
+	if len(parts) > 1 {
+		root = multipartEntity("alternative", parts)
+	}
+
+	var inline, attached []*entity
+	for i := range m.Attachments {
+		a := &m.Attachments[i]
+		if a.ContentID != "" {
+			inline = append(inline, attachmentEntity(a))
+		} else {
+			attached = append(attached, attachmentEntity(a))
+		}
+	}
+
+	if len(inline) > 0 {
+		root = multipartEntity("related", append([]*entity{root}, inline...))
+	}
+
+	if len(attached) > 0 {
+		root = multipartEntity("mixed", append([]*entity{root}, attached...))
+	}
+
+	return root
+}
+
+func textEntity(contentType, s string) *entity {
+	var buf bytes.Buffer
+	w := quotedprintable.NewWriter(&buf)
+	w.Write([]byte(s)) // nolint: errcheck
+	w.Close()          // nolint: errcheck
+
+	return &entity{
+		header: textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(contentType, map[string]string{"charset": "utf-8"})},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		},
+		body: buf.Bytes(),
+	}
+}
+
+func attachmentEntity(a *Attachment) *entity {
+	contentType := a.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	disposition := "attachment"
+	h := textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"base64"},
+	}
+	if a.ContentID != "" {
+		disposition = "inline"
+		h.Set("Content-ID", "<"+a.ContentID+">")
+	}
+	h.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename}))
+
+	return &entity{header: h, body: encodeBase64Lines(a.Data)}
+}
+
+func multipartEntity(subtype string, parts []*entity) *entity {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for _, p := range parts {
+		pw, _ := w.CreatePart(p.header) // nolint: errcheck
+		pw.Write(p.body)                // nolint: errcheck
+	}
+	w.Close() // nolint: errcheck
+
+	return &entity{
+		header: textproto.MIMEHeader{
+			"Content-Type": {mime.FormatMediaType("multipart/"+subtype, map[string]string{"boundary": w.Boundary()})},
+		},
+		body: buf.Bytes(),
+	}
+}
+
+func encodeBase64Lines(data []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(data)
+
+	var buf bytes.Buffer
+	for len(encoded) > base64LineLength {
+		buf.WriteString(encoded[:base64LineLength])
+		buf.WriteString("\r\n")
+		encoded = encoded[base64LineLength:]
+	}
+	buf.WriteString(encoded)
+
+	return buf.Bytes()
+}
+
+func writeHeader(buf *bytes.Buffer, key, value string) {
+	// the values come from the users, so the header must not be split
+	buf.WriteString(key + ": " + singleLine(value) + "\r\n")
+}
+
+var lineBreaks = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+func singleLine(s string) string {
+	return lineBreaks.Replace(s)
+}
+
+// newMessageID returns the unique id in the domain of the sender.
+func newMessageID(from string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	domain := "localhost"
+	if i := strings.LastIndexByte(from, '@'); i >= 0 && i < len(from)-1 {
+		domain = from[i+1:]
+	}
+
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">", nil
+}
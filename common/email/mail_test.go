@@ -0,0 +1,253 @@
+package email_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/ivch/dynasty/common/email"
+)
+
+// part is the parsed MIME entity: the content type with the decoded body or the nested parts.
+type part struct {
+	contentType string
+	disposition string
+	contentID   string
+	body        string
+	parts       []part
+}
+
+func parseEntity(t *testing.T, contentType, disposition, contentID string, body io.Reader) part {
+	t.Helper()
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("bad content type %q: %v", contentType, err)
+	}
+
+	p := part{contentType: mediaType, disposition: disposition, contentID: contentID}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		b, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.body = string(b)
+		return p
+	}
+
+	r := multipart.NewReader(body, params["boundary"])
+	for {
+		mp, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var data io.Reader = mp
+		if mp.Header.Get("Content-Transfer-Encoding") == "base64" {
+			data = base64Reader(t, mp)
+		}
+
+		p.parts = append(p.parts, parseEntity(t, mp.Header.Get("Content-Type"),
+			mp.Header.Get("Content-Disposition"), mp.Header.Get("Content-ID"), data))
+	}
+
+	return p
+}
+
+func base64Reader(t *testing.T, r io.Reader) io.Reader {
+	t.Helper()
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range strings.Split(string(b), "\r\n") {
+		if len(line) > 76 {
+			t.Errorf("base64 line is longer than 76: %d", len(line))
+		}
+	}
+
+	dec, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, bytes.NewReader(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return bytes.NewReader(dec)
+}
+
+// shape describes the structure of the parsed entity, e.g. "multipart/mixed(text/plain,image/png)".
+func (p part) shape() string {
+	if len(p.parts) == 0 {
+		return p.contentType
+	}
+
+	s := make([]string, len(p.parts))
+	for i := range p.parts {
+		s[i] = p.parts[i].shape()
+	}
+
+	return p.contentType + "(" + strings.Join(s, ",") + ")"
+}
+
+func TestMail_Bytes(t *testing.T) {
+	attachment := email.Attachment{Filename: "report.pdf", ContentType: "application/pdf", Data: bytes.Repeat([]byte("pdf"), 100)}
+	logo := email.Attachment{Filename: "logo.png", ContentType: "image/png", Data: []byte("png"), ContentID: "logo"}
+
+	tests := []struct {
+		name      string
+		mail      email.Mail
+		wantShape string
+		wantErr   bool
+	}{
+		{
+			name:      "text",
+			mail:      email.Mail{Text: "Привіт"},
+			wantShape: "text/plain",
+		},
+		{
+			name:      "html",
+			mail:      email.Mail{HTML: "<p>Привіт</p>"},
+			wantShape: "text/html",
+		},
+		{
+			name:      "alternative",
+			mail:      email.Mail{Text: "Привіт", HTML: "<p>Привіт</p>"},
+			wantShape: "multipart/alternative(text/plain,text/html)",
+		},
+		{
+			name:      "inline image",
+			mail:      email.Mail{Text: "Привіт", HTML: `<img src="cid:logo">`, Attachments: []email.Attachment{logo}},
+			wantShape: "multipart/related(multipart/alternative(text/plain,text/html),image/png)",
+		},
+		{
+			name:      "attachment",
+			mail:      email.Mail{Text: "Привіт", Attachments: []email.Attachment{attachment}},
+			wantShape: "multipart/mixed(text/plain,application/pdf)",
+		},
+		{
+			name:      "all",
+			mail:      email.Mail{Text: "Привіт", HTML: `<img src="cid:logo">`, Attachments: []email.Attachment{attachment, logo}},
+			wantShape: "multipart/mixed(multipart/related(multipart/alternative(text/plain,text/html),image/png),application/pdf)",
+		},
+		{
+			name:    "empty",
+			mail:    email.Mail{},
+			wantErr: true,
+		},
+		{
+			name:    "no recipients",
+			mail:    email.Mail{Text: "text"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.mail
+			m.From = mail.Address{Name: "Династія", Address: "noreply@example.com"}
+			m.Subject = "Відновлення пароля\r\nBcc: evil@example.com"
+			if tt.name != "no recipients" {
+				m.To = []mail.Address{{Address: "john@example.com"}}
+			}
+
+			data, err := m.Bytes()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Bytes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			msg, err := mail.ReadMessage(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("ReadMessage() error = %v", err)
+			}
+
+			if msg.Header.Get("Bcc") != "" {
+				t.Error("header injected via subject")
+			}
+
+			subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "Відновлення пароля Bcc: evil@example.com"; subject != want {
+				t.Errorf("Subject = %q, want %q", subject, want)
+			}
+
+			from, err := msg.Header.AddressList("From")
+			if err != nil || len(from) != 1 || from[0].Name != "Династія" {
+				t.Errorf("From = %v, %v", from, err)
+			}
+
+			for _, h := range []string{"Date", "Message-ID", "MIME-Version"} {
+				if msg.Header.Get(h) == "" {
+					t.Errorf("no %s header", h)
+				}
+			}
+
+			body := msg.Body
+			if msg.Header.Get("Content-Transfer-Encoding") == "quoted-printable" {
+				body = quotedprintable.NewReader(msg.Body)
+			}
+
+			p := parseEntity(t, msg.Header.Get("Content-Type"), "", "", body)
+			if got := p.shape(); got != tt.wantShape {
+				t.Errorf("shape = %s, want %s", got, tt.wantShape)
+			}
+
+			if len(p.parts) == 0 && !strings.Contains(p.body, "Привіт") {
+				t.Errorf("body = %q", p.body)
+			}
+		})
+	}
+}
+
+func TestMail_Bytes_Attachments(t *testing.T) {
+	data := bytes.Repeat([]byte{0, 1, 2, 255}, 100)
+	m := email.Mail{
+		From: mail.Address{Address: "noreply@example.com"},
+		To:   []mail.Address{{Address: "john@example.com"}},
+		Text: "text",
+		Attachments: []email.Attachment{
+			{Filename: "звіт.bin", Data: data},
+			{Filename: "logo.png", ContentType: "image/png", Data: []byte("png"), ContentID: "logo"},
+		},
+	}
+
+	b, err := m.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := parseEntity(t, msg.Header.Get("Content-Type"), "", "", msg.Body)
+	attached := p.parts[1]
+	inline := p.parts[0].parts[1]
+
+	if attached.contentType != "application/octet-stream" || attached.body != string(data) {
+		t.Errorf("attachment = %s %q", attached.contentType, attached.body)
+	}
+
+	if d, params, err := mime.ParseMediaType(attached.disposition); err != nil || d != "attachment" || params["filename"] != "звіт.bin" {
+		t.Errorf("attachment disposition = %q, %v", attached.disposition, err)
+	}
+
+	if !strings.HasPrefix(inline.disposition, "inline") || inline.contentID != "<logo>" || inline.body != "png" {
+		t.Errorf("inline = %q %q %q", inline.disposition, inline.contentID, inline.body)
+	}
+}
@@ -0,0 +1,168 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+)
+
+const (
+	LangEN = "en"
+	LangRU = "ru"
+	LangUA = "ua"
+
+	// DefaultLang is used for the languages without the templates.
+	DefaultLang = LangRU
+
+	MessagePasswordRecovery = "password_recovery"
+
+	templatesDir = "templates"
+	subjectTpl   = "subject"
+)
+
+// Langs are the languages every message has the templates in, the same as of the errs messages.
+var Langs = []string{LangEN, LangRU, LangUA}
+
+//go:embed templates
+var defaultTemplates embed.FS
+
+// PasswordRecoveryData is the data of MessagePasswordRecovery templates.
+type PasswordRecoveryData struct {
+	Username     string
+	RecoveryCode string
+}
+
+// messages are the message types with the sample data the templates are validated with.
+var messages = map[string]interface{}{
+	MessagePasswordRecovery: PasswordRecoveryData{Username: "John Doe", RecoveryCode: "ABCDEFGHIJ"},
+}
+
+// Message is the rendered email.
+type Message struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type messageTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// Templates is the registry of the email templates by the message type and the language.
+// A message is defined by two templates in templates/<type>/: <lang>.txt is the plain text
+// part which also defines the "subject" template, and <lang>.html is the HTML part.
+type Templates struct {
+	tpls map[string]map[string]*messageTemplate
+}
+
+// LoadTemplates loads the embedded templates, each of them is overridden by the file with
+// the same path in dir if it exists. Every template is validated by rendering it with sample data.
+func LoadTemplates(dir string) (*Templates, error) {
+	t := Templates{tpls: make(map[string]map[string]*messageTemplate)}
+
+	for name, sample := range messages {
+		t.tpls[name] = make(map[string]*messageTemplate)
+		for _, lang := range Langs {
+			tpl, err := loadTemplate(dir, name, lang)
+			if err != nil {
+				return nil, fmt.Errorf("template %s/%s: %w", name, lang, err)
+			}
+
+			t.tpls[name][lang] = tpl
+			if _, err := t.Render(name, lang, sample); err != nil {
+				return nil, fmt.Errorf("template %s/%s: %w", name, lang, err)
+			}
+		}
+	}
+
+	return &t, nil
+}
+
+func loadTemplate(dir, name, lang string) (*messageTemplate, error) {
+	text, err := readTemplate(dir, path.Join(name, lang+".txt"))
+	if err != nil {
+		return nil, err
+	}
+
+	html, err := readTemplate(dir, path.Join(name, lang+".html"))
+	if err != nil {
+		return nil, err
+	}
+
+	var tpl messageTemplate
+	if tpl.text, err = texttemplate.New(lang + ".txt").Option("missingkey=error").Parse(text); err != nil {
+		return nil, err
+	}
+
+	if tpl.text.Lookup(subjectTpl) == nil {
+		return nil, errors.New("no subject defined")
+	}
+
+	if tpl.html, err = htmltemplate.New(lang + ".html").Option("missingkey=error").Parse(html); err != nil {
+		return nil, err
+	}
+
+	return &tpl, nil
+}
+
+// readTemplate reads the template from dir, falling back to the embedded one.
+func readTemplate(dir, name string) (string, error) {
+	if dir != "" {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err == nil {
+			return string(data), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+	}
+
+	data, err := defaultTemplates.ReadFile(path.Join(templatesDir, name))
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// Render renders the message in the language, falling back to DefaultLang.
+func (t *Templates) Render(name, lang string, data interface{}) (*Message, error) {
+	tpls, ok := t.tpls[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown message %q", name)
+	}
+
+	tpl, ok := tpls[lang]
+	if !ok {
+		tpl = tpls[DefaultLang]
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tpl.text.ExecuteTemplate(&subject, subjectTpl, data); err != nil {
+		return nil, err
+	}
+
+	if err := tpl.text.Execute(&text, data); err != nil {
+		return nil, err
+	}
+
+	if err := tpl.html.Execute(&html, data); err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		// the subject is a header, so it must be a single line
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Text:    strings.TrimSpace(text.String()),
+		HTML:    html.String(),
+	}, nil
+}
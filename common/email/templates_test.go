@@ -0,0 +1,130 @@
+package email_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ivch/dynasty/common/email"
+)
+
+func writeTemplate(t *testing.T, dir, name, content string) {
+	t.Helper()
+	p := filepath.Join(dir, email.MessagePasswordRecovery, name)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadTemplates(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name: "embedded",
+		},
+		{
+			name:  "override",
+			files: map[string]string{"en.txt": `{{define "subject"}}Reset{{end}}Code {{.RecoveryCode}}`},
+		},
+		{
+			name:    "syntax error",
+			files:   map[string]string{"ua.html": `<p>{{.RecoveryCode</p>`},
+			wantErr: "password_recovery/ua",
+		},
+		{
+			name:    "no subject",
+			files:   map[string]string{"ru.txt": `Code {{.RecoveryCode}}`},
+			wantErr: "no subject defined",
+		},
+		{
+			name:    "unknown field",
+			files:   map[string]string{"en.html": `<p>{{.Password}}</p>`},
+			wantErr: "Password",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				writeTemplate(t, dir, name, content)
+			}
+
+			_, err := email.LoadTemplates(dir)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("LoadTemplates() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTemplates_Render(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "en.txt", "{{define \"subject\"}}\n  Reset for\n {{.Username}}\n{{end}}\nCode {{.RecoveryCode}}\n")
+
+	tpls, err := email.LoadTemplates(dir)
+	if err != nil {
+		t.Fatalf("LoadTemplates() error = %v", err)
+	}
+
+	data := email.PasswordRecoveryData{Username: "<John>", RecoveryCode: "CODE"}
+
+	tests := []struct {
+		name        string
+		lang        string
+		wantSubject string
+		wantText    string
+		wantHTML    string
+	}{
+		{
+			name:        "overridden",
+			lang:        email.LangEN,
+			wantSubject: "Reset for <John>",
+			wantText:    "Code CODE",
+			wantHTML:    "Hello, &lt;John&gt;!",
+		},
+		{
+			name:        "embedded",
+			lang:        email.LangUA,
+			wantSubject: "Відновлення пароля",
+			wantText:    "Вітаємо, <John>!",
+			wantHTML:    "<h3><b>CODE</b></h3>",
+		},
+		{
+			name:        "default language",
+			lang:        "de",
+			wantSubject: "Восстановление пароля",
+			wantText:    "Здравствуйте, <John>!",
+			wantHTML:    "Здравствуйте, &lt;John&gt;!",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := tpls.Render(email.MessagePasswordRecovery, tt.lang, data)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if msg.Subject != tt.wantSubject {
+				t.Errorf("Render() subject = %q, want %q", msg.Subject, tt.wantSubject)
+			}
+			if !strings.Contains(msg.Text, tt.wantText) {
+				t.Errorf("Render() text = %q, want %q in it", msg.Text, tt.wantText)
+			}
+			if !strings.Contains(msg.HTML, tt.wantHTML) {
+				t.Errorf("Render() html = %q, want %q in it", msg.HTML, tt.wantHTML)
+			}
+		})
+	}
+
+	if _, err := tpls.Render("unknown", email.LangEN, data); err == nil {
+		t.Errorf("Render() expected error for unknown message")
+	}
+}
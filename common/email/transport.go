@@ -0,0 +1,135 @@
+package email
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+const (
+	// SecurityStartTLS upgrades the plain connection with STARTTLS, the server must support it.
+	SecurityStartTLS = "starttls"
+	// SecurityTLS connects with TLS, usually to the port 465.
+	SecurityTLS = "tls"
+	// SecurityNone sends the mail over the plain connection, e.g. to a local SMTP stand-in.
+	SecurityNone = "none"
+
+	// TransportSMTP, TransportDir and TransportMemory name the transports in the config.
+	TransportSMTP   = "smtp"
+	TransportDir    = "dir"
+	TransportMemory = "memory"
+
+	dialTimeout = 10 * time.Second
+	sendTimeout = time.Minute
+)
+
+// Transport delivers the composed mail to the recipients.
+type Transport interface {
+	Send(from string, to []string, msg []byte) error
+}
+
+// SMTP delivers the mail through the SMTP server.
+type SMTP struct {
+	Host     string
+	Port     string
+	Username string
+	// Pass is empty for the servers accepting the mail without authentication.
+	Pass     string
+	Security string
+	// Timeout limits the whole session with the server, sendTimeout if zero.
+	Timeout time.Duration
+}
+
+func (s *SMTP) Send(from string, to []string, msg []byte) error {
+	c, err := s.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close() // nolint: errcheck
+
+	if s.Pass != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Pass, s.Host)); err != nil {
+			return fmt.Errorf("smtp auth failed: %w", err)
+		}
+	}
+
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+
+	for _, addr := range to {
+		if err := c.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+func (s *SMTP) dial() (*smtp.Client, error) {
+	addr := net.JoinHostPort(s.Host, s.Port)
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	tlsConfig := &tls.Config{ServerName: s.Host, MinVersion: tls.VersionTLS12}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+	switch s.Security {
+	case SecurityTLS:
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	case SecurityStartTLS, SecurityNone:
+		conn, err = dialer.Dial("tcp", addr)
+	default:
+		return nil, fmt.Errorf("unknown smtp security %q", s.Security)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// the server accepting the connection and stalling must not block the sender forever
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = sendTimeout
+	}
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		conn.Close() // nolint: errcheck
+		return nil, err
+	}
+
+	c, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close() // nolint: errcheck
+		return nil, err
+	}
+
+	if s.Security == SecurityStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			c.Close() // nolint: errcheck
+			return nil, errors.New("smtp server does not support STARTTLS")
+		}
+
+		if err := c.StartTLS(tlsConfig); err != nil {
+			c.Close() // nolint: errcheck
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
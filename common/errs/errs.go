@@ -65,6 +65,30 @@ const (
 	emailAlreadyExistsCode
 	insufficientPermissionsCode
 	noRegCodesAvailableCode
+	requestNotPendingCode
+	approvalAlreadyResolvedCode
+	approvalExpiredCode
+	wrongApprovalDecisionCode
+	requestBlocklistedCode
+	blocklistEntryEmptyCode
+	blocklistEntryNotFoundCode
+	notificationChannelUnknownCode
+	notificationLangUnknownCode
+	notificationAddressEmptyCode
+	outboxMessageNotDeadCode
+	telegramLinkCodeInvalidCode
+	pushSubscriptionInvalidCode
+	sessionRevokedCode
+	sessionNotFoundCode
+	tooManyLoginAttemptsCode
+	accountLockedCode
+	passwordTooLongCode
+	passwordTooWeakCode
+	blocklistNameTooShortCode
+	blocklistNameTooLongCode
+	blocklistPlateTooLongCode
+	blocklistPhoneWrongLengthCode
+	blocklistReasonTooLongCode
 )
 
 type SvcError struct {
@@ -92,7 +116,7 @@ var (
 	EmptyUserID                   = New(emptyUserIDCode, "empty user id", "не указан ID", "не вказано ID")
 	BadUserID                     = New(badUserIDCode, "bad user id", "неправильный ID", "невірний iD")
 	BadRequest                    = New(badRequestCode, "failed to decode request", "неправильный запрос", "невірний запит")
-	PasswordTooShort              = New(passwordTooShortCode, "password should be at least 6 characters", "пароль должен быть не короче 6 символов", "пароль має бути більше 6 символів")
+	PasswordTooShort              = New(passwordTooShortCode, "password should be at least 8 characters", "пароль должен быть не короче 8 символов", "пароль має бути не коротшим за 8 символів")
 	PhoneWrongLength              = New(phoneWrongLengthCode, "phone should min 12, max 13 character", "телефон может быть 12 или 13 символов", "телефон може бути 12 або 13 символів")
 	PhoneWrongChars               = New(phoneWrongCharsCode, "phone should contain only numeric characters", "телефон должен содержать только цифры", "телефон має складатися лише з цифр")
 	FNameLength                   = New(fNameLengthCode, "first name is required", "имя обязательно", "ім'я обов'язкове")
@@ -148,6 +172,30 @@ var (
 	EmailAlreadyExists            = New(emailAlreadyExistsCode, "provided email already in use", "указанный email уже используется", "вказаний email вже викорістовується")
 	InsufficientPermissions       = New(insufficientPermissionsCode, "insufficient permissions", "недостаточно прав", "недостатньо прав")
 	NoRegCodesAvailable           = New(noRegCodesAvailableCode, "no registration codes available", "нет доступных кодов регистрации", "немає доступних кодів реєстрації")
+	RequestNotPending             = New(requestNotPendingCode, "request is not awaiting confirmation", "заявка не ожидает подтверждения", "заява не очікує підтвердження")
+	ApprovalAlreadyResolved       = New(approvalAlreadyResolvedCode, "visitor check is already resolved", "решение по посетителю уже принято", "рішення щодо відвідувача вже прийнято")
+	ApprovalExpired               = New(approvalExpiredCode, "visitor check expired", "время ответа по посетителю истекло", "час відповіді щодо відвідувача сплив")
+	WrongApprovalDecision         = New(wrongApprovalDecisionCode, "decision should be approve or deny", "решение должно быть approve или deny", "рішення має бути approve або deny")
+	RequestBlocklisted            = New(requestBlocklistedCode, "entry is prohibited by the management", "въезд запрещен управляющей компанией", "в'їзд заборонено керуючою компанією")
+	BlocklistEntryEmpty           = New(blocklistEntryEmptyCode, "plate, name or phone should be provided", "укажите номер авто, имя или телефон", "вкажіть номер авто, ім'я або телефон")
+	BlocklistEntryNotFound        = New(blocklistEntryNotFoundCode, "blocklist entry not found", "запись в черном списке не найдена", "запис у чорному списку не знайдено")
+	NotificationChannelUnknown    = New(notificationChannelUnknownCode, "notification channel is not available", "канал уведомлений недоступен", "канал сповіщень недоступний")
+	NotificationLangUnknown       = New(notificationLangUnknownCode, "notification language is not supported", "язык уведомлений не поддерживается", "мова сповіщень не підтримується")
+	NotificationAddressEmpty      = New(notificationAddressEmptyCode, "notification address is empty", "не указан адрес для уведомлений", "не вказано адресу для сповіщень")
+	OutboxMessageNotDead          = New(outboxMessageNotDeadCode, "message not found or not dead", "сообщение не найдено или не в очереди ошибок", "повідомлення не знайдено або не в черзі помилок")
+	TelegramLinkCodeInvalid       = New(telegramLinkCodeInvalidCode, "link code is invalid or expired", "код привязки неверный или устарел", "код прив'язки невірний або застарів")
+	PushSubscriptionInvalid       = New(pushSubscriptionInvalidCode, "push subscription is invalid", "неправильная подписка на push-уведомления", "невірна підписка на push-сповіщення")
+	SessionRevoked                = New(sessionRevokedCode, "session revoked, please log in again", "сессия отозвана, войдите снова", "сесію відкликано, увійдіть знову")
+	SessionNotFound               = New(sessionNotFoundCode, "session not found", "сессия не найдена", "сесію не знайдено")
+	TooManyLoginAttempts          = New(tooManyLoginAttemptsCode, "too many login attempts, try again later", "слишком много попыток входа, попробуйте позже", "забагато спроб входу, спробуйте пізніше")
+	PasswordTooLong               = New(passwordTooLongCode, "password is too long", "пароль слишком длинный", "пароль занадто довгий")
+	PasswordTooWeak               = New(passwordTooWeakCode, "password is too easy to guess", "пароль слишком легко подобрать", "пароль занадто легко підібрати")
+	BlocklistNameTooShort         = New(blocklistNameTooShortCode, "name should be at least 4 characters", "имя должно быть не короче 4 символов", "ім'я має бути не коротше 4 символів")
+	BlocklistNameTooLong          = New(blocklistNameTooLongCode, "name should be at most 100 characters", "имя должно быть не длиннее 100 символов", "ім'я має бути не довше 100 символів")
+	BlocklistPlateTooLong         = New(blocklistPlateTooLongCode, "plate should be at most 20 characters", "номер авто должен быть не длиннее 20 символов", "номер авто має бути не довше 20 символів")
+	BlocklistPhoneWrongLength     = New(blocklistPhoneWrongLengthCode, "phone should have 9 to 13 digits", "телефон должен содержать от 9 до 13 цифр", "телефон має містити від 9 до 13 цифр")
+	BlocklistReasonTooLong        = New(blocklistReasonTooLongCode, "reason should be at most 1000 characters", "причина должна быть не длиннее 1000 символов", "причина має бути не довше 1000 символів")
+	AccountLocked                 = New(accountLockedCode, "account is locked after too many login attempts, recover the password to unlock it", "аккаунт заблокирован после множества попыток входа, восстановите пароль, чтобы разблокировать его", "акаунт заблоковано після багатьох спроб входу, відновіть пароль, щоб розблокувати його")
 
 	codes = map[error]uint{
 		Generic:                       genericCode,
@@ -210,6 +258,30 @@ var (
 		EmailAlreadyExists:            emailAlreadyExistsCode,
 		InsufficientPermissions:       insufficientPermissionsCode,
 		NoRegCodesAvailable:           noRegCodesAvailableCode,
+		RequestNotPending:             requestNotPendingCode,
+		ApprovalAlreadyResolved:       approvalAlreadyResolvedCode,
+		ApprovalExpired:               approvalExpiredCode,
+		WrongApprovalDecision:         wrongApprovalDecisionCode,
+		RequestBlocklisted:            requestBlocklistedCode,
+		BlocklistEntryEmpty:           blocklistEntryEmptyCode,
+		BlocklistEntryNotFound:        blocklistEntryNotFoundCode,
+		NotificationChannelUnknown:    notificationChannelUnknownCode,
+		NotificationLangUnknown:       notificationLangUnknownCode,
+		NotificationAddressEmpty:      notificationAddressEmptyCode,
+		OutboxMessageNotDead:          outboxMessageNotDeadCode,
+		TelegramLinkCodeInvalid:       telegramLinkCodeInvalidCode,
+		PushSubscriptionInvalid:       pushSubscriptionInvalidCode,
+		SessionRevoked:                sessionRevokedCode,
+		SessionNotFound:               sessionNotFoundCode,
+		TooManyLoginAttempts:          tooManyLoginAttemptsCode,
+		AccountLocked:                 accountLockedCode,
+		PasswordTooLong:               passwordTooLongCode,
+		PasswordTooWeak:               passwordTooWeakCode,
+		BlocklistNameTooShort:         blocklistNameTooShortCode,
+		BlocklistNameTooLong:          blocklistNameTooLongCode,
+		BlocklistPlateTooLong:         blocklistPlateTooLongCode,
+		BlocklistPhoneWrongLength:     blocklistPhoneWrongLengthCode,
+		BlocklistReasonTooLong:        blocklistReasonTooLongCode,
 	}
 )
 
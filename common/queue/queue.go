@@ -0,0 +1,47 @@
+// Package queue runs the jobs kept in the database tables, claimed with a lease and retried with backoff.
+package queue
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+)
+
+// Work runs the jobs until ctx is done, the one in progress is finished before it returns.
+func Work(ctx context.Context, pollInterval time.Duration, wake <-chan struct{},
+	next func(ctx context.Context) (bool, error), onError func(err error)) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			ok, err := next(context.WithoutCancel(ctx))
+			if err != nil {
+				onError(err)
+			}
+			if !ok || err != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-wake:
+		}
+	}
+}
+
+// Backoff is the delay before the next attempt, doubled after every failed one up to limit.
+func Backoff(base, limit time.Duration, attempts int) time.Duration {
+	d := base << max(attempts-1, 0)
+	if d <= 0 || d > limit {
+		return limit
+	}
+	return d
+}
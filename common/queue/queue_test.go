@@ -0,0 +1,92 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ivch/dynasty/common/queue"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		want     time.Duration
+	}{
+		{name: "first attempt", attempts: 1, want: time.Second},
+		{name: "no attempts", attempts: 0, want: time.Second},
+		{name: "doubled", attempts: 3, want: 4 * time.Second},
+		{name: "limited", attempts: 10, want: time.Minute},
+		{name: "overflow", attempts: 100, want: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := queue.Backoff(time.Second, time.Minute, tt.attempts); got != tt.want {
+				t.Errorf("Backoff() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWork(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var (
+		wake   = make(chan struct{})
+		jobs   = make(chan struct{}, 3)
+		ran    = make(chan bool, 10)
+		failed error
+	)
+	next := func(ctx context.Context) (bool, error) {
+		if ctx.Err() != nil {
+			return false, errors.New("job context canceled")
+		}
+		select {
+		case <-jobs:
+			ran <- true
+			return true, nil
+		default:
+			ran <- false
+			return false, nil
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		jobs <- struct{}{}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		queue.Work(ctx, time.Hour, wake, next, func(err error) { failed = err })
+		close(done)
+	}()
+
+	// the queue is drained, then the worker waits for the wake up
+	for _, want := range []bool{true, true, true, false} {
+		if got := <-ran; got != want {
+			t.Fatalf("Work() ran job = %v, want %v", got, want)
+		}
+	}
+
+	jobs <- struct{}{}
+	wake <- struct{}{}
+	for _, want := range []bool{true, false} {
+		if got := <-ran; got != want {
+			t.Fatalf("Work() after wake up ran job = %v, want %v", got, want)
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Work() did not return after cancel")
+	}
+
+	if failed != nil {
+		t.Errorf("Work() error = %v", failed)
+	}
+}
@@ -0,0 +1,51 @@
+package queue
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Claim leases the next due row of the table of dst, false if there is none.
+func Claim(db *gorm.DB, dst interface{}, now time.Time, lease time.Duration) (bool, error) {
+	table := db.NewScope(dst).TableName()
+
+	// nolint: gosec
+	err := db.Raw(`UPDATE `+table+` SET status = ?, attempts = attempts + 1, locked_until = ?, updated_at = ?
+		WHERE id = (SELECT id FROM `+table+`
+			WHERE (status = ? AND run_at <= ?) OR (status = ? AND locked_until <= ?)
+			ORDER BY run_at LIMIT 1 FOR UPDATE SKIP LOCKED)
+		RETURNING *`,
+		StatusRunning, now.Add(lease), now, StatusPending, now, StatusRunning, now).Scan(dst).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Release ends the lease of the running row with the update, false if it is gone.
+func Release(db *gorm.DB, model interface{}, id uint, update map[string]interface{}) (bool, error) {
+	update["locked_until"] = nil
+	update["updated_at"] = time.Now()
+
+	res := db.Model(model).Where("id = ? AND status = ?", id, StatusRunning).Updates(update)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// Retry schedules the running row of model to be claimed again at runAt.
+func Retry(db *gorm.DB, model interface{}, id uint, runAt time.Time, lastErr string) error {
+	_, err := Release(db, model, id, map[string]interface{}{
+		"status":     StatusPending,
+		"run_at":     runAt,
+		"last_error": lastErr,
+	})
+	return err
+}
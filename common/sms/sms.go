@@ -0,0 +1,79 @@
+// Package sms sends text messages through an HTTP gateway. The gateway accepts
+// POST requests with the JSON body {"to": "<phone>", "text": "<message>"}
+// and answers with any 2xx status once the message is accepted.
+package sms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ivch/dynasty/common/logger"
+)
+
+const requestTimeout = 10 * time.Second
+
+type Gateway struct {
+	url    string
+	client *http.Client
+}
+
+func New(url string) *Gateway {
+	return &Gateway{url: url, client: &http.Client{Timeout: requestTimeout}}
+}
+
+// Message is the body of the gateway request.
+type Message struct {
+	To   string `json:"to"`
+	Text string `json:"text"`
+}
+
+func (g *Gateway) SendText(ctx context.Context, to, text string) error {
+	body, err := json.Marshal(Message{To: to, Text: text})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sms gateway responded %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	return nil
+}
+
+// StandIn returns the handler of the stand-in gateway for the local environment.
+// It accepts the messages like the real gateway and only logs them.
+func StandIn(log logger.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		var m Message
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil || m.To == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		log.Info("sms to %s: %s", m.To, m.Text)
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
@@ -0,0 +1,64 @@
+package sms_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ivch/dynasty/common/logger"
+	"github.com/ivch/dynasty/common/sms"
+)
+
+func TestGateway_SendText(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    int
+		wantErr bool
+	}{
+		{name: "accepted", code: http.StatusAccepted},
+		{name: "rejected", code: http.StatusBadRequest, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got sms.Message
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Errorf("bad request body: %v", err)
+				}
+				w.WriteHeader(tt.code)
+			}))
+			defer srv.Close()
+
+			err := sms.New(srv.URL).SendText(context.Background(), "380001112233", "hello")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SendText() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if want := (sms.Message{To: "380001112233", Text: "hello"}); got != want {
+				t.Errorf("SendText() sent = %#v, want %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestStandIn(t *testing.T) {
+	srv := httptest.NewServer(sms.StandIn(logger.NewStdLog(logger.WithWriter(io.Discard))))
+	defer srv.Close()
+
+	if err := sms.New(srv.URL).SendText(context.Background(), "380001112233", "hello"); err != nil {
+		t.Errorf("SendText() error = %v", err)
+	}
+
+	resp, err := http.Post(srv.URL, "application/json", strings.NewReader(`{"text":"no phone"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("StandIn() status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
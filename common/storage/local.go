@@ -0,0 +1,280 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	metaDir       = ".meta"
+	tmpFilePrefix = ".tmp-"
+	publicMaxAge  = 24 * time.Hour
+	dirPermission = 0o750
+)
+
+var errBadKey = errors.New("bad object key")
+
+type localMeta struct {
+	ContentType string `json:"content_type"`
+	Public      bool   `json:"public"`
+}
+
+// Local keeps objects in a directory of the local filesystem. They are served by Handler:
+// public ones by their plain links, private ones only by links signed with the secret.
+type Local struct {
+	dir     string
+	baseURL string
+	secret  []byte
+	now     func() time.Time
+}
+
+// NewLocal returns the driver storing objects under dir. The baseURL is
+// where Handler is reachable from the outside, e.g. https://example.com/storage.
+func NewLocal(dir, baseURL string, secret []byte) (*Local, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("empty local storage secret")
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, metaDir), dirPermission); err != nil {
+		return nil, fmt.Errorf("failed to create storage dir: %w", err)
+	}
+
+	return &Local{
+		dir:     dir,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		secret:  secret,
+		now:     time.Now,
+	}, nil
+}
+
+func (l *Local) Put(_ context.Context, key string, body io.Reader, opts PutOptions) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := writeFile(p, body); err != nil {
+		return err
+	}
+
+	meta, err := json.Marshal(localMeta{ContentType: opts.ContentType, Public: opts.Public})
+	if err != nil {
+		return err
+	}
+
+	return writeFile(l.metaPath(key), strings.NewReader(string(meta)))
+}
+
+func (l *Local) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// #nosec G304 -- the path is cleaned and kept inside the storage dir
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotExist
+	}
+
+	return f, err
+}
+
+func (l *Local) Delete(_ context.Context, key string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range []string{p, l.metaPath(key)} {
+		if err := os.Remove(f); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *Local) Exists(_ context.Context, key string) (bool, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = os.Stat(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func (l *Local) List(_ context.Context, prefix string) ([]ObjectInfo, error) {
+	var list []ObjectInfo
+	err := filepath.WalkDir(l.dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(l.dir, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if key == metaDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !strings.HasPrefix(key, prefix) || strings.HasPrefix(d.Name(), tmpFilePrefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		list = append(list, ObjectInfo{Key: key, Size: info.Size(), LastModified: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (l *Local) Presign(_ context.Context, key string, ttl time.Duration) (string, error) {
+	if _, err := l.path(key); err != nil {
+		return "", err
+	}
+
+	expires := strconv.FormatInt(l.now().Add(ttl).Unix(), 10)
+	q := url.Values{}
+	q.Set("expires", expires)
+	q.Set("signature", l.sign(key, expires))
+
+	return fmt.Sprintf("%s/%s?%s", l.baseURL, (&url.URL{Path: key}).EscapedPath(), q.Encode()), nil
+}
+
+// Handler serves the stored objects.
+func (l *Local) Handler() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/*", l.serveObject)
+	return r
+}
+
+func (l *Local) serveObject(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "*")
+	p, err := l.path(key)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	meta, err := l.meta(key)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	cacheControl := fmt.Sprintf("public, max-age=%d", int(publicMaxAge.Seconds()))
+	if !meta.Public || r.URL.Query().Get("signature") != "" {
+		if !l.verify(key, r.URL.Query().Get("expires"), r.URL.Query().Get("signature")) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		cacheControl = "private, no-store"
+	}
+
+	w.Header().Set("Content-Type", meta.ContentType)
+	w.Header().Set("Cache-Control", cacheControl)
+	http.ServeFile(w, r, p)
+}
+
+func (l *Local) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte(key + "\n" + expires)) // nolint: errcheck
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (l *Local) verify(key, expires, signature string) bool {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || l.now().Unix() > exp {
+		return false
+	}
+
+	return hmac.Equal([]byte(signature), []byte(l.sign(key, expires)))
+}
+
+func (l *Local) meta(key string) (*localMeta, error) {
+	data, err := os.ReadFile(l.metaPath(key))
+	if err != nil {
+		return nil, err
+	}
+
+	var m localMeta
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// path returns the file of the object, making sure the key does not escape the storage dir.
+func (l *Local) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if key == "" || clean == "/" || strings.HasPrefix(clean, "/"+metaDir) {
+		return "", errBadKey
+	}
+
+	return filepath.Join(l.dir, filepath.FromSlash(clean)), nil
+}
+
+func (l *Local) metaPath(key string) string {
+	return filepath.Join(l.dir, metaDir, filepath.FromSlash(path.Clean("/"+key))+".json")
+}
+
+// writeFile writes the file atomically, so readers never see partial content.
+func writeFile(p string, body io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(p), dirPermission); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), tmpFilePrefix+"*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // nolint: errcheck
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close() // nolint: errcheck
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), p)
+}
@@ -0,0 +1,179 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivch/dynasty/common/storage"
+)
+
+func newLocal(t *testing.T) (*storage.Local, *httptest.Server) {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	l, err := storage.NewLocal(t.TempDir(), srv.URL+"/storage", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux.Handle("/storage/", http.StripPrefix("/storage", l.Handler()))
+
+	return l, srv
+}
+
+func TestLocal_PutGetDelete(t *testing.T) {
+	ctx := context.Background()
+	l, _ := newLocal(t)
+
+	if err := l.Put(ctx, "req/i/a.jpg", strings.NewReader("image"), storage.PutOptions{ContentType: "image/jpeg"}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	ok, err := l.Exists(ctx, "req/i/a.jpg")
+	if err != nil || !ok {
+		t.Fatalf("Exists() = %v, %v", ok, err)
+	}
+
+	rc, err := l.Get(ctx, "req/i/a.jpg")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	data, _ := io.ReadAll(rc)
+	_ = rc.Close()
+	if string(data) != "image" {
+		t.Errorf("Get() = %q", data)
+	}
+
+	if err := l.Delete(ctx, "req/i/a.jpg"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if err := l.Delete(ctx, "req/i/a.jpg"); err != nil {
+		t.Errorf("Delete() of missing object error = %v", err)
+	}
+
+	if _, err := l.Get(ctx, "req/i/a.jpg"); !errors.Is(err, storage.ErrNotExist) {
+		t.Errorf("Get() of deleted object error = %v", err)
+	}
+
+	if ok, err := l.Exists(ctx, "req/i/a.jpg"); err != nil || ok {
+		t.Errorf("Exists() of deleted object = %v, %v", ok, err)
+	}
+}
+
+func TestLocal_BadKey(t *testing.T) {
+	l, _ := newLocal(t)
+
+	for _, key := range []string{"", "/", ".meta/a.jpg"} {
+		if err := l.Put(context.Background(), key, strings.NewReader("x"), storage.PutOptions{}); err == nil {
+			t.Errorf("Put(%q) expected error", key)
+		}
+	}
+
+	// keys climbing up are kept inside the storage dir
+	if err := l.Put(context.Background(), "../../a.txt", strings.NewReader("x"), storage.PutOptions{}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if ok, _ := l.Exists(context.Background(), "a.txt"); !ok {
+		t.Error("Put() stored the object outside the storage dir")
+	}
+}
+
+func TestLocal_Handler(t *testing.T) {
+	ctx := context.Background()
+	l, srv := newLocal(t)
+
+	if err := l.Put(ctx, "pub/a.jpg", strings.NewReader("public"), storage.PutOptions{ContentType: "image/jpeg", Public: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Put(ctx, "priv/a.pdf", strings.NewReader("private"), storage.PutOptions{ContentType: "application/pdf"}); err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := l.Presign(ctx, "priv/a.pdf", time.Minute)
+	if err != nil {
+		t.Fatalf("Presign() error = %v", err)
+	}
+
+	expired, err := l.Presign(ctx, "priv/a.pdf", -time.Minute)
+	if err != nil {
+		t.Fatalf("Presign() error = %v", err)
+	}
+
+	tampered, _ := url.Parse(signed)
+	q := tampered.Query()
+	q.Set("expires", "9999999999")
+	tampered.RawQuery = q.Encode()
+
+	tests := []struct {
+		name     string
+		link     string
+		wantCode int
+		wantType string
+		wantBody string
+	}{
+		{name: "public", link: srv.URL + "/storage/pub/a.jpg", wantCode: http.StatusOK, wantType: "image/jpeg", wantBody: "public"},
+		{name: "private without signature", link: srv.URL + "/storage/priv/a.pdf", wantCode: http.StatusForbidden},
+		{name: "private presigned", link: signed, wantCode: http.StatusOK, wantType: "application/pdf", wantBody: "private"},
+		{name: "expired link", link: expired, wantCode: http.StatusForbidden},
+		{name: "tampered link", link: tampered.String(), wantCode: http.StatusForbidden},
+		{name: "not found", link: srv.URL + "/storage/pub/b.jpg", wantCode: http.StatusNotFound},
+		{name: "metadata is hidden", link: srv.URL + "/storage/.meta/pub/a.jpg.json", wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(tt.link) // #nosec G107 -- test server link
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantCode {
+				t.Fatalf("GET %s status = %d, want %d", tt.link, resp.StatusCode, tt.wantCode)
+			}
+
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != tt.wantBody || resp.Header.Get("Content-Type") != tt.wantType {
+				t.Errorf("GET %s = %q (%s)", tt.link, body, resp.Header.Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestLocal_List(t *testing.T) {
+	ctx := context.Background()
+	l, _ := newLocal(t)
+
+	for _, key := range []string{"req/i/a.jpg", "req/i/b.jpg", "req/t/a.jpg"} {
+		if err := l.Put(ctx, key, strings.NewReader(key), storage.PutOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list, err := l.List(ctx, "req/i/")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	if len(list) != 2 || list[0].Key != "req/i/a.jpg" || list[1].Key != "req/i/b.jpg" {
+		t.Fatalf("List() = %+v", list)
+	}
+
+	if list[0].Size != int64(len("req/i/a.jpg")) || list[0].LastModified.IsZero() {
+		t.Errorf("List() wrong object info %+v", list[0])
+	}
+}
@@ -0,0 +1,106 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/ivch/dynasty/common/storage"
+)
+
+func TestS3_Put(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    storage.PutOptions
+		wantACL string
+	}{
+		{name: "public", opts: storage.PutOptions{ContentType: "image/jpeg", Public: true}, wantACL: "public-read"},
+		{name: "private", opts: storage.PutOptions{ContentType: "image/jpeg"}, wantACL: "private"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input *s3.PutObjectInput
+			cli := &storage.S3APIMock{
+				PutObjectWithContextFunc: func(_ aws.Context, in *s3.PutObjectInput, _ ...request.Option) (*s3.PutObjectOutput, error) {
+					input = in
+					return &s3.PutObjectOutput{}, nil
+				},
+			}
+
+			// a plain reader is buffered, the sdk needs to seek the body
+			body := io.MultiReader(strings.NewReader("data"))
+			if err := storage.NewS3(cli, "bucket").Put(context.Background(), "key", body, tt.opts); err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+
+			if *input.Bucket != "bucket" || *input.Key != "key" || *input.ACL != tt.wantACL || *input.ContentType != tt.opts.ContentType {
+				t.Errorf("Put() input = %v", input)
+			}
+
+			data, _ := io.ReadAll(input.Body)
+			if string(data) != "data" {
+				t.Errorf("Put() body = %q", data)
+			}
+		})
+	}
+}
+
+func TestS3_NotFound(t *testing.T) {
+	notFound := awserr.NewRequestFailure(awserr.New("NotFound", "not found", nil), http.StatusNotFound, "id")
+	cli := &storage.S3APIMock{
+		GetObjectWithContextFunc: func(_ aws.Context, _ *s3.GetObjectInput, _ ...request.Option) (*s3.GetObjectOutput, error) {
+			return nil, awserr.New(s3.ErrCodeNoSuchKey, "no such key", nil)
+		},
+		HeadObjectWithContextFunc: func(_ aws.Context, _ *s3.HeadObjectInput, _ ...request.Option) (*s3.HeadObjectOutput, error) {
+			return nil, notFound
+		},
+	}
+	s := storage.NewS3(cli, "bucket")
+
+	if _, err := s.Get(context.Background(), "key"); !errors.Is(err, storage.ErrNotExist) {
+		t.Errorf("Get() error = %v, want ErrNotExist", err)
+	}
+
+	ok, err := s.Exists(context.Background(), "key")
+	if err != nil || ok {
+		t.Errorf("Exists() = %v, %v", ok, err)
+	}
+}
+
+func TestS3_List(t *testing.T) {
+	modified := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cli := &storage.S3APIMock{
+		ListObjectsV2PagesWithContextFunc: func(_ aws.Context, in *s3.ListObjectsV2Input, fn func(*s3.ListObjectsV2Output, bool) bool, _ ...request.Option) error {
+			if *in.Prefix != "req/i/" {
+				t.Errorf("List() prefix = %s", *in.Prefix)
+			}
+			fn(&s3.ListObjectsV2Output{Contents: []*s3.Object{{Key: aws.String("req/i/a.jpg"), Size: aws.Int64(1), LastModified: &modified}}}, false)
+			fn(&s3.ListObjectsV2Output{Contents: []*s3.Object{{Key: aws.String("req/i/b.jpg"), Size: aws.Int64(2), LastModified: &modified}}}, true)
+			return nil
+		},
+	}
+
+	list, err := storage.NewS3(cli, "bucket").List(context.Background(), "req/i/")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	want := []storage.ObjectInfo{
+		{Key: "req/i/a.jpg", Size: 1, LastModified: modified},
+		{Key: "req/i/b.jpg", Size: 2, LastModified: modified},
+	}
+	if !reflect.DeepEqual(list, want) {
+		t.Errorf("List() = %+v, want %+v", list, want)
+	}
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+const (
+	DriverS3    = "s3"
+	DriverLocal = "local"
+)
+
+// ErrNotExist is returned when the requested object is not in the storage.
+var ErrNotExist = errors.New("object does not exist")
+
+// PutOptions describes the stored object.
+type PutOptions struct {
+	ContentType string
+	// Public objects are readable by anyone knowing the link,
+	// private ones only through presigned links.
+	Public bool
+}
+
+// ObjectInfo describes the stored object.
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// Storage is a blob storage keeping objects by their keys.
+type Storage interface {
+	Put(ctx context.Context, key string, body io.Reader, opts PutOptions) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	Exists(ctx context.Context, key string) (bool, error)
+	// List returns the objects with keys starting with prefix.
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	// Presign returns a link to the object valid for ttl.
+	Presign(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
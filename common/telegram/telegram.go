@@ -0,0 +1,182 @@
+// Package telegram is a minimal client of the Telegram Bot API.
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultAPIURL = "https://api.telegram.org"
+
+	requestTimeout = 10 * time.Second
+)
+
+type Bot struct {
+	apiURL string
+	token  string
+	client *http.Client
+}
+
+func New(apiURL, token string) *Bot {
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+
+	return &Bot{
+		apiURL: strings.TrimSuffix(apiURL, "/"),
+		token:  token,
+		// the timeouts are set per request as the long polling takes longer
+		client: &http.Client{},
+	}
+}
+
+type response struct {
+	OK          bool            `json:"ok"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
+}
+
+// User is the Telegram user, LanguageCode is the IETF tag of the user's client language.
+type User struct {
+	ID           int64  `json:"id"`
+	FirstName    string `json:"first_name"`
+	LanguageCode string `json:"language_code"`
+}
+
+type Chat struct {
+	ID int64 `json:"id"`
+}
+
+type Message struct {
+	MessageID int64  `json:"message_id"`
+	From      *User  `json:"from"`
+	Chat      Chat   `json:"chat"`
+	Text      string `json:"text"`
+}
+
+// CallbackQuery is sent when the user presses the inline keyboard button.
+type CallbackQuery struct {
+	ID      string   `json:"id"`
+	From    User     `json:"from"`
+	Message *Message `json:"message"`
+	Data    string   `json:"data"`
+}
+
+// Update is the incoming update, only the messages and the callback queries are requested.
+type Update struct {
+	UpdateID      int64          `json:"update_id"`
+	Message       *Message       `json:"message"`
+	CallbackQuery *CallbackQuery `json:"callback_query"`
+}
+
+type InlineKeyboardButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data"`
+}
+
+type InlineKeyboardMarkup struct {
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+}
+
+type sendMessageParams struct {
+	ChatID      string                `json:"chat_id"`
+	Text        string                `json:"text"`
+	ReplyMarkup *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+// SendText sends the message to the chat.
+func (b *Bot) SendText(ctx context.Context, chatID, text string) error {
+	return b.SendMessage(ctx, chatID, text, nil)
+}
+
+// SendMessage sends the message to the chat with the optional inline keyboard.
+func (b *Bot) SendMessage(ctx context.Context, chatID, text string, keyboard *InlineKeyboardMarkup) error {
+	return b.call(ctx, "sendMessage", &sendMessageParams{ChatID: chatID, Text: text, ReplyMarkup: keyboard}, nil)
+}
+
+// AnswerCallbackQuery stops the progress of the pressed button, showing the text if it is not empty.
+func (b *Bot) AnswerCallbackQuery(ctx context.Context, id, text string) error {
+	return b.call(ctx, "answerCallbackQuery", map[string]string{"callback_query_id": id, "text": text}, nil)
+}
+
+// GetUpdates waits up to timeout for the updates starting with offset, the id
+// of the last handled update plus one confirms the updates before it.
+func (b *Bot) GetUpdates(ctx context.Context, offset int64, timeout time.Duration) ([]Update, error) {
+	params := map[string]interface{}{
+		"offset":          offset,
+		"timeout":         int(timeout.Seconds()),
+		"allowed_updates": []string{"message", "callback_query"},
+	}
+
+	var res []Update
+	if err := b.do(ctx, "getUpdates", params, &res, timeout+requestTimeout); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ChatID formats the id of the chat as it is stored in the notification endpoints.
+func ChatID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+// call runs the API method and decodes its result into res if it is not nil.
+func (b *Bot) call(ctx context.Context, method string, params interface{}, res interface{}) error {
+	return b.do(ctx, method, params, res, requestTimeout)
+}
+
+func (b *Bot) do(ctx context.Context, method string, params interface{}, res interface{}, timeout time.Duration) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/bot%s/%s", b.apiURL, b.token, method), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		// the error has the url with the token in it
+		return fmt.Errorf("telegram %s failed: %w", method, unwrapURLError(err))
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	var r response
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return fmt.Errorf("telegram %s: bad response %d: %w", method, resp.StatusCode, err)
+	}
+
+	if !r.OK {
+		return fmt.Errorf("telegram %s: %s", method, r.Description)
+	}
+
+	if res != nil {
+		return json.Unmarshal(r.Result, res)
+	}
+
+	return nil
+}
+
+func unwrapURLError(err error) error {
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		return uerr.Err
+	}
+	return err
+}
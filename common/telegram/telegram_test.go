@@ -0,0 +1,107 @@
+package telegram_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivch/dynasty/common/telegram"
+)
+
+func TestBot_SendText(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    string
+		wantErr string
+	}{
+		{name: "ok", resp: `{"ok":true,"result":{"message_id":1}}`},
+		{name: "api error", resp: `{"ok":false,"description":"Bad Request: chat not found"}`, wantErr: "chat not found"},
+		{name: "bad response", resp: `<html>`, wantErr: "bad response"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string]string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/botsecret/sendMessage" {
+					t.Errorf("path = %s", r.URL.Path)
+				}
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Errorf("bad request body: %v", err)
+				}
+				w.Write([]byte(tt.resp)) // nolint: errcheck
+			}))
+			defer srv.Close()
+
+			err := telegram.New(srv.URL+"/", "secret").SendText(context.Background(), "100", "hello")
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("SendText() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got["chat_id"] != "100" || got["text"] != "hello" {
+				t.Errorf("SendText() sent = %v", got)
+			}
+		})
+	}
+}
+
+func TestBot_SendTextHidesToken(t *testing.T) {
+	err := telegram.New("http://127.0.0.1:1", "secret").SendText(context.Background(), "100", "hello")
+	if err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("SendText() error = %v", err)
+	}
+}
+
+func TestBot_SendMessage(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got = string(b)
+		w.Write([]byte(`{"ok":true,"result":{}}`)) // nolint: errcheck
+	}))
+	defer srv.Close()
+
+	keyboard := &telegram.InlineKeyboardMarkup{InlineKeyboard: [][]telegram.InlineKeyboardButton{{{Text: "Taxi", CallbackData: "new:2"}}}}
+	if err := telegram.New(srv.URL, "secret").SendMessage(context.Background(), "100", "hello", keyboard); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+
+	want := `{"chat_id":"100","text":"hello","reply_markup":{"inline_keyboard":[[{"text":"Taxi","callback_data":"new:2"}]]}}`
+	if got != want {
+		t.Errorf("SendMessage() sent = %s, want %s", got, want)
+	}
+}
+
+func TestBot_GetUpdates(t *testing.T) {
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/botsecret/getUpdates" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("bad request body: %v", err)
+		}
+		w.Write([]byte(`{"ok":true,"result":[` + // nolint: errcheck
+			`{"update_id":5,"message":{"message_id":1,"from":{"id":10,"language_code":"uk"},"chat":{"id":100},"text":"/new"}},` +
+			`{"update_id":6,"callback_query":{"id":"q","from":{"id":10},"message":{"message_id":2,"chat":{"id":100}},"data":"new:2"}}]}`))
+	}))
+	defer srv.Close()
+
+	updates, err := telegram.New(srv.URL, "secret").GetUpdates(context.Background(), 5, 30*time.Second)
+	if err != nil {
+		t.Fatalf("GetUpdates() error = %v", err)
+	}
+
+	if got["offset"] != float64(5) || got["timeout"] != float64(30) {
+		t.Errorf("GetUpdates() sent = %v", got)
+	}
+
+	if len(updates) != 2 || updates[0].Message.Text != "/new" || updates[0].Message.From.LanguageCode != "uk" ||
+		updates[1].CallbackQuery.Data != "new:2" || updates[1].CallbackQuery.Message.Chat.ID != 100 {
+		t.Errorf("GetUpdates() got = %+v", updates)
+	}
+}
@@ -0,0 +1,78 @@
+// Package webpush sends the Web Push messages, encrypted per RFC 8291 and
+// authorized with the VAPID keys of the server per RFC 8292.
+package webpush
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/SherClockHolmes/webpush-go"
+)
+
+const (
+	requestTimeout = 10 * time.Second
+	// messageTTL is how long the push service keeps the message while the device is offline.
+	messageTTL = 24 * time.Hour
+)
+
+// ErrSubscriptionGone is returned when the push service reports the subscription expired or unsubscribed.
+var ErrSubscriptionGone = errors.New("push subscription is gone")
+
+type Client struct {
+	publicKey  string
+	privateKey string
+	subscriber string
+	client     *http.Client
+}
+
+// New returns the client with the VAPID key pair. The subscriber is the contact
+// of the server operator for the push services, the email or the https URL.
+func New(publicKey, privateKey, subscriber string) *Client {
+	return &Client{
+		publicKey:  publicKey,
+		privateKey: privateKey,
+		subscriber: subscriber,
+		client:     &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// GenerateKeys returns the new VAPID key pair.
+func GenerateKeys() (publicKey, privateKey string, err error) {
+	privateKey, publicKey, err = webpush.GenerateVAPIDKeys()
+	return publicKey, privateKey, err
+}
+
+// SendPush sends the payload to the subscription, the JSON of the browser's PushSubscription.
+func (c *Client) SendPush(ctx context.Context, subscription string, payload []byte) error {
+	var sub webpush.Subscription
+	if err := json.Unmarshal([]byte(subscription), &sub); err != nil {
+		return fmt.Errorf("bad push subscription: %w", err)
+	}
+
+	resp, err := webpush.SendNotificationWithContext(ctx, payload, &sub, &webpush.Options{
+		HTTPClient:      c.client,
+		Subscriber:      c.subscriber,
+		TTL:             int(messageTTL.Seconds()),
+		VAPIDPublicKey:  c.publicKey,
+		VAPIDPrivateKey: c.privateKey,
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrSubscriptionGone
+	case resp.StatusCode >= http.StatusBadRequest:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512)) // nolint: errcheck
+		return fmt.Errorf("push service error %d: %s", resp.StatusCode, body)
+	}
+
+	return nil
+}
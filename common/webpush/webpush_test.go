@@ -0,0 +1,96 @@
+package webpush_test
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ivch/dynasty/common/webpush"
+)
+
+func subscription(t *testing.T, endpoint string) string {
+	t.Helper()
+
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := make([]byte, 16)
+	if _, err := rand.Read(auth); err != nil {
+		t.Fatal(err)
+	}
+
+	sub, err := json.Marshal(map[string]interface{}{
+		"endpoint": endpoint,
+		"keys": map[string]string{
+			"p256dh": base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+			"auth":   base64.RawURLEncoding.EncodeToString(auth),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(sub)
+}
+
+func TestClient_SendPush(t *testing.T) {
+	public, private, err := webpush.GenerateKeys()
+	if err != nil {
+		t.Fatalf("GenerateKeys() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		status  int
+		wantErr error
+		errText string
+	}{
+		{name: "ok", status: http.StatusCreated},
+		{name: "not found", status: http.StatusNotFound, wantErr: webpush.ErrSubscriptionGone},
+		{name: "gone", status: http.StatusGone, wantErr: webpush.ErrSubscriptionGone},
+		{name: "push service error", status: http.StatusInternalServerError, errText: "push service error 500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Content-Encoding") != "aes128gcm" {
+					t.Errorf("Content-Encoding = %s", r.Header.Get("Content-Encoding"))
+				}
+				if r.Header.Get("TTL") != "86400" {
+					t.Errorf("TTL = %s", r.Header.Get("TTL"))
+				}
+				if auth := r.Header.Get("Authorization"); !strings.HasPrefix(auth, "vapid t=") || !strings.Contains(auth, "k="+public) {
+					t.Errorf("Authorization = %s", auth)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			c := webpush.New(public, private, "admin@example.com")
+			err := c.SendPush(context.Background(), subscription(t, srv.URL+"/push/1"), []byte(`{"title":"Hi"}`))
+			switch {
+			case tt.errText != "":
+				if err == nil || !strings.Contains(err.Error(), tt.errText) {
+					t.Errorf("SendPush() error = %v, want %s", err, tt.errText)
+				}
+			case !errors.Is(err, tt.wantErr):
+				t.Errorf("SendPush() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_SendPushBadSubscription(t *testing.T) {
+	if err := webpush.New("", "", "").SendPush(context.Background(), "{", nil); err == nil {
+		t.Error("SendPush() error = nil")
+	}
+}
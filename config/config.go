@@ -1,8 +1,17 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/spf13/viper"
 	"gopkg.in/go-playground/validator.v9"
+
+	"github.com/ivch/dynasty/common/email"
+	"github.com/ivch/dynasty/common/storage"
 )
 
 type Config struct {
@@ -13,22 +22,52 @@ type Config struct {
 	GuardUI
 	S3
 	SMTP
+	Notifications
 	HTTPPort string `validate:"required"`
 	LogLevel string
 }
 
+// UserService configures the users and the hashing of their passwords.
 type UserService struct {
 	VerifyRegCode bool
 	MembersLimit  int
+
+	PasswordHash          string `validate:"omitempty,oneof=bcrypt argon2id"`
+	PasswordBcryptCost    int    `validate:"omitempty,min=12,max=31"`
+	PasswordArgon2Time    uint32
+	PasswordArgon2Memory  uint32
+	PasswordArgon2Threads uint `validate:"max=255"`
 }
 
+// AuthService configures the tokens, the legacy clients and the login lockout.
 type AuthService struct {
-	JWTSecret string `validate:"required"`
+	JWTSecret        string
+	JWTKeysDir       string
+	JWTSigningKey    string
+	AccessTokenTTL   time.Duration
+	LegacyUserAgents []string
+	LegacyMinVersion string
+	LegacyTokenTTL   time.Duration
+
+	LoginAttemptsStore string `validate:"omitempty,oneof=postgres memory"`
+	LoginMaxFailures   int    `validate:"min=0"`
+	LoginIPMaxFailures int    `validate:"min=0"`
+	LoginLockoutTTL    time.Duration
 }
 
 type RequestService struct {
-	S3SpaceName string `validate:"required"`
-	CDNHost     string `validate:"required"`
+	S3SpaceName       string
+	CDNHost           string `validate:"required"`
+	ApprovalTTL       time.Duration
+	AttachmentLimits  map[string]int64
+	MaxImageDimension int
+	ImageVariants     string
+	PrivateImages     bool
+	ImageURLTTL       time.Duration
+	ImageWorkers      int `validate:"min=0"`
+	ImageGCInterval   time.Duration
+	ImageGCDelete     bool
+	ImageGCMinAge     time.Duration
 }
 
 type GuardUI struct {
@@ -46,19 +85,43 @@ type DB struct {
 	SSL      string `validate:"required,oneof=enable disable require"`
 }
 
+// S3 configures the blob storage, the local driver serves files through the backend.
 type S3 struct {
-	Region   string `validate:"required"`
-	Key      string `validate:"required"`
-	Secret   string `validate:"required"`
-	Endpoint string `validate:"required"`
+	Driver      string `validate:"required,oneof=s3 local"`
+	Region      string
+	Key         string
+	Secret      string
+	Endpoint    string
+	LocalPath   string
+	LocalURL    string
+	LocalSecret string
 }
 
+// Notifications configures the optional channels, disabled while their settings are empty.
+type Notifications struct {
+	SMSGatewayURL    string
+	TelegramBotToken string
+	TelegramAPIURL   string
+	// TelegramBotPolling runs the bot of the residents, it needs TelegramBotToken.
+	TelegramBotPolling bool
+	// WebPushSubscriber is the contact for the push services, SMTP.From if empty.
+	WebPushPublicKey  string
+	WebPushPrivateKey string
+	WebPushSubscriber string
+}
+
+// SMTP configures the email sending, the dir and memory transports capture the mail instead.
 type SMTP struct {
-	TplPath string `validate:"required"`
-	Host    string `validate:"required"`
-	Port    string `validate:"required"`
-	From    string `validate:"required"`
-	Pass    string `validate:"required"`
+	TplPath      string
+	Transport    string `validate:"oneof=smtp dir memory"`
+	Host         string
+	Port         string
+	From         string `validate:"required"`
+	FromName     string
+	Pass         string
+	Security     string `validate:"oneof=starttls tls none"`
+	CaptureDir   string
+	CaptureLimit int `validate:"min=0"`
 }
 
 func New() (*Config, error) {
@@ -77,15 +140,41 @@ func New() (*Config, error) {
 			SSL:      v.GetString("DB_SSL"),
 		},
 		AuthService: AuthService{
-			JWTSecret: v.GetString("AUTH_JWT_SECRET"),
+			JWTSecret:        v.GetString("AUTH_JWT_SECRET"),
+			JWTKeysDir:       v.GetString("AUTH_JWT_KEYS_DIR"),
+			JWTSigningKey:    v.GetString("AUTH_JWT_SIGNING_KEY"),
+			AccessTokenTTL:   v.GetDuration("AUTH_ACCESS_TOKEN_TTL"),
+			LegacyUserAgents: splitList(v.GetString("AUTH_LEGACY_USER_AGENTS")),
+			LegacyMinVersion: v.GetString("AUTH_LEGACY_MIN_VERSION"),
+			LegacyTokenTTL:   v.GetDuration("AUTH_LEGACY_TOKEN_TTL"),
+
+			LoginAttemptsStore: v.GetString("AUTH_LOGIN_ATTEMPTS_STORE"),
+			LoginMaxFailures:   v.GetInt("AUTH_LOGIN_MAX_FAILURES"),
+			LoginIPMaxFailures: v.GetInt("AUTH_LOGIN_IP_MAX_FAILURES"),
+			LoginLockoutTTL:    v.GetDuration("AUTH_LOGIN_LOCKOUT_TTL"),
 		},
 		UserService: UserService{
 			VerifyRegCode: v.GetBool("USER_VERIFY_REG_CODE"),
 			MembersLimit:  v.GetInt("FAMILY_MEMBERS_LIMIT"),
+
+			PasswordHash:          v.GetString("PASSWORD_HASH"),
+			PasswordBcryptCost:    v.GetInt("PASSWORD_BCRYPT_COST"),
+			PasswordArgon2Time:    v.GetUint32("PASSWORD_ARGON2_TIME"),
+			PasswordArgon2Memory:  v.GetUint32("PASSWORD_ARGON2_MEMORY"),
+			PasswordArgon2Threads: v.GetUint("PASSWORD_ARGON2_THREADS"),
 		},
 		RequestService: RequestService{
-			S3SpaceName: v.GetString("S3_SPACE_NAME"),
-			CDNHost:     v.GetString("CDN_HOST"),
+			S3SpaceName:       v.GetString("S3_SPACE_NAME"),
+			CDNHost:           v.GetString("CDN_HOST"),
+			ApprovalTTL:       v.GetDuration("REQUEST_APPROVAL_TTL"),
+			MaxImageDimension: v.GetInt("REQUEST_IMAGE_MAX_DIMENSION"),
+			ImageVariants:     v.GetString("REQUEST_IMAGE_VARIANTS"),
+			PrivateImages:     v.GetBool("REQUEST_IMAGES_PRIVATE"),
+			ImageURLTTL:       v.GetDuration("REQUEST_IMAGE_URL_TTL"),
+			ImageWorkers:      v.GetInt("REQUEST_IMAGE_WORKERS"),
+			ImageGCInterval:   v.GetDuration("REQUEST_IMAGE_GC_INTERVAL"),
+			ImageGCDelete:     v.GetBool("REQUEST_IMAGE_GC_DELETE"),
+			ImageGCMinAge:     v.GetDuration("REQUEST_IMAGE_GC_MIN_AGE"),
 		},
 		GuardUI: GuardUI{
 			APIHost:    v.GetString("UI_GUARD_API_HOST"),
@@ -93,23 +182,181 @@ func New() (*Config, error) {
 			PagerLimit: v.GetInt("UI_GUARD_PAGER_LIMIT"),
 		},
 		S3: S3{
-			Region:   v.GetString("S3_REGION"),
-			Key:      v.GetString("S3_KEY"),
-			Secret:   v.GetString("S3_SECRET"),
-			Endpoint: v.GetString("S3_ENDPOINT"),
+			Driver:      v.GetString("S3_DRIVER"),
+			Region:      v.GetString("S3_REGION"),
+			Key:         v.GetString("S3_KEY"),
+			Secret:      v.GetString("S3_SECRET"),
+			Endpoint:    v.GetString("S3_ENDPOINT"),
+			LocalPath:   v.GetString("S3_LOCAL_PATH"),
+			LocalURL:    v.GetString("S3_LOCAL_URL"),
+			LocalSecret: v.GetString("S3_LOCAL_SECRET"),
 		},
 		SMTP: SMTP{
-			TplPath: v.GetString("EMAIL_TPL_PATH"),
-			Host:    v.GetString("SMTP_HOST"),
-			Port:    v.GetString("SMTP_PORT"),
-			From:    v.GetString("SMTP_FROM"),
-			Pass:    v.GetString("SMTP_PASS"),
+			TplPath:      v.GetString("EMAIL_TPL_PATH"),
+			Host:         v.GetString("SMTP_HOST"),
+			Port:         v.GetString("SMTP_PORT"),
+			From:         v.GetString("SMTP_FROM"),
+			FromName:     v.GetString("SMTP_FROM_NAME"),
+			Pass:         v.GetString("SMTP_PASS"),
+			Security:     v.GetString("SMTP_SECURITY"),
+			Transport:    v.GetString("MAIL_TRANSPORT"),
+			CaptureDir:   v.GetString("MAIL_CAPTURE_DIR"),
+			CaptureLimit: v.GetInt("MAIL_CAPTURE_LIMIT"),
 		},
+		Notifications: Notifications{
+			SMSGatewayURL:      v.GetString("NOTIFY_SMS_GATEWAY_URL"),
+			TelegramBotToken:   v.GetString("NOTIFY_TELEGRAM_BOT_TOKEN"),
+			TelegramAPIURL:     v.GetString("NOTIFY_TELEGRAM_API_URL"),
+			TelegramBotPolling: v.GetBool("NOTIFY_TELEGRAM_BOT_POLLING"),
+			WebPushPublicKey:   v.GetString("NOTIFY_WEBPUSH_PUBLIC_KEY"),
+			WebPushPrivateKey:  v.GetString("NOTIFY_WEBPUSH_PRIVATE_KEY"),
+			WebPushSubscriber:  v.GetString("NOTIFY_WEBPUSH_SUBSCRIBER"),
+		},
+	}
+
+	limits, err := parseAttachmentLimits(v.GetString("REQUEST_ATTACHMENTS"))
+	if err != nil {
+		return nil, err
+	}
+	c.AttachmentLimits = limits
+
+	if c.Driver == "" {
+		c.Driver = storage.DriverS3
+	}
+
+	if c.Security == "" {
+		c.Security = email.SecurityStartTLS
+	}
+
+	if c.SMTP.Transport == "" {
+		c.SMTP.Transport = email.TransportSMTP
+	}
+
+	// objects of the local storage are served by the backend itself
+	if c.Driver == storage.DriverLocal && c.CDNHost == "" {
+		c.CDNHost = c.LocalURL
 	}
 
 	if err := validator.New().Struct(&c); err != nil {
 		return nil, err
 	}
 
+	if err := c.S3.validate(c.S3SpaceName); err != nil {
+		return nil, err
+	}
+
+	if err := c.SMTP.validate(); err != nil {
+		return nil, err
+	}
+
+	if err := c.AuthService.validate(); err != nil {
+		return nil, err
+	}
+
 	return &c, nil
 }
+
+// splitList splits the comma separated list, skipping the empty items.
+func splitList(s string) []string {
+	var res []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// parseAttachmentLimits parses the content types with their sizes in MB, e.g. "image/jpeg:5".
+func parseAttachmentLimits(s string) (map[string]int64, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	limits := make(map[string]int64)
+	for _, item := range strings.Split(s, ",") {
+		parts := strings.Split(strings.TrimSpace(item), ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("bad attachment limit %q", item)
+		}
+
+		// HEIC is recognized on upload, but there is no pure-Go decoder to convert it
+		if parts[0] == "image/heic" {
+			return nil, fmt.Errorf("attachment type %q is not supported", parts[0])
+		}
+
+		size, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("bad attachment size %q", item)
+		}
+
+		limits[parts[0]] = size << 20
+	}
+
+	return limits, nil
+}
+
+// validate checks the settings required by the selected storage driver.
+func (s *S3) validate(spaceName string) error {
+	var required [][2]string
+	switch s.Driver {
+	case storage.DriverS3:
+		required = [][2]string{
+			{"S3_REGION", s.Region},
+			{"S3_KEY", s.Key},
+			{"S3_SECRET", s.Secret},
+			{"S3_ENDPOINT", s.Endpoint},
+			{"S3_SPACE_NAME", spaceName},
+		}
+	case storage.DriverLocal:
+		required = [][2]string{
+			{"S3_LOCAL_PATH", s.LocalPath},
+			{"S3_LOCAL_URL", s.LocalURL},
+			{"S3_LOCAL_SECRET", s.LocalSecret},
+		}
+	}
+
+	for _, r := range required {
+		if r[1] == "" {
+			return fmt.Errorf("%s is required for the %s storage driver", r[0], s.Driver)
+		}
+	}
+
+	return nil
+}
+
+func (s *SMTP) validate() error {
+	var required [][2]string
+	switch s.Transport {
+	case email.TransportSMTP:
+		required = [][2]string{
+			{"SMTP_HOST", s.Host},
+			{"SMTP_PORT", s.Port},
+		}
+	case email.TransportDir:
+		required = [][2]string{
+			{"MAIL_CAPTURE_DIR", s.CaptureDir},
+		}
+	}
+
+	for _, r := range required {
+		if r[1] == "" {
+			return fmt.Errorf("%s is required for the %s mail transport", r[0], s.Transport)
+		}
+	}
+
+	return nil
+}
+
+// validate checks the tokens are signed either with the keys or with the secret.
+func (a *AuthService) validate() error {
+	if a.JWTKeysDir == "" && a.JWTSecret == "" {
+		return errors.New("AUTH_JWT_SECRET or AUTH_JWT_KEYS_DIR is required")
+	}
+
+	if a.JWTKeysDir != "" && a.JWTSigningKey == "" {
+		return errors.New("AUTH_JWT_SIGNING_KEY is required with AUTH_JWT_KEYS_DIR")
+	}
+
+	return nil
+}
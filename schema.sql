@@ -146,4 +146,239 @@ create table password_recovery
     code varchar not null,
     created_at timestamp default current_timestamp,
     active boolean default true
-);
\ No newline at end of file
+);
+
+alter table requests
+    add guard_id integer
+        constraint requests_guard_id_fk
+            references users (id)
+            on delete set null;
+
+create table approvals
+(
+    id          serial
+        constraint approvals_pk
+            primary key,
+    guard_id    integer                             not null
+        constraint approvals_guard_id_fk
+            references users (id)
+            on delete cascade,
+    building_id integer                             not null,
+    apartment   integer                             not null,
+    description varchar(1000),
+    status      varchar(15) default 'pending'       not null,
+    decided_by  integer
+        constraint approvals_decided_by_fk
+            references users (id)
+            on delete set null,
+    decided_at  timestamp,
+    expires_at  timestamp                           not null,
+    created_at  timestamp   default CURRENT_TIMESTAMP not null
+);
+
+create index approvals_apartment_status_index
+    on approvals (building_id, apartment, status);
+
+create table blocklist
+(
+    id         serial
+        constraint blocklist_pk
+            primary key,
+    plate      varchar(20),
+    name       varchar(100),
+    phone      varchar(13),
+    reason     varchar(1000),
+    reject     boolean   default false             not null,
+    expires_at timestamp,
+    created_by integer                             not null
+        constraint blocklist_created_by_fk
+            references users (id),
+    created_at timestamp default CURRENT_TIMESTAMP not null,
+    updated_at timestamp,
+    deleted_at timestamp
+);
+
+create table blocklist_audit
+(
+    id         serial
+        constraint blocklist_audit_pk
+            primary key,
+    entry_id   integer                             not null
+        constraint blocklist_audit_entry_id_fk
+            references blocklist (id)
+            on delete cascade,
+    admin_id   integer                             not null
+        constraint blocklist_audit_admin_id_fk
+            references users (id),
+    action     varchar(10)                         not null,
+    data       jsonb                               not null,
+    created_at timestamp default CURRENT_TIMESTAMP not null
+);
+
+create index blocklist_audit_entry_id_index
+    on blocklist_audit (entry_id);
+
+create table image_jobs
+(
+    id           serial
+        constraint image_jobs_pk
+            primary key,
+    request_id   integer                             not null
+        constraint image_jobs_request_id_fk
+            references requests (id)
+            on delete cascade,
+    user_id      integer                             not null,
+    filename     varchar(255)                        not null,
+    content_type varchar(50)                         not null,
+    status       varchar(15) default 'pending'       not null,
+    attempts     integer     default 0               not null,
+    last_error   text,
+    run_at       timestamp                           not null,
+    locked_until timestamp,
+    created_at   timestamp   default CURRENT_TIMESTAMP not null,
+    updated_at   timestamp
+);
+
+create index image_jobs_status_run_at_index
+    on image_jobs (status, run_at);
+
+create index image_jobs_request_id_index
+    on image_jobs (request_id);
+
+create table notification_preferences
+(
+    user_id  integer                        not null
+        constraint notification_preferences_pk
+            primary key
+        constraint notification_preferences_users_id_fk
+            references users (id)
+            on delete cascade,
+    channels text[]      default '{email}'  not null,
+    lang     varchar(2)  default 'ua'       not null
+);
+
+create table notification_endpoints
+(
+    id         serial
+        constraint notification_endpoints_pk
+            primary key,
+    user_id    integer                             not null
+        constraint notification_endpoints_users_id_fk
+            references users (id)
+            on delete cascade,
+    channel    varchar(15)                         not null,
+    address    text                                not null,
+    created_at timestamp default CURRENT_TIMESTAMP not null
+);
+
+create index notification_endpoints_user_id_index
+    on notification_endpoints (user_id, channel);
+
+create table notification_deliveries
+(
+    id         serial
+        constraint notification_deliveries_pk
+            primary key,
+    user_id    integer                             not null,
+    event      varchar(50)                         not null,
+    channel    varchar(15)                         not null,
+    address    text,
+    status     varchar(15)                         not null,
+    error      text,
+    created_at timestamp default CURRENT_TIMESTAMP not null
+);
+
+create index notification_deliveries_user_id_index
+    on notification_deliveries (user_id, created_at);
+
+create table outbox
+(
+    id           serial
+        constraint outbox_pk
+            primary key,
+    topic        varchar(50)                         not null,
+    payload      jsonb                               not null,
+    status       varchar(15) default 'pending'       not null,
+    attempts     integer     default 0               not null,
+    last_error   text,
+    run_at       timestamp                           not null,
+    locked_until timestamp,
+    created_at   timestamp   default CURRENT_TIMESTAMP not null,
+    updated_at   timestamp
+);
+
+create index outbox_status_run_at_index
+    on outbox (status, run_at);
+
+create table telegram_link_codes
+(
+    code       varchar(16)
+        constraint telegram_link_codes_pk
+            primary key,
+    user_id    integer   not null
+        constraint telegram_link_codes_users_id_fk
+            references users (id)
+            on delete cascade,
+    expires_at timestamp not null
+);
+
+create table telegram_links
+(
+    chat_id      bigint
+        constraint telegram_links_pk
+            primary key,
+    user_id      integer                             not null
+        constraint telegram_links_users_id_fk
+            references users (id)
+            on delete cascade,
+    pending_type integer   default 0                 not null,
+    created_at   timestamp default CURRENT_TIMESTAMP not null
+);
+
+create index telegram_links_user_id_index
+    on telegram_links (user_id);
+
+create table login_attempts
+(
+    key           varchar(60)
+        constraint login_attempts_pk
+            primary key,
+    failures      integer   default 0                   not null,
+    blocked_until timestamp default '0001-01-01 00:00:00' not null,
+    updated_at    timestamp default CURRENT_TIMESTAMP   not null
+);
+
+create index login_attempts_updated_at_index
+    on login_attempts (updated_at);
+
+alter table sessions
+    add family_id uuid;
+
+-- every existing session starts its own family
+update sessions
+set family_id = md5(random()::text || id::text)::uuid
+where family_id is null;
+
+alter table sessions
+    alter column family_id set not null;
+
+alter table sessions
+    add ip varchar(45);
+
+alter table sessions
+    add user_agent text;
+
+alter table sessions
+    add rotated_at timestamp;
+
+create index sessions_family_id_index
+    on sessions (family_id);
+
+create index sessions_rotated_at_index
+    on sessions (rotated_at);
+
+alter table sessions
+    add name varchar(100);
+
+alter table users
+    add avatar varchar default null;
@@ -3,7 +3,6 @@ package auth
 import (
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
 	uuid "github.com/satori/go.uuid"
 )
 
@@ -16,14 +15,24 @@ type Session struct {
 	ID           string
 	UserID       uint
 	RefreshToken uuid.UUID
-	ExpiresIn    int64
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	// FamilyID groups the sessions rotated from the same login.
+	FamilyID uuid.UUID
+	// Name is the device name given by the client at the login.
+	Name      string
+	IP        string
+	UserAgent string
+	ExpiresIn int64
+	// RotatedAt is set once the refresh token is exchanged, the rotated token must never come back.
+	RotatedAt *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
-type Token struct {
-	ID   uint
-	Name string
-	Role uint
-	jwt.StandardClaims
+// Client is the device the session is created from.
+type Client struct {
+	Name      string
+	IP        string
+	UserAgent string
+	// Version is the app version the client reports in the X-Client-Version header.
+	Version string
 }
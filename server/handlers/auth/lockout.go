@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/common/logger"
+)
+
+const (
+	LoginAttemptsPostgres = "postgres"
+	LoginAttemptsMemory   = "memory"
+)
+
+const (
+	DefaultLoginFreeFailures  = 3
+	DefaultLoginMaxFailures   = 10
+	DefaultLoginIPMaxFailures = 50
+	DefaultLoginBackoff       = time.Second
+	DefaultLoginMaxBackoff    = time.Minute
+	DefaultLoginLockoutTTL    = 30 * time.Minute
+	DefaultLoginWindow        = time.Hour
+)
+
+// LoginAttempt counts the failed logins of the phone or of the IP.
+type LoginAttempt struct {
+	Key          string `gorm:"primary_key"`
+	Failures     int
+	BlockedUntil time.Time
+	UpdatedAt    time.Time
+}
+
+// LoginAttempts keeps the failed logins. The PostgreSQL store is shared by all the instances.
+type LoginAttempts interface {
+	// GetLoginAttempt returns the attempt of the key, the zero one when there is none.
+	GetLoginAttempt(key string) (*LoginAttempt, error)
+	// FailLogin counts the failure of the key since the given time and blocks it.
+	FailLogin(key string, since time.Time, block func(failures int) time.Time) (*LoginAttempt, error)
+	ResetLogin(key string) error
+	// PruneLogins deletes the attempts not failed since and not blocked anymore.
+	PruneLogins(since time.Time) error
+}
+
+// LoginLimits configure the brute-force protection of the login.
+type LoginLimits struct {
+	FreeFailures  int
+	MaxFailures   int
+	IPMaxFailures int
+	Backoff       time.Duration
+	MaxBackoff    time.Duration
+	LockoutTTL    time.Duration
+	Window        time.Duration
+}
+
+// LoginBlockedError rejects the login of the blocked phone or IP, Err is the localized reason.
+type LoginBlockedError struct {
+	Err        errs.SvcError
+	RetryAfter time.Duration
+}
+
+func (e *LoginBlockedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *LoginBlockedError) Unwrap() error {
+	return e.Err
+}
+
+// LoginGuard tracks the failed logins by the phone and by the IP.
+type LoginGuard struct {
+	log    logger.Logger
+	store  LoginAttempts
+	limits LoginLimits
+}
+
+func NewLoginGuard(log logger.Logger, store LoginAttempts, limits LoginLimits) *LoginGuard {
+	if limits.FreeFailures <= 0 {
+		limits.FreeFailures = DefaultLoginFreeFailures
+	}
+	if limits.MaxFailures <= 0 {
+		limits.MaxFailures = DefaultLoginMaxFailures
+	}
+	if limits.IPMaxFailures <= 0 {
+		limits.IPMaxFailures = DefaultLoginIPMaxFailures
+	}
+	if limits.Backoff <= 0 {
+		limits.Backoff = DefaultLoginBackoff
+	}
+	if limits.MaxBackoff <= 0 {
+		limits.MaxBackoff = DefaultLoginMaxBackoff
+	}
+	if limits.LockoutTTL <= 0 {
+		limits.LockoutTTL = DefaultLoginLockoutTTL
+	}
+	if limits.Window <= 0 {
+		limits.Window = DefaultLoginWindow
+	}
+
+	return &LoginGuard{log: log, store: store, limits: limits}
+}
+
+func phoneKey(phone string) string {
+	return "phone:" + phone
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check rejects the login while the phone or the IP is blocked.
+func (g *LoginGuard) Check(phone, ip string) error {
+	now := time.Now()
+
+	a, err := g.store.GetLoginAttempt(phoneKey(phone))
+	if err != nil {
+		return err
+	}
+	if a.BlockedUntil.After(now) {
+		reason := errs.TooManyLoginAttempts
+		if a.Failures >= g.limits.MaxFailures {
+			reason = errs.AccountLocked
+		}
+		return &LoginBlockedError{Err: reason, RetryAfter: a.BlockedUntil.Sub(now)}
+	}
+
+	if ip == "" {
+		return nil
+	}
+
+	a, err = g.store.GetLoginAttempt(ipKey(ip))
+	if err != nil {
+		return err
+	}
+	if a.BlockedUntil.After(now) {
+		return &LoginBlockedError{Err: errs.TooManyLoginAttempts, RetryAfter: a.BlockedUntil.Sub(now)}
+	}
+
+	return nil
+}
+
+// Fail counts the failed login of the phone and of the IP.
+func (g *LoginGuard) Fail(phone, ip string) {
+	now := time.Now()
+	since := now.Add(-g.limits.Window)
+
+	a, err := g.store.FailLogin(phoneKey(phone), since, func(failures int) time.Time {
+		return now.Add(g.phoneDelay(failures))
+	})
+	if err != nil {
+		g.log.Error("failed to count login failure: %w", err)
+	} else if a.Failures == g.limits.MaxFailures {
+		g.log.Warn("security: login of %s locked after %d failures", phone, a.Failures)
+	}
+
+	if ip == "" {
+		return
+	}
+
+	a, err = g.store.FailLogin(ipKey(ip), since, func(failures int) time.Time {
+		if failures < g.limits.IPMaxFailures {
+			return time.Time{}
+		}
+		return now.Add(g.limits.LockoutTTL)
+	})
+	if err != nil {
+		g.log.Error("failed to count login failure: %w", err)
+	} else if a.Failures == g.limits.IPMaxFailures {
+		g.log.Warn("security: logins from %s blocked after %d failures", ip, a.Failures)
+	}
+}
+
+// phoneDelay is how long the phone is blocked after the failures.
+func (g *LoginGuard) phoneDelay(failures int) time.Duration {
+	if failures >= g.limits.MaxFailures {
+		return g.limits.LockoutTTL
+	}
+
+	if failures <= g.limits.FreeFailures {
+		return 0
+	}
+
+	d := g.limits.Backoff
+	for i := g.limits.FreeFailures + 1; i < failures && d < g.limits.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > g.limits.MaxBackoff {
+		d = g.limits.MaxBackoff
+	}
+
+	return d
+}
+
+// Succeed forgets the failures of the phone, the ones of the IP are kept.
+func (g *LoginGuard) Succeed(phone string) {
+	if err := g.store.ResetLogin(phoneKey(phone)); err != nil {
+		g.log.Error("failed to reset login failures: %w", err)
+	}
+}
+
+// Unlock lifts the lockout of the phone, e.g. once its password is reset.
+func (g *LoginGuard) Unlock(phone string) error {
+	return g.store.ResetLogin(phoneKey(phone))
+}
+
+// RunCleanup deletes the outdated attempts every interval until the context is done.
+func (g *LoginGuard) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := g.store.PruneLogins(time.Now().Add(-g.limits.Window)); err != nil {
+				g.log.Error("error pruning login attempts: %w", err)
+			}
+		}
+	}
+}
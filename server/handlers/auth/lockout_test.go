@@ -0,0 +1,214 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/server/handlers/auth"
+	repository "github.com/ivch/dynasty/server/handlers/auth/repo"
+	"github.com/ivch/dynasty/server/handlers/users/transport"
+)
+
+func TestLoginGuard(t *testing.T) {
+	limits := auth.LoginLimits{
+		FreeFailures:  2,
+		MaxFailures:   5,
+		IPMaxFailures: 8,
+		Backoff:       time.Second,
+		MaxBackoff:    4 * time.Second,
+		LockoutTTL:    time.Hour,
+	}
+
+	tests := []struct {
+		name      string
+		prepare   func(g *auth.LoginGuard)
+		phone     string
+		ip        string
+		wantErr   error
+		wantRetry time.Duration
+	}{
+		{
+			name:  "ok no failures",
+			phone: "380001112233",
+			ip:    "192.0.2.1",
+		},
+		{
+			name: "ok free failures",
+			prepare: func(g *auth.LoginGuard) {
+				g.Fail("380001112233", "192.0.2.1")
+				g.Fail("380001112233", "192.0.2.1")
+			},
+			phone: "380001112233",
+			ip:    "192.0.2.1",
+		},
+		{
+			name: "error backoff",
+			prepare: func(g *auth.LoginGuard) {
+				for i := 0; i < 3; i++ {
+					g.Fail("380001112233", "192.0.2.1")
+				}
+			},
+			phone:     "380001112233",
+			ip:        "192.0.2.1",
+			wantErr:   errs.TooManyLoginAttempts,
+			wantRetry: time.Second,
+		},
+		{
+			name: "error backoff doubles up to max",
+			prepare: func(g *auth.LoginGuard) {
+				for i := 0; i < 4; i++ {
+					g.Fail("380001112233", "192.0.2.1")
+				}
+			},
+			phone:     "380001112233",
+			wantErr:   errs.TooManyLoginAttempts,
+			wantRetry: 2 * time.Second,
+		},
+		{
+			name: "error account locked",
+			prepare: func(g *auth.LoginGuard) {
+				for i := 0; i < 5; i++ {
+					g.Fail("380001112233", "192.0.2.1")
+				}
+			},
+			phone:     "380001112233",
+			ip:        "192.0.2.2",
+			wantErr:   errs.AccountLocked,
+			wantRetry: time.Hour,
+		},
+		{
+			name: "ok other phone",
+			prepare: func(g *auth.LoginGuard) {
+				for i := 0; i < 5; i++ {
+					g.Fail("380001112233", "192.0.2.1")
+				}
+			},
+			phone: "380001112234",
+			ip:    "192.0.2.1",
+		},
+		{
+			name: "ok unlocked",
+			prepare: func(g *auth.LoginGuard) {
+				for i := 0; i < 5; i++ {
+					g.Fail("380001112233", "192.0.2.1")
+				}
+				if err := g.Unlock("380001112233"); err != nil {
+					t.Fatal(err)
+				}
+			},
+			phone: "380001112233",
+			ip:    "192.0.2.1",
+		},
+		{
+			name: "ok succeeded",
+			prepare: func(g *auth.LoginGuard) {
+				for i := 0; i < 3; i++ {
+					g.Fail("380001112233", "192.0.2.1")
+				}
+				g.Succeed("380001112233")
+			},
+			phone: "380001112233",
+			ip:    "192.0.2.1",
+		},
+		{
+			name: "error ip blocked",
+			prepare: func(g *auth.LoginGuard) {
+				for i := 0; i < 8; i++ {
+					g.Fail("38000111223"+string(rune('0'+i)), "192.0.2.1")
+				}
+			},
+			phone:     "380001112239",
+			ip:        "192.0.2.1",
+			wantErr:   errs.TooManyLoginAttempts,
+			wantRetry: time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := auth.NewLoginGuard(defaultLogger, repository.NewMemoryLoginAttempts(), limits)
+			if tt.prepare != nil {
+				tt.prepare(g)
+			}
+
+			err := g.Check(tt.phone, tt.ip)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil) != (err == nil) {
+				t.Fatalf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+
+			var blocked *auth.LoginBlockedError
+			if !errors.As(err, &blocked) {
+				t.Fatalf("Check() error = %T, want *auth.LoginBlockedError", err)
+			}
+			if blocked.RetryAfter > tt.wantRetry || blocked.RetryAfter < tt.wantRetry-time.Second {
+				t.Errorf("Check() retry after = %s, want %s", blocked.RetryAfter, tt.wantRetry)
+			}
+		})
+	}
+}
+
+func TestService_LoginGuard(t *testing.T) {
+	const phone = "380001112233"
+
+	usrv := &auth.UserServiceMock{
+		UserByPhoneAndPasswordFunc: func(_ context.Context, _, password string) (*transport.UserByIDResponse, error) {
+			switch password {
+			case "password":
+				return &transport.UserByIDResponse{ID: 1, Active: true}, nil
+			case "outage":
+				return nil, errTestError
+			}
+			return nil, errs.InvalidCredentials
+		},
+	}
+	repo := &auth.RepositoryMock{
+		CreateSessionFunc: func(_ *auth.Session) (string, error) {
+			return "token", nil
+		},
+	}
+	guard := auth.NewLoginGuard(defaultLogger, repository.NewMemoryLoginAttempts(), auth.LoginLimits{FreeFailures: 1, MaxFailures: 2})
+	s := auth.New(defaultLogger, repo, usrv, "secret", auth.WithLoginGuard(guard))
+	client := auth.Client{IP: "192.0.2.1"}
+
+	if _, err := s.Login(context.Background(), phone, "wrong", client); !errors.Is(err, errs.InvalidCredentials) {
+		t.Fatalf("Login() error = %v, want %v", err, errs.InvalidCredentials)
+	}
+	if _, err := s.Login(context.Background(), phone, "password", client); err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	// the errors other than the wrong credentials are not counted
+	for i := 0; i < 3; i++ {
+		if _, err := s.Login(context.Background(), phone, "outage", client); !errors.Is(err, errTestError) {
+			t.Fatalf("Login() error = %v, want %v", err, errTestError)
+		}
+	}
+
+	// the success forgets the failure, so only the next two lock the phone
+	for i := 0; i < 2; i++ {
+		if _, err := s.Login(context.Background(), phone, "wrong", client); !errors.Is(err, errs.InvalidCredentials) {
+			t.Fatalf("Login() error = %v, want %v", err, errs.InvalidCredentials)
+		}
+	}
+
+	calls := len(usrv.UserByPhoneAndPasswordCalls())
+	if _, err := s.Login(context.Background(), phone, "password", client); !errors.Is(err, errs.AccountLocked) {
+		t.Fatalf("Login() error = %v, want %v", err, errs.AccountLocked)
+	}
+	if len(usrv.UserByPhoneAndPasswordCalls()) != calls {
+		t.Error("Login() checked the password of the locked account")
+	}
+
+	if err := guard.Unlock(phone); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Login(context.Background(), phone, "password", client); err != nil {
+		t.Errorf("Login() after unlock error = %v", err)
+	}
+}
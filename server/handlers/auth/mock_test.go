@@ -6,7 +6,9 @@ package auth
 import (
 	"context"
 	users "github.com/ivch/dynasty/server/handlers/users/transport"
+	"github.com/satori/go.uuid"
 	"sync"
+	"time"
 )
 
 // Ensure, that UserServiceMock does implement UserService.
@@ -147,20 +149,32 @@ var _ Repository = &RepositoryMock{}
 //
 //		// make and configure a mocked Repository
 //		mockedRepository := &RepositoryMock{
-//			CreateSessionFunc: func(userID uint) (string, error) {
+//			CreateSessionFunc: func(sess *Session) (string, error) {
 //				panic("mock out the CreateSession method")
 //			},
-//			DeleteSessionByIDFunc: func(id string) error {
-//				panic("mock out the DeleteSessionByID method")
-//			},
 //			DeleteSessionByUserIDFunc: func(id uint) error {
 //				panic("mock out the DeleteSessionByUserID method")
 //			},
+//			DeleteSessionFamilyFunc: func(familyID uuid.UUID) error {
+//				panic("mock out the DeleteSessionFamily method")
+//			},
+//			DeleteUserSessionFamilyFunc: func(userID uint, familyID uuid.UUID) (bool, error) {
+//				panic("mock out the DeleteUserSessionFamily method")
+//			},
 //			FindSessionByAccessTokenFunc: func(token string) (*Session, error) {
 //				panic("mock out the FindSessionByAccessToken method")
 //			},
-//			FindSessionByUserIDFunc: func(id uint) (*Session, error) {
-//				panic("mock out the FindSessionByUserID method")
+//			FindSessionByFamilyFunc: func(familyID uuid.UUID) (*Session, error) {
+//				panic("mock out the FindSessionByFamily method")
+//			},
+//			ListSessionsFunc: func(userID uint) ([]*Session, error) {
+//				panic("mock out the ListSessions method")
+//			},
+//			PruneSessionsFunc: func(rotatedBefore time.Time) error {
+//				panic("mock out the PruneSessions method")
+//			},
+//			RotateSessionFunc: func(id string, next *Session) (string, bool, error) {
+//				panic("mock out the RotateSession method")
 //			},
 //		}
 //
@@ -170,69 +184,109 @@ var _ Repository = &RepositoryMock{}
 //	}
 type RepositoryMock struct {
 	// CreateSessionFunc mocks the CreateSession method.
-	CreateSessionFunc func(userID uint) (string, error)
-
-	// DeleteSessionByIDFunc mocks the DeleteSessionByID method.
-	DeleteSessionByIDFunc func(id string) error
+	CreateSessionFunc func(sess *Session) (string, error)
 
 	// DeleteSessionByUserIDFunc mocks the DeleteSessionByUserID method.
 	DeleteSessionByUserIDFunc func(id uint) error
 
+	// DeleteSessionFamilyFunc mocks the DeleteSessionFamily method.
+	DeleteSessionFamilyFunc func(familyID uuid.UUID) error
+
+	// DeleteUserSessionFamilyFunc mocks the DeleteUserSessionFamily method.
+	DeleteUserSessionFamilyFunc func(userID uint, familyID uuid.UUID) (bool, error)
+
 	// FindSessionByAccessTokenFunc mocks the FindSessionByAccessToken method.
 	FindSessionByAccessTokenFunc func(token string) (*Session, error)
 
-	// FindSessionByUserIDFunc mocks the FindSessionByUserID method.
-	FindSessionByUserIDFunc func(id uint) (*Session, error)
+	// FindSessionByFamilyFunc mocks the FindSessionByFamily method.
+	FindSessionByFamilyFunc func(familyID uuid.UUID) (*Session, error)
+
+	// ListSessionsFunc mocks the ListSessions method.
+	ListSessionsFunc func(userID uint) ([]*Session, error)
+
+	// PruneSessionsFunc mocks the PruneSessions method.
+	PruneSessionsFunc func(rotatedBefore time.Time) error
+
+	// RotateSessionFunc mocks the RotateSession method.
+	RotateSessionFunc func(id string, next *Session) (string, bool, error)
 
 	// calls tracks calls to the methods.
 	calls struct {
 		// CreateSession holds details about calls to the CreateSession method.
 		CreateSession []struct {
-			// UserID is the userID argument value.
-			UserID uint
-		}
-		// DeleteSessionByID holds details about calls to the DeleteSessionByID method.
-		DeleteSessionByID []struct {
-			// ID is the id argument value.
-			ID string
+			// Sess is the sess argument value.
+			Sess *Session
 		}
 		// DeleteSessionByUserID holds details about calls to the DeleteSessionByUserID method.
 		DeleteSessionByUserID []struct {
 			// ID is the id argument value.
 			ID uint
 		}
+		// DeleteSessionFamily holds details about calls to the DeleteSessionFamily method.
+		DeleteSessionFamily []struct {
+			// FamilyID is the familyID argument value.
+			FamilyID uuid.UUID
+		}
+		// DeleteUserSessionFamily holds details about calls to the DeleteUserSessionFamily method.
+		DeleteUserSessionFamily []struct {
+			// UserID is the userID argument value.
+			UserID uint
+			// FamilyID is the familyID argument value.
+			FamilyID uuid.UUID
+		}
 		// FindSessionByAccessToken holds details about calls to the FindSessionByAccessToken method.
 		FindSessionByAccessToken []struct {
 			// Token is the token argument value.
 			Token string
 		}
-		// FindSessionByUserID holds details about calls to the FindSessionByUserID method.
-		FindSessionByUserID []struct {
+		// FindSessionByFamily holds details about calls to the FindSessionByFamily method.
+		FindSessionByFamily []struct {
+			// FamilyID is the familyID argument value.
+			FamilyID uuid.UUID
+		}
+		// ListSessions holds details about calls to the ListSessions method.
+		ListSessions []struct {
+			// UserID is the userID argument value.
+			UserID uint
+		}
+		// PruneSessions holds details about calls to the PruneSessions method.
+		PruneSessions []struct {
+			// RotatedBefore is the rotatedBefore argument value.
+			RotatedBefore time.Time
+		}
+		// RotateSession holds details about calls to the RotateSession method.
+		RotateSession []struct {
 			// ID is the id argument value.
-			ID uint
+			ID string
+			// Next is the next argument value.
+			Next *Session
 		}
 	}
 	lockCreateSession            sync.RWMutex
-	lockDeleteSessionByID        sync.RWMutex
 	lockDeleteSessionByUserID    sync.RWMutex
+	lockDeleteSessionFamily      sync.RWMutex
+	lockDeleteUserSessionFamily  sync.RWMutex
 	lockFindSessionByAccessToken sync.RWMutex
-	lockFindSessionByUserID      sync.RWMutex
+	lockFindSessionByFamily      sync.RWMutex
+	lockListSessions             sync.RWMutex
+	lockPruneSessions            sync.RWMutex
+	lockRotateSession            sync.RWMutex
 }
 
 // CreateSession calls CreateSessionFunc.
-func (mock *RepositoryMock) CreateSession(userID uint) (string, error) {
+func (mock *RepositoryMock) CreateSession(sess *Session) (string, error) {
 	if mock.CreateSessionFunc == nil {
 		panic("RepositoryMock.CreateSessionFunc: method is nil but Repository.CreateSession was just called")
 	}
 	callInfo := struct {
-		UserID uint
+		Sess *Session
 	}{
-		UserID: userID,
+		Sess: sess,
 	}
 	mock.lockCreateSession.Lock()
 	mock.calls.CreateSession = append(mock.calls.CreateSession, callInfo)
 	mock.lockCreateSession.Unlock()
-	return mock.CreateSessionFunc(userID)
+	return mock.CreateSessionFunc(sess)
 }
 
 // CreateSessionCalls gets all the calls that were made to CreateSession.
@@ -240,10 +294,10 @@ func (mock *RepositoryMock) CreateSession(userID uint) (string, error) {
 //
 //	len(mockedRepository.CreateSessionCalls())
 func (mock *RepositoryMock) CreateSessionCalls() []struct {
-	UserID uint
+	Sess *Session
 } {
 	var calls []struct {
-		UserID uint
+		Sess *Session
 	}
 	mock.lockCreateSession.RLock()
 	calls = mock.calls.CreateSession
@@ -251,38 +305,6 @@ func (mock *RepositoryMock) CreateSessionCalls() []struct {
 	return calls
 }
 
-// DeleteSessionByID calls DeleteSessionByIDFunc.
-func (mock *RepositoryMock) DeleteSessionByID(id string) error {
-	if mock.DeleteSessionByIDFunc == nil {
-		panic("RepositoryMock.DeleteSessionByIDFunc: method is nil but Repository.DeleteSessionByID was just called")
-	}
-	callInfo := struct {
-		ID string
-	}{
-		ID: id,
-	}
-	mock.lockDeleteSessionByID.Lock()
-	mock.calls.DeleteSessionByID = append(mock.calls.DeleteSessionByID, callInfo)
-	mock.lockDeleteSessionByID.Unlock()
-	return mock.DeleteSessionByIDFunc(id)
-}
-
-// DeleteSessionByIDCalls gets all the calls that were made to DeleteSessionByID.
-// Check the length with:
-//
-//	len(mockedRepository.DeleteSessionByIDCalls())
-func (mock *RepositoryMock) DeleteSessionByIDCalls() []struct {
-	ID string
-} {
-	var calls []struct {
-		ID string
-	}
-	mock.lockDeleteSessionByID.RLock()
-	calls = mock.calls.DeleteSessionByID
-	mock.lockDeleteSessionByID.RUnlock()
-	return calls
-}
-
 // DeleteSessionByUserID calls DeleteSessionByUserIDFunc.
 func (mock *RepositoryMock) DeleteSessionByUserID(id uint) error {
 	if mock.DeleteSessionByUserIDFunc == nil {
@@ -315,6 +337,74 @@ func (mock *RepositoryMock) DeleteSessionByUserIDCalls() []struct {
 	return calls
 }
 
+// DeleteSessionFamily calls DeleteSessionFamilyFunc.
+func (mock *RepositoryMock) DeleteSessionFamily(familyID uuid.UUID) error {
+	if mock.DeleteSessionFamilyFunc == nil {
+		panic("RepositoryMock.DeleteSessionFamilyFunc: method is nil but Repository.DeleteSessionFamily was just called")
+	}
+	callInfo := struct {
+		FamilyID uuid.UUID
+	}{
+		FamilyID: familyID,
+	}
+	mock.lockDeleteSessionFamily.Lock()
+	mock.calls.DeleteSessionFamily = append(mock.calls.DeleteSessionFamily, callInfo)
+	mock.lockDeleteSessionFamily.Unlock()
+	return mock.DeleteSessionFamilyFunc(familyID)
+}
+
+// DeleteSessionFamilyCalls gets all the calls that were made to DeleteSessionFamily.
+// Check the length with:
+//
+//	len(mockedRepository.DeleteSessionFamilyCalls())
+func (mock *RepositoryMock) DeleteSessionFamilyCalls() []struct {
+	FamilyID uuid.UUID
+} {
+	var calls []struct {
+		FamilyID uuid.UUID
+	}
+	mock.lockDeleteSessionFamily.RLock()
+	calls = mock.calls.DeleteSessionFamily
+	mock.lockDeleteSessionFamily.RUnlock()
+	return calls
+}
+
+// DeleteUserSessionFamily calls DeleteUserSessionFamilyFunc.
+func (mock *RepositoryMock) DeleteUserSessionFamily(userID uint, familyID uuid.UUID) (bool, error) {
+	if mock.DeleteUserSessionFamilyFunc == nil {
+		panic("RepositoryMock.DeleteUserSessionFamilyFunc: method is nil but Repository.DeleteUserSessionFamily was just called")
+	}
+	callInfo := struct {
+		UserID   uint
+		FamilyID uuid.UUID
+	}{
+		UserID:   userID,
+		FamilyID: familyID,
+	}
+	mock.lockDeleteUserSessionFamily.Lock()
+	mock.calls.DeleteUserSessionFamily = append(mock.calls.DeleteUserSessionFamily, callInfo)
+	mock.lockDeleteUserSessionFamily.Unlock()
+	return mock.DeleteUserSessionFamilyFunc(userID, familyID)
+}
+
+// DeleteUserSessionFamilyCalls gets all the calls that were made to DeleteUserSessionFamily.
+// Check the length with:
+//
+//	len(mockedRepository.DeleteUserSessionFamilyCalls())
+func (mock *RepositoryMock) DeleteUserSessionFamilyCalls() []struct {
+	UserID   uint
+	FamilyID uuid.UUID
+} {
+	var calls []struct {
+		UserID   uint
+		FamilyID uuid.UUID
+	}
+	mock.lockDeleteUserSessionFamily.RLock()
+	calls = mock.calls.DeleteUserSessionFamily
+	mock.lockDeleteUserSessionFamily.RUnlock()
+	return calls
+}
+
 // FindSessionByAccessToken calls FindSessionByAccessTokenFunc.
 func (mock *RepositoryMock) FindSessionByAccessToken(token string) (*Session, error) {
 	if mock.FindSessionByAccessTokenFunc == nil {
@@ -347,34 +437,218 @@ func (mock *RepositoryMock) FindSessionByAccessTokenCalls() []struct {
 	return calls
 }
 
-// FindSessionByUserID calls FindSessionByUserIDFunc.
-func (mock *RepositoryMock) FindSessionByUserID(id uint) (*Session, error) {
-	if mock.FindSessionByUserIDFunc == nil {
-		panic("RepositoryMock.FindSessionByUserIDFunc: method is nil but Repository.FindSessionByUserID was just called")
+// FindSessionByFamily calls FindSessionByFamilyFunc.
+func (mock *RepositoryMock) FindSessionByFamily(familyID uuid.UUID) (*Session, error) {
+	if mock.FindSessionByFamilyFunc == nil {
+		panic("RepositoryMock.FindSessionByFamilyFunc: method is nil but Repository.FindSessionByFamily was just called")
 	}
 	callInfo := struct {
-		ID uint
+		FamilyID uuid.UUID
 	}{
-		ID: id,
+		FamilyID: familyID,
 	}
-	mock.lockFindSessionByUserID.Lock()
-	mock.calls.FindSessionByUserID = append(mock.calls.FindSessionByUserID, callInfo)
-	mock.lockFindSessionByUserID.Unlock()
-	return mock.FindSessionByUserIDFunc(id)
+	mock.lockFindSessionByFamily.Lock()
+	mock.calls.FindSessionByFamily = append(mock.calls.FindSessionByFamily, callInfo)
+	mock.lockFindSessionByFamily.Unlock()
+	return mock.FindSessionByFamilyFunc(familyID)
 }
 
-// FindSessionByUserIDCalls gets all the calls that were made to FindSessionByUserID.
+// FindSessionByFamilyCalls gets all the calls that were made to FindSessionByFamily.
 // Check the length with:
 //
-//	len(mockedRepository.FindSessionByUserIDCalls())
-func (mock *RepositoryMock) FindSessionByUserIDCalls() []struct {
-	ID uint
+//	len(mockedRepository.FindSessionByFamilyCalls())
+func (mock *RepositoryMock) FindSessionByFamilyCalls() []struct {
+	FamilyID uuid.UUID
 } {
 	var calls []struct {
-		ID uint
+		FamilyID uuid.UUID
+	}
+	mock.lockFindSessionByFamily.RLock()
+	calls = mock.calls.FindSessionByFamily
+	mock.lockFindSessionByFamily.RUnlock()
+	return calls
+}
+
+// ListSessions calls ListSessionsFunc.
+func (mock *RepositoryMock) ListSessions(userID uint) ([]*Session, error) {
+	if mock.ListSessionsFunc == nil {
+		panic("RepositoryMock.ListSessionsFunc: method is nil but Repository.ListSessions was just called")
+	}
+	callInfo := struct {
+		UserID uint
+	}{
+		UserID: userID,
+	}
+	mock.lockListSessions.Lock()
+	mock.calls.ListSessions = append(mock.calls.ListSessions, callInfo)
+	mock.lockListSessions.Unlock()
+	return mock.ListSessionsFunc(userID)
+}
+
+// ListSessionsCalls gets all the calls that were made to ListSessions.
+// Check the length with:
+//
+//	len(mockedRepository.ListSessionsCalls())
+func (mock *RepositoryMock) ListSessionsCalls() []struct {
+	UserID uint
+} {
+	var calls []struct {
+		UserID uint
+	}
+	mock.lockListSessions.RLock()
+	calls = mock.calls.ListSessions
+	mock.lockListSessions.RUnlock()
+	return calls
+}
+
+// PruneSessions calls PruneSessionsFunc.
+func (mock *RepositoryMock) PruneSessions(rotatedBefore time.Time) error {
+	if mock.PruneSessionsFunc == nil {
+		panic("RepositoryMock.PruneSessionsFunc: method is nil but Repository.PruneSessions was just called")
+	}
+	callInfo := struct {
+		RotatedBefore time.Time
+	}{
+		RotatedBefore: rotatedBefore,
+	}
+	mock.lockPruneSessions.Lock()
+	mock.calls.PruneSessions = append(mock.calls.PruneSessions, callInfo)
+	mock.lockPruneSessions.Unlock()
+	return mock.PruneSessionsFunc(rotatedBefore)
+}
+
+// PruneSessionsCalls gets all the calls that were made to PruneSessions.
+// Check the length with:
+//
+//	len(mockedRepository.PruneSessionsCalls())
+func (mock *RepositoryMock) PruneSessionsCalls() []struct {
+	RotatedBefore time.Time
+} {
+	var calls []struct {
+		RotatedBefore time.Time
+	}
+	mock.lockPruneSessions.RLock()
+	calls = mock.calls.PruneSessions
+	mock.lockPruneSessions.RUnlock()
+	return calls
+}
+
+// RotateSession calls RotateSessionFunc.
+func (mock *RepositoryMock) RotateSession(id string, next *Session) (string, bool, error) {
+	if mock.RotateSessionFunc == nil {
+		panic("RepositoryMock.RotateSessionFunc: method is nil but Repository.RotateSession was just called")
+	}
+	callInfo := struct {
+		ID   string
+		Next *Session
+	}{
+		ID:   id,
+		Next: next,
+	}
+	mock.lockRotateSession.Lock()
+	mock.calls.RotateSession = append(mock.calls.RotateSession, callInfo)
+	mock.lockRotateSession.Unlock()
+	return mock.RotateSessionFunc(id, next)
+}
+
+// RotateSessionCalls gets all the calls that were made to RotateSession.
+// Check the length with:
+//
+//	len(mockedRepository.RotateSessionCalls())
+func (mock *RepositoryMock) RotateSessionCalls() []struct {
+	ID   string
+	Next *Session
+} {
+	var calls []struct {
+		ID   string
+		Next *Session
+	}
+	mock.lockRotateSession.RLock()
+	calls = mock.calls.RotateSession
+	mock.lockRotateSession.RUnlock()
+	return calls
+}
+
+// Ensure, that NotifierMock does implement Notifier.
+// If this is not the case, regenerate this file with moq.
+var _ Notifier = &NotifierMock{}
+
+// NotifierMock is a mock implementation of Notifier.
+//
+//	func TestSomethingThatUsesNotifier(t *testing.T) {
+//
+//		// make and configure a mocked Notifier
+//		mockedNotifier := &NotifierMock{
+//			NotifyFunc: func(ctx context.Context, userID uint, event string, data map[string]string)  {
+//				panic("mock out the Notify method")
+//			},
+//		}
+//
+//		// use mockedNotifier in code that requires Notifier
+//		// and then make assertions.
+//
+//	}
+type NotifierMock struct {
+	// NotifyFunc mocks the Notify method.
+	NotifyFunc func(ctx context.Context, userID uint, event string, data map[string]string)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Notify holds details about calls to the Notify method.
+		Notify []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// UserID is the userID argument value.
+			UserID uint
+			// Event is the event argument value.
+			Event string
+			// Data is the data argument value.
+			Data map[string]string
+		}
+	}
+	lockNotify sync.RWMutex
+}
+
+// Notify calls NotifyFunc.
+func (mock *NotifierMock) Notify(ctx context.Context, userID uint, event string, data map[string]string) {
+	if mock.NotifyFunc == nil {
+		panic("NotifierMock.NotifyFunc: method is nil but Notifier.Notify was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		UserID uint
+		Event  string
+		Data   map[string]string
+	}{
+		Ctx:    ctx,
+		UserID: userID,
+		Event:  event,
+		Data:   data,
+	}
+	mock.lockNotify.Lock()
+	mock.calls.Notify = append(mock.calls.Notify, callInfo)
+	mock.lockNotify.Unlock()
+	mock.NotifyFunc(ctx, userID, event, data)
+}
+
+// NotifyCalls gets all the calls that were made to Notify.
+// Check the length with:
+//
+//	len(mockedNotifier.NotifyCalls())
+func (mock *NotifierMock) NotifyCalls() []struct {
+	Ctx    context.Context
+	UserID uint
+	Event  string
+	Data   map[string]string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		UserID uint
+		Event  string
+		Data   map[string]string
 	}
-	mock.lockFindSessionByUserID.RLock()
-	calls = mock.calls.FindSessionByUserID
-	mock.lockFindSessionByUserID.RUnlock()
+	mock.lockNotify.RLock()
+	calls = mock.calls.Notify
+	mock.lockNotify.RUnlock()
 	return calls
 }
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/ivch/dynasty/server/handlers/auth"
+)
+
+// LoginAttempts keeps the failed logins in PostgreSQL, so all the instances share them.
+type LoginAttempts struct {
+	db *gorm.DB
+}
+
+func NewLoginAttempts(db *gorm.DB) *LoginAttempts {
+	return &LoginAttempts{db: db}
+}
+
+func (l *LoginAttempts) GetLoginAttempt(key string) (*auth.LoginAttempt, error) {
+	var a auth.LoginAttempt
+	if err := l.db.Where("key = ?", key).First(&a).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return &auth.LoginAttempt{Key: key}, nil
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+// FailLogin counts the failure, the row stays locked until the block is set.
+func (l *LoginAttempts) FailLogin(key string, since time.Time, block func(failures int) time.Time) (*auth.LoginAttempt, error) {
+	a := auth.LoginAttempt{Key: key}
+	err := l.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Raw(`INSERT INTO login_attempts (key, failures, blocked_until, updated_at) VALUES (?, 1, ?, ?)
+			ON CONFLICT (key) DO UPDATE SET
+				failures = CASE WHEN login_attempts.updated_at < ? THEN 1 ELSE login_attempts.failures + 1 END,
+				updated_at = EXCLUDED.updated_at
+			RETURNING failures, blocked_until, updated_at`, key, time.Time{}, now, since).
+			Row().Scan(&a.Failures, &a.BlockedUntil, &a.UpdatedAt); err != nil {
+			return err
+		}
+
+		if until := block(a.Failures); until.After(a.BlockedUntil) {
+			a.BlockedUntil = until
+			return tx.Model(&auth.LoginAttempt{}).Where("key = ?", key).Update("blocked_until", until).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (l *LoginAttempts) ResetLogin(key string) error {
+	return l.db.Where("key = ?", key).Delete(auth.LoginAttempt{}).Error
+}
+
+func (l *LoginAttempts) PruneLogins(since time.Time) error {
+	return l.db.Where("updated_at < ? AND blocked_until < ?", since, time.Now()).Delete(auth.LoginAttempt{}).Error
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ivch/dynasty/server/handlers/auth"
+)
+
+// MemoryLoginAttempts keeps the failed logins in memory, for the single instance.
+type MemoryLoginAttempts struct {
+	mu       sync.Mutex
+	attempts map[string]auth.LoginAttempt
+}
+
+func NewMemoryLoginAttempts() *MemoryLoginAttempts {
+	return &MemoryLoginAttempts{attempts: make(map[string]auth.LoginAttempt)}
+}
+
+func (m *MemoryLoginAttempts) GetLoginAttempt(key string) (*auth.LoginAttempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.attempts[key]
+	if !ok {
+		a = auth.LoginAttempt{Key: key}
+	}
+	return &a, nil
+}
+
+func (m *MemoryLoginAttempts) FailLogin(key string, since time.Time, block func(failures int) time.Time) (*auth.LoginAttempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.attempts[key]
+	if !ok || a.UpdatedAt.Before(since) {
+		a.Key, a.Failures = key, 0
+	}
+	a.Failures++
+	a.UpdatedAt = time.Now()
+	if until := block(a.Failures); until.After(a.BlockedUntil) {
+		a.BlockedUntil = until
+	}
+	m.attempts[key] = a
+
+	return &a, nil
+}
+
+func (m *MemoryLoginAttempts) ResetLogin(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.attempts, key)
+	return nil
+}
+
+func (m *MemoryLoginAttempts) PruneLogins(since time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for key, a := range m.attempts {
+		if a.UpdatedAt.Before(since) && a.BlockedUntil.Before(now) {
+			delete(m.attempts, key)
+		}
+	}
+	return nil
+}
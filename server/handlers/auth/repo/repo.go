@@ -17,31 +17,48 @@ func New(db *gorm.DB) *Auth {
 	return &Auth{db: db}
 }
 
-func (a *Auth) CreateSession(userID uint) (string, error) {
+// CreateSession stores the session, the one without the family starts the new one.
+func (a *Auth) CreateSession(sess *auth.Session) (string, error) {
+	return createSession(a.db, sess)
+}
+
+func createSession(db *gorm.DB, sess *auth.Session) (string, error) {
 	rt := uuid.NewV4()
-	sess := auth.Session{
-		UserID:       userID,
-		RefreshToken: rt,
-		ExpiresIn:    time.Now().Add(30 * 24 * time.Hour).Unix(), // 30 days
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+	sess.RefreshToken = rt
+	if uuid.Equal(sess.FamilyID, uuid.Nil) {
+		sess.FamilyID = uuid.NewV4()
 	}
+	sess.ExpiresIn = time.Now().Add(30 * 24 * time.Hour).Unix() // 30 days
+	sess.CreatedAt = time.Now()
+	sess.UpdatedAt = time.Now()
 
-	if err := a.db.Create(&sess).Error; err != nil {
+	if err := db.Create(sess).Error; err != nil {
 		return "", err
 	}
 
 	return rt.String(), nil
 }
 
-func (a *Auth) FindSessionByUserID(id uint) (*auth.Session, error) {
+// FindSessionByFamily returns the current session of the family, the one not rotated yet.
+func (a *Auth) FindSessionByFamily(familyID uuid.UUID) (*auth.Session, error) {
 	var sess auth.Session
-	if err := a.db.Where("user_id = ?", id).First(&sess).Error; err != nil {
+	if err := a.db.Where("family_id = ? AND rotated_at IS NULL AND expires_in > ?", familyID, time.Now().Unix()).
+		First(&sess).Error; err != nil {
 		return nil, err
 	}
 	return &sess, nil
 }
 
+// ListSessions returns the current sessions of the user's families, the latest refreshed first.
+func (a *Auth) ListSessions(userID uint) ([]*auth.Session, error) {
+	var res []*auth.Session
+	if err := a.db.Where("user_id = ? AND rotated_at IS NULL AND expires_in > ?", userID, time.Now().Unix()).
+		Order("created_at desc").Find(&res).Error; err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
 func (a *Auth) FindSessionByAccessToken(token string) (*auth.Session, error) {
 	var sess auth.Session
 	if err := a.db.Where("refresh_token = ?", token).First(&sess).Error; err != nil {
@@ -50,8 +67,46 @@ func (a *Auth) FindSessionByAccessToken(token string) (*auth.Session, error) {
 	return &sess, nil
 }
 
-func (a *Auth) DeleteSessionByID(id string) error {
-	return a.db.Where("id = ?", id).Delete(auth.Session{}).Error
+// RotateSession replaces the session with the next one, false if it was rotated already.
+func (a *Auth) RotateSession(id string, next *auth.Session) (string, bool, error) {
+	var rt string
+	var rotated bool
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&auth.Session{}).Where("id = ? AND rotated_at IS NULL", id).Update("rotated_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if rotated = res.RowsAffected == 1; !rotated {
+			return nil
+		}
+
+		var err error
+		rt, err = createSession(tx, next)
+		return err
+	})
+	if err != nil {
+		return "", false, err
+	}
+	return rt, rotated, nil
+}
+
+// PruneSessions deletes the expired sessions and the ones rotated before the time.
+func (a *Auth) PruneSessions(rotatedBefore time.Time) error {
+	return a.db.Where("rotated_at < ? OR expires_in < ?", rotatedBefore, time.Now().Unix()).
+		Delete(auth.Session{}).Error
+}
+
+func (a *Auth) DeleteSessionFamily(familyID uuid.UUID) error {
+	return a.db.Where("family_id = ?", familyID).Delete(auth.Session{}).Error
+}
+
+// DeleteUserSessionFamily deletes the family of the user's sessions, it returns false when there is no such family.
+func (a *Auth) DeleteUserSessionFamily(userID uint, familyID uuid.UUID) (bool, error) {
+	res := a.db.Where("user_id = ? AND family_id = ?", userID, familyID).Delete(auth.Session{})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
 }
 
 func (a *Auth) DeleteSessionByUserID(id uint) error {
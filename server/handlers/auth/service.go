@@ -2,13 +2,17 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/ivch/dynasty/common/errs"
 	"github.com/ivch/dynasty/common/logger"
+	"github.com/ivch/dynasty/server/handlers/auth/token"
 	users "github.com/ivch/dynasty/server/handlers/users/transport"
+	uuid "github.com/satori/go.uuid"
 )
 
 type UserService interface {
@@ -17,65 +21,155 @@ type UserService interface {
 }
 
 type Repository interface {
-	CreateSession(userID uint) (string, error)
+	CreateSession(sess *Session) (string, error)
 	FindSessionByAccessToken(token string) (*Session, error)
-	DeleteSessionByID(id string) error
+	RotateSession(id string, next *Session) (string, bool, error)
+	DeleteSessionFamily(familyID uuid.UUID) error
+	DeleteUserSessionFamily(userID uint, familyID uuid.UUID) (bool, error)
 	DeleteSessionByUserID(id uint) error
-	FindSessionByUserID(id uint) (*Session, error)
+	FindSessionByFamily(familyID uuid.UUID) (*Session, error)
+	ListSessions(userID uint) ([]*Session, error)
+	PruneSessions(rotatedBefore time.Time) error
+}
+
+const (
+	// DefaultAccessTokenTTL is the lifetime of the access token, the clients refresh it with the refresh token.
+	DefaultAccessTokenTTL = 15 * time.Minute
+	// DefaultLegacyTokenTTL keeps the legacy clients, which cannot refresh the token, logged in.
+	DefaultLegacyTokenTTL = 90 * 24 * time.Hour
+	// ReuseWindow is how long the rotated sessions are kept to detect the reuse of their refresh tokens.
+	ReuseWindow = 7 * 24 * time.Hour
+)
+
+// LegacyClients are the clients still getting the long-living access tokens.
+type LegacyClients struct {
+	UserAgents []string
+	MinVersion string
+	TokenTTL   time.Duration
+}
+
+// EventRefreshTokenReused tells the user the sessions of the login were revoked.
+const EventRefreshTokenReused = "refresh_token_reused"
+
+// Notifier delivers the events to the users through their notification channels.
+type Notifier interface {
+	Notify(ctx context.Context, userID uint, event string, data map[string]string)
 }
 
 type Service struct {
-	log       logger.Logger
-	uSrv      UserService
-	repo      Repository
-	jwtSecret string
+	log            logger.Logger
+	uSrv           UserService
+	repo           Repository
+	jwtSecret      string
+	keys           *token.KeySet
+	tokens         *token.Manager
+	notifier       Notifier
+	guard          *LoginGuard
+	accessTokenTTL time.Duration
+	legacy         LegacyClients
+}
+
+type Option func(*Service)
+
+// WithNotifier sends the security events to the notifier.
+func WithNotifier(n Notifier) Option {
+	return func(s *Service) {
+		s.notifier = n
+	}
+}
+
+// WithLoginGuard protects the login from the password guessing.
+func WithLoginGuard(g *LoginGuard) Option {
+	return func(s *Service) {
+		s.guard = g
+	}
+}
+
+// WithKeys signs the tokens with the signing key of the set.
+func WithKeys(k *token.KeySet) Option {
+	return func(s *Service) {
+		s.keys = k
+	}
+}
+
+// WithAccessTokenTTL sets the lifetime of the access tokens, DefaultAccessTokenTTL by default.
+func WithAccessTokenTTL(d time.Duration) Option {
+	return func(s *Service) {
+		if d > 0 {
+			s.accessTokenTTL = d
+		}
+	}
 }
 
-func New(log logger.Logger, repo Repository, uSrv UserService, jwtSecret string) *Service {
+// WithLegacyClients issues the access tokens of the legacy clients for TokenTTL, DefaultLegacyTokenTTL by default.
+func WithLegacyClients(c LegacyClients) Option {
+	return func(s *Service) {
+		if c.TokenTTL <= 0 {
+			c.TokenTTL = DefaultLegacyTokenTTL
+		}
+		s.legacy = c
+	}
+}
+
+func New(log logger.Logger, repo Repository, uSrv UserService, jwtSecret string, opts ...Option) *Service {
 	s := Service{
-		log:       log,
-		repo:      repo,
-		uSrv:      uSrv,
-		jwtSecret: jwtSecret,
+		log:            log,
+		repo:           repo,
+		uSrv:           uSrv,
+		jwtSecret:      jwtSecret,
+		accessTokenTTL: DefaultAccessTokenTTL,
+	}
+
+	for _, o := range opts {
+		o(&s)
 	}
 
+	s.tokens = token.New(jwtSecret, s.keys)
+
 	return &s
 }
 
-func (s *Service) Gwfa(token string) (uint, error) {
-	var myClaims Token
-	t, err := jwt.ParseWithClaims(token, &myClaims, func(token *jwt.Token) (i interface{}, e error) {
-		return []byte(s.jwtSecret), nil
-	})
+// Gwfa returns the user and the session of the access token, the session must still be active.
+func (s *Service) Gwfa(tok string) (uint, string, error) {
+	claims, err := s.tokens.Parse(tok)
 	if err != nil {
-		return 0, fmt.Errorf("%s: %w", errs.FailedParsingToken, err)
+		// only the otherwise valid token is reported as expired
+		if errors.Is(err, token.ErrExpired) {
+			return 0, "", errs.TokenExpired
+		}
+		return 0, "", fmt.Errorf("%s: %w", errs.FailedParsingToken, err)
 	}
 
-	claims, ok := t.Claims.(*Token)
-	if !ok {
-		return 0, errs.FailedParsingTokenClaims
+	// the tokens issued before the sessions were tracked are refreshed by the clients
+	familyID, err := uuid.FromString(claims.SessionID)
+	if err != nil {
+		return 0, "", errs.TokenExpired
 	}
 
-	sess, err := s.repo.FindSessionByUserID(claims.ID)
-	if err != nil {
-		return 0, errs.TokenExpired
+	sess, err := s.repo.FindSessionByFamily(familyID)
+	if err != nil || sess.UserID != claims.ID {
+		return 0, "", errs.TokenExpired
 	}
 
-	return sess.UserID, nil
+	return sess.UserID, claims.SessionID, nil
 }
 
-func (s *Service) Refresh(ctx context.Context, token string) (*Tokens, error) {
+// Refresh exchanges the refresh token for the new pair, the reused one revokes its family.
+func (s *Service) Refresh(ctx context.Context, token string, client Client) (*Tokens, error) {
 	sess, err := s.repo.FindSessionByAccessToken(token)
 	if err != nil {
 		return nil, errs.NoSessionToRefresh
 	}
 
-	if err := s.repo.DeleteSessionByID(sess.ID); err != nil {
-		s.log.Error("error deleting sessions: %w", err)
-		return nil, err
+	if sess.RotatedAt != nil {
+		return nil, s.revokeFamily(ctx, sess, client)
 	}
 
 	if time.Now().Unix() > sess.ExpiresIn {
+		if err := s.repo.DeleteSessionFamily(sess.FamilyID); err != nil {
+			s.log.Error("error deleting sessions: %w", err)
+			return nil, err
+		}
 		return nil, errs.TokenExpired
 	}
 
@@ -85,13 +179,24 @@ func (s *Service) Refresh(ctx context.Context, token string) (*Tokens, error) {
 		return nil, err
 	}
 
-	rt, err := s.repo.CreateSession(sess.UserID)
+	rt, rotated, err := s.repo.RotateSession(sess.ID, &Session{
+		UserID:    sess.UserID,
+		FamilyID:  sess.FamilyID,
+		Name:      sess.Name,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+	})
 	if err != nil {
-		s.log.Error("failed to create session: %w", err)
+		s.log.Error("error rotating session: %w", err)
 		return nil, err
 	}
 
-	at, err := s.generateAccessToken(u)
+	// the token was exchanged concurrently by someone else
+	if !rotated {
+		return nil, s.revokeFamily(ctx, sess, client)
+	}
+
+	at, err := s.generateAccessToken(u, sess.FamilyID, s.tokenTTL(client))
 	if err != nil {
 		s.log.Error("failed to create access token: %w", err)
 		return nil, err
@@ -103,25 +208,80 @@ func (s *Service) Refresh(ctx context.Context, token string) (*Tokens, error) {
 	}, nil
 }
 
-func (s *Service) Login(ctx context.Context, phone, password string) (*Tokens, error) {
+// RunSessionsCleanup deletes the expired and the long rotated sessions every interval.
+func (s *Service) RunSessionsCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.repo.PruneSessions(time.Now().Add(-ReuseWindow)); err != nil {
+				s.log.Error("error pruning sessions: %w", err)
+			}
+		}
+	}
+}
+
+// revokeFamily deletes the sessions of the login the reused token belongs to and warns the user.
+func (s *Service) revokeFamily(ctx context.Context, sess *Session, client Client) error {
+	s.log.Warn("security: reused refresh token of user %d, revoking session family %s (ip %s, user agent %q)",
+		sess.UserID, sess.FamilyID, client.IP, client.UserAgent)
+
+	if err := s.repo.DeleteSessionFamily(sess.FamilyID); err != nil {
+		s.log.Error("error revoking session family: %w", err)
+		return err
+	}
+
+	if s.notifier != nil {
+		s.notifier.Notify(ctx, sess.UserID, EventRefreshTokenReused, map[string]string{
+			"ip":         client.IP,
+			"user_agent": client.UserAgent,
+		})
+	}
+
+	return errs.SessionRevoked
+}
+
+func (s *Service) Login(ctx context.Context, phone, password string, client Client) (*Tokens, error) {
+	if s.guard != nil {
+		if err := s.guard.Check(phone, client.IP); err != nil {
+			return nil, err
+		}
+	}
+
 	u, err := s.uSrv.UserByPhoneAndPassword(ctx, phone, password)
 	if err != nil {
 		s.log.Error("error finding users: %w", err.Error())
+		// only the wrong credentials are counted, the database outage must not lock the users out
+		if !errors.Is(err, errs.InvalidCredentials) && !errors.Is(err, errs.UserNotFound) {
+			return nil, err
+		}
+		if s.guard != nil {
+			s.guard.Fail(phone, client.IP)
+		}
 		return nil, errs.InvalidCredentials
 	}
 
+	if s.guard != nil {
+		s.guard.Succeed(phone)
+	}
+
 	if !u.Active {
 		return nil, errs.UserIsInactive
 	}
 
-	// todo do not create session if user already has one
-	rt, err := s.repo.CreateSession(u.ID)
+	// every login is the separate session, so the user stays logged in on all the devices
+	sess := Session{UserID: u.ID, Name: client.Name, IP: client.IP, UserAgent: client.UserAgent}
+	rt, err := s.repo.CreateSession(&sess)
 	if err != nil {
 		s.log.Error("failed to create session: %w", err)
 		return nil, err
 	}
 
-	at, err := s.generateAccessToken(u)
+	at, err := s.generateAccessToken(u, sess.FamilyID, s.tokenTTL(client))
 	if err != nil {
 		return nil, err
 	}
@@ -132,30 +292,95 @@ func (s *Service) Login(ctx context.Context, phone, password string) (*Tokens, e
 	}, nil
 }
 
-func (s *Service) Logout(ctx context.Context, id uint) error {
+// Logout ends the session of the request, without the session all the sessions of the user are ended.
+func (s *Service) Logout(ctx context.Context, id uint, sessionID string) error {
 	_, err := s.uSrv.UserByID(ctx, id)
 	if err != nil {
 		s.log.Error("failed to get user: %w", err)
 		return err
 	}
 
-	return s.repo.DeleteSessionByUserID(id)
+	if sessionID == "" {
+		return s.repo.DeleteSessionByUserID(id)
+	}
+
+	return s.RevokeSession(ctx, id, sessionID)
+}
+
+// Sessions returns the active sessions of the user, one per logged in device.
+func (s *Service) Sessions(_ context.Context, userID uint) ([]*Session, error) {
+	res, err := s.repo.ListSessions(userID)
+	if err != nil {
+		s.log.Error("error listing sessions: %w", err)
+		return nil, err
+	}
+
+	return res, nil
 }
 
-func (s *Service) generateAccessToken(u *users.UserByIDResponse) (string, error) {
-	claims := Token{
-		ID:   u.ID,
-		Name: fmt.Sprintf("%s %s", u.FirstName, u.LastName),
-		Role: u.Role,
-		StandardClaims: jwt.StandardClaims{
-			Audience:  "dynapp",
-			ExpiresAt: time.Now().Add(3 * 30 * 24 * time.Hour).Unix(), // 29 days us a tmp fix due to broken ios client
-			Issuer:    "auth.dynapp",
-			IssuedAt:  time.Now().Unix(),
-		},
+// RevokeSession ends the session of the user on the device, its access token stops working right away.
+func (s *Service) RevokeSession(_ context.Context, userID uint, sessionID string) error {
+	familyID, err := uuid.FromString(sessionID)
+	if err != nil {
+		return errs.SessionNotFound
 	}
 
-	token := jwt.NewWithClaims(jwt.GetSigningMethod("HS256"), claims)
+	ok, err := s.repo.DeleteUserSessionFamily(userID, familyID)
+	if err != nil {
+		s.log.Error("error revoking session: %w", err)
+		return err
+	}
+
+	if !ok {
+		return errs.SessionNotFound
+	}
+
+	return nil
+}
+
+// tokenTTL returns the lifetime of the access token of the client.
+func (s *Service) tokenTTL(c Client) time.Duration {
+	for _, ua := range s.legacy.UserAgents {
+		if ua != "" && strings.Contains(c.UserAgent, ua) {
+			return s.legacy.TokenTTL
+		}
+	}
+
+	if s.legacy.MinVersion != "" && c.Version != "" && versionLess(c.Version, s.legacy.MinVersion) {
+		return s.legacy.TokenTTL
+	}
+
+	return s.accessTokenTTL
+}
+
+// versionLess compares the dotted versions numerically, e.g. 1.9 < 1.10, the missing parts are zeros.
+func versionLess(a, b string) bool {
+	ap, bp := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(ap) || i < len(bp); i++ {
+		var x, y int
+		if i < len(ap) {
+			x, _ = strconv.Atoi(ap[i]) // nolint: errcheck
+		}
+		if i < len(bp) {
+			y, _ = strconv.Atoi(bp[i]) // nolint: errcheck
+		}
+		if x != y {
+			return x < y
+		}
+	}
+	return false
+}
+
+func (s *Service) generateAccessToken(u *users.UserByIDResponse, sessionID uuid.UUID, ttl time.Duration) (string, error) {
+	return s.tokens.Sign(&token.Claims{
+		ID:        u.ID,
+		Name:      fmt.Sprintf("%s %s", u.FirstName, u.LastName),
+		Role:      u.Role,
+		SessionID: sessionID.String(),
+	}, ttl)
+}
 
-	return token.SignedString([]byte(s.jwtSecret))
+// JWKS returns the public keys the tokens are verified with.
+func (s *Service) JWKS() *token.JWKS {
+	return s.tokens.JWKS()
 }
@@ -0,0 +1,213 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Algorithms of the signing keys.
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+const (
+	keyFileExt = ".pem"
+	minRSABits = 2048
+)
+
+// Key signs or verifies the access tokens, the key without the private part only verifies.
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// KeySet is the signing key together with all the keys the tokens are verified with.
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+// JWK is the public key in the JSON Web Key format, RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is the published set of the verification keys.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewKeySet returns the set signing with the key of signingKeyID, which must have the private part.
+func NewKeySet(signingKeyID string, keys ...*Key) (*KeySet, error) {
+	s := KeySet{keys: make(map[string]*Key, len(keys))}
+	for _, k := range keys {
+		if _, ok := s.keys[k.ID]; ok {
+			return nil, fmt.Errorf("duplicate key %q", k.ID)
+		}
+		s.keys[k.ID] = k
+	}
+
+	k, ok := s.keys[signingKeyID]
+	if !ok {
+		return nil, fmt.Errorf("signing key %q not found", signingKeyID)
+	}
+	if k.Private == nil {
+		return nil, fmt.Errorf("signing key %q has no private key", signingKeyID)
+	}
+	s.signing = k
+
+	return &s, nil
+}
+
+// LoadKeys loads the PEM keys of the dir, named by their key IDs.
+func LoadKeys(dir, signingKeyID string) (*KeySet, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+keyFileExt))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*Key, 0, len(files))
+	for _, f := range files {
+		data, err := os.ReadFile(f) // nolint: gosec
+		if err != nil {
+			return nil, err
+		}
+
+		k, err := ParseKey(strings.TrimSuffix(filepath.Base(f), keyFileExt), data)
+		if err != nil {
+			return nil, fmt.Errorf("bad key file %s: %w", f, err)
+		}
+		keys = append(keys, k)
+	}
+
+	return NewKeySet(signingKeyID, keys...)
+}
+
+// ParseKey parses the PEM encoded private or public key.
+func ParseKey(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data")
+	}
+
+	var (
+		key interface{}
+		err error
+	)
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	k := Key{ID: id}
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		k.Method, k.Private, k.Public = jwt.SigningMethodRS256, key, &key.PublicKey
+	case *rsa.PublicKey:
+		k.Method, k.Public = jwt.SigningMethodRS256, key
+	case ed25519.PrivateKey:
+		k.Method, k.Private, k.Public = jwt.SigningMethodEdDSA, key, key.Public()
+	case ed25519.PublicKey:
+		k.Method, k.Public = jwt.SigningMethodEdDSA, key
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+
+	if pub, ok := k.Public.(*rsa.PublicKey); ok && pub.N.BitLen() < minRSABits {
+		return nil, fmt.Errorf("RSA key is shorter than %d bits", minRSABits)
+	}
+
+	return &k, nil
+}
+
+// GenerateKey returns the new PKCS#8 private key of the algorithm in PEM.
+func GenerateKey(alg string) ([]byte, error) {
+	var (
+		key interface{}
+		err error
+	)
+	switch alg {
+	case AlgRS256:
+		key, err = rsa.GenerateKey(rand.Reader, minRSABits)
+	case AlgEdDSA:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// Signing returns the key the new tokens are signed with.
+func (s *KeySet) Signing() *Key {
+	return s.signing
+}
+
+// Key returns the verification key by the ID.
+func (s *KeySet) Key(id string) (*Key, bool) {
+	k, ok := s.keys[id]
+	return k, ok
+}
+
+// JWKS returns the public keys of the set ordered by the ID.
+func (s *KeySet) JWKS() *JWKS {
+	res := JWKS{Keys: make([]JWK, 0, len(s.keys))}
+	for _, k := range s.keys {
+		jwk := JWK{Kid: k.ID, Use: "sig", Alg: k.Method.Alg()}
+		switch pub := k.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty, jwk.Crv = "OKP", "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		res.Keys = append(res.Keys, jwk)
+	}
+
+	sort.Slice(res.Keys, func(i, j int) bool { return res.Keys[i].Kid < res.Keys[j].Kid })
+
+	return &res
+}
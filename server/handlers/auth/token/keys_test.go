@@ -0,0 +1,115 @@
+package token_test
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ivch/dynasty/server/handlers/auth/token"
+)
+
+func generateKey(t *testing.T, id, alg string) *token.Key {
+	t.Helper()
+
+	data, err := token.GenerateKey(alg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k, err := token.ParseKey(id, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return k
+}
+
+func publicPEM(t *testing.T, k *token.Key) []byte {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(k.Public)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func TestLoadKeys(t *testing.T) {
+	signing, err := token.GenerateKey(token.AlgEdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retiring := publicPEM(t, generateKey(t, "old", token.AlgRS256))
+
+	tests := []struct {
+		name       string
+		files      map[string][]byte
+		signingKey string
+		wantErr    bool
+		wantKeys   []string
+	}{
+		{
+			name:       "ok",
+			files:      map[string][]byte{"new.pem": signing, "old.pem": retiring, "README": []byte("not a key")},
+			signingKey: "new",
+			wantKeys:   []string{"EdDSA new OKP", "RS256 old RSA"},
+		},
+		{
+			name:       "error no signing key",
+			files:      map[string][]byte{"old.pem": retiring},
+			signingKey: "new",
+			wantErr:    true,
+		},
+		{
+			name:       "error signing key without private part",
+			files:      map[string][]byte{"old.pem": retiring},
+			signingKey: "old",
+			wantErr:    true,
+		},
+		{
+			name:       "error bad key file",
+			files:      map[string][]byte{"new.pem": signing, "bad.pem": []byte("garbage")},
+			signingKey: "new",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, data := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			keys, err := token.LoadKeys(dir, tt.signingKey)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadKeys() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if keys.Signing().ID != tt.signingKey {
+				t.Errorf("LoadKeys() signing = %s, want %s", keys.Signing().ID, tt.signingKey)
+			}
+
+			jwks := keys.JWKS()
+			if len(jwks.Keys) != len(tt.wantKeys) {
+				t.Fatalf("JWKS() got %d keys, want %d", len(jwks.Keys), len(tt.wantKeys))
+			}
+			for i, k := range jwks.Keys {
+				if got := k.Alg + " " + k.Kid + " " + k.Kty; got != tt.wantKeys[i] || k.Use != "sig" {
+					t.Errorf("JWKS() key = %#v, want %s", k, tt.wantKeys[i])
+				}
+				if k.Kty == "RSA" && (k.N == "" || k.E != "AQAB") || k.Kty == "OKP" && (k.Crv != "Ed25519" || k.X == "") {
+					t.Errorf("JWKS() bad key material %#v", k)
+				}
+			}
+		})
+	}
+}
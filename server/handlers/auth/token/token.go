@@ -0,0 +1,135 @@
+// Package token issues and verifies the access tokens.
+package token
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	Audience = "dynapp"
+	Issuer   = "auth.dynapp"
+	// Leeway tolerates the clock skew between the issuer and the verifiers.
+	Leeway = 30 * time.Second
+)
+
+var (
+	// ErrExpired is returned for the otherwise valid token which has expired.
+	ErrExpired = errors.New("token expired")
+	// ErrInvalid is returned for the malformed, forged or foreign token.
+	ErrInvalid = errors.New("token invalid")
+)
+
+// Claims are the claims of the access token. The user claims keep their historical names.
+type Claims struct {
+	ID   uint   `json:"ID"`
+	Name string `json:"Name"`
+	Role uint   `json:"Role"`
+	// SessionID is the family of the session the token is issued for.
+	SessionID string `json:"sid,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// signedClaims encode the audience as the plain string.
+type signedClaims struct {
+	*Claims
+	Audience string `json:"aud"`
+}
+
+// Manager signs and verifies the access tokens.
+type Manager struct {
+	secret []byte
+	keys   *KeySet
+}
+
+func New(secret string, keys *KeySet) *Manager {
+	return &Manager{secret: []byte(secret), keys: keys}
+}
+
+// Sign issues the token with the claims living ttl.
+func (m *Manager) Sign(c *Claims, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := *c
+	claims.Audience = jwt.ClaimStrings{Audience}
+	claims.Issuer = Issuer
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(ttl))
+	signed := &signedClaims{Claims: &claims, Audience: Audience}
+
+	if m.keys != nil {
+		k := m.keys.Signing()
+		t := jwt.NewWithClaims(k.Method, signed)
+		t.Header["kid"] = k.ID
+		return t.SignedString(k.Private)
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, signed).SignedString(m.secret)
+}
+
+// Parse verifies the token and returns its claims.
+func (m *Manager) Parse(token string) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, m.verificationKey,
+		jwt.WithValidMethods(m.methods()),
+		jwt.WithAudience(Audience),
+		jwt.WithIssuer(Issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(Leeway),
+	)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpired
+		}
+		return nil, fmt.Errorf("%w: %s", ErrInvalid, err)
+	}
+
+	return &claims, nil
+}
+
+// JWKS returns the public keys the tokens are verified with.
+func (m *Manager) JWKS() *JWKS {
+	if m.keys == nil {
+		return &JWKS{Keys: []JWK{}}
+	}
+	return m.keys.JWKS()
+}
+
+// methods is the allowlist of the algorithms: HS256 with the secret and the ones of the keys.
+func (m *Manager) methods() []string {
+	res := []string{}
+	if len(m.secret) > 0 {
+		res = append(res, jwt.SigningMethodHS256.Alg())
+	}
+	if m.keys != nil {
+		for _, k := range m.keys.keys {
+			res = append(res, k.Method.Alg())
+		}
+	}
+	return res
+}
+
+// verificationKey returns the key of the token by its kid and algorithm.
+func (m *Manager) verificationKey(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string) // nolint: errcheck
+	if kid == "" {
+		if t.Method != jwt.SigningMethodHS256 || len(m.secret) == 0 {
+			return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+		}
+		return m.secret, nil
+	}
+
+	if m.keys == nil {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+
+	k, ok := m.keys.Key(kid)
+	if !ok || k.Method.Alg() != t.Method.Alg() {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+
+	return k.Public, nil
+}
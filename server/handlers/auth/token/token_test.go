@@ -0,0 +1,256 @@
+package token_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/ivch/dynasty/server/handlers/auth/token"
+)
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	t.Helper()
+
+	tok := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		tok.Header["kid"] = kid
+	}
+
+	signed, err := tok.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed
+}
+
+func validClaims(change func(c jwt.MapClaims)) jwt.MapClaims {
+	now := time.Now()
+	c := jwt.MapClaims{
+		"ID":  10,
+		"sid": "family",
+		"aud": token.Audience,
+		"iss": token.Issuer,
+		"iat": now.Unix(),
+		"exp": now.Add(time.Minute).Unix(),
+	}
+	if change != nil {
+		change(c)
+	}
+	return c
+}
+
+func TestManager_SignParse(t *testing.T) {
+	for _, alg := range []string{token.AlgEdDSA, token.AlgRS256} {
+		t.Run(alg, func(t *testing.T) {
+			keys, err := token.NewKeySet("current", generateKey(t, "current", alg), generateKey(t, "other", alg))
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := token.New("secret", keys)
+
+			signed, err := m.Sign(&token.Claims{ID: 10, Name: "John Doe", Role: 1, SessionID: "family"}, time.Minute)
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(signed, jwt.MapClaims{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if parsed.Header["kid"] != "current" || parsed.Header["alg"] != alg {
+				t.Errorf("Sign() token header = %v", parsed.Header)
+			}
+			if aud := parsed.Claims.(jwt.MapClaims)["aud"]; aud != token.Audience {
+				t.Errorf("Sign() aud = %v, want %s", aud, token.Audience)
+			}
+
+			claims, err := m.Parse(signed)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if claims.ID != 10 || claims.Name != "John Doe" || claims.Role != 1 || claims.SessionID != "family" {
+				t.Errorf("Parse() claims = %#v", claims)
+			}
+			if got := claims.ExpiresAt.Sub(claims.IssuedAt.Time); got != time.Minute {
+				t.Errorf("Parse() ttl = %s, want %s", got, time.Minute)
+			}
+		})
+	}
+}
+
+func TestManager_Parse(t *testing.T) {
+	current := generateKey(t, "current", token.AlgEdDSA)
+	other := generateKey(t, "other", token.AlgRS256)
+	stranger := generateKey(t, "current", token.AlgEdDSA)
+	keys, err := token.NewKeySet("current", current, other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := token.New("secret", keys)
+	withoutSecret := token.New("", keys)
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		m       *token.Manager
+		token   string
+		wantErr error
+	}{
+		{
+			name:  "ok",
+			m:     m,
+			token: sign(t, current.Method, "current", current.Private, validClaims(nil)),
+		},
+		{
+			name:  "ok HS256 with the secret",
+			m:     m,
+			token: sign(t, jwt.SigningMethodHS256, "", []byte("secret"), validClaims(nil)),
+		},
+		{
+			name: "ok expired within leeway",
+			m:    m,
+			token: sign(t, current.Method, "current", current.Private, validClaims(func(c jwt.MapClaims) {
+				c["exp"] = now.Add(-token.Leeway / 2).Unix()
+			})),
+		},
+		{
+			name: "error expired",
+			m:    m,
+			token: sign(t, current.Method, "current", current.Private, validClaims(func(c jwt.MapClaims) {
+				c["exp"] = now.Add(-time.Minute).Unix()
+			})),
+			wantErr: token.ErrExpired,
+		},
+		{
+			name:    "error empty",
+			m:       m,
+			token:   "",
+			wantErr: token.ErrInvalid,
+		},
+		{
+			name:    "error malformed",
+			m:       m,
+			token:   "not.a.token",
+			wantErr: token.ErrInvalid,
+		},
+		{
+			name:    "error bad signature",
+			m:       m,
+			token:   sign(t, stranger.Method, "current", stranger.Private, validClaims(nil)),
+			wantErr: token.ErrInvalid,
+		},
+		{
+			name: "error expired with bad signature",
+			m:    m,
+			token: sign(t, stranger.Method, "current", stranger.Private, validClaims(func(c jwt.MapClaims) {
+				c["exp"] = now.Add(-time.Minute).Unix()
+			})),
+			wantErr: token.ErrInvalid,
+		},
+		{
+			name:    "error alg none",
+			m:       m,
+			token:   sign(t, jwt.SigningMethodNone, "", jwt.UnsafeAllowNoneSignatureType, validClaims(nil)),
+			wantErr: token.ErrInvalid,
+		},
+		{
+			name:    "error alg not allowed",
+			m:       m,
+			token:   sign(t, jwt.SigningMethodHS512, "", []byte("secret"), validClaims(nil)),
+			wantErr: token.ErrInvalid,
+		},
+		{
+			name:    "error public key as HMAC secret",
+			m:       m,
+			token:   sign(t, jwt.SigningMethodHS256, "current", publicPEM(t, current), validClaims(nil)),
+			wantErr: token.ErrInvalid,
+		},
+		{
+			name:    "error key of another kid",
+			m:       m,
+			token:   sign(t, current.Method, "other", current.Private, validClaims(nil)),
+			wantErr: token.ErrInvalid,
+		},
+		{
+			name:    "error unknown kid",
+			m:       m,
+			token:   sign(t, current.Method, "unknown", current.Private, validClaims(nil)),
+			wantErr: token.ErrInvalid,
+		},
+		{
+			name:    "error HS256 with wrong secret",
+			m:       m,
+			token:   sign(t, jwt.SigningMethodHS256, "", []byte("wrong"), validClaims(nil)),
+			wantErr: token.ErrInvalid,
+		},
+		{
+			name:    "error HS256 without the secret",
+			m:       withoutSecret,
+			token:   sign(t, jwt.SigningMethodHS256, "", []byte(""), validClaims(nil)),
+			wantErr: token.ErrInvalid,
+		},
+		{
+			name: "error wrong audience",
+			m:    m,
+			token: sign(t, current.Method, "current", current.Private, validClaims(func(c jwt.MapClaims) {
+				c["aud"] = "other"
+			})),
+			wantErr: token.ErrInvalid,
+		},
+		{
+			name: "error no audience",
+			m:    m,
+			token: sign(t, current.Method, "current", current.Private, validClaims(func(c jwt.MapClaims) {
+				delete(c, "aud")
+			})),
+			wantErr: token.ErrInvalid,
+		},
+		{
+			name: "error wrong issuer",
+			m:    m,
+			token: sign(t, current.Method, "current", current.Private, validClaims(func(c jwt.MapClaims) {
+				c["iss"] = "other"
+			})),
+			wantErr: token.ErrInvalid,
+		},
+		{
+			name: "error no expiry",
+			m:    m,
+			token: sign(t, current.Method, "current", current.Private, validClaims(func(c jwt.MapClaims) {
+				delete(c, "exp")
+			})),
+			wantErr: token.ErrInvalid,
+		},
+		{
+			name: "error issued in the future",
+			m:    m,
+			token: sign(t, current.Method, "current", current.Private, validClaims(func(c jwt.MapClaims) {
+				c["iat"] = now.Add(time.Minute).Unix()
+			})),
+			wantErr: token.ErrInvalid,
+		},
+		{
+			name: "error not valid yet",
+			m:    m,
+			token: sign(t, current.Method, "current", current.Private, validClaims(func(c jwt.MapClaims) {
+				c["nbf"] = now.Add(time.Minute).Unix()
+			})),
+			wantErr: token.ErrInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := tt.m.Parse(tt.token)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil) != (err == nil) {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (claims.ID != 10 || claims.SessionID != "family") {
+				t.Errorf("Parse() claims = %#v", claims)
+			}
+		})
+	}
+}
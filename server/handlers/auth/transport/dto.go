@@ -1,5 +1,7 @@
 package transport
 
+import "time"
+
 type errorResponse struct {
 	Error     string `json:"error"`
 	ErrorCode uint   `json:"error_code"`
@@ -10,8 +12,8 @@ type errorResponse struct {
 type loginRequest struct {
 	Phone    string `json:"phone" validate:"required,numeric"`
 	Password string `json:"password" validate:"required"`
-	// IP       net.IP `json:"ip" validate:"required"`
-	// Ua       string `json:"ua" validate:"required"`
+	// Device names the session, e.g. "Jane's iPhone".
+	Device string `json:"device"`
 }
 
 type loginResponse struct {
@@ -22,3 +24,16 @@ type loginResponse struct {
 type authRefreshTokenRequest struct {
 	Token string `validate:"required,uuid"`
 }
+
+type sessionResponse struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	Current    bool      `json:"current"`
+}
+
+type sessionsResponse struct {
+	Data []*sessionResponse `json:"data"`
+}
@@ -3,26 +3,41 @@ package transport
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"math"
+	"net"
 	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/go-chi/chi/v5"
 
 	"github.com/ivch/dynasty/common/errs"
 	"github.com/ivch/dynasty/common/logger"
 	"github.com/ivch/dynasty/server/handlers/auth"
+	"github.com/ivch/dynasty/server/handlers/auth/token"
 	"github.com/ivch/dynasty/server/middlewares"
 )
 
+const (
+	clientVersionHeader = "X-Client-Version"
+	jwksCacheControl    = "public, max-age=300"
+	maxUserAgentLen     = 512
+	maxDeviceNameLen    = 100
+)
+
 var uuidRegexp = regexp.MustCompile("^[a-fA-F0-9]{8}-[a-fA-F0-9]{4}-4[a-fA-F0-9]{3}-[8|9|aA|bB][a-fA-F0-9]{3}-[a-fA-F0-9]{12}$")
 
 type AuthService interface {
-	Login(ctx context.Context, phone, password string) (*auth.Tokens, error)
-	Logout(ctx context.Context, id uint) error
-	Refresh(ctx context.Context, token string) (*auth.Tokens, error)
-	Gwfa(token string) (uint, error)
+	Login(ctx context.Context, phone, password string, client auth.Client) (*auth.Tokens, error)
+	Logout(ctx context.Context, id uint, sessionID string) error
+	Refresh(ctx context.Context, token string, client auth.Client) (*auth.Tokens, error)
+	Gwfa(token string) (uint, string, error)
+	Sessions(ctx context.Context, userID uint) ([]*auth.Session, error)
+	RevokeSession(ctx context.Context, userID uint, sessionID string) error
+	JWKS() *token.JWKS
 }
 
 type HTTPTransport struct {
@@ -47,6 +62,9 @@ func (h *HTTPTransport) attachRoutes() {
 	h.router.Get("/v1/logout", h.Logout)
 	h.router.Post("/v1/refresh", h.Refresh)
 	h.router.Get("/v1/gwfa", h.Gwfa)
+	h.router.Get("/v1/sessions", h.Sessions)
+	h.router.Delete("/v1/sessions/{id}", h.RevokeSession)
+	h.router.Get("/v1/.well-known/jwks.json", h.JWKS)
 }
 
 func (h *HTTPTransport) Login(w http.ResponseWriter, r *http.Request) {
@@ -61,23 +79,17 @@ func (h *HTTPTransport) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// ip, _, err := net.SplitHostPort(r.RemoteAddr)
-	// if err != nil {
-	//	log.Error().Err(err).Msg("failed to parse host")
-	//	return nil, fmt.Errorf("userip: %q is not IP:port", r.RemoteAddr)
-	// }
-	//
-	// userIP := net.ParseIP(ip)
-	// if userIP == nil {
-	//	log.Error().Err(err).Msg("failed to parse ip")
-	//	return nil, fmt.Errorf("userip: %q is not IP:port", r.RemoteAddr)
-	// }
-	//
-	// req.IP = userIP
-	// req.Ua = r.Header.Get("User-Agent")
-
-	res, err := h.svc.Login(r.Context(), req.Phone, req.Password)
+	client := clientFromRequest(r)
+	client.Name = req.Device
+
+	res, err := h.svc.Login(r.Context(), req.Phone, req.Password, client)
 	if err != nil {
+		var blocked *auth.LoginBlockedError
+		if errors.As(err, &blocked) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(blocked.RetryAfter.Seconds()))))
+			h.sendError(w, http.StatusTooManyRequests, blocked.Err)
+			return
+		}
 		h.sendError(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -102,8 +114,12 @@ func (h *HTTPTransport) Refresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := h.svc.Refresh(r.Context(), req.Token)
+	res, err := h.svc.Refresh(r.Context(), req.Token, clientFromRequest(r))
 	if err != nil {
+		if err == errs.SessionRevoked {
+			h.sendError(w, http.StatusUnauthorized, err)
+			return
+		}
 		h.sendError(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -123,13 +139,19 @@ func (h *HTTPTransport) Gwfa(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id, err := h.svc.Gwfa(strings.TrimPrefix(token, "Bearer "))
+	id, sessionID, err := h.svc.Gwfa(strings.TrimPrefix(token, "Bearer "))
 	if err != nil {
+		// the expired token tells the client to refresh it
+		if err == errs.TokenExpired {
+			h.sendError(w, http.StatusUnauthorized, err)
+			return
+		}
 		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
 		return
 	}
 
-	w.Header().Set("X-Auth-User", strconv.FormatUint(uint64(id), 10))
+	w.Header().Set(middlewares.UserIDHeader.String(), strconv.FormatUint(uint64(id), 10))
+	w.Header().Set(middlewares.SessionIDHeader.String(), sessionID)
 
 	h.sendHTTPResponse(r.Context(), w, nil)
 }
@@ -141,7 +163,8 @@ func (h *HTTPTransport) Logout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.svc.Logout(r.Context(), userID); err != nil {
+	sessionID, _ := middlewares.SessionIDFromContext(r.Context())
+	if err := h.svc.Logout(r.Context(), userID, sessionID); err != nil {
 		h.sendError(w, http.StatusInternalServerError, nil)
 		return
 	}
@@ -149,9 +172,68 @@ func (h *HTTPTransport) Logout(w http.ResponseWriter, r *http.Request) {
 	h.sendHTTPResponse(r.Context(), w, nil)
 }
 
+func (h *HTTPTransport) Sessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
+		return
+	}
+
+	list, err := h.svc.Sessions(r.Context(), userID)
+	if err != nil {
+		h.sendError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	current, _ := middlewares.SessionIDFromContext(r.Context())
+	res := sessionsResponse{Data: make([]*sessionResponse, len(list))}
+	for i, s := range list {
+		res.Data[i] = &sessionResponse{
+			ID:         s.FamilyID.String(),
+			Name:       s.Name,
+			IP:         s.IP,
+			UserAgent:  s.UserAgent,
+			LastUsedAt: s.CreatedAt,
+			Current:    s.FamilyID.String() == current,
+		}
+	}
+
+	h.sendHTTPResponse(r.Context(), w, res)
+}
+
+func (h *HTTPTransport) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
+		return
+	}
+
+	if err := h.svc.RevokeSession(r.Context(), userID, chi.URLParam(r, "id")); err != nil {
+		if err == errs.SessionNotFound {
+			h.sendError(w, http.StatusNotFound, err)
+			return
+		}
+		h.sendError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.sendHTTPResponse(r.Context(), w, nil)
+}
+
+// JWKS publishes the keys the access tokens are verified with, the verifiers cache them for a while.
+func (h *HTTPTransport) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", jwksCacheControl)
+	h.sendHTTPResponse(r.Context(), w, h.svc.JWKS())
+}
+
 func validateLoginRequest(r *loginRequest) error {
-	if len(r.Password) < 6 {
-		return errs.PasswordTooShort
+	if utf8.RuneCountInString(r.Device) > maxDeviceNameLen {
+		return errs.BadRequest
+	}
+
+	// the length policy applies to the new passwords only, the older ones may be shorter
+	if r.Password == "" {
+		return errs.EmptyPassword
 	}
 
 	if len(r.Phone) < 12 || len(r.Phone) > 13 {
@@ -165,6 +247,28 @@ func validateLoginRequest(r *loginRequest) error {
 	return nil
 }
 
+// clientFromRequest returns the device of the request.
+func clientFromRequest(r *http.Request) auth.Client {
+	ip := ""
+	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+		parts := strings.Split(fwd, ",")
+		ip = strings.TrimSpace(parts[len(parts)-1])
+	} else if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ip = host
+	}
+
+	if net.ParseIP(ip) == nil {
+		ip = ""
+	}
+
+	ua := r.Header.Get("User-Agent")
+	if len(ua) > maxUserAgentLen {
+		ua = ua[:maxUserAgentLen]
+	}
+
+	return auth.Client{IP: ip, UserAgent: ua, Version: strings.TrimSpace(r.Header.Get(clientVersionHeader))}
+}
+
 func getUserID(ctx context.Context) (uint, error) {
 	idStr, ok := middlewares.UserIDFromContext(ctx)
 	if !ok {
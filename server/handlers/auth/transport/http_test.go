@@ -9,9 +9,14 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/ivch/dynasty/common/errs"
 	"github.com/ivch/dynasty/common/logger"
 	"github.com/ivch/dynasty/server/handlers/auth"
+	"github.com/ivch/dynasty/server/handlers/auth/token"
 	"github.com/ivch/dynasty/server/handlers/auth/transport"
 	"github.com/ivch/dynasty/server/middlewares"
 )
@@ -28,12 +33,14 @@ func TestMain(m *testing.M) {
 
 func TestHTTP_Login(t *testing.T) {
 	tests := []struct {
-		name     string
-		request  string
-		svc      transport.AuthService
-		wantErr  bool
-		wantCode int
-		want     string
+		name       string
+		request    string
+		headers    map[string]string
+		svc        transport.AuthService
+		wantErr    bool
+		wantCode   int
+		want       string
+		wantHeader map[string]string
 	}{
 		{
 			name:     "error decode request",
@@ -59,11 +66,29 @@ func TestHTTP_Login(t *testing.T) {
 			wantErr:  true,
 			wantCode: http.StatusBadRequest,
 		},
+		{
+			name:     "error device name too long",
+			request:  `{"password":"123456", "phone":"123123123123", "device":"` + strings.Repeat("x", 101) + `"}`,
+			wantErr:  true,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:    "error login blocked",
+			request: `{"password":"123456", "phone":"123123123123"}`,
+			svc: &transport.AuthServiceMock{
+				LoginFunc: func(_ context.Context, _, _ string, _ auth.Client) (*auth.Tokens, error) {
+					return nil, &auth.LoginBlockedError{Err: errs.AccountLocked, RetryAfter: 1500 * time.Millisecond}
+				},
+			},
+			wantErr:    true,
+			wantCode:   http.StatusTooManyRequests,
+			wantHeader: map[string]string{"Retry-After": "2"},
+		},
 		{
 			name:    "error service error",
 			request: `{"password":"123456", "phone":"123123123123"}`,
 			svc: &transport.AuthServiceMock{
-				LoginFunc: func(_ context.Context, _, _ string) (*auth.Tokens, error) {
+				LoginFunc: func(_ context.Context, _, _ string, _ auth.Client) (*auth.Tokens, error) {
 					return nil, errTestError
 				},
 			},
@@ -74,7 +99,7 @@ func TestHTTP_Login(t *testing.T) {
 			name:    "ok",
 			request: `{"password":"123456", "phone":"123123123123"}`,
 			svc: &transport.AuthServiceMock{
-				LoginFunc: func(_ context.Context, _, _ string) (*auth.Tokens, error) {
+				LoginFunc: func(_ context.Context, _, _ string, _ auth.Client) (*auth.Tokens, error) {
 					return &auth.Tokens{
 						AccessToken:  "at",
 						RefreshToken: "rt",
@@ -85,6 +110,35 @@ func TestHTTP_Login(t *testing.T) {
 			want:     `{"access_token":"at","refresh_token":"rt"}`,
 			wantCode: http.StatusOK,
 		},
+		{
+			name:    "ok client from proxy",
+			request: `{"password":"123456", "phone":"123123123123", "device":"phone"}`,
+			headers: map[string]string{"X-Forwarded-For": "1.1.1.1, 10.0.0.7", "User-Agent": "app/1.0", "X-Client-Version": "2.1.0"},
+			svc: &transport.AuthServiceMock{
+				LoginFunc: func(_ context.Context, _, _ string, client auth.Client) (*auth.Tokens, error) {
+					if client != (auth.Client{Name: "phone", IP: "10.0.0.7", UserAgent: "app/1.0", Version: "2.1.0"}) {
+						return nil, errTestError
+					}
+					return &auth.Tokens{AccessToken: "at", RefreshToken: "rt"}, nil
+				},
+			},
+			want:     `{"access_token":"at","refresh_token":"rt"}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:    "ok client from remote address",
+			request: `{"password":"123456", "phone":"123123123123"}`,
+			svc: &transport.AuthServiceMock{
+				LoginFunc: func(_ context.Context, _, _ string, client auth.Client) (*auth.Tokens, error) {
+					if client != (auth.Client{IP: "192.0.2.1"}) {
+						return nil, errTestError
+					}
+					return &auth.Tokens{AccessToken: "at", RefreshToken: "rt"}, nil
+				},
+			},
+			want:     `{"access_token":"at","refresh_token":"rt"}`,
+			wantCode: http.StatusOK,
+		},
 	}
 
 	for _, tt := range tests {
@@ -92,7 +146,11 @@ func TestHTTP_Login(t *testing.T) {
 			svc := tt.svc
 			h := transport.NewHTTPTransport(defaultLogger, svc, middlewares.NewIDCtx(defaultLogger).Middleware)
 			rr := httptest.NewRecorder()
-			rq, _ := http.NewRequest(http.MethodPost, "/v1/login", strings.NewReader(tt.request))
+			rq := httptest.NewRequest(http.MethodPost, "/v1/login", strings.NewReader(tt.request))
+			rq.Header.Del("User-Agent")
+			for k, v := range tt.headers {
+				rq.Header.Set(k, v)
+			}
 			h.ServeHTTP(rr, rq)
 			if (rr.Code != tt.wantCode) && tt.wantErr {
 				t.Errorf("Request error. status = %d, wantCode = %d, wantErr %v", rr.Code, tt.wantCode, tt.wantErr)
@@ -100,6 +158,11 @@ func TestHTTP_Login(t *testing.T) {
 			if !tt.wantErr && tt.want != strings.TrimSpace(rr.Body.String()) {
 				t.Errorf("Response error, got = %s, want = %s", rr.Body.String(), tt.want)
 			}
+			for k, v := range tt.wantHeader {
+				if got := rr.Header().Get(k); got != v {
+					t.Errorf("Response header %s = %q, want %q", k, got, v)
+				}
+			}
 		})
 	}
 }
@@ -135,18 +198,29 @@ func TestHTTP_Refresh(t *testing.T) {
 			name:    "error service error",
 			request: `{"token":"d3ffebcf-1cec-441b-93d6-a984b7647d48"}`,
 			svc: &transport.AuthServiceMock{
-				RefreshFunc: func(_ context.Context, _ string) (*auth.Tokens, error) {
+				RefreshFunc: func(_ context.Context, _ string, _ auth.Client) (*auth.Tokens, error) {
 					return nil, errTestError
 				},
 			},
 			wantErr:  true,
 			wantCode: http.StatusInternalServerError,
 		},
+		{
+			name:    "error session revoked",
+			request: `{"token":"d3ffebcf-1cec-441b-93d6-a984b7647d48"}`,
+			svc: &transport.AuthServiceMock{
+				RefreshFunc: func(_ context.Context, _ string, _ auth.Client) (*auth.Tokens, error) {
+					return nil, errs.SessionRevoked
+				},
+			},
+			wantErr:  true,
+			wantCode: http.StatusUnauthorized,
+		},
 		{
 			name:    "ok",
 			request: `{"token":"d3ffebcf-1cec-441b-93d6-a984b7647d48"}`,
 			svc: &transport.AuthServiceMock{
-				RefreshFunc: func(_ context.Context, _ string) (*auth.Tokens, error) {
+				RefreshFunc: func(_ context.Context, _ string, _ auth.Client) (*auth.Tokens, error) {
 					return &auth.Tokens{
 						AccessToken:  "at",
 						RefreshToken: "rt",
@@ -195,19 +269,32 @@ func TestHTTP_Gwfa(t *testing.T) {
 			name:   "error service error",
 			header: "Bearer token",
 			svc: &transport.AuthServiceMock{
-				GwfaFunc: func(string) (uint, error) {
-					return 0, errTestError
+				GwfaFunc: func(string) (uint, string, error) {
+					return 0, "", errTestError
 				},
 			},
 			wantErr:  true,
 			wantCode: http.StatusUnauthorized,
+			want:     `"error":"user is unauthorized"`,
+		},
+		{
+			name:   "error token expired",
+			header: "Bearer token",
+			svc: &transport.AuthServiceMock{
+				GwfaFunc: func(string) (uint, string, error) {
+					return 0, "", errs.TokenExpired
+				},
+			},
+			wantErr:  true,
+			wantCode: http.StatusUnauthorized,
+			want:     `"error":"token expired"`,
 		},
 		{
 			name:   "ok",
 			header: "Bearer token",
 			svc: &transport.AuthServiceMock{
-				GwfaFunc: func(string) (uint, error) {
-					return 1, nil
+				GwfaFunc: func(string) (uint, string, error) {
+					return 1, "d3ffebcf-1cec-441b-93d6-a984b7647d48", nil
 				},
 			},
 			wantErr:  false,
@@ -230,9 +317,15 @@ func TestHTTP_Gwfa(t *testing.T) {
 			if !tt.wantErr && tt.want != strings.TrimSpace(rr.Body.String()) {
 				t.Errorf("Response error, got = %s, want = %s", rr.Body.String(), tt.want)
 			}
+			if tt.wantErr && !strings.Contains(rr.Body.String(), tt.want) {
+				t.Errorf("Response error, got = %s, want = %s", rr.Body.String(), tt.want)
+			}
 			if rr.Code == http.StatusOK && rr.Header().Get("X-Auth-User") != "1" {
 				t.Errorf("Wrogn header, got = %s, want = %s", rr.Header().Get("X-Auth-User"), "1")
 			}
+			if rr.Code == http.StatusOK && rr.Header().Get("X-Auth-Session") != "d3ffebcf-1cec-441b-93d6-a984b7647d48" {
+				t.Errorf("Wrong session header, got = %s", rr.Header().Get("X-Auth-Session"))
+			}
 		})
 	}
 }
@@ -267,7 +360,7 @@ func TestHTTP_Logout(t *testing.T) {
 			name:   "error service error",
 			header: "1",
 			svc: &transport.AuthServiceMock{
-				LogoutFunc: func(_ context.Context, _ uint) error {
+				LogoutFunc: func(_ context.Context, _ uint, _ string) error {
 					return errTestError
 				},
 			},
@@ -278,7 +371,10 @@ func TestHTTP_Logout(t *testing.T) {
 			name:   "ok",
 			header: "1",
 			svc: &transport.AuthServiceMock{
-				LogoutFunc: func(_ context.Context, _ uint) error {
+				LogoutFunc: func(_ context.Context, _ uint, sessionID string) error {
+					if sessionID != "d3ffebcf-1cec-441b-93d6-a984b7647d48" {
+						return errTestError
+					}
 					return nil
 				},
 			},
@@ -294,10 +390,153 @@ func TestHTTP_Logout(t *testing.T) {
 			rr := httptest.NewRecorder()
 			rq, _ := http.NewRequest(http.MethodGet, "/v1/logout", nil)
 			rq.Header.Add("X-Auth-User", tt.header)
+			rq.Header.Add("X-Auth-Session", "d3ffebcf-1cec-441b-93d6-a984b7647d48")
 			h.ServeHTTP(rr, rq)
-			if (rr.Code != tt.wantCode) && tt.wantErr {
+			if rr.Code != tt.wantCode {
 				t.Errorf("Request error. status = %d, wantCode = %d, wantErr %v", rr.Code, tt.wantCode, tt.wantErr)
 			}
 		})
 	}
 }
+
+func TestHTTP_Sessions(t *testing.T) {
+	lastUsed := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		header   string
+		svc      transport.AuthService
+		wantCode int
+		want     string
+	}{
+		{
+			name:     "error no user",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "error service error",
+			header: "1",
+			svc: &transport.AuthServiceMock{
+				SessionsFunc: func(_ context.Context, _ uint) ([]*auth.Session, error) {
+					return nil, errTestError
+				},
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "ok",
+			header: "1",
+			svc: &transport.AuthServiceMock{
+				SessionsFunc: func(_ context.Context, _ uint) ([]*auth.Session, error) {
+					return []*auth.Session{
+						{FamilyID: uuid.FromStringOrNil("d3ffebcf-1cec-441b-93d6-a984b7647d48"), Name: "phone", IP: "10.0.0.1", UserAgent: "app/1.0", CreatedAt: lastUsed},
+						{FamilyID: uuid.FromStringOrNil("0f8d2f4a-5b0e-4c39-9a53-47e2a0e5b1c2"), CreatedAt: lastUsed},
+					}, nil
+				},
+			},
+			wantCode: http.StatusOK,
+			want: `{"data":[` +
+				`{"id":"d3ffebcf-1cec-441b-93d6-a984b7647d48","name":"phone","ip":"10.0.0.1","user_agent":"app/1.0","last_used_at":"2024-05-01T10:00:00Z","current":true},` +
+				`{"id":"0f8d2f4a-5b0e-4c39-9a53-47e2a0e5b1c2","name":"","ip":"","user_agent":"","last_used_at":"2024-05-01T10:00:00Z","current":false}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := transport.NewHTTPTransport(defaultLogger, tt.svc, middlewares.NewIDCtx(defaultLogger).Middleware)
+			rr := httptest.NewRecorder()
+			rq, _ := http.NewRequest(http.MethodGet, "/v1/sessions", nil)
+			rq.Header.Add("X-Auth-User", tt.header)
+			rq.Header.Add("X-Auth-Session", "d3ffebcf-1cec-441b-93d6-a984b7647d48")
+			h.ServeHTTP(rr, rq)
+			if rr.Code != tt.wantCode {
+				t.Errorf("Request error. status = %d, wantCode = %d", rr.Code, tt.wantCode)
+			}
+			if tt.want != "" && tt.want != strings.TrimSpace(rr.Body.String()) {
+				t.Errorf("Response error, got = %s, want = %s", rr.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTP_RevokeSession(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		svc      transport.AuthService
+		wantCode int
+	}{
+		{
+			name:     "error no user",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "error not found",
+			header: "1",
+			svc: &transport.AuthServiceMock{
+				RevokeSessionFunc: func(_ context.Context, _ uint, _ string) error {
+					return errs.SessionNotFound
+				},
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:   "error service error",
+			header: "1",
+			svc: &transport.AuthServiceMock{
+				RevokeSessionFunc: func(_ context.Context, _ uint, _ string) error {
+					return errTestError
+				},
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "ok",
+			header: "1",
+			svc: &transport.AuthServiceMock{
+				RevokeSessionFunc: func(_ context.Context, userID uint, sessionID string) error {
+					if userID != 1 || sessionID != "d3ffebcf-1cec-441b-93d6-a984b7647d48" {
+						return errTestError
+					}
+					return nil
+				},
+			},
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := transport.NewHTTPTransport(defaultLogger, tt.svc, middlewares.NewIDCtx(defaultLogger).Middleware)
+			rr := httptest.NewRecorder()
+			rq, _ := http.NewRequest(http.MethodDelete, "/v1/sessions/d3ffebcf-1cec-441b-93d6-a984b7647d48", nil)
+			rq.Header.Add("X-Auth-User", tt.header)
+			h.ServeHTTP(rr, rq)
+			if rr.Code != tt.wantCode {
+				t.Errorf("Request error. status = %d, wantCode = %d", rr.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestHTTP_JWKS(t *testing.T) {
+	svc := &transport.AuthServiceMock{
+		JWKSFunc: func() *token.JWKS {
+			return &token.JWKS{Keys: []token.JWK{{Kty: "OKP", Kid: "k1", Use: "sig", Alg: "EdDSA", Crv: "Ed25519", X: "eA"}}}
+		},
+	}
+
+	h := transport.NewHTTPTransport(defaultLogger, svc, middlewares.NewIDCtx(defaultLogger).Middleware)
+	rr := httptest.NewRecorder()
+	rq, _ := http.NewRequest(http.MethodGet, "/v1/.well-known/jwks.json", nil)
+	h.ServeHTTP(rr, rq)
+
+	if rr.Code != http.StatusOK || rr.Header().Get("Cache-Control") == "" {
+		t.Errorf("Request error. status = %d, headers = %v", rr.Code, rr.Header())
+	}
+
+	want := `{"keys":[{"kty":"OKP","kid":"k1","use":"sig","alg":"EdDSA","crv":"Ed25519","x":"eA"}]}`
+	if got := strings.TrimSpace(rr.Body.String()); got != want {
+		t.Errorf("Response error, got = %s, want = %s", got, want)
+	}
+}
@@ -6,6 +6,7 @@ package transport
 import (
 	"context"
 	"github.com/ivch/dynasty/server/handlers/auth"
+	"github.com/ivch/dynasty/server/handlers/auth/token"
 	"sync"
 )
 
@@ -19,18 +20,27 @@ var _ AuthService = &AuthServiceMock{}
 //
 //		// make and configure a mocked AuthService
 //		mockedAuthService := &AuthServiceMock{
-//			GwfaFunc: func(token string) (uint, error) {
+//			GwfaFunc: func(token string) (uint, string, error) {
 //				panic("mock out the Gwfa method")
 //			},
-//			LoginFunc: func(ctx context.Context, phone string, password string) (*auth.Tokens, error) {
+//			JWKSFunc: func() *token.JWKS {
+//				panic("mock out the JWKS method")
+//			},
+//			LoginFunc: func(ctx context.Context, phone string, password string, client auth.Client) (*auth.Tokens, error) {
 //				panic("mock out the Login method")
 //			},
-//			LogoutFunc: func(ctx context.Context, id uint) error {
+//			LogoutFunc: func(ctx context.Context, id uint, sessionID string) error {
 //				panic("mock out the Logout method")
 //			},
-//			RefreshFunc: func(ctx context.Context, token string) (*auth.Tokens, error) {
+//			RefreshFunc: func(ctx context.Context, token string, client auth.Client) (*auth.Tokens, error) {
 //				panic("mock out the Refresh method")
 //			},
+//			RevokeSessionFunc: func(ctx context.Context, userID uint, sessionID string) error {
+//				panic("mock out the RevokeSession method")
+//			},
+//			SessionsFunc: func(ctx context.Context, userID uint) ([]*auth.Session, error) {
+//				panic("mock out the Sessions method")
+//			},
 //		}
 //
 //		// use mockedAuthService in code that requires AuthService
@@ -39,16 +49,25 @@ var _ AuthService = &AuthServiceMock{}
 //	}
 type AuthServiceMock struct {
 	// GwfaFunc mocks the Gwfa method.
-	GwfaFunc func(token string) (uint, error)
+	GwfaFunc func(token string) (uint, string, error)
+
+	// JWKSFunc mocks the JWKS method.
+	JWKSFunc func() *token.JWKS
 
 	// LoginFunc mocks the Login method.
-	LoginFunc func(ctx context.Context, phone string, password string) (*auth.Tokens, error)
+	LoginFunc func(ctx context.Context, phone string, password string, client auth.Client) (*auth.Tokens, error)
 
 	// LogoutFunc mocks the Logout method.
-	LogoutFunc func(ctx context.Context, id uint) error
+	LogoutFunc func(ctx context.Context, id uint, sessionID string) error
 
 	// RefreshFunc mocks the Refresh method.
-	RefreshFunc func(ctx context.Context, token string) (*auth.Tokens, error)
+	RefreshFunc func(ctx context.Context, token string, client auth.Client) (*auth.Tokens, error)
+
+	// RevokeSessionFunc mocks the RevokeSession method.
+	RevokeSessionFunc func(ctx context.Context, userID uint, sessionID string) error
+
+	// SessionsFunc mocks the Sessions method.
+	SessionsFunc func(ctx context.Context, userID uint) ([]*auth.Session, error)
 
 	// calls tracks calls to the methods.
 	calls struct {
@@ -57,6 +76,9 @@ type AuthServiceMock struct {
 			// Token is the token argument value.
 			Token string
 		}
+		// JWKS holds details about calls to the JWKS method.
+		JWKS []struct {
+		}
 		// Login holds details about calls to the Login method.
 		Login []struct {
 			// Ctx is the ctx argument value.
@@ -65,6 +87,8 @@ type AuthServiceMock struct {
 			Phone string
 			// Password is the password argument value.
 			Password string
+			// Client is the client argument value.
+			Client auth.Client
 		}
 		// Logout holds details about calls to the Logout method.
 		Logout []struct {
@@ -72,6 +96,8 @@ type AuthServiceMock struct {
 			Ctx context.Context
 			// ID is the id argument value.
 			ID uint
+			// SessionID is the sessionID argument value.
+			SessionID string
 		}
 		// Refresh holds details about calls to the Refresh method.
 		Refresh []struct {
@@ -79,16 +105,37 @@ type AuthServiceMock struct {
 			Ctx context.Context
 			// Token is the token argument value.
 			Token string
+			// Client is the client argument value.
+			Client auth.Client
+		}
+		// RevokeSession holds details about calls to the RevokeSession method.
+		RevokeSession []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// UserID is the userID argument value.
+			UserID uint
+			// SessionID is the sessionID argument value.
+			SessionID string
+		}
+		// Sessions holds details about calls to the Sessions method.
+		Sessions []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// UserID is the userID argument value.
+			UserID uint
 		}
 	}
-	lockGwfa    sync.RWMutex
-	lockLogin   sync.RWMutex
-	lockLogout  sync.RWMutex
-	lockRefresh sync.RWMutex
+	lockGwfa          sync.RWMutex
+	lockJWKS          sync.RWMutex
+	lockLogin         sync.RWMutex
+	lockLogout        sync.RWMutex
+	lockRefresh       sync.RWMutex
+	lockRevokeSession sync.RWMutex
+	lockSessions      sync.RWMutex
 }
 
 // Gwfa calls GwfaFunc.
-func (mock *AuthServiceMock) Gwfa(token string) (uint, error) {
+func (mock *AuthServiceMock) Gwfa(token string) (uint, string, error) {
 	if mock.GwfaFunc == nil {
 		panic("AuthServiceMock.GwfaFunc: method is nil but AuthService.Gwfa was just called")
 	}
@@ -119,8 +166,35 @@ func (mock *AuthServiceMock) GwfaCalls() []struct {
 	return calls
 }
 
+// JWKS calls JWKSFunc.
+func (mock *AuthServiceMock) JWKS() *token.JWKS {
+	if mock.JWKSFunc == nil {
+		panic("AuthServiceMock.JWKSFunc: method is nil but AuthService.JWKS was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockJWKS.Lock()
+	mock.calls.JWKS = append(mock.calls.JWKS, callInfo)
+	mock.lockJWKS.Unlock()
+	return mock.JWKSFunc()
+}
+
+// JWKSCalls gets all the calls that were made to JWKS.
+// Check the length with:
+//
+//	len(mockedAuthService.JWKSCalls())
+func (mock *AuthServiceMock) JWKSCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockJWKS.RLock()
+	calls = mock.calls.JWKS
+	mock.lockJWKS.RUnlock()
+	return calls
+}
+
 // Login calls LoginFunc.
-func (mock *AuthServiceMock) Login(ctx context.Context, phone string, password string) (*auth.Tokens, error) {
+func (mock *AuthServiceMock) Login(ctx context.Context, phone string, password string, client auth.Client) (*auth.Tokens, error) {
 	if mock.LoginFunc == nil {
 		panic("AuthServiceMock.LoginFunc: method is nil but AuthService.Login was just called")
 	}
@@ -128,15 +202,17 @@ func (mock *AuthServiceMock) Login(ctx context.Context, phone string, password s
 		Ctx      context.Context
 		Phone    string
 		Password string
+		Client   auth.Client
 	}{
 		Ctx:      ctx,
 		Phone:    phone,
 		Password: password,
+		Client:   client,
 	}
 	mock.lockLogin.Lock()
 	mock.calls.Login = append(mock.calls.Login, callInfo)
 	mock.lockLogin.Unlock()
-	return mock.LoginFunc(ctx, phone, password)
+	return mock.LoginFunc(ctx, phone, password, client)
 }
 
 // LoginCalls gets all the calls that were made to Login.
@@ -147,11 +223,13 @@ func (mock *AuthServiceMock) LoginCalls() []struct {
 	Ctx      context.Context
 	Phone    string
 	Password string
+	Client   auth.Client
 } {
 	var calls []struct {
 		Ctx      context.Context
 		Phone    string
 		Password string
+		Client   auth.Client
 	}
 	mock.lockLogin.RLock()
 	calls = mock.calls.Login
@@ -160,21 +238,23 @@ func (mock *AuthServiceMock) LoginCalls() []struct {
 }
 
 // Logout calls LogoutFunc.
-func (mock *AuthServiceMock) Logout(ctx context.Context, id uint) error {
+func (mock *AuthServiceMock) Logout(ctx context.Context, id uint, sessionID string) error {
 	if mock.LogoutFunc == nil {
 		panic("AuthServiceMock.LogoutFunc: method is nil but AuthService.Logout was just called")
 	}
 	callInfo := struct {
-		Ctx context.Context
-		ID  uint
+		Ctx       context.Context
+		ID        uint
+		SessionID string
 	}{
-		Ctx: ctx,
-		ID:  id,
+		Ctx:       ctx,
+		ID:        id,
+		SessionID: sessionID,
 	}
 	mock.lockLogout.Lock()
 	mock.calls.Logout = append(mock.calls.Logout, callInfo)
 	mock.lockLogout.Unlock()
-	return mock.LogoutFunc(ctx, id)
+	return mock.LogoutFunc(ctx, id, sessionID)
 }
 
 // LogoutCalls gets all the calls that were made to Logout.
@@ -182,12 +262,14 @@ func (mock *AuthServiceMock) Logout(ctx context.Context, id uint) error {
 //
 //	len(mockedAuthService.LogoutCalls())
 func (mock *AuthServiceMock) LogoutCalls() []struct {
-	Ctx context.Context
-	ID  uint
+	Ctx       context.Context
+	ID        uint
+	SessionID string
 } {
 	var calls []struct {
-		Ctx context.Context
-		ID  uint
+		Ctx       context.Context
+		ID        uint
+		SessionID string
 	}
 	mock.lockLogout.RLock()
 	calls = mock.calls.Logout
@@ -196,21 +278,23 @@ func (mock *AuthServiceMock) LogoutCalls() []struct {
 }
 
 // Refresh calls RefreshFunc.
-func (mock *AuthServiceMock) Refresh(ctx context.Context, token string) (*auth.Tokens, error) {
+func (mock *AuthServiceMock) Refresh(ctx context.Context, token string, client auth.Client) (*auth.Tokens, error) {
 	if mock.RefreshFunc == nil {
 		panic("AuthServiceMock.RefreshFunc: method is nil but AuthService.Refresh was just called")
 	}
 	callInfo := struct {
-		Ctx   context.Context
-		Token string
+		Ctx    context.Context
+		Token  string
+		Client auth.Client
 	}{
-		Ctx:   ctx,
-		Token: token,
+		Ctx:    ctx,
+		Token:  token,
+		Client: client,
 	}
 	mock.lockRefresh.Lock()
 	mock.calls.Refresh = append(mock.calls.Refresh, callInfo)
 	mock.lockRefresh.Unlock()
-	return mock.RefreshFunc(ctx, token)
+	return mock.RefreshFunc(ctx, token, client)
 }
 
 // RefreshCalls gets all the calls that were made to Refresh.
@@ -218,15 +302,93 @@ func (mock *AuthServiceMock) Refresh(ctx context.Context, token string) (*auth.T
 //
 //	len(mockedAuthService.RefreshCalls())
 func (mock *AuthServiceMock) RefreshCalls() []struct {
-	Ctx   context.Context
-	Token string
+	Ctx    context.Context
+	Token  string
+	Client auth.Client
 } {
 	var calls []struct {
-		Ctx   context.Context
-		Token string
+		Ctx    context.Context
+		Token  string
+		Client auth.Client
 	}
 	mock.lockRefresh.RLock()
 	calls = mock.calls.Refresh
 	mock.lockRefresh.RUnlock()
 	return calls
 }
+
+// RevokeSession calls RevokeSessionFunc.
+func (mock *AuthServiceMock) RevokeSession(ctx context.Context, userID uint, sessionID string) error {
+	if mock.RevokeSessionFunc == nil {
+		panic("AuthServiceMock.RevokeSessionFunc: method is nil but AuthService.RevokeSession was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		UserID    uint
+		SessionID string
+	}{
+		Ctx:       ctx,
+		UserID:    userID,
+		SessionID: sessionID,
+	}
+	mock.lockRevokeSession.Lock()
+	mock.calls.RevokeSession = append(mock.calls.RevokeSession, callInfo)
+	mock.lockRevokeSession.Unlock()
+	return mock.RevokeSessionFunc(ctx, userID, sessionID)
+}
+
+// RevokeSessionCalls gets all the calls that were made to RevokeSession.
+// Check the length with:
+//
+//	len(mockedAuthService.RevokeSessionCalls())
+func (mock *AuthServiceMock) RevokeSessionCalls() []struct {
+	Ctx       context.Context
+	UserID    uint
+	SessionID string
+} {
+	var calls []struct {
+		Ctx       context.Context
+		UserID    uint
+		SessionID string
+	}
+	mock.lockRevokeSession.RLock()
+	calls = mock.calls.RevokeSession
+	mock.lockRevokeSession.RUnlock()
+	return calls
+}
+
+// Sessions calls SessionsFunc.
+func (mock *AuthServiceMock) Sessions(ctx context.Context, userID uint) ([]*auth.Session, error) {
+	if mock.SessionsFunc == nil {
+		panic("AuthServiceMock.SessionsFunc: method is nil but AuthService.Sessions was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		UserID uint
+	}{
+		Ctx:    ctx,
+		UserID: userID,
+	}
+	mock.lockSessions.Lock()
+	mock.calls.Sessions = append(mock.calls.Sessions, callInfo)
+	mock.lockSessions.Unlock()
+	return mock.SessionsFunc(ctx, userID)
+}
+
+// SessionsCalls gets all the calls that were made to Sessions.
+// Check the length with:
+//
+//	len(mockedAuthService.SessionsCalls())
+func (mock *AuthServiceMock) SessionsCalls() []struct {
+	Ctx    context.Context
+	UserID uint
+} {
+	var calls []struct {
+		Ctx    context.Context
+		UserID uint
+	}
+	mock.lockSessions.RLock()
+	calls = mock.calls.Sessions
+	mock.lockSessions.RUnlock()
+	return calls
+}
@@ -0,0 +1,24 @@
+package bot
+
+import (
+	"time"
+)
+
+// LinkCode is the one-time code linking the Telegram chat to the account.
+type LinkCode struct {
+	Code      string `gorm:"primary_key"`
+	UserID    uint
+	ExpiresAt time.Time
+}
+
+func (LinkCode) TableName() string { return "telegram_link_codes" }
+
+// Link is the Telegram chat linked to the user.
+type Link struct {
+	ChatID      int64 `gorm:"primary_key;auto_increment:false"`
+	UserID      uint
+	PendingType int
+	CreatedAt   time.Time
+}
+
+func (Link) TableName() string { return "telegram_links" }
@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/common/telegram"
+	"github.com/ivch/dynasty/server/handlers/notifications"
+)
+
+const (
+	msgHelp = iota
+	msgNotLinked
+	msgLinked
+	msgUnlinked
+	msgChooseType
+	msgDescribe
+	msgCreated
+	msgNoRequests
+	msgRequests
+	msgUnknownType
+)
+
+var messages = map[string]map[int]string{
+	notifications.LangEN: {
+		msgHelp:        "/new - create a request\n/requests - your open requests\n/unlink - unlink this chat from the account",
+		msgNotLinked:   "Link your account first: get the code in the app and send /link <code>.",
+		msgLinked:      "The chat is linked to your account, the status changes of your requests will come here.\n\n",
+		msgUnlinked:    "The chat is unlinked from your account.",
+		msgChooseType:  "Choose the request type:",
+		msgDescribe:    "New request: %s. Send the description, e.g. the guest name or the car number.",
+		msgCreated:     "Request #%d is created.",
+		msgNoRequests:  "You have no open requests.",
+		msgRequests:    "Your open requests:",
+		msgUnknownType: "Unknown request type, send /new to start again.",
+	},
+	notifications.LangRU: {
+		msgHelp:        "/new - создать заявку\n/requests - ваши открытые заявки\n/unlink - отвязать этот чат от аккаунта",
+		msgNotLinked:   "Сначала привяжите аккаунт: получите код в приложении и отправьте /link <код>.",
+		msgLinked:      "Чат привязан к вашему аккаунту, сюда будут приходить изменения статусов ваших заявок.\n\n",
+		msgUnlinked:    "Чат отвязан от вашего аккаунта.",
+		msgChooseType:  "Выберите тип заявки:",
+		msgDescribe:    "Новая заявка: %s. Отправьте описание, например имя гостя или номер машины.",
+		msgCreated:     "Заявка #%d создана.",
+		msgNoRequests:  "У вас нет открытых заявок.",
+		msgRequests:    "Ваши открытые заявки:",
+		msgUnknownType: "Неизвестный тип заявки, отправьте /new, чтобы начать заново.",
+	},
+	notifications.LangUA: {
+		msgHelp:        "/new - створити заявку\n/requests - ваші відкриті заявки\n/unlink - відв'язати цей чат від акаунту",
+		msgNotLinked:   "Спочатку прив'яжіть акаунт: отримайте код у застосунку та надішліть /link <код>.",
+		msgLinked:      "Чат прив'язано до вашого акаунту, сюди надходитимуть зміни статусів ваших заявок.\n\n",
+		msgUnlinked:    "Чат відв'язано від вашого акаунту.",
+		msgChooseType:  "Оберіть тип заявки:",
+		msgDescribe:    "Нова заявка: %s. Надішліть опис, наприклад ім'я гостя або номер машини.",
+		msgCreated:     "Заявку #%d створено.",
+		msgNoRequests:  "У вас немає відкритих заявок.",
+		msgRequests:    "Ваші відкриті заявки:",
+		msgUnknownType: "Невідомий тип заявки, надішліть /new, щоб почати знову.",
+	},
+}
+
+var statuses = map[string]map[string]string{
+	notifications.LangEN: {"new": "new", "pending": "awaiting confirmation", "closed": "closed"},
+	notifications.LangRU: {"new": "новая", "pending": "ожидает подтверждения", "closed": "закрыта"},
+	notifications.LangUA: {"new": "нова", "pending": "очікує підтвердження", "closed": "закрита"},
+}
+
+// langOf maps the language of the Telegram client to the one of the messages.
+func langOf(u *telegram.User) string {
+	if u == nil {
+		return notifications.DefaultLang
+	}
+
+	switch strings.ToLower(strings.SplitN(u.LanguageCode, "-", 2)[0]) {
+	case "en":
+		return notifications.LangEN
+	case "ru":
+		return notifications.LangRU
+	}
+
+	return notifications.DefaultLang
+}
+
+func text(lang string, msg int, args ...interface{}) string {
+	if len(args) == 0 {
+		return messages[lang][msg]
+	}
+	return fmt.Sprintf(messages[lang][msg], args...)
+}
+
+// errorText returns the localized message of the service error, the generic one for the others.
+func errorText(lang string, err error) string {
+	e, ok := err.(errs.SvcError)
+	if !ok {
+		e = errs.Generic
+	}
+
+	switch lang {
+	case notifications.LangRU:
+		return e.Ru
+	case notifications.LangUA:
+		return e.Ua
+	}
+
+	return e.Error()
+}
@@ -0,0 +1,73 @@
+package repo
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/ivch/dynasty/server/handlers/bot"
+)
+
+type Repo struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Repo {
+	return &Repo{db: db}
+}
+
+func (r *Repo) CreateLinkCode(c *bot.LinkCode) error {
+	return r.db.Create(c).Error
+}
+
+// UseLinkCode deletes the code and returns it, nil if it does not exist or is expired.
+func (r *Repo) UseLinkCode(code string, now time.Time) (*bot.LinkCode, error) {
+	var c bot.LinkCode
+	if err := r.db.Raw(`delete from telegram_link_codes where code = ? returning *`, code).Scan(&c).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if c.ExpiresAt.Before(now) {
+		return nil, nil
+	}
+
+	return &c, nil
+}
+
+// SaveLink links the chat to the user, replacing the previous link of the chat.
+func (r *Repo) SaveLink(l *bot.Link) error {
+	return r.db.Exec(`insert into telegram_links (chat_id, user_id, pending_type) values (?, ?, 0)
+		on conflict (chat_id) do update set user_id = excluded.user_id, pending_type = 0, created_at = CURRENT_TIMESTAMP`,
+		l.ChatID, l.UserID).Error
+}
+
+// GetLink returns nil if the chat is not linked.
+func (r *Repo) GetLink(chatID int64) (*bot.Link, error) {
+	var l bot.Link
+	if err := r.db.Where("chat_id = ?", chatID).First(&l).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (r *Repo) ListLinks(userID uint) ([]*bot.Link, error) {
+	var res []*bot.Link
+	if err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&res).Error; err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (r *Repo) DeleteLink(chatID int64) error {
+	return r.db.Where("chat_id = ?", chatID).Delete(&bot.Link{}).Error
+}
+
+func (r *Repo) SetPendingType(chatID int64, rtype int) error {
+	return r.db.Model(&bot.Link{}).Where("chat_id = ?", chatID).Update("pending_type", rtype).Error
+}
@@ -0,0 +1,470 @@
+package bot
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/microcosm-cc/bluemonday"
+
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/common/logger"
+	"github.com/ivch/dynasty/common/telegram"
+	"github.com/ivch/dynasty/server/handlers/notifications"
+	"github.com/ivch/dynasty/server/handlers/requests"
+)
+
+const (
+	linkCodeTTL      = 10 * time.Minute
+	linkCodeLength   = 8
+	linkCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	retryDelay       = 5 * time.Second
+	openRequestsMax  = 20
+
+	callbackNew   = "new:"
+	timeLayout    = "02.01 15:04"
+	maxRequestLen = 1000
+)
+
+// openStatuses are the statuses of the requests listed as open.
+var openStatuses = []string{"new", "pending"}
+
+// requestTypes are the types the residents create in the bot, in the keyboard order.
+var requestTypes = []requests.RequestType{requests.Guest, requests.Taxi, requests.Delivery}
+
+type Repository interface {
+	CreateLinkCode(c *LinkCode) error
+	UseLinkCode(code string, now time.Time) (*LinkCode, error)
+	SaveLink(l *Link) error
+	GetLink(chatID int64) (*Link, error)
+	ListLinks(userID uint) ([]*Link, error)
+	DeleteLink(chatID int64) error
+	SetPendingType(chatID int64, rtype int) error
+}
+
+// Bot is the Telegram Bot API client.
+type Bot interface {
+	GetUpdates(ctx context.Context, offset int64, timeout time.Duration) ([]telegram.Update, error)
+	SendMessage(ctx context.Context, chatID, text string, keyboard *telegram.InlineKeyboardMarkup) error
+	AnswerCallbackQuery(ctx context.Context, id, text string) error
+}
+
+type RequestsService interface {
+	Create(ctx context.Context, r *requests.Request) (*requests.Request, error)
+	My(ctx context.Context, r *requests.RequestListFilter) ([]*requests.Request, error)
+}
+
+// NotificationsService delivers the status changes of the requests to the linked chats.
+type NotificationsService interface {
+	Preferences(ctx context.Context, userID uint) (*notifications.Preferences, error)
+	UpdatePreferences(ctx context.Context, p *notifications.Preferences) error
+	Endpoints(ctx context.Context, userID uint) ([]*notifications.Endpoint, error)
+	LinkTelegram(ctx context.Context, userID uint, chatID string) (*notifications.Endpoint, error)
+	DeleteEndpoint(ctx context.Context, userID, id uint) error
+}
+
+// Service is the Telegram bot of the residents.
+type Service struct {
+	repo      Repository
+	bot       Bot
+	requests  RequestsService
+	notif     NotificationsService
+	sanitizer *bluemonday.Policy
+	log       logger.Logger
+	now       func() time.Time
+}
+
+func New(log logger.Logger, repo Repository, bot Bot, reqs RequestsService, notif NotificationsService, sanitizer *bluemonday.Policy) *Service {
+	return &Service{
+		repo:      repo,
+		bot:       bot,
+		requests:  reqs,
+		notif:     notif,
+		sanitizer: sanitizer,
+		log:       log,
+		now:       time.Now,
+	}
+}
+
+// LinkCode returns the new one-time code linking the chat it is sent from to the user.
+func (s *Service) LinkCode(_ context.Context, userID uint) (*LinkCode, error) {
+	code, err := newLinkCode()
+	if err != nil {
+		return nil, err
+	}
+
+	c := LinkCode{Code: code, UserID: userID, ExpiresAt: s.now().Add(linkCodeTTL)}
+	if err := s.repo.CreateLinkCode(&c); err != nil {
+		s.log.Error("error creating telegram link code: %w", err)
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (s *Service) Links(_ context.Context, userID uint) ([]*Link, error) {
+	return s.repo.ListLinks(userID)
+}
+
+// Unlink unlinks all the chats of the user.
+func (s *Service) Unlink(ctx context.Context, userID uint) error {
+	links, err := s.repo.ListLinks(userID)
+	if err != nil {
+		return err
+	}
+
+	for _, l := range links {
+		if err := s.unlink(ctx, l); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Run polls the updates until the context is done.
+func (s *Service) Run(ctx context.Context, pollTimeout time.Duration) {
+	var offset int64
+	for ctx.Err() == nil {
+		updates, err := s.bot.GetUpdates(ctx, offset, pollTimeout)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.log.Error("failed to get telegram updates: %w", err)
+				sleep(ctx, retryDelay)
+			}
+			continue
+		}
+
+		for i := range updates {
+			s.HandleUpdate(ctx, &updates[i])
+			offset = updates[i].UpdateID + 1
+		}
+	}
+}
+
+// HandleUpdate handles the message or the pressed button, the errors are reported to the chat.
+func (s *Service) HandleUpdate(ctx context.Context, u *telegram.Update) {
+	var (
+		chatID int64
+		lang   string
+		err    error
+	)
+
+	switch {
+	case u.Message != nil:
+		chatID, lang = u.Message.Chat.ID, langOf(u.Message.From)
+		err = s.handleMessage(ctx, u.Message, lang)
+	case u.CallbackQuery != nil && u.CallbackQuery.Message != nil:
+		chatID, lang = u.CallbackQuery.Message.Chat.ID, langOf(&u.CallbackQuery.From)
+		err = s.handleCallback(ctx, u.CallbackQuery, lang)
+	default:
+		return
+	}
+
+	if err != nil {
+		if _, ok := err.(errs.SvcError); !ok {
+			s.log.Error("failed to handle telegram update %d: %w", u.UpdateID, err)
+		}
+		s.reply(ctx, chatID, errorText(lang, err), nil)
+	}
+}
+
+func (s *Service) handleMessage(ctx context.Context, m *telegram.Message, lang string) error {
+	cmd, arg := parseCommand(m.Text)
+
+	switch cmd {
+	case "start", "link":
+		if arg == "" {
+			s.reply(ctx, m.Chat.ID, text(lang, msgNotLinked), nil)
+			return nil
+		}
+		return s.link(ctx, m.Chat.ID, arg, lang)
+	case "help":
+		s.reply(ctx, m.Chat.ID, text(lang, msgHelp), nil)
+		return nil
+	}
+
+	link, err := s.repo.GetLink(m.Chat.ID)
+	if err != nil {
+		return err
+	}
+
+	if link == nil {
+		s.reply(ctx, m.Chat.ID, text(lang, msgNotLinked), nil)
+		return nil
+	}
+
+	switch cmd {
+	case "new":
+		s.reply(ctx, m.Chat.ID, text(lang, msgChooseType), typesKeyboard(lang))
+		return nil
+	case "requests":
+		return s.listRequests(ctx, link, lang)
+	case "unlink":
+		if err := s.unlink(ctx, link); err != nil {
+			return err
+		}
+		s.reply(ctx, m.Chat.ID, text(lang, msgUnlinked), nil)
+		return nil
+	case "":
+		if link.PendingType != 0 {
+			return s.createRequest(ctx, link, m.Text, lang)
+		}
+	}
+
+	s.reply(ctx, m.Chat.ID, text(lang, msgHelp), nil)
+	return nil
+}
+
+func (s *Service) handleCallback(ctx context.Context, q *telegram.CallbackQuery, lang string) error {
+	if err := s.bot.AnswerCallbackQuery(ctx, q.ID, ""); err != nil {
+		s.log.Error("failed to answer telegram callback: %w", err)
+	}
+
+	chatID := q.Message.Chat.ID
+	link, err := s.repo.GetLink(chatID)
+	if err != nil {
+		return err
+	}
+
+	if link == nil {
+		s.reply(ctx, chatID, text(lang, msgNotLinked), nil)
+		return nil
+	}
+
+	if !strings.HasPrefix(q.Data, callbackNew) {
+		return nil
+	}
+
+	rtype, err := strconv.Atoi(strings.TrimPrefix(q.Data, callbackNew))
+	if err != nil || !isBotRequestType(requests.RequestType(rtype)) {
+		s.reply(ctx, chatID, text(lang, msgUnknownType), nil)
+		return nil
+	}
+
+	if err := s.repo.SetPendingType(chatID, rtype); err != nil {
+		return err
+	}
+
+	s.reply(ctx, chatID, text(lang, msgDescribe, typeName(requests.RequestType(rtype), lang)), nil)
+	return nil
+}
+
+// link links the chat to the owner of the code and subscribes it to the notifications.
+func (s *Service) link(ctx context.Context, chatID int64, code, lang string) error {
+	c, err := s.repo.UseLinkCode(strings.ToUpper(code), s.now())
+	if err != nil {
+		return err
+	}
+
+	if c == nil {
+		return errs.TelegramLinkCodeInvalid
+	}
+
+	if err := s.repo.SaveLink(&Link{ChatID: chatID, UserID: c.UserID}); err != nil {
+		return err
+	}
+
+	if err := s.subscribe(ctx, c.UserID, telegram.ChatID(chatID)); err != nil {
+		return err
+	}
+
+	s.reply(ctx, chatID, text(lang, msgLinked)+text(lang, msgHelp), nil)
+	return nil
+}
+
+// subscribe adds the chat to the notification endpoints of the user and enables the channel.
+func (s *Service) subscribe(ctx context.Context, userID uint, address string) error {
+	endpoints, err := s.notif.Endpoints(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if findEndpoint(endpoints, address) == nil {
+		if _, err := s.notif.LinkTelegram(ctx, userID, address); err != nil {
+			return err
+		}
+	}
+
+	p, err := s.notif.Preferences(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, ch := range p.Channels {
+		if ch == notifications.ChannelTelegram {
+			return nil
+		}
+	}
+
+	p.Channels = append(p.Channels, notifications.ChannelTelegram)
+	return s.notif.UpdatePreferences(ctx, p)
+}
+
+func (s *Service) unlink(ctx context.Context, l *Link) error {
+	endpoints, err := s.notif.Endpoints(ctx, l.UserID)
+	if err != nil {
+		return err
+	}
+
+	if e := findEndpoint(endpoints, telegram.ChatID(l.ChatID)); e != nil {
+		if err := s.notif.DeleteEndpoint(ctx, l.UserID, e.ID); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.DeleteLink(l.ChatID)
+}
+
+func (s *Service) createRequest(ctx context.Context, link *Link, description, lang string) error {
+	description = strings.TrimSpace(s.sanitizer.Sanitize(description))
+	if len([]rune(description)) > maxRequestLen {
+		description = string([]rune(description)[:maxRequestLen])
+	}
+
+	req, err := s.requests.Create(ctx, &requests.Request{
+		UserID:      link.UserID,
+		Rtype:       requests.RequestType(link.PendingType),
+		Time:        s.now().Unix(),
+		Description: description,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.SetPendingType(link.ChatID, 0); err != nil {
+		return err
+	}
+
+	s.reply(ctx, link.ChatID, text(lang, msgCreated, req.ID), nil)
+	return nil
+}
+
+func (s *Service) listRequests(ctx context.Context, link *Link, lang string) error {
+	var reqs []*requests.Request
+	for _, status := range openStatuses {
+		res, err := s.requests.My(ctx, &requests.RequestListFilter{
+			UserID: link.UserID,
+			Status: status,
+			Limit:  openRequestsMax,
+		})
+		if err != nil {
+			return err
+		}
+		reqs = append(reqs, res...)
+	}
+
+	sort.SliceStable(reqs, func(i, j int) bool { return reqs[i].Time > reqs[j].Time })
+	if len(reqs) > openRequestsMax {
+		reqs = reqs[:openRequestsMax]
+	}
+
+	var b strings.Builder
+	for _, r := range reqs {
+		status := statuses[lang][r.Status]
+		if status == "" {
+			status = r.Status
+		}
+
+		fmt.Fprintf(&b, "\n#%d %s, %s: %s", r.ID, typeName(r.Rtype, lang),
+			time.Unix(r.Time, 0).Format(timeLayout), status)
+		if r.Description != "" {
+			fmt.Fprintf(&b, "\n%s", r.Description)
+		}
+	}
+
+	if b.Len() == 0 {
+		s.reply(ctx, link.ChatID, text(lang, msgNoRequests), nil)
+		return nil
+	}
+
+	s.reply(ctx, link.ChatID, text(lang, msgRequests)+"\n"+b.String(), nil)
+	return nil
+}
+
+func (s *Service) reply(ctx context.Context, chatID int64, msg string, keyboard *telegram.InlineKeyboardMarkup) {
+	if err := s.bot.SendMessage(ctx, telegram.ChatID(chatID), msg, keyboard); err != nil {
+		s.log.Error("failed to send telegram message: %w", err)
+	}
+}
+
+func typesKeyboard(lang string) *telegram.InlineKeyboardMarkup {
+	row := make([]telegram.InlineKeyboardButton, len(requestTypes))
+	for i, t := range requestTypes {
+		row[i] = telegram.InlineKeyboardButton{
+			Text:         typeName(t, lang),
+			CallbackData: callbackNew + strconv.Itoa(int(t)),
+		}
+	}
+
+	return &telegram.InlineKeyboardMarkup{InlineKeyboard: [][]telegram.InlineKeyboardButton{row}}
+}
+
+func typeName(t requests.RequestType, lang string) string {
+	if name := requests.GetRequestTypes()[t][lang]; name != "" {
+		return name
+	}
+	return strconv.Itoa(int(t))
+}
+
+func isBotRequestType(t requests.RequestType) bool {
+	for _, v := range requestTypes {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+func findEndpoint(endpoints []*notifications.Endpoint, address string) *notifications.Endpoint {
+	for _, e := range endpoints {
+		if e.Channel == notifications.ChannelTelegram && e.Address == address {
+			return e
+		}
+	}
+	return nil
+}
+
+// parseCommand splits "/cmd@bot arg" into the command and its argument.
+func parseCommand(s string) (cmd, arg string) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "/") {
+		return "", ""
+	}
+
+	parts := strings.SplitN(s[1:], " ", 2)
+	cmd = strings.ToLower(strings.SplitN(parts[0], "@", 2)[0])
+	if len(parts) > 1 {
+		arg = strings.TrimSpace(parts[1])
+	}
+
+	return cmd, arg
+}
+
+func newLinkCode() (string, error) {
+	b := make([]byte, linkCodeLength)
+	max := big.NewInt(int64(len(linkCodeAlphabet)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = linkCodeAlphabet[n.Int64()]
+	}
+	return string(b), nil
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
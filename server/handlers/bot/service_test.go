@@ -0,0 +1,397 @@
+package bot_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/microcosm-cc/bluemonday"
+
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/common/logger"
+	"github.com/ivch/dynasty/common/telegram"
+	"github.com/ivch/dynasty/server/handlers/bot"
+	"github.com/ivch/dynasty/server/handlers/notifications"
+	"github.com/ivch/dynasty/server/handlers/requests"
+)
+
+var (
+	defaultLogger *logger.StdLog
+	errTestError  = errors.New("some err")
+)
+
+func TestMain(m *testing.M) {
+	defaultLogger = logger.NewStdLog(logger.WithWriter(io.Discard))
+	os.Exit(m.Run())
+}
+
+type sent struct {
+	chatID   string
+	text     string
+	keyboard *telegram.InlineKeyboardMarkup
+}
+
+func newBot(got *[]sent) *bot.BotMock {
+	return &bot.BotMock{
+		SendMessageFunc: func(_ context.Context, chatID, text string, keyboard *telegram.InlineKeyboardMarkup) error {
+			*got = append(*got, sent{chatID: chatID, text: text, keyboard: keyboard})
+			return nil
+		},
+		AnswerCallbackQueryFunc: func(_ context.Context, _, _ string) error {
+			return nil
+		},
+	}
+}
+
+func message(text string) *telegram.Update {
+	return &telegram.Update{UpdateID: 1, Message: &telegram.Message{
+		From: &telegram.User{ID: 10, LanguageCode: "en"},
+		Chat: telegram.Chat{ID: 100},
+		Text: text,
+	}}
+}
+
+func callback(data string) *telegram.Update {
+	return &telegram.Update{UpdateID: 1, CallbackQuery: &telegram.CallbackQuery{
+		ID:      "q",
+		From:    telegram.User{ID: 10, LanguageCode: "en"},
+		Message: &telegram.Message{Chat: telegram.Chat{ID: 100}},
+		Data:    data,
+	}}
+}
+
+func linked(pendingType int) func(int64) (*bot.Link, error) {
+	return func(chatID int64) (*bot.Link, error) {
+		return &bot.Link{ChatID: chatID, UserID: 1, PendingType: pendingType}, nil
+	}
+}
+
+func notLinked(int64) (*bot.Link, error) {
+	return nil, nil
+}
+
+func TestService_LinkCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		repoErr error
+		wantErr bool
+	}{
+		{name: "error repo", repoErr: errTestError, wantErr: true},
+		{name: "ok"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &bot.RepositoryMock{
+				CreateLinkCodeFunc: func(_ *bot.LinkCode) error {
+					return tt.repoErr
+				},
+			}
+
+			s := bot.New(defaultLogger, repo, nil, nil, nil, bluemonday.StrictPolicy())
+			got, err := s.LinkCode(context.Background(), 1)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LinkCode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if len(got.Code) != 8 || got.UserID != 1 || time.Until(got.ExpiresAt) < 9*time.Minute {
+				t.Errorf("LinkCode() got = %+v", got)
+			}
+		})
+	}
+}
+
+func TestService_HandleUpdate(t *testing.T) {
+	newKeyboard := &telegram.InlineKeyboardMarkup{InlineKeyboard: [][]telegram.InlineKeyboardButton{{
+		{Text: "Guest", CallbackData: "new:1"},
+		{Text: "Taxi", CallbackData: "new:2"},
+		{Text: "Delivery", CallbackData: "new:3"},
+	}}}
+
+	tests := []struct {
+		name         string
+		update       *telegram.Update
+		repo         *bot.RepositoryMock
+		reqs         *bot.RequestsServiceMock
+		notif        *bot.NotificationsServiceMock
+		wantText     string
+		wantKeyboard *telegram.InlineKeyboardMarkup
+		wantRepo     func(t *testing.T, repo *bot.RepositoryMock)
+		wantNotif    func(t *testing.T, notif *bot.NotificationsServiceMock)
+		wantCreated  *requests.Request
+	}{
+		{
+			name:     "start without code",
+			update:   message("/start"),
+			repo:     &bot.RepositoryMock{},
+			wantText: "Link your account first",
+		},
+		{
+			name:   "link bad code",
+			update: message("/link abc"),
+			repo: &bot.RepositoryMock{
+				UseLinkCodeFunc: func(code string, _ time.Time) (*bot.LinkCode, error) {
+					if code != "ABC" {
+						t.Errorf("UseLinkCode() code = %s", code)
+					}
+					return nil, nil
+				},
+			},
+			wantText: errs.TelegramLinkCodeInvalid.Error(),
+		},
+		{
+			name:   "link repo error",
+			update: message("/link abc"),
+			repo: &bot.RepositoryMock{
+				UseLinkCodeFunc: func(_ string, _ time.Time) (*bot.LinkCode, error) {
+					return nil, errTestError
+				},
+			},
+			wantText: errs.Generic.Error(),
+		},
+		{
+			name:   "link",
+			update: message("/start@dynasty_bot ABC"),
+			repo: &bot.RepositoryMock{
+				UseLinkCodeFunc: func(_ string, _ time.Time) (*bot.LinkCode, error) {
+					return &bot.LinkCode{Code: "ABC", UserID: 1}, nil
+				},
+				SaveLinkFunc: func(_ *bot.Link) error {
+					return nil
+				},
+			},
+			notif: &bot.NotificationsServiceMock{
+				EndpointsFunc: func(_ context.Context, _ uint) ([]*notifications.Endpoint, error) {
+					return nil, nil
+				},
+				LinkTelegramFunc: func(_ context.Context, userID uint, chatID string) (*notifications.Endpoint, error) {
+					return &notifications.Endpoint{UserID: userID, Channel: notifications.ChannelTelegram, Address: chatID}, nil
+				},
+				PreferencesFunc: func(_ context.Context, userID uint) (*notifications.Preferences, error) {
+					return &notifications.Preferences{UserID: userID, Channels: []string{notifications.ChannelEmail}, Lang: notifications.LangEN}, nil
+				},
+				UpdatePreferencesFunc: func(_ context.Context, _ *notifications.Preferences) error {
+					return nil
+				},
+			},
+			wantText: "The chat is linked",
+			wantRepo: func(t *testing.T, repo *bot.RepositoryMock) {
+				if calls := repo.SaveLinkCalls(); len(calls) != 1 || *calls[0].L != (bot.Link{ChatID: 100, UserID: 1}) {
+					t.Errorf("SaveLink() calls = %+v", calls)
+				}
+			},
+			wantNotif: func(t *testing.T, notif *bot.NotificationsServiceMock) {
+				if calls := notif.LinkTelegramCalls(); len(calls) != 1 || calls[0].UserID != 1 || calls[0].ChatID != "100" {
+					t.Errorf("LinkTelegram() calls = %+v", calls)
+				}
+				want2 := []string{notifications.ChannelEmail, notifications.ChannelTelegram}
+				if calls := notif.UpdatePreferencesCalls(); len(calls) != 1 || !reflect.DeepEqual([]string(calls[0].P.Channels), want2) {
+					t.Errorf("UpdatePreferences() calls = %+v", calls)
+				}
+			},
+		},
+		{
+			name:     "not linked",
+			update:   message("/new"),
+			repo:     &bot.RepositoryMock{GetLinkFunc: notLinked},
+			wantText: "Link your account first",
+		},
+		{
+			name:         "new",
+			update:       message("/new"),
+			repo:         &bot.RepositoryMock{GetLinkFunc: linked(0)},
+			wantText:     "Choose the request type",
+			wantKeyboard: newKeyboard,
+		},
+		{
+			name:     "plain text without request",
+			update:   message("hello"),
+			repo:     &bot.RepositoryMock{GetLinkFunc: linked(0)},
+			wantText: "/new - create a request",
+		},
+		{
+			name:   "choose type",
+			update: callback("new:2"),
+			repo: &bot.RepositoryMock{
+				GetLinkFunc: linked(0),
+				SetPendingTypeFunc: func(_ int64, _ int) error {
+					return nil
+				},
+			},
+			wantText: "New request: Taxi.",
+			wantRepo: func(t *testing.T, repo *bot.RepositoryMock) {
+				if calls := repo.SetPendingTypeCalls(); len(calls) != 1 || calls[0].ChatID != 100 || calls[0].Rtype != 2 {
+					t.Errorf("SetPendingType() calls = %+v", calls)
+				}
+			},
+		},
+		{
+			name:     "choose unknown type",
+			update:   callback("new:4"),
+			repo:     &bot.RepositoryMock{GetLinkFunc: linked(0)},
+			wantText: "Unknown request type",
+		},
+		{
+			name:   "create",
+			update: message("AA1234BB <b>"),
+			repo: &bot.RepositoryMock{
+				GetLinkFunc: linked(2),
+				SetPendingTypeFunc: func(_ int64, _ int) error {
+					return nil
+				},
+			},
+			reqs: &bot.RequestsServiceMock{
+				CreateFunc: func(_ context.Context, r *requests.Request) (*requests.Request, error) {
+					r.ID = 5
+					return r, nil
+				},
+			},
+			wantText:    "Request #5 is created.",
+			wantCreated: &requests.Request{ID: 5, UserID: 1, Rtype: requests.Taxi, Description: "AA1234BB"},
+			wantRepo: func(t *testing.T, repo *bot.RepositoryMock) {
+				if calls := repo.SetPendingTypeCalls(); len(calls) != 1 || calls[0].Rtype != 0 {
+					t.Errorf("SetPendingType() calls = %+v", calls)
+				}
+			},
+		},
+		{
+			name:   "create blocklisted",
+			update: message("AA1234BB"),
+			repo:   &bot.RepositoryMock{GetLinkFunc: linked(2)},
+			reqs: &bot.RequestsServiceMock{
+				CreateFunc: func(_ context.Context, _ *requests.Request) (*requests.Request, error) {
+					return nil, errs.RequestBlocklisted
+				},
+			},
+			wantText: errs.RequestBlocklisted.Error(),
+		},
+		{
+			name:   "requests",
+			update: message("/requests"),
+			repo:   &bot.RepositoryMock{GetLinkFunc: linked(0)},
+			reqs: &bot.RequestsServiceMock{
+				MyFunc: func(_ context.Context, r *requests.RequestListFilter) ([]*requests.Request, error) {
+					if r.UserID != 1 {
+						t.Errorf("My() user = %d", r.UserID)
+					}
+					switch r.Status {
+					case "new":
+						return []*requests.Request{{ID: 2, Rtype: requests.Guest, Status: "new", Description: "John"}}, nil
+					case "pending":
+						return []*requests.Request{{ID: 3, Rtype: requests.Taxi, Status: "pending", Time: 1}}, nil
+					}
+					t.Errorf("My() status = %q", r.Status)
+					return nil, nil
+				},
+			},
+			wantText: "#3 Taxi",
+		},
+		{
+			name:   "no requests",
+			update: message("/requests"),
+			repo:   &bot.RepositoryMock{GetLinkFunc: linked(0)},
+			reqs: &bot.RequestsServiceMock{
+				MyFunc: func(_ context.Context, _ *requests.RequestListFilter) ([]*requests.Request, error) {
+					return nil, nil
+				},
+			},
+			wantText: "You have no open requests.",
+		},
+		{
+			name:   "unlink",
+			update: message("/unlink"),
+			repo: &bot.RepositoryMock{
+				GetLinkFunc: linked(0),
+				DeleteLinkFunc: func(_ int64) error {
+					return nil
+				},
+			},
+			notif: &bot.NotificationsServiceMock{
+				EndpointsFunc: func(_ context.Context, _ uint) ([]*notifications.Endpoint, error) {
+					return []*notifications.Endpoint{
+						{ID: 3, Channel: notifications.ChannelTelegram, Address: "200"},
+						{ID: 4, Channel: notifications.ChannelTelegram, Address: "100"},
+					}, nil
+				},
+				DeleteEndpointFunc: func(_ context.Context, _, _ uint) error {
+					return nil
+				},
+			},
+			wantText: "The chat is unlinked",
+			wantNotif: func(t *testing.T, notif *bot.NotificationsServiceMock) {
+				if calls := notif.DeleteEndpointCalls(); len(calls) != 1 || calls[0].ID != 4 {
+					t.Errorf("DeleteEndpoint() calls = %+v", calls)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []sent
+			s := bot.New(defaultLogger, tt.repo, newBot(&got), tt.reqs, tt.notif, bluemonday.StrictPolicy())
+			s.HandleUpdate(context.Background(), tt.update)
+
+			if len(got) != 1 {
+				t.Fatalf("HandleUpdate() sent = %+v, want 1 message", got)
+			}
+			if got[0].chatID != "100" || !strings.Contains(got[0].text, tt.wantText) {
+				t.Errorf("HandleUpdate() sent = %+v, want %q", got[0], tt.wantText)
+			}
+			if !reflect.DeepEqual(got[0].keyboard, tt.wantKeyboard) {
+				t.Errorf("HandleUpdate() keyboard = %+v, want %+v", got[0].keyboard, tt.wantKeyboard)
+			}
+
+			if tt.wantRepo != nil {
+				tt.wantRepo(t, tt.repo)
+			}
+			if tt.wantNotif != nil {
+				tt.wantNotif(t, tt.notif)
+			}
+			if tt.wantCreated != nil {
+				created := tt.reqs.CreateCalls()[0].R
+				created.Time = 0
+				if !reflect.DeepEqual(created, tt.wantCreated) {
+					t.Errorf("Create() got = %+v, want %+v", created, tt.wantCreated)
+				}
+			}
+		})
+	}
+}
+
+func TestService_Run(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		offsets []int64
+		got     []sent
+	)
+	b := newBot(&got)
+	b.GetUpdatesFunc = func(_ context.Context, offset int64, _ time.Duration) ([]telegram.Update, error) {
+		offsets = append(offsets, offset)
+		if len(offsets) == 2 {
+			cancel()
+			return nil, context.Canceled
+		}
+		return []telegram.Update{*message("/help"), {UpdateID: 7, Message: message("/help").Message}}, nil
+	}
+
+	s := bot.New(defaultLogger, &bot.RepositoryMock{}, b, nil, nil, bluemonday.StrictPolicy())
+	s.Run(ctx, time.Second)
+
+	if want := []int64{0, 8}; !reflect.DeepEqual(offsets, want) {
+		t.Errorf("Run() offsets = %v, want %v", offsets, want)
+	}
+	if len(got) != 2 {
+		t.Errorf("Run() sent %d messages, want 2", len(got))
+	}
+}
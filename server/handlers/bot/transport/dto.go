@@ -0,0 +1,27 @@
+package transport
+
+import (
+	"time"
+)
+
+type errorResponse struct {
+	Error     string `json:"error"`
+	ErrorCode uint   `json:"error_code"`
+	Ru        string `json:"ru"`
+	Ua        string `json:"ua"`
+}
+
+type linkCodeResponse struct {
+	Code      string    `json:"code"`
+	Command   string    `json:"command"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type linkResponse struct {
+	ChatID    int64     `json:"chat_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type linksResponse struct {
+	Data []*linkResponse `json:"data"`
+}
@@ -0,0 +1,151 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/common/logger"
+	"github.com/ivch/dynasty/server/handlers/bot"
+	"github.com/ivch/dynasty/server/middlewares"
+)
+
+type BotService interface {
+	LinkCode(ctx context.Context, userID uint) (*bot.LinkCode, error)
+	Links(ctx context.Context, userID uint) ([]*bot.Link, error)
+	Unlink(ctx context.Context, userID uint) error
+}
+
+type HTTPTransport struct {
+	svc    BotService
+	log    logger.Logger
+	router chi.Router
+}
+
+func (h *HTTPTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.router.ServeHTTP(w, r)
+}
+
+// NewHTTPTransport returns a new instance of HTTPTransport.
+func NewHTTPTransport(log logger.Logger, svc BotService, mdl ...func(http.Handler) http.Handler) http.Handler {
+	h := &HTTPTransport{log: log, router: chi.NewRouter().With(mdl...), svc: svc}
+	h.attachRoutes()
+	return h
+}
+
+func (h *HTTPTransport) attachRoutes() {
+	h.router.Post("/v1/link-code", h.LinkCode)
+	h.router.Get("/v1/links", h.Links)
+	h.router.Delete("/v1/links", h.Unlink)
+}
+
+func (h *HTTPTransport) LinkCode(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
+		return
+	}
+
+	c, err := h.svc.LinkCode(r.Context(), userID)
+	if err != nil {
+		h.sendError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.sendHTTPResponse(r.Context(), w, linkCodeResponse{Code: c.Code, Command: "/link " + c.Code, ExpiresAt: c.ExpiresAt})
+}
+
+func (h *HTTPTransport) Links(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
+		return
+	}
+
+	res, err := h.svc.Links(r.Context(), userID)
+	if err != nil {
+		h.sendError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	result := make([]*linkResponse, len(res))
+	for i := range res {
+		result[i] = &linkResponse{ChatID: res[i].ChatID, CreatedAt: res[i].CreatedAt}
+	}
+
+	h.sendHTTPResponse(r.Context(), w, linksResponse{Data: result})
+}
+
+func (h *HTTPTransport) Unlink(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
+		return
+	}
+
+	if err := h.svc.Unlink(r.Context(), userID); err != nil {
+		h.sendError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.sendHTTPResponse(r.Context(), w, nil)
+}
+
+func (h *HTTPTransport) sendHTTPResponse(_ context.Context, w http.ResponseWriter, response interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.log.Debug("failed to send response error: %w", err)
+	}
+}
+
+func (h *HTTPTransport) sendError(w http.ResponseWriter, httpCode int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpCode)
+
+	if err == nil {
+		err = errs.Generic
+	}
+
+	var (
+		ru string
+		ua string
+	)
+
+	if e, ok := err.(errs.SvcError); ok {
+		ru, ua = e.Ru, e.Ua
+	}
+
+	res := errorResponse{
+		ErrorCode: errs.Code(err),
+		Error:     err.Error(),
+		Ru:        ru,
+		Ua:        ua,
+	}
+
+	if err := json.NewEncoder(w).Encode(&res); err != nil {
+		h.log.Debug("failed to send response error: %w", err)
+	}
+}
+
+func getUserID(ctx context.Context) (uint, error) {
+	idStr, ok := middlewares.UserIDFromContext(ctx)
+	if !ok {
+		return 0, errs.EmptyUserID
+	}
+
+	userID, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return 0, errs.BadUserID
+	}
+
+	if userID == 0 {
+		return 0, errs.BadUserID
+	}
+
+	return uint(userID), nil
+}
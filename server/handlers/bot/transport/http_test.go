@@ -0,0 +1,159 @@
+package transport_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivch/dynasty/common/logger"
+	"github.com/ivch/dynasty/server/handlers/bot"
+	"github.com/ivch/dynasty/server/handlers/bot/transport"
+	"github.com/ivch/dynasty/server/middlewares"
+)
+
+var (
+	defaultLogger *logger.StdLog
+	errTestError  = errors.New("some err")
+	testTime      = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+)
+
+func TestMain(m *testing.M) {
+	defaultLogger = logger.NewStdLog(logger.WithWriter(io.Discard))
+	os.Exit(m.Run())
+}
+
+func TestHTTP(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		header   string
+		svc      transport.BotService
+		wantErr  bool
+		want     string
+		wantCode int
+	}{
+		{
+			name:     "link code error no user",
+			method:   http.MethodPost,
+			path:     "/v1/link-code",
+			wantErr:  true,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "link code error service error",
+			method: http.MethodPost,
+			path:   "/v1/link-code",
+			header: "1",
+			svc: &transport.BotServiceMock{
+				LinkCodeFunc: func(_ context.Context, _ uint) (*bot.LinkCode, error) {
+					return nil, errTestError
+				},
+			},
+			wantErr:  true,
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "link code ok",
+			method: http.MethodPost,
+			path:   "/v1/link-code",
+			header: "1",
+			svc: &transport.BotServiceMock{
+				LinkCodeFunc: func(_ context.Context, userID uint) (*bot.LinkCode, error) {
+					return &bot.LinkCode{Code: "ABCD2345", UserID: userID, ExpiresAt: testTime}, nil
+				},
+			},
+			want:     `{"code":"ABCD2345","command":"/link ABCD2345","expires_at":"2026-10-19T12:00:00Z"}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "links error no user",
+			method:   http.MethodGet,
+			path:     "/v1/links",
+			wantErr:  true,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "links error service error",
+			method: http.MethodGet,
+			path:   "/v1/links",
+			header: "1",
+			svc: &transport.BotServiceMock{
+				LinksFunc: func(_ context.Context, _ uint) ([]*bot.Link, error) {
+					return nil, errTestError
+				},
+			},
+			wantErr:  true,
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "links ok",
+			method: http.MethodGet,
+			path:   "/v1/links",
+			header: "1",
+			svc: &transport.BotServiceMock{
+				LinksFunc: func(_ context.Context, userID uint) ([]*bot.Link, error) {
+					return []*bot.Link{{ChatID: 100, UserID: userID, CreatedAt: testTime}}, nil
+				},
+			},
+			want:     `{"data":[{"chat_id":100,"created_at":"2026-10-19T12:00:00Z"}]}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "unlink error no user",
+			method:   http.MethodDelete,
+			path:     "/v1/links",
+			wantErr:  true,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "unlink error service error",
+			method: http.MethodDelete,
+			path:   "/v1/links",
+			header: "1",
+			svc: &transport.BotServiceMock{
+				UnlinkFunc: func(_ context.Context, _ uint) error {
+					return errTestError
+				},
+			},
+			wantErr:  true,
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "unlink ok",
+			method: http.MethodDelete,
+			path:   "/v1/links",
+			header: "1",
+			svc: &transport.BotServiceMock{
+				UnlinkFunc: func(_ context.Context, _ uint) error {
+					return nil
+				},
+			},
+			want:     `null`,
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := transport.NewHTTPTransport(defaultLogger, tt.svc, middlewares.NewIDCtx(defaultLogger).Middleware)
+			rr := httptest.NewRecorder()
+			rq, _ := http.NewRequest(tt.method, tt.path, nil)
+			rq.Header.Add("X-Auth-User", tt.header)
+			h.ServeHTTP(rr, rq)
+			if rr.Code != tt.wantCode {
+				t.Errorf("Request error. status = %d, wantCode = %d", rr.Code, tt.wantCode)
+			}
+
+			if !tt.wantErr && tt.want != strings.TrimSpace(rr.Body.String()) {
+				t.Errorf("Response error, got = %s, want = %s", rr.Body.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"html"
+	"strings"
+
+	"github.com/ivch/dynasty/common/webpush"
+)
+
+// Mailer sends the email with the plain text and HTML alternatives.
+type Mailer interface {
+	SendEmail(to, subject, text, html string) error
+}
+
+// TextSender sends the plain text message, e.g. SMS or the Telegram message.
+type TextSender interface {
+	SendText(ctx context.Context, to, text string) error
+}
+
+// PushSender sends the Web Push message to the browser's subscription.
+type PushSender interface {
+	SendPush(ctx context.Context, subscription string, payload []byte) error
+}
+
+// EmailChannel delivers the messages by email.
+type EmailChannel struct {
+	mailer Mailer
+}
+
+func NewEmailChannel(m Mailer) *EmailChannel {
+	return &EmailChannel{mailer: m}
+}
+
+func (c *EmailChannel) Send(_ context.Context, to string, msg *Message) error {
+	body := "<html><body><p>" + strings.ReplaceAll(html.EscapeString(msg.Text), "\n", "<br/>") + "</p></body></html>"
+	return c.mailer.SendEmail(to, msg.Subject, msg.Text, body)
+}
+
+// TextChannel delivers the messages as plain text with the subject on the first line.
+type TextChannel struct {
+	sender TextSender
+}
+
+func NewTextChannel(s TextSender) *TextChannel {
+	return &TextChannel{sender: s}
+}
+
+func (c *TextChannel) Send(ctx context.Context, to string, msg *Message) error {
+	return c.sender.SendText(ctx, to, msg.Subject+"\n"+msg.Text)
+}
+
+// WebPushChannel delivers the messages to the browsers.
+type WebPushChannel struct {
+	sender PushSender
+}
+
+func NewWebPushChannel(s PushSender) *WebPushChannel {
+	return &WebPushChannel{sender: s}
+}
+
+type pushPayload struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+func (c *WebPushChannel) Send(ctx context.Context, to string, msg *Message) error {
+	payload, err := json.Marshal(pushPayload{Title: msg.Subject, Body: msg.Text})
+	if err != nil {
+		return err
+	}
+
+	if err := c.sender.SendPush(ctx, to, payload); err != nil {
+		if errors.Is(err, webpush.ErrSubscriptionGone) {
+			return ErrEndpointGone
+		}
+		return err
+	}
+
+	return nil
+}
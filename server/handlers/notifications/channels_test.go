@@ -0,0 +1,89 @@
+package notifications_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ivch/dynasty/common/webpush"
+	"github.com/ivch/dynasty/server/handlers/notifications"
+)
+
+type mailerFunc func(to, subject, text, html string) error
+
+func (f mailerFunc) SendEmail(to, subject, text, html string) error {
+	return f(to, subject, text, html)
+}
+
+type textSenderFunc func(ctx context.Context, to, text string) error
+
+func (f textSenderFunc) SendText(ctx context.Context, to, text string) error { return f(ctx, to, text) }
+
+type pushSenderFunc func(ctx context.Context, subscription string, payload []byte) error
+
+func (f pushSenderFunc) SendPush(ctx context.Context, subscription string, payload []byte) error {
+	return f(ctx, subscription, payload)
+}
+
+func TestEmailChannel_Send(t *testing.T) {
+	var got [4]string
+	ch := notifications.NewEmailChannel(mailerFunc(func(to, subject, text, html string) error {
+		got = [4]string{to, subject, text, html}
+		return nil
+	}))
+
+	if err := ch.Send(context.Background(), "john@example.com", &notifications.Message{Subject: "Hi", Text: "<b>one</b>\ntwo"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	want := [4]string{"john@example.com", "Hi", "<b>one</b>\ntwo", "<html><body><p>&lt;b&gt;one&lt;/b&gt;<br/>two</p></body></html>"}
+	if got != want {
+		t.Errorf("Send() got = %q, want %q", got, want)
+	}
+}
+
+func TestTextChannel_Send(t *testing.T) {
+	var got [2]string
+	ch := notifications.NewTextChannel(textSenderFunc(func(_ context.Context, to, text string) error {
+		got = [2]string{to, text}
+		return nil
+	}))
+
+	if err := ch.Send(context.Background(), "100", &notifications.Message{Subject: "Hi", Text: "text"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if want := [2]string{"100", "Hi\ntext"}; got != want {
+		t.Errorf("Send() got = %q, want %q", got, want)
+	}
+}
+
+func TestWebPushChannel_Send(t *testing.T) {
+	tests := []struct {
+		name    string
+		sendErr error
+		wantErr error
+	}{
+		{name: "ok"},
+		{name: "subscription is gone", sendErr: webpush.ErrSubscriptionGone, wantErr: notifications.ErrEndpointGone},
+		{name: "send error", sendErr: errTestError, wantErr: errTestError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [2]string
+			ch := notifications.NewWebPushChannel(pushSenderFunc(func(_ context.Context, to string, payload []byte) error {
+				got = [2]string{to, string(payload)}
+				return tt.sendErr
+			}))
+
+			err := ch.Send(context.Background(), "{}", &notifications.Message{Subject: "Hi", Text: "text"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if want := [2]string{"{}", `{"title":"Hi","body":"text"}`}; got != want {
+				t.Errorf("Send() got = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package notifications
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	ChannelEmail    = "email"
+	ChannelWebPush  = "webpush"
+	ChannelTelegram = "telegram"
+	ChannelSMS      = "sms"
+
+	LangEN = "en"
+	LangRU = "ru"
+	LangUA = "ua"
+
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed"
+	DeliverySkipped = "skipped"
+)
+
+// Preferences are the channels the user is notified through and the language of the messages.
+type Preferences struct {
+	UserID   uint           `gorm:"primary_key"`
+	Channels pq.StringArray `gorm:"type:text[]"`
+	Lang     string
+}
+
+func (Preferences) TableName() string { return "notification_preferences" }
+
+// Endpoint is the address of the user in a channel, e.g. the Telegram chat.
+type Endpoint struct {
+	ID        uint `gorm:"primary_key"`
+	UserID    uint
+	Channel   string
+	Address   string
+	CreatedAt time.Time
+}
+
+func (Endpoint) TableName() string { return "notification_endpoints" }
+
+// Delivery logs an attempt to deliver the event through the channel.
+type Delivery struct {
+	ID        uint `gorm:"primary_key"`
+	UserID    uint
+	Event     string
+	Channel   string
+	Address   string
+	Status    string
+	Error     string
+	CreatedAt time.Time
+}
+
+func (Delivery) TableName() string { return "notification_deliveries" }
+
+// Recipient is the user the event is delivered to.
+type Recipient struct {
+	ID        uint
+	Email     string
+	Phone     string
+	FirstName string
+	LastName  string
+}
+
+// PushSubscription is the browser's PushSubscription, its JSON is the address of the webpush endpoint.
+type PushSubscription struct {
+	Endpoint string               `json:"endpoint"`
+	Keys     PushSubscriptionKeys `json:"keys"`
+}
+
+type PushSubscriptionKeys struct {
+	P256dh string `json:"p256dh"`
+	Auth   string `json:"auth"`
+}
+
+// Message is the rendered notification.
+type Message struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+}
+
+type eventPayload struct {
+	UserID uint              `json:"user_id"`
+	Event  string            `json:"event"`
+	Data   map[string]string `json:"data"`
+	// Channels override the preferences of the user if they are set.
+	Channels []string `json:"channels,omitempty"`
+}
+
+type deliveryPayload struct {
+	UserID  uint    `json:"user_id"`
+	Event   string  `json:"event"`
+	Channel string  `json:"channel"`
+	Address string  `json:"address"`
+	Message Message `json:"message"`
+}
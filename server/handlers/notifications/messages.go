@@ -0,0 +1,192 @@
+package notifications
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/ivch/dynasty/server/handlers/auth"
+	"github.com/ivch/dynasty/server/handlers/requests"
+	"github.com/ivch/dynasty/server/handlers/users"
+)
+
+// messageTemplate is the localized message of the event.
+type messageTemplate struct {
+	Subject string
+	Text    string
+}
+
+var messages = map[string]map[string]messageTemplate{
+	requests.EventRequestStatusChanged: {
+		LangEN: {
+			Subject: "Request #{{.id}}",
+			Text:    "{{.name}}, the status of your request #{{.id}} was changed to \"{{.status}}\".",
+		},
+		LangRU: {
+			Subject: "Заявка #{{.id}}",
+			Text:    "{{.name}}, статус вашей заявки #{{.id}} изменен на \"{{.status}}\".",
+		},
+		LangUA: {
+			Subject: "Заявка #{{.id}}",
+			Text:    "{{.name}}, статус вашої заявки #{{.id}} змінено на \"{{.status}}\".",
+		},
+	},
+	requests.EventKPPRequestCreated: {
+		LangEN: {
+			Subject: "{{requestType .type}} #{{.id}}",
+			Text:    "{{requestType .type}} to the apartment {{.apartment}}{{if .building}}, {{.building}}{{end}}{{if .description}}: {{.description}}{{end}}",
+		},
+		LangRU: {
+			Subject: "{{requestType .type}} #{{.id}}",
+			Text:    "{{requestType .type}} в квартиру {{.apartment}}{{if .building}}, {{.building}}{{end}}{{if .description}}: {{.description}}{{end}}",
+		},
+		LangUA: {
+			Subject: "{{requestType .type}} #{{.id}}",
+			Text:    "{{requestType .type}} до помешкання {{.apartment}}{{if .building}}, {{.building}}{{end}}{{if .description}}: {{.description}}{{end}}",
+		},
+	},
+	users.EventFamilyMemberJoined: {
+		LangEN: {
+			Subject: "New family member",
+			Text:    "{{.name}}, {{.member}} ({{.phone}}) has joined your apartment account.",
+		},
+		LangRU: {
+			Subject: "Новый член семьи",
+			Text:    "{{.name}}, {{.member}} ({{.phone}}) присоединился к аккаунту вашей квартиры.",
+		},
+		LangUA: {
+			Subject: "Новий член родини",
+			Text:    "{{.name}}, {{.member}} ({{.phone}}) приєднався до акаунту вашої квартири.",
+		},
+	},
+	users.EventPasswordChanged: {
+		LangEN: {
+			Subject: "Password changed",
+			Text:    "{{.name}}, the password of your account was changed. If it was not you, restore the access with the password recovery.",
+		},
+		LangRU: {
+			Subject: "Пароль изменен",
+			Text:    "{{.name}}, пароль вашей учетной записи был изменен. Если это были не вы, восстановите доступ через восстановление пароля.",
+		},
+		LangUA: {
+			Subject: "Пароль змінено",
+			Text:    "{{.name}}, пароль вашого облікового запису було змінено. Якщо це були не ви, відновіть доступ через відновлення пароля.",
+		},
+	},
+	auth.EventRefreshTokenReused: {
+		LangEN: {
+			Subject: "Suspicious sign-in",
+			Text:    "{{.name}}, an old sign-in token of your account was used again{{if .ip}} from {{.ip}}{{end}}, so the device was signed out. If you did not expect it, change your password.",
+		},
+		LangRU: {
+			Subject: "Подозрительный вход",
+			Text:    "{{.name}}, старый токен входа вашей учетной записи был использован повторно{{if .ip}} с адреса {{.ip}}{{end}}, поэтому устройство было разлогинено. Если вы этого не ожидали, смените пароль.",
+		},
+		LangUA: {
+			Subject: "Підозрілий вхід",
+			Text:    "{{.name}}, старий токен входу вашого облікового запису було використано повторно{{if .ip}} з адреси {{.ip}}{{end}}, тому пристрій було розлогінено. Якщо ви цього не очікували, змініть пароль.",
+		},
+	},
+}
+
+// messageSample is the data every message template is checked with.
+var messageSample = map[string]string{
+	"id":          "1",
+	"name":        "John",
+	"status":      "new",
+	"type":        "guest",
+	"apartment":   "12",
+	"building":    "1",
+	"description": "courier",
+	"member":      "Jane",
+	"phone":       "380501112233",
+	"ip":          "192.0.2.1",
+}
+
+// parsedMessage is the message template parsed for its language.
+type parsedMessage struct {
+	subject *template.Template
+	text    *template.Template
+}
+
+// templates are parsed once and checked with the sample data, so a broken one fails at startup.
+var templates = mustParseMessages()
+
+func mustParseMessages() map[string]map[string]*parsedMessage {
+	res := make(map[string]map[string]*parsedMessage)
+	for name, tpls := range messages {
+		res[name] = make(map[string]*parsedMessage)
+		for _, lang := range []string{LangEN, LangRU, LangUA} {
+			tpl, ok := tpls[lang]
+			if !ok {
+				panic(fmt.Sprintf("message %s/%s: no template", name, lang))
+			}
+
+			p, err := parseMessage(tpl, lang)
+			if err == nil {
+				_, err = p.render(messageSample)
+			}
+			if err != nil {
+				panic(fmt.Sprintf("message %s/%s: %s", name, lang, err))
+			}
+
+			res[name][lang] = p
+		}
+	}
+	return res
+}
+
+func parseMessage(tpl messageTemplate, lang string) (*parsedMessage, error) {
+	funcs := template.FuncMap{"requestType": func(key string) string { return requestTypeName(key, lang) }}
+
+	var (
+		p   parsedMessage
+		err error
+	)
+	if p.subject, err = template.New("subject").Funcs(funcs).Option("missingkey=zero").Parse(tpl.Subject); err != nil {
+		return nil, err
+	}
+
+	if p.text, err = template.New("text").Funcs(funcs).Option("missingkey=zero").Parse(tpl.Text); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// renderMessage renders the message of the event in the language, falling back to the default one.
+func renderMessage(name, lang string, data map[string]string) (*Message, error) {
+	tpls, ok := templates[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown event %q", name)
+	}
+
+	tpl, ok := tpls[lang]
+	if !ok {
+		tpl = tpls[DefaultLang]
+	}
+
+	return tpl.render(data)
+}
+
+func (p *parsedMessage) render(data map[string]string) (*Message, error) {
+	var subject, text bytes.Buffer
+	if err := p.subject.Execute(&subject, data); err != nil {
+		return nil, err
+	}
+
+	if err := p.text.Execute(&text, data); err != nil {
+		return nil, err
+	}
+
+	return &Message{Subject: subject.String(), Text: text.String()}, nil
+}
+
+func requestTypeName(key, lang string) string {
+	for _, t := range requests.GetRequestTypes() {
+		if t["key"] == key {
+			return t[lang]
+		}
+	}
+	return key
+}
@@ -0,0 +1,94 @@
+package repo
+
+import (
+	"github.com/jinzhu/gorm"
+
+	"github.com/ivch/dynasty/server/handlers/notifications"
+	"github.com/ivch/dynasty/server/handlers/outbox"
+	outboxRepo "github.com/ivch/dynasty/server/handlers/outbox/repo"
+	"github.com/ivch/dynasty/server/handlers/users"
+)
+
+type Repo struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Repo {
+	return &Repo{db: db}
+}
+
+func (r *Repo) GetRecipient(userID uint) (*notifications.Recipient, error) {
+	var rcpt notifications.Recipient
+	if err := r.db.Table(users.User{}.TableName()).
+		Select("id, email, phone, first_name, last_name").
+		Where("id = ?", userID).Scan(&rcpt).Error; err != nil {
+		return nil, err
+	}
+	return &rcpt, nil
+}
+
+// GetPreferences returns nil if the user has not set the preferences.
+func (r *Repo) GetPreferences(userID uint) (*notifications.Preferences, error) {
+	var p notifications.Preferences
+	if err := r.db.Where("user_id = ?", userID).First(&p).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *Repo) SavePreferences(p *notifications.Preferences) error {
+	return r.db.Exec(`insert into notification_preferences (user_id, channels, lang) values (?, ?, ?)
+		on conflict (user_id) do update set channels = excluded.channels, lang = excluded.lang`,
+		p.UserID, p.Channels, p.Lang).Error
+}
+
+// ListEndpoints returns the endpoints of the user in the channel, of all the channels if it is empty.
+func (r *Repo) ListEndpoints(userID uint, channel string) ([]*notifications.Endpoint, error) {
+	q := r.db.Where("user_id = ?", userID)
+	if channel != "" {
+		q = q.Where("channel = ?", channel)
+	}
+
+	var res []*notifications.Endpoint
+	if err := q.Order("id").Find(&res).Error; err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (r *Repo) CreateEndpoint(e *notifications.Endpoint) error {
+	return r.db.Create(e).Error
+}
+
+func (r *Repo) DeleteEndpoint(id, userID uint) error {
+	return r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&notifications.Endpoint{}).Error
+}
+
+// DeleteEndpointsByAddress removes the address of all the users, e.g. the expired push subscription.
+func (r *Repo) DeleteEndpointsByAddress(channel, address string) error {
+	return r.db.Where("channel = ? AND address = ?", channel, address).Delete(&notifications.Endpoint{}).Error
+}
+
+// GetGuardIDs returns the ids of the active guards.
+func (r *Repo) GetGuardIDs() ([]uint, error) {
+	var ids []uint
+	if err := r.db.Table(users.User{}.TableName()).
+		Where("role = ? AND active = true", users.GuardUserRole).
+		Order("id").Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *Repo) LogDelivery(d *notifications.Delivery) error {
+	return r.db.Create(d).Error
+}
+
+func (r *Repo) AddMessages(msgs ...*outbox.Message) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return outboxRepo.Add(tx, msgs...)
+	})
+}
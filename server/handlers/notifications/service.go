@@ -0,0 +1,486 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/ivch/dynasty/common/email"
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/common/logger"
+	"github.com/ivch/dynasty/server/handlers/outbox"
+)
+
+const (
+	// DefaultLang is the same as of the email templates.
+	DefaultLang = email.DefaultLang
+
+	// TopicEvent is the outbox topic of the events to be delivered to the user.
+	TopicEvent = "notifications.event"
+	// TopicDelivery is the outbox topic of the rendered message to be sent to a single address.
+	TopicDelivery = "notifications.delivery"
+	// TopicGuardEvent is the outbox topic of the events to be delivered to all the guards.
+	TopicGuardEvent = "notifications.guard_event"
+)
+
+var (
+	// DefaultChannels are used until the user sets the preferences.
+	DefaultChannels = []string{ChannelEmail}
+
+	// guardChannels are where the guard events go regardless of the preferences, the guard console.
+	guardChannels = []string{ChannelWebPush}
+
+	// ErrEndpointGone is returned by the channel when the address no longer exists.
+	ErrEndpointGone = errors.New("endpoint is gone")
+)
+
+type Repository interface {
+	GetRecipient(userID uint) (*Recipient, error)
+	GetPreferences(userID uint) (*Preferences, error)
+	SavePreferences(p *Preferences) error
+	ListEndpoints(userID uint, channel string) ([]*Endpoint, error)
+	CreateEndpoint(e *Endpoint) error
+	DeleteEndpoint(id, userID uint) error
+	DeleteEndpointsByAddress(channel, address string) error
+	GetGuardIDs() ([]uint, error)
+	LogDelivery(d *Delivery) error
+	AddMessages(msgs ...*outbox.Message) error
+}
+
+// Channel delivers the rendered message to the address of the user in the channel.
+type Channel interface {
+	Send(ctx context.Context, to string, msg *Message) error
+}
+
+type Service struct {
+	repo       Repository
+	channels   map[string]Channel
+	webPushKey string
+	log        logger.Logger
+}
+
+// Option configures optional Service parameters.
+type Option func(s *Service)
+
+// WithChannel makes the channel available to the users.
+func WithChannel(name string, ch Channel) Option {
+	return func(s *Service) {
+		s.channels[name] = ch
+	}
+}
+
+// WithWebPushKey sets the VAPID public key the browsers subscribe with.
+func WithWebPushKey(key string) Option {
+	return func(s *Service) {
+		s.webPushKey = key
+	}
+}
+
+func New(log logger.Logger, repo Repository, opts ...Option) *Service {
+	s := Service{
+		repo:     repo,
+		channels: make(map[string]Channel),
+		log:      log,
+	}
+
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	return &s
+}
+
+// Notify stores the event in the outbox, the errors are logged.
+func (s *Service) Notify(_ context.Context, userID uint, name string, data map[string]string) {
+	msg, err := s.EventMessage(userID, name, data)
+	if err == nil {
+		err = s.repo.AddMessages(msg)
+	}
+
+	if err != nil {
+		s.log.Error("failed to queue %s for user %d: %w", name, userID, err)
+	}
+}
+
+// EventMessage returns the outbox message of the event for the user,
+// to be stored in the same transaction as the change it tells about.
+func (s *Service) EventMessage(userID uint, name string, data map[string]string) (*outbox.Message, error) {
+	return outbox.NewMessage(TopicEvent, &eventPayload{UserID: userID, Event: name, Data: data})
+}
+
+// GuardEventMessage returns the outbox message of the event for every guard,
+// to be stored in the same transaction as the change it tells about.
+func (s *Service) GuardEventMessage(name string, data map[string]string) (*outbox.Message, error) {
+	return outbox.NewMessage(TopicGuardEvent, &eventPayload{Event: name, Data: data})
+}
+
+// HandleGuardEvent is the outbox handler of TopicGuardEvent, it queues the event to every guard.
+func (s *Service) HandleGuardEvent(_ context.Context, payload []byte) error {
+	var e eventPayload
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return outbox.Permanent(err)
+	}
+
+	ids, err := s.repo.GetGuardIDs()
+	if err != nil {
+		return err
+	}
+
+	msgs := make([]*outbox.Message, 0, len(ids))
+	for _, id := range ids {
+		m, err := outbox.NewMessage(TopicEvent, &eventPayload{UserID: id, Event: e.Event, Data: e.Data, Channels: guardChannels})
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, m)
+	}
+
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	return s.repo.AddMessages(msgs...)
+}
+
+// HandleEvent is the outbox handler of TopicEvent.
+func (s *Service) HandleEvent(ctx context.Context, payload []byte) error {
+	var e eventPayload
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return outbox.Permanent(err)
+	}
+
+	return s.deliver(ctx, &e)
+}
+
+// Deliver queues a delivery of the event to every address of the user.
+func (s *Service) Deliver(ctx context.Context, userID uint, name string, data map[string]string) error {
+	return s.deliver(ctx, &eventPayload{UserID: userID, Event: name, Data: data})
+}
+
+// deliver queues the deliveries of the event to its channels or the preferred ones.
+func (s *Service) deliver(ctx context.Context, e *eventPayload) error {
+	userID, name, data := e.UserID, e.Event, e.Data
+
+	rcpt, err := s.repo.GetRecipient(userID)
+	if err != nil {
+		return err
+	}
+
+	prefs, err := s.Preferences(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	channels := prefs.Channels
+	if len(e.Channels) > 0 {
+		channels = e.Channels
+	}
+
+	vars := map[string]string{"name": strings.TrimSpace(rcpt.FirstName + " " + rcpt.LastName)}
+	for k, v := range data {
+		vars[k] = v
+	}
+
+	msg, err := renderMessage(name, prefs.Lang, vars)
+	if err != nil {
+		return outbox.Permanent(err)
+	}
+
+	var msgs []*outbox.Message
+	for _, channel := range channels {
+		if _, ok := s.channels[channel]; !ok {
+			s.logDelivery(&Delivery{UserID: userID, Event: name, Channel: channel, Status: DeliverySkipped, Error: "channel is not available"})
+			continue
+		}
+
+		addresses, err := s.addresses(rcpt, channel)
+		if err != nil {
+			return err
+		}
+
+		if len(addresses) == 0 {
+			s.logDelivery(&Delivery{UserID: userID, Event: name, Channel: channel, Status: DeliverySkipped, Error: "no address"})
+			continue
+		}
+
+		for _, to := range addresses {
+			m, err := outbox.NewMessage(TopicDelivery, &deliveryPayload{
+				UserID:  userID,
+				Event:   name,
+				Channel: channel,
+				Address: to,
+				Message: *msg,
+			})
+			if err != nil {
+				return err
+			}
+			msgs = append(msgs, m)
+		}
+	}
+
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	return s.repo.AddMessages(msgs...)
+}
+
+// HandleDelivery is the outbox handler of TopicDelivery. Every attempt is logged.
+func (s *Service) HandleDelivery(ctx context.Context, payload []byte) error {
+	var p deliveryPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return outbox.Permanent(err)
+	}
+
+	d := Delivery{UserID: p.UserID, Event: p.Event, Channel: p.Channel, Address: p.Address, Status: DeliverySent}
+
+	ch, ok := s.channels[p.Channel]
+	if !ok {
+		d.Status, d.Error = DeliverySkipped, "channel is not available"
+		s.logDelivery(&d)
+		return nil
+	}
+
+	if err := ch.Send(ctx, p.Address, &p.Message); err != nil {
+		d.Status, d.Error = DeliveryFailed, err.Error()
+		s.logDelivery(&d)
+
+		if errors.Is(err, ErrEndpointGone) {
+			if err := s.repo.DeleteEndpointsByAddress(p.Channel, p.Address); err != nil {
+				return err
+			}
+			return nil
+		}
+
+		return fmt.Errorf("failed to send via %s: %w", p.Channel, err)
+	}
+
+	s.logDelivery(&d)
+	return nil
+}
+
+// addresses returns where the user gets the messages of the channel.
+func (s *Service) addresses(rcpt *Recipient, channel string) ([]string, error) {
+	switch channel {
+	case ChannelEmail:
+		return nonEmpty(rcpt.Email), nil
+	case ChannelSMS:
+		return nonEmpty(rcpt.Phone), nil
+	}
+
+	endpoints, err := s.repo.ListEndpoints(rcpt.ID, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]string, len(endpoints))
+	for i := range endpoints {
+		res[i] = endpoints[i].Address
+	}
+
+	return res, nil
+}
+
+func (s *Service) logDelivery(d *Delivery) {
+	if d.Status != DeliverySent {
+		s.log.Info("notification %s to user %d via %s %s: %s", d.Event, d.UserID, d.Channel, d.Status, d.Error)
+	}
+
+	if err := s.repo.LogDelivery(d); err != nil {
+		s.log.Error("failed to log delivery: %w", err)
+	}
+}
+
+// Channels returns the names of the channels available to the users.
+func (s *Service) Channels() []string {
+	res := make([]string, 0, len(s.channels))
+	for _, name := range []string{ChannelEmail, ChannelWebPush, ChannelTelegram, ChannelSMS} {
+		if _, ok := s.channels[name]; ok {
+			res = append(res, name)
+		}
+	}
+	return res
+}
+
+// Preferences returns the preferences of the user, the defaults if they are not set.
+func (s *Service) Preferences(_ context.Context, userID uint) (*Preferences, error) {
+	p, err := s.repo.GetPreferences(userID)
+	if err != nil {
+		s.log.Error("error getting notification preferences: %w", err)
+		return nil, err
+	}
+
+	if p == nil {
+		p = &Preferences{UserID: userID, Channels: DefaultChannels, Lang: DefaultLang}
+	}
+
+	return p, nil
+}
+
+func (s *Service) UpdatePreferences(_ context.Context, p *Preferences) error {
+	for _, ch := range p.Channels {
+		if _, ok := s.channels[ch]; !ok {
+			return errs.NotificationChannelUnknown
+		}
+	}
+
+	switch p.Lang {
+	case LangEN, LangRU, LangUA:
+	default:
+		return errs.NotificationLangUnknown
+	}
+
+	return s.repo.SavePreferences(p)
+}
+
+func (s *Service) Endpoints(_ context.Context, userID uint) ([]*Endpoint, error) {
+	return s.repo.ListEndpoints(userID, "")
+}
+
+// AddEndpoint subscribes the user to the channel, except Telegram, see LinkTelegram.
+func (s *Service) AddEndpoint(_ context.Context, e *Endpoint) (*Endpoint, error) {
+	if e.Channel == ChannelEmail || e.Channel == ChannelSMS || e.Channel == ChannelTelegram {
+		return nil, errs.NotificationChannelUnknown
+	}
+
+	return s.addEndpoint(e)
+}
+
+// LinkTelegram subscribes the user to the Telegram chat linked by the bot.
+func (s *Service) LinkTelegram(_ context.Context, userID uint, chatID string) (*Endpoint, error) {
+	return s.addEndpoint(&Endpoint{UserID: userID, Channel: ChannelTelegram, Address: chatID})
+}
+
+func (s *Service) addEndpoint(e *Endpoint) (*Endpoint, error) {
+	if _, ok := s.channels[e.Channel]; !ok {
+		return nil, errs.NotificationChannelUnknown
+	}
+
+	if e.Address == "" {
+		return nil, errs.NotificationAddressEmpty
+	}
+
+	if e.Channel == ChannelWebPush {
+		address, err := normalizePushSubscription(e.Address)
+		if err != nil {
+			return nil, err
+		}
+		e.Address = address
+	}
+
+	// the browser subscribes again with the same address, e.g. on every page load
+	existing, err := s.repo.ListEndpoints(e.UserID, e.Channel)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range existing {
+		if v.Address == e.Address {
+			return v, nil
+		}
+	}
+
+	if err := s.repo.CreateEndpoint(e); err != nil {
+		s.log.Error("error creating notification endpoint: %w", err)
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (s *Service) DeleteEndpoint(_ context.Context, userID, id uint) error {
+	return s.repo.DeleteEndpoint(id, userID)
+}
+
+// WebPushKey returns the VAPID public key, empty if the Web Push is not available.
+func (s *Service) WebPushKey() string {
+	if _, ok := s.channels[ChannelWebPush]; !ok {
+		return ""
+	}
+	return s.webPushKey
+}
+
+// SubscribeWebPush stores the push subscription of the user's browser and enables the channel.
+func (s *Service) SubscribeWebPush(ctx context.Context, userID uint, sub *PushSubscription) (*Endpoint, error) {
+	address, err := json.Marshal(sub)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := s.AddEndpoint(ctx, &Endpoint{UserID: userID, Channel: ChannelWebPush, Address: string(address)})
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := s.Preferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ch := range p.Channels {
+		if ch == ChannelWebPush {
+			return e, nil
+		}
+	}
+
+	p.Channels = append(p.Channels, ChannelWebPush)
+	if err := s.repo.SavePreferences(p); err != nil {
+		s.log.Error("error enabling web push: %w", err)
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// pushServices are the hosts of the browsers' push services, the subdomains included.
+var pushServices = []string{
+	"fcm.googleapis.com",                // Chrome, Edge on Android, Opera
+	"updates.push.services.mozilla.com", // Firefox
+	"push.apple.com",                    // Safari
+	"notify.windows.com",                // Edge on Windows
+}
+
+func isPushService(u *url.URL) bool {
+	if u.User != nil || (u.Port() != "" && u.Port() != "443") {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, h := range pushServices {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizePushSubscription checks the subscription and returns its canonical JSON.
+func normalizePushSubscription(address string) (string, error) {
+	var sub PushSubscription
+	if err := json.Unmarshal([]byte(address), &sub); err != nil {
+		return "", errs.PushSubscriptionInvalid
+	}
+
+	u, err := url.Parse(sub.Endpoint)
+	if err != nil || u.Scheme != "https" || !isPushService(u) || sub.Keys.P256dh == "" || sub.Keys.Auth == "" {
+		return "", errs.PushSubscriptionInvalid
+	}
+
+	b, err := json.Marshal(&sub)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+func nonEmpty(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
@@ -0,0 +1,610 @@
+package notifications_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/common/logger"
+	"github.com/ivch/dynasty/server/handlers/notifications"
+	"github.com/ivch/dynasty/server/handlers/outbox"
+	"github.com/ivch/dynasty/server/handlers/users"
+)
+
+var (
+	defaultLogger *logger.StdLog
+	errTestError  = errors.New("some err")
+)
+
+func TestMain(m *testing.M) {
+	defaultLogger = logger.NewStdLog(logger.WithWriter(io.Discard))
+	os.Exit(m.Run())
+}
+
+func testRecipient(userID uint) (*notifications.Recipient, error) {
+	return &notifications.Recipient{ID: userID, Email: "john@example.com", Phone: "380001112233", FirstName: "John", LastName: "Doe"}, nil
+}
+
+func TestService_Notify(t *testing.T) {
+	var got []*outbox.Message
+	repo := &notifications.RepositoryMock{
+		AddMessagesFunc: func(msgs ...*outbox.Message) error {
+			got = append(got, msgs...)
+			return nil
+		},
+	}
+
+	s := notifications.New(defaultLogger, repo)
+	s.Notify(context.Background(), 1, users.EventFamilyMemberJoined, map[string]string{"member": "Jane Doe"})
+
+	if len(got) != 1 || got[0].Topic != notifications.TopicEvent || got[0].Status != outbox.StatusPending {
+		t.Fatalf("Notify() queued %#v", got)
+	}
+
+	if want := `{"user_id":1,"event":"family_member_joined","data":{"member":"Jane Doe"}}`; got[0].Payload != want {
+		t.Errorf("Notify() payload = %s, want %s", got[0].Payload, want)
+	}
+}
+
+func TestService_GuardEventMessage(t *testing.T) {
+	s := notifications.New(defaultLogger, &notifications.RepositoryMock{})
+	msg, err := s.GuardEventMessage("kpp_request_created", map[string]string{"id": "7"})
+	if err != nil {
+		t.Fatalf("GuardEventMessage() error = %v", err)
+	}
+
+	if msg.Topic != notifications.TopicGuardEvent || msg.Status != outbox.StatusPending {
+		t.Fatalf("GuardEventMessage() = %#v", msg)
+	}
+
+	if want := `{"user_id":0,"event":"kpp_request_created","data":{"id":"7"}}`; msg.Payload != want {
+		t.Errorf("GuardEventMessage() payload = %s, want %s", msg.Payload, want)
+	}
+}
+
+func TestService_HandleEvent(t *testing.T) {
+	type delivery struct {
+		channel, address, status string
+	}
+
+	statusChanged := `{"user_id":1,"event":"request_status_changed","data":{"id":"7","status":"closed"}}`
+
+	tests := []struct {
+		name           string
+		payload        string
+		prefs          *notifications.Preferences
+		endpoints      []*notifications.Endpoint
+		addErr         error
+		wantErr        bool
+		wantPermanent  bool
+		wantQueued     []string
+		wantDeliveries []delivery
+	}{
+		{
+			name:          "bad payload",
+			payload:       `{`,
+			wantErr:       true,
+			wantPermanent: true,
+		},
+		{
+			name:          "unknown event",
+			payload:       `{"user_id":1,"event":"unknown"}`,
+			wantErr:       true,
+			wantPermanent: true,
+		},
+		{
+			name:    "default preferences",
+			payload: statusChanged,
+			wantQueued: []string{
+				`{"user_id":1,"event":"request_status_changed","channel":"email","address":"john@example.com","message":{"subject":"Заявка #7","text":"John Doe, статус вашей заявки #7 изменен на \"closed\"."}}`,
+			},
+		},
+		{
+			name:    "language and channels from preferences",
+			payload: statusChanged,
+			prefs:   &notifications.Preferences{UserID: 1, Channels: []string{notifications.ChannelTelegram, notifications.ChannelSMS}, Lang: notifications.LangEN},
+			endpoints: []*notifications.Endpoint{
+				{ID: 1, UserID: 1, Channel: notifications.ChannelTelegram, Address: "100"},
+				{ID: 2, UserID: 1, Channel: notifications.ChannelTelegram, Address: "200"},
+			},
+			wantQueued: []string{
+				`{"user_id":1,"event":"request_status_changed","channel":"telegram","address":"100","message":{"subject":"Request #7","text":"John Doe, the status of your request #7 was changed to \"closed\"."}}`,
+				`{"user_id":1,"event":"request_status_changed","channel":"telegram","address":"200","message":{"subject":"Request #7","text":"John Doe, the status of your request #7 was changed to \"closed\"."}}`,
+			},
+			wantDeliveries: []delivery{
+				{notifications.ChannelSMS, "", notifications.DeliverySkipped},
+			},
+		},
+		{
+			name:    "no endpoints",
+			payload: statusChanged,
+			prefs:   &notifications.Preferences{UserID: 1, Channels: []string{notifications.ChannelTelegram}, Lang: notifications.LangEN},
+			wantDeliveries: []delivery{
+				{notifications.ChannelTelegram, "", notifications.DeliverySkipped},
+			},
+		},
+		{
+			name:       "error queueing deliveries",
+			payload:    statusChanged,
+			addErr:     errTestError,
+			wantErr:    true,
+			wantQueued: []string{`{"user_id":1,"event":"request_status_changed","channel":"email","address":"john@example.com","message":{"subject":"Заявка #7","text":"John Doe, статус вашей заявки #7 изменен на \"closed\"."}}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				queued     []string
+				deliveries []delivery
+			)
+
+			repo := &notifications.RepositoryMock{
+				GetRecipientFunc: testRecipient,
+				GetPreferencesFunc: func(_ uint) (*notifications.Preferences, error) {
+					return tt.prefs, nil
+				},
+				ListEndpointsFunc: func(_ uint, channel string) ([]*notifications.Endpoint, error) {
+					if channel != notifications.ChannelTelegram {
+						t.Errorf("ListEndpoints() channel = %s", channel)
+					}
+					return tt.endpoints, nil
+				},
+				AddMessagesFunc: func(msgs ...*outbox.Message) error {
+					for _, m := range msgs {
+						if m.Topic != notifications.TopicDelivery {
+							t.Errorf("AddMessages() topic = %s", m.Topic)
+						}
+						queued = append(queued, m.Payload)
+					}
+					return tt.addErr
+				},
+				LogDeliveryFunc: func(d *notifications.Delivery) error {
+					deliveries = append(deliveries, delivery{d.Channel, d.Address, d.Status})
+					return nil
+				},
+			}
+
+			s := notifications.New(defaultLogger, repo,
+				notifications.WithChannel(notifications.ChannelEmail, &notifications.ChannelMock{}),
+				notifications.WithChannel(notifications.ChannelTelegram, &notifications.ChannelMock{}),
+			)
+			err := s.HandleEvent(context.Background(), []byte(tt.payload))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("HandleEvent() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if outbox.IsPermanent(err) != tt.wantPermanent {
+				t.Errorf("HandleEvent() error = %v, wantPermanent %v", err, tt.wantPermanent)
+			}
+			if !reflect.DeepEqual(queued, tt.wantQueued) {
+				t.Errorf("HandleEvent() queued = %v, want %v", queued, tt.wantQueued)
+			}
+			if !reflect.DeepEqual(deliveries, tt.wantDeliveries) {
+				t.Errorf("HandleEvent() deliveries = %#v, want %#v", deliveries, tt.wantDeliveries)
+			}
+		})
+	}
+}
+
+func TestService_HandleDelivery(t *testing.T) {
+	tests := []struct {
+		name       string
+		payload    string
+		sendErr    error
+		wantErr    bool
+		wantSent   *notifications.Message
+		wantStatus string
+		wantPruned bool
+	}{
+		{
+			name:    "bad payload",
+			payload: `[]`,
+			wantErr: true,
+		},
+		{
+			name:       "channel is gone",
+			payload:    `{"user_id":1,"event":"password_changed","channel":"sms","address":"380001112233","message":{"subject":"s","text":"t"}}`,
+			wantStatus: notifications.DeliverySkipped,
+		},
+		{
+			name:       "send error",
+			payload:    `{"user_id":1,"event":"password_changed","channel":"email","address":"john@example.com","message":{"subject":"s","text":"t"}}`,
+			sendErr:    errTestError,
+			wantErr:    true,
+			wantSent:   &notifications.Message{Subject: "s", Text: "t"},
+			wantStatus: notifications.DeliveryFailed,
+		},
+		{
+			name:       "endpoint is gone",
+			payload:    `{"user_id":1,"event":"password_changed","channel":"email","address":"john@example.com","message":{"subject":"s","text":"t"}}`,
+			sendErr:    notifications.ErrEndpointGone,
+			wantSent:   &notifications.Message{Subject: "s", Text: "t"},
+			wantStatus: notifications.DeliveryFailed,
+			wantPruned: true,
+		},
+		{
+			name:       "ok",
+			payload:    `{"user_id":1,"event":"password_changed","channel":"email","address":"john@example.com","message":{"subject":"s","text":"t"}}`,
+			wantSent:   &notifications.Message{Subject: "s", Text: "t"},
+			wantStatus: notifications.DeliverySent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				sent   *notifications.Message
+				status string
+				pruned bool
+			)
+
+			repo := &notifications.RepositoryMock{
+				LogDeliveryFunc: func(d *notifications.Delivery) error {
+					status = d.Status
+					return nil
+				},
+				DeleteEndpointsByAddressFunc: func(channel, address string) error {
+					pruned = channel == notifications.ChannelEmail && address == "john@example.com"
+					return nil
+				},
+			}
+			ch := &notifications.ChannelMock{
+				SendFunc: func(_ context.Context, to string, msg *notifications.Message) error {
+					if to != "john@example.com" {
+						t.Errorf("Send() to = %s", to)
+					}
+					sent = msg
+					return tt.sendErr
+				},
+			}
+
+			s := notifications.New(defaultLogger, repo, notifications.WithChannel(notifications.ChannelEmail, ch))
+			err := s.HandleDelivery(context.Background(), []byte(tt.payload))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("HandleDelivery() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(sent, tt.wantSent) {
+				t.Errorf("HandleDelivery() sent = %#v, want %#v", sent, tt.wantSent)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("HandleDelivery() status = %s, want %s", status, tt.wantStatus)
+			}
+			if pruned != tt.wantPruned {
+				t.Errorf("HandleDelivery() pruned = %v, want %v", pruned, tt.wantPruned)
+			}
+		})
+	}
+}
+
+func TestService_HandleGuardEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		guards   []uint
+		repoErr  error
+		wantErr  bool
+		wantMsgs []string
+	}{
+		{
+			name:    "bad payload",
+			payload: `[]`,
+			wantErr: true,
+		},
+		{
+			name:    "repo error",
+			payload: `{"event":"kpp_request_created"}`,
+			repoErr: errTestError,
+			wantErr: true,
+		},
+		{
+			name:    "no guards",
+			payload: `{"event":"kpp_request_created"}`,
+		},
+		{
+			name:    "ok",
+			payload: `{"event":"kpp_request_created","data":{"id":"5"}}`,
+			guards:  []uint{2, 3},
+			wantMsgs: []string{
+				`{"user_id":2,"event":"kpp_request_created","data":{"id":"5"},"channels":["webpush"]}`,
+				`{"user_id":3,"event":"kpp_request_created","data":{"id":"5"},"channels":["webpush"]}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var msgs []string
+			repo := &notifications.RepositoryMock{
+				GetGuardIDsFunc: func() ([]uint, error) {
+					return tt.guards, tt.repoErr
+				},
+				AddMessagesFunc: func(m ...*outbox.Message) error {
+					for _, msg := range m {
+						if msg.Topic != notifications.TopicEvent {
+							t.Errorf("HandleGuardEvent() topic = %s", msg.Topic)
+						}
+						msgs = append(msgs, msg.Payload)
+					}
+					return nil
+				},
+			}
+
+			s := notifications.New(defaultLogger, repo)
+			err := s.HandleGuardEvent(context.Background(), []byte(tt.payload))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("HandleGuardEvent() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(msgs, tt.wantMsgs) {
+				t.Errorf("HandleGuardEvent() messages = %v, want %v", msgs, tt.wantMsgs)
+			}
+		})
+	}
+}
+
+func TestService_UpdatePreferences(t *testing.T) {
+	tests := []struct {
+		name    string
+		prefs   *notifications.Preferences
+		repoErr error
+		wantErr error
+	}{
+		{
+			name:    "unavailable channel",
+			prefs:   &notifications.Preferences{UserID: 1, Channels: []string{notifications.ChannelSMS}, Lang: notifications.LangEN},
+			wantErr: errs.NotificationChannelUnknown,
+		},
+		{
+			name:    "unknown language",
+			prefs:   &notifications.Preferences{UserID: 1, Channels: []string{notifications.ChannelEmail}, Lang: "de"},
+			wantErr: errs.NotificationLangUnknown,
+		},
+		{
+			name:    "repo error",
+			prefs:   &notifications.Preferences{UserID: 1, Channels: []string{notifications.ChannelEmail}, Lang: notifications.LangEN},
+			repoErr: errTestError,
+			wantErr: errTestError,
+		},
+		{
+			name:  "ok",
+			prefs: &notifications.Preferences{UserID: 1, Channels: []string{}, Lang: notifications.LangRU},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &notifications.RepositoryMock{
+				SavePreferencesFunc: func(_ *notifications.Preferences) error {
+					return tt.repoErr
+				},
+			}
+			s := notifications.New(defaultLogger, repo, notifications.WithChannel(notifications.ChannelEmail, &notifications.ChannelMock{}))
+			if err := s.UpdatePreferences(context.Background(), tt.prefs); err != tt.wantErr {
+				t.Errorf("UpdatePreferences() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestService_AddEndpoint(t *testing.T) {
+	subscription := `{"keys":{"auth":"a","p256dh":"p"},"endpoint":"https://fcm.googleapis.com/fcm/send/1","expirationTime":null}`
+
+	tests := []struct {
+		name        string
+		endpoint    *notifications.Endpoint
+		channels    []string
+		existing    []*notifications.Endpoint
+		repoErr     error
+		wantErr     error
+		wantID      uint
+		wantAddress string
+	}{
+		{
+			name:     "profile channel",
+			endpoint: &notifications.Endpoint{UserID: 1, Channel: notifications.ChannelEmail, Address: "john@example.com"},
+			wantErr:  errs.NotificationChannelUnknown,
+		},
+		{
+			name:     "unavailable channel",
+			endpoint: &notifications.Endpoint{UserID: 1, Channel: notifications.ChannelWebPush, Address: "{}"},
+			wantErr:  errs.NotificationChannelUnknown,
+		},
+		{
+			name:     "telegram is linked by the bot only",
+			endpoint: &notifications.Endpoint{UserID: 1, Channel: notifications.ChannelTelegram, Address: "100"},
+			wantErr:  errs.NotificationChannelUnknown,
+		},
+		{
+			name:     "empty address",
+			endpoint: &notifications.Endpoint{UserID: 1, Channel: notifications.ChannelWebPush},
+			channels: []string{notifications.ChannelWebPush},
+			wantErr:  errs.NotificationAddressEmpty,
+		},
+		{
+			name:     "repo error",
+			endpoint: &notifications.Endpoint{UserID: 1, Channel: notifications.ChannelWebPush, Address: subscription},
+			channels: []string{notifications.ChannelWebPush},
+			repoErr:  errTestError,
+			wantErr:  errTestError,
+		},
+		{
+			name:     "existing",
+			endpoint: &notifications.Endpoint{UserID: 1, Channel: notifications.ChannelWebPush, Address: subscription},
+			channels: []string{notifications.ChannelWebPush},
+			existing: []*notifications.Endpoint{{ID: 7, UserID: 1, Channel: notifications.ChannelWebPush, Address: `{"endpoint":"https://fcm.googleapis.com/fcm/send/1","keys":{"p256dh":"p","auth":"a"}}`}},
+			wantID:   7,
+		},
+		{
+			name:     "bad push subscription",
+			endpoint: &notifications.Endpoint{UserID: 1, Channel: notifications.ChannelWebPush, Address: "{"},
+			channels: []string{notifications.ChannelWebPush},
+			wantErr:  errs.PushSubscriptionInvalid,
+		},
+		{
+			name:     "push subscription without keys",
+			endpoint: &notifications.Endpoint{UserID: 1, Channel: notifications.ChannelWebPush, Address: `{"endpoint":"https://fcm.googleapis.com/fcm/send/1"}`},
+			channels: []string{notifications.ChannelWebPush},
+			wantErr:  errs.PushSubscriptionInvalid,
+		},
+		{
+			name:     "push subscription not https",
+			endpoint: &notifications.Endpoint{UserID: 1, Channel: notifications.ChannelWebPush, Address: `{"endpoint":"http://push.example.com/1","keys":{"auth":"a","p256dh":"p"}}`},
+			channels: []string{notifications.ChannelWebPush},
+			wantErr:  errs.PushSubscriptionInvalid,
+		},
+		{
+			name:     "push subscription of unknown service",
+			endpoint: &notifications.Endpoint{UserID: 1, Channel: notifications.ChannelWebPush, Address: `{"endpoint":"https://push.example.com/1","keys":{"auth":"a","p256dh":"p"}}`},
+			channels: []string{notifications.ChannelWebPush},
+			wantErr:  errs.PushSubscriptionInvalid,
+		},
+		{
+			name:     "push subscription to private address",
+			endpoint: &notifications.Endpoint{UserID: 1, Channel: notifications.ChannelWebPush, Address: `{"endpoint":"https://169.254.169.254/latest","keys":{"auth":"a","p256dh":"p"}}`},
+			channels: []string{notifications.ChannelWebPush},
+			wantErr:  errs.PushSubscriptionInvalid,
+		},
+		{
+			name:     "push subscription to lookalike host",
+			endpoint: &notifications.Endpoint{UserID: 1, Channel: notifications.ChannelWebPush, Address: `{"endpoint":"https://fcm.googleapis.com.example.com/1","keys":{"auth":"a","p256dh":"p"}}`},
+			channels: []string{notifications.ChannelWebPush},
+			wantErr:  errs.PushSubscriptionInvalid,
+		},
+		{
+			name:     "push subscription to other port",
+			endpoint: &notifications.Endpoint{UserID: 1, Channel: notifications.ChannelWebPush, Address: `{"endpoint":"https://fcm.googleapis.com:8443/1","keys":{"auth":"a","p256dh":"p"}}`},
+			channels: []string{notifications.ChannelWebPush},
+			wantErr:  errs.PushSubscriptionInvalid,
+		},
+		{
+			name:        "push subscription of apple",
+			endpoint:    &notifications.Endpoint{UserID: 1, Channel: notifications.ChannelWebPush, Address: `{"endpoint":"https://web.push.apple.com/1","keys":{"auth":"a","p256dh":"p"}}`},
+			channels:    []string{notifications.ChannelWebPush},
+			wantID:      1,
+			wantAddress: `{"endpoint":"https://web.push.apple.com/1","keys":{"p256dh":"p","auth":"a"}}`,
+		},
+		{
+			name:        "push subscription",
+			endpoint:    &notifications.Endpoint{UserID: 1, Channel: notifications.ChannelWebPush, Address: subscription},
+			channels:    []string{notifications.ChannelWebPush},
+			wantID:      1,
+			wantAddress: `{"endpoint":"https://fcm.googleapis.com/fcm/send/1","keys":{"p256dh":"p","auth":"a"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &notifications.RepositoryMock{
+				ListEndpointsFunc: func(_ uint, _ string) ([]*notifications.Endpoint, error) {
+					return tt.existing, nil
+				},
+				CreateEndpointFunc: func(e *notifications.Endpoint) error {
+					e.ID = 1
+					return tt.repoErr
+				},
+			}
+			opts := []notifications.Option{notifications.WithChannel(notifications.ChannelTelegram, &notifications.ChannelMock{})}
+			for _, ch := range tt.channels {
+				opts = append(opts, notifications.WithChannel(ch, &notifications.ChannelMock{}))
+			}
+			s := notifications.New(defaultLogger, repo, opts...)
+			got, err := s.AddEndpoint(context.Background(), tt.endpoint)
+			if err != tt.wantErr {
+				t.Errorf("AddEndpoint() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if got.ID != tt.wantID {
+				t.Errorf("AddEndpoint() got = %#v", got)
+			}
+			if tt.wantAddress != "" && got.Address != tt.wantAddress {
+				t.Errorf("AddEndpoint() address = %s, want %s", got.Address, tt.wantAddress)
+			}
+		})
+	}
+}
+
+func TestService_LinkTelegram(t *testing.T) {
+	repo := &notifications.RepositoryMock{
+		ListEndpointsFunc: func(_ uint, _ string) ([]*notifications.Endpoint, error) {
+			return nil, nil
+		},
+		CreateEndpointFunc: func(e *notifications.Endpoint) error {
+			e.ID = 1
+			return nil
+		},
+	}
+	s := notifications.New(defaultLogger, repo, notifications.WithChannel(notifications.ChannelTelegram, &notifications.ChannelMock{}))
+
+	got, err := s.LinkTelegram(context.Background(), 1, "100")
+	if err != nil {
+		t.Fatalf("LinkTelegram() error = %v", err)
+	}
+
+	want := notifications.Endpoint{ID: 1, UserID: 1, Channel: notifications.ChannelTelegram, Address: "100"}
+	if *got != want {
+		t.Errorf("LinkTelegram() got = %#v, want %#v", got, want)
+	}
+}
+
+func TestService_SubscribeWebPush(t *testing.T) {
+	tests := []struct {
+		name      string
+		prefs     *notifications.Preferences
+		wantSaved []string
+	}{
+		{
+			name:      "default preferences",
+			wantSaved: []string{notifications.ChannelEmail, notifications.ChannelWebPush},
+		},
+		{
+			name:  "already enabled",
+			prefs: &notifications.Preferences{UserID: 1, Channels: []string{notifications.ChannelWebPush}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var saved []string
+			repo := &notifications.RepositoryMock{
+				ListEndpointsFunc: func(_ uint, _ string) ([]*notifications.Endpoint, error) {
+					return nil, nil
+				},
+				CreateEndpointFunc: func(e *notifications.Endpoint) error {
+					e.ID = 1
+					return nil
+				},
+				GetPreferencesFunc: func(_ uint) (*notifications.Preferences, error) {
+					return tt.prefs, nil
+				},
+				SavePreferencesFunc: func(p *notifications.Preferences) error {
+					saved = p.Channels
+					return nil
+				},
+			}
+
+			s := notifications.New(defaultLogger, repo, notifications.WithChannel(notifications.ChannelWebPush, &notifications.ChannelMock{}))
+			got, err := s.SubscribeWebPush(context.Background(), 1, &notifications.PushSubscription{
+				Endpoint: "https://fcm.googleapis.com/fcm/send/1",
+				Keys:     notifications.PushSubscriptionKeys{P256dh: "p", Auth: "a"},
+			})
+			if err != nil {
+				t.Fatalf("SubscribeWebPush() error = %v", err)
+			}
+			if got.ID != 1 || got.Channel != notifications.ChannelWebPush {
+				t.Errorf("SubscribeWebPush() got = %#v", got)
+			}
+			if !reflect.DeepEqual(saved, tt.wantSaved) {
+				t.Errorf("SubscribeWebPush() saved = %v, want %v", saved, tt.wantSaved)
+			}
+		})
+	}
+}
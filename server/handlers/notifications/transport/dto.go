@@ -0,0 +1,38 @@
+package transport
+
+type errorResponse struct {
+	Error     string `json:"error"`
+	ErrorCode uint   `json:"error_code"`
+	Ru        string `json:"ru"`
+	Ua        string `json:"ua"`
+}
+
+type preferencesRequest struct {
+	Channels []string `json:"channels"`
+	Lang     string   `json:"lang"`
+}
+
+type preferencesResponse struct {
+	Channels  []string `json:"channels"`
+	Lang      string   `json:"lang"`
+	Available []string `json:"available"`
+}
+
+type endpointRequest struct {
+	Channel string `json:"channel"`
+	Address string `json:"address"`
+}
+
+type endpointResponse struct {
+	ID      uint   `json:"id"`
+	Channel string `json:"channel"`
+	Address string `json:"address"`
+}
+
+type endpointsResponse struct {
+	Data []*endpointResponse `json:"data"`
+}
+
+type webPushKeyResponse struct {
+	PublicKey string `json:"public_key"`
+}
@@ -0,0 +1,282 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/common/logger"
+	"github.com/ivch/dynasty/server/handlers/notifications"
+	"github.com/ivch/dynasty/server/middlewares"
+)
+
+type NotificationsService interface {
+	Channels() []string
+	Preferences(ctx context.Context, userID uint) (*notifications.Preferences, error)
+	UpdatePreferences(ctx context.Context, p *notifications.Preferences) error
+	Endpoints(ctx context.Context, userID uint) ([]*notifications.Endpoint, error)
+	AddEndpoint(ctx context.Context, e *notifications.Endpoint) (*notifications.Endpoint, error)
+	DeleteEndpoint(ctx context.Context, userID, id uint) error
+	WebPushKey() string
+	SubscribeWebPush(ctx context.Context, userID uint, sub *notifications.PushSubscription) (*notifications.Endpoint, error)
+}
+
+type HTTPTransport struct {
+	svc    NotificationsService
+	log    logger.Logger
+	router chi.Router
+}
+
+func (h *HTTPTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.router.ServeHTTP(w, r)
+}
+
+// NewHTTPTransport returns a new instance of HTTPTransport.
+func NewHTTPTransport(log logger.Logger, svc NotificationsService, mdl ...func(http.Handler) http.Handler) http.Handler {
+	h := &HTTPTransport{log: log, router: chi.NewRouter().With(mdl...), svc: svc}
+	h.attachRoutes()
+	return h
+}
+
+func (h *HTTPTransport) attachRoutes() {
+	h.router.Get("/v1/preferences", h.Preferences)
+	h.router.Put("/v1/preferences", h.UpdatePreferences)
+	h.router.Get("/v1/endpoints", h.Endpoints)
+	h.router.Post("/v1/endpoints", h.AddEndpoint)
+	h.router.Delete("/v1/endpoints/{id}", h.DeleteEndpoint)
+	h.router.Get("/v1/webpush/key", h.WebPushKey)
+	h.router.Post("/v1/webpush/subscriptions", h.SubscribeWebPush)
+}
+
+func (h *HTTPTransport) Preferences(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
+		return
+	}
+
+	p, err := h.svc.Preferences(r.Context(), userID)
+	if err != nil {
+		h.sendError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.sendHTTPResponse(r.Context(), w, h.preferencesResponse(p))
+}
+
+func (h *HTTPTransport) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
+		return
+	}
+
+	var req preferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, http.StatusBadRequest, errs.BadRequest)
+		return
+	}
+
+	p := notifications.Preferences{
+		UserID:   userID,
+		Channels: req.Channels,
+		Lang:     req.Lang,
+	}
+
+	if err := h.svc.UpdatePreferences(r.Context(), &p); err != nil {
+		if err == errs.NotificationChannelUnknown || err == errs.NotificationLangUnknown {
+			h.sendError(w, http.StatusBadRequest, err)
+			return
+		}
+		h.sendError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.sendHTTPResponse(r.Context(), w, h.preferencesResponse(&p))
+}
+
+func (h *HTTPTransport) Endpoints(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
+		return
+	}
+
+	res, err := h.svc.Endpoints(r.Context(), userID)
+	if err != nil {
+		h.sendError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	result := make([]*endpointResponse, len(res))
+	for i := range res {
+		result[i] = &endpointResponse{
+			ID:      res[i].ID,
+			Channel: res[i].Channel,
+			Address: res[i].Address,
+		}
+	}
+
+	h.sendHTTPResponse(r.Context(), w, endpointsResponse{Data: result})
+}
+
+func (h *HTTPTransport) AddEndpoint(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
+		return
+	}
+
+	var req endpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, http.StatusBadRequest, errs.BadRequest)
+		return
+	}
+
+	e, err := h.svc.AddEndpoint(r.Context(), &notifications.Endpoint{
+		UserID:  userID,
+		Channel: req.Channel,
+		Address: req.Address,
+	})
+	if err != nil {
+		if err == errs.NotificationChannelUnknown || err == errs.NotificationAddressEmpty || err == errs.PushSubscriptionInvalid {
+			h.sendError(w, http.StatusBadRequest, err)
+			return
+		}
+		h.sendError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.sendHTTPResponse(r.Context(), w, endpointResponse{ID: e.ID, Channel: e.Channel, Address: e.Address})
+}
+
+func (h *HTTPTransport) DeleteEndpoint(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id == 0 {
+		h.sendError(w, http.StatusBadRequest, errs.BadRequest)
+		return
+	}
+
+	if err := h.svc.DeleteEndpoint(r.Context(), userID, uint(id)); err != nil {
+		h.sendError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.sendHTTPResponse(r.Context(), w, nil)
+}
+
+// WebPushKey returns the VAPID public key the browser subscribes with.
+func (h *HTTPTransport) WebPushKey(w http.ResponseWriter, r *http.Request) {
+	key := h.svc.WebPushKey()
+	if key == "" {
+		h.sendError(w, http.StatusNotFound, errs.NotificationChannelUnknown)
+		return
+	}
+
+	h.sendHTTPResponse(r.Context(), w, webPushKeyResponse{PublicKey: key})
+}
+
+// SubscribeWebPush stores the browser's PushSubscription as it is serialized by PushSubscription.toJSON().
+func (h *HTTPTransport) SubscribeWebPush(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
+		return
+	}
+
+	var req notifications.PushSubscription
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, http.StatusBadRequest, errs.BadRequest)
+		return
+	}
+
+	e, err := h.svc.SubscribeWebPush(r.Context(), userID, &req)
+	if err != nil {
+		if err == errs.NotificationChannelUnknown || err == errs.PushSubscriptionInvalid {
+			h.sendError(w, http.StatusBadRequest, err)
+			return
+		}
+		h.sendError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.sendHTTPResponse(r.Context(), w, endpointResponse{ID: e.ID, Channel: e.Channel, Address: e.Address})
+}
+
+func (h *HTTPTransport) preferencesResponse(p *notifications.Preferences) preferencesResponse {
+	channels := p.Channels
+	if channels == nil {
+		channels = []string{}
+	}
+
+	return preferencesResponse{
+		Channels:  channels,
+		Lang:      p.Lang,
+		Available: h.svc.Channels(),
+	}
+}
+
+func (h *HTTPTransport) sendHTTPResponse(_ context.Context, w http.ResponseWriter, response interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.log.Debug("failed to send response error: %w", err)
+	}
+}
+
+func (h *HTTPTransport) sendError(w http.ResponseWriter, httpCode int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpCode)
+
+	if err == nil {
+		err = errs.Generic
+	}
+
+	var (
+		ru string
+		ua string
+	)
+
+	if e, ok := err.(errs.SvcError); ok {
+		ru, ua = e.Ru, e.Ua
+	}
+
+	res := errorResponse{
+		ErrorCode: errs.Code(err),
+		Error:     err.Error(),
+		Ru:        ru,
+		Ua:        ua,
+	}
+
+	if err := json.NewEncoder(w).Encode(&res); err != nil {
+		h.log.Debug("failed to send response error: %w", err)
+	}
+}
+
+func getUserID(ctx context.Context) (uint, error) {
+	idStr, ok := middlewares.UserIDFromContext(ctx)
+	if !ok {
+		return 0, errs.EmptyUserID
+	}
+
+	userID, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return 0, errs.BadUserID
+	}
+
+	if userID == 0 {
+		return 0, errs.BadUserID
+	}
+
+	return uint(userID), nil
+}
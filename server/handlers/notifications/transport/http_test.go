@@ -0,0 +1,430 @@
+package transport_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/common/logger"
+	"github.com/ivch/dynasty/server/handlers/notifications"
+	"github.com/ivch/dynasty/server/handlers/notifications/transport"
+	"github.com/ivch/dynasty/server/middlewares"
+)
+
+var (
+	defaultLogger *logger.StdLog
+	errTestError  = errors.New("some err")
+)
+
+func TestMain(m *testing.M) {
+	defaultLogger = logger.NewStdLog(logger.WithWriter(io.Discard))
+	os.Exit(m.Run())
+}
+
+func channels() []string {
+	return []string{notifications.ChannelEmail, notifications.ChannelTelegram}
+}
+
+func TestHTTP_Preferences(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		svc      transport.NotificationsService
+		wantErr  bool
+		want     string
+		wantCode int
+	}{
+		{
+			name:     "error no user",
+			wantErr:  true,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "error service error",
+			header: "1",
+			svc: &transport.NotificationsServiceMock{
+				PreferencesFunc: func(_ context.Context, _ uint) (*notifications.Preferences, error) {
+					return nil, errTestError
+				},
+			},
+			wantErr:  true,
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "ok",
+			header: "1",
+			svc: &transport.NotificationsServiceMock{
+				ChannelsFunc: channels,
+				PreferencesFunc: func(_ context.Context, id uint) (*notifications.Preferences, error) {
+					return &notifications.Preferences{UserID: id, Channels: []string{notifications.ChannelEmail}, Lang: notifications.LangUA}, nil
+				},
+			},
+			want:     `{"channels":["email"],"lang":"ua","available":["email","telegram"]}`,
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := transport.NewHTTPTransport(defaultLogger, tt.svc, middlewares.NewIDCtx(defaultLogger).Middleware)
+			rr := httptest.NewRecorder()
+			rq, _ := http.NewRequest(http.MethodGet, "/v1/preferences", nil)
+			rq.Header.Add("X-Auth-User", tt.header)
+			h.ServeHTTP(rr, rq)
+			if rr.Code != tt.wantCode {
+				t.Errorf("Request error. status = %d, wantCode = %d", rr.Code, tt.wantCode)
+			}
+
+			if !tt.wantErr && tt.want != strings.TrimSpace(rr.Body.String()) {
+				t.Errorf("Response error, got = %s, want = %s", rr.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTP_UpdatePreferences(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		body     string
+		svc      transport.NotificationsService
+		wantErr  bool
+		want     string
+		wantCode int
+	}{
+		{
+			name:     "error no user",
+			body:     `{}`,
+			wantErr:  true,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "error bad body",
+			header:   "1",
+			body:     `{`,
+			wantErr:  true,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "error unknown channel",
+			header: "1",
+			body:   `{"channels":["pigeon"],"lang":"en"}`,
+			svc: &transport.NotificationsServiceMock{
+				UpdatePreferencesFunc: func(_ context.Context, _ *notifications.Preferences) error {
+					return errs.NotificationChannelUnknown
+				},
+			},
+			wantErr:  true,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "error service error",
+			header: "1",
+			body:   `{"channels":["email"],"lang":"en"}`,
+			svc: &transport.NotificationsServiceMock{
+				UpdatePreferencesFunc: func(_ context.Context, _ *notifications.Preferences) error {
+					return errTestError
+				},
+			},
+			wantErr:  true,
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "ok",
+			header: "1",
+			body:   `{"channels":["email","telegram"],"lang":"en"}`,
+			svc: &transport.NotificationsServiceMock{
+				ChannelsFunc: channels,
+				UpdatePreferencesFunc: func(_ context.Context, p *notifications.Preferences) error {
+					if p.UserID != 1 {
+						return errTestError
+					}
+					return nil
+				},
+			},
+			want:     `{"channels":["email","telegram"],"lang":"en","available":["email","telegram"]}`,
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := transport.NewHTTPTransport(defaultLogger, tt.svc, middlewares.NewIDCtx(defaultLogger).Middleware)
+			rr := httptest.NewRecorder()
+			rq, _ := http.NewRequest(http.MethodPut, "/v1/preferences", strings.NewReader(tt.body))
+			rq.Header.Add("X-Auth-User", tt.header)
+			h.ServeHTTP(rr, rq)
+			if rr.Code != tt.wantCode {
+				t.Errorf("Request error. status = %d, wantCode = %d", rr.Code, tt.wantCode)
+			}
+
+			if !tt.wantErr && tt.want != strings.TrimSpace(rr.Body.String()) {
+				t.Errorf("Response error, got = %s, want = %s", rr.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTP_Endpoints(t *testing.T) {
+	svc := &transport.NotificationsServiceMock{
+		EndpointsFunc: func(_ context.Context, id uint) ([]*notifications.Endpoint, error) {
+			return []*notifications.Endpoint{{ID: 3, UserID: id, Channel: notifications.ChannelTelegram, Address: "100"}}, nil
+		},
+	}
+
+	h := transport.NewHTTPTransport(defaultLogger, svc, middlewares.NewIDCtx(defaultLogger).Middleware)
+	rr := httptest.NewRecorder()
+	rq, _ := http.NewRequest(http.MethodGet, "/v1/endpoints", nil)
+	rq.Header.Add("X-Auth-User", "1")
+	h.ServeHTTP(rr, rq)
+
+	if want := `{"data":[{"id":3,"channel":"telegram","address":"100"}]}`; strings.TrimSpace(rr.Body.String()) != want {
+		t.Errorf("Response error, got = %s, want = %s", rr.Body.String(), want)
+	}
+}
+
+func TestHTTP_AddEndpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		body     string
+		svc      transport.NotificationsService
+		wantErr  bool
+		want     string
+		wantCode int
+	}{
+		{
+			name:     "error no user",
+			body:     `{}`,
+			wantErr:  true,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "error bad body",
+			header:   "1",
+			body:     `[`,
+			wantErr:  true,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "error empty address",
+			header: "1",
+			body:   `{"channel":"telegram"}`,
+			svc: &transport.NotificationsServiceMock{
+				AddEndpointFunc: func(_ context.Context, _ *notifications.Endpoint) (*notifications.Endpoint, error) {
+					return nil, errs.NotificationAddressEmpty
+				},
+			},
+			wantErr:  true,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "ok",
+			header: "1",
+			body:   `{"channel":"telegram","address":"100"}`,
+			svc: &transport.NotificationsServiceMock{
+				AddEndpointFunc: func(_ context.Context, e *notifications.Endpoint) (*notifications.Endpoint, error) {
+					e.ID = 3
+					return e, nil
+				},
+			},
+			want:     `{"id":3,"channel":"telegram","address":"100"}`,
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := transport.NewHTTPTransport(defaultLogger, tt.svc, middlewares.NewIDCtx(defaultLogger).Middleware)
+			rr := httptest.NewRecorder()
+			rq, _ := http.NewRequest(http.MethodPost, "/v1/endpoints", strings.NewReader(tt.body))
+			rq.Header.Add("X-Auth-User", tt.header)
+			h.ServeHTTP(rr, rq)
+			if rr.Code != tt.wantCode {
+				t.Errorf("Request error. status = %d, wantCode = %d", rr.Code, tt.wantCode)
+			}
+
+			if !tt.wantErr && tt.want != strings.TrimSpace(rr.Body.String()) {
+				t.Errorf("Response error, got = %s, want = %s", rr.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTP_DeleteEndpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		svc      transport.NotificationsService
+		wantCode int
+	}{
+		{
+			name:     "error bad id",
+			id:       "abc",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "error service error",
+			id:   "3",
+			svc: &transport.NotificationsServiceMock{
+				DeleteEndpointFunc: func(_ context.Context, _, _ uint) error {
+					return errTestError
+				},
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name: "ok",
+			id:   "3",
+			svc: &transport.NotificationsServiceMock{
+				DeleteEndpointFunc: func(_ context.Context, userID, id uint) error {
+					if userID != 1 || id != 3 {
+						return errTestError
+					}
+					return nil
+				},
+			},
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := transport.NewHTTPTransport(defaultLogger, tt.svc, middlewares.NewIDCtx(defaultLogger).Middleware)
+			rr := httptest.NewRecorder()
+			rq, _ := http.NewRequest(http.MethodDelete, "/v1/endpoints/"+tt.id, nil)
+			rq.Header.Add("X-Auth-User", "1")
+			h.ServeHTTP(rr, rq)
+			if rr.Code != tt.wantCode {
+				t.Errorf("Request error. status = %d, wantCode = %d", rr.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestHTTP_WebPushKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		want     string
+		wantCode int
+	}{
+		{
+			name:     "disabled",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "ok",
+			key:      "BPub",
+			want:     `{"public_key":"BPub"}`,
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &transport.NotificationsServiceMock{
+				WebPushKeyFunc: func() string { return tt.key },
+			}
+			h := transport.NewHTTPTransport(defaultLogger, svc, middlewares.NewIDCtx(defaultLogger).Middleware)
+			rr := httptest.NewRecorder()
+			rq, _ := http.NewRequest(http.MethodGet, "/v1/webpush/key", nil)
+			h.ServeHTTP(rr, rq)
+			if rr.Code != tt.wantCode {
+				t.Errorf("Request error. status = %d, wantCode = %d", rr.Code, tt.wantCode)
+			}
+
+			if tt.want != "" && tt.want != strings.TrimSpace(rr.Body.String()) {
+				t.Errorf("Response error, got = %s, want = %s", rr.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTP_SubscribeWebPush(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		body     string
+		svc      transport.NotificationsService
+		wantErr  bool
+		want     string
+		wantCode int
+	}{
+		{
+			name:     "error no user",
+			body:     `{}`,
+			wantErr:  true,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "error bad body",
+			header:   "1",
+			body:     `[`,
+			wantErr:  true,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "error invalid subscription",
+			header: "1",
+			body:   `{"endpoint":"http://push.example.com/1"}`,
+			svc: &transport.NotificationsServiceMock{
+				SubscribeWebPushFunc: func(_ context.Context, _ uint, _ *notifications.PushSubscription) (*notifications.Endpoint, error) {
+					return nil, errs.PushSubscriptionInvalid
+				},
+			},
+			wantErr:  true,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "error service",
+			header: "1",
+			body:   `{"endpoint":"https://push.example.com/1","keys":{"p256dh":"p","auth":"a"}}`,
+			svc: &transport.NotificationsServiceMock{
+				SubscribeWebPushFunc: func(_ context.Context, _ uint, _ *notifications.PushSubscription) (*notifications.Endpoint, error) {
+					return nil, errTestError
+				},
+			},
+			wantErr:  true,
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "ok",
+			header: "1",
+			body:   `{"endpoint":"https://push.example.com/1","expirationTime":null,"keys":{"p256dh":"p","auth":"a"}}`,
+			svc: &transport.NotificationsServiceMock{
+				SubscribeWebPushFunc: func(_ context.Context, userID uint, sub *notifications.PushSubscription) (*notifications.Endpoint, error) {
+					if userID != 1 || sub.Endpoint != "https://push.example.com/1" || sub.Keys.P256dh != "p" || sub.Keys.Auth != "a" {
+						return nil, errTestError
+					}
+					return &notifications.Endpoint{ID: 4, Channel: notifications.ChannelWebPush, Address: "{}"}, nil
+				},
+			},
+			want:     `{"id":4,"channel":"webpush","address":"{}"}`,
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := transport.NewHTTPTransport(defaultLogger, tt.svc, middlewares.NewIDCtx(defaultLogger).Middleware)
+			rr := httptest.NewRecorder()
+			rq, _ := http.NewRequest(http.MethodPost, "/v1/webpush/subscriptions", strings.NewReader(tt.body))
+			rq.Header.Add("X-Auth-User", tt.header)
+			h.ServeHTTP(rr, rq)
+			if rr.Code != tt.wantCode {
+				t.Errorf("Request error. status = %d, wantCode = %d", rr.Code, tt.wantCode)
+			}
+
+			if !tt.wantErr && tt.want != strings.TrimSpace(rr.Body.String()) {
+				t.Errorf("Response error, got = %s, want = %s", rr.Body.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ivch/dynasty/common/queue"
+)
+
+const (
+	StatusPending = queue.StatusPending
+	StatusRunning = queue.StatusRunning
+	StatusSent    = "sent"
+	StatusDead    = "dead"
+)
+
+// Message is the side effect of a domain change, delivered after it is committed.
+type Message struct {
+	ID          uint       `json:"id"`
+	Topic       string     `json:"topic"`
+	Payload     string     `json:"-"` // may hold personal data, never listed
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	LastError   string     `json:"last_error"`
+	RunAt       time.Time  `json:"run_at"`
+	LockedUntil *time.Time `json:"-"`
+	CreatedAt   *time.Time `json:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at"`
+}
+
+func (Message) TableName() string { return "outbox" }
+
+// NewMessage returns the pending message of the topic with the payload encoded to JSON.
+func NewMessage(topic string, payload interface{}) (*Message, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		Topic:   topic,
+		Payload: string(data),
+		Status:  StatusPending,
+		RunAt:   time.Now(),
+	}, nil
+}
+
+type MessageFilter struct {
+	Status string
+	Topic  string
+	Offset uint
+	Limit  uint
+}
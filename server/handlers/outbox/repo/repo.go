@@ -0,0 +1,96 @@
+package repo
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/ivch/dynasty/common/queue"
+	"github.com/ivch/dynasty/server/handlers/outbox"
+)
+
+type Repo struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Repo {
+	return &Repo{db: db}
+}
+
+// Add stores the messages with db, which is the transaction of the domain change they come from.
+func Add(db *gorm.DB, msgs ...*outbox.Message) error {
+	for _, m := range msgs {
+		if err := db.Create(m).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repo) ClaimMessage(now time.Time, lease time.Duration) (*outbox.Message, error) {
+	var msg outbox.Message
+	ok, err := queue.Claim(r.db, &msg, now, lease)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func (r *Repo) CompleteMessage(id uint) error {
+	_, err := queue.Release(r.db, &outbox.Message{}, id, map[string]interface{}{
+		"status": outbox.StatusSent,
+	})
+	return err
+}
+
+func (r *Repo) RetryMessage(id uint, runAt time.Time, lastErr string) error {
+	return queue.Retry(r.db, &outbox.Message{}, id, runAt, lastErr)
+}
+
+func (r *Repo) BuryMessage(id uint, lastErr string) error {
+	_, err := queue.Release(r.db, &outbox.Message{}, id, map[string]interface{}{
+		"status":     outbox.StatusDead,
+		"last_error": lastErr,
+	})
+	return err
+}
+
+func (r *Repo) ListMessages(f *outbox.MessageFilter) ([]*outbox.Message, int, error) {
+	q := r.db.Model(&outbox.Message{}).Where("status = ?", f.Status)
+	if f.Topic != "" {
+		q = q.Where("topic = ?", f.Topic)
+	}
+
+	var cnt int
+	if err := q.Count(&cnt).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var res []*outbox.Message
+	if err := q.Order("id desc").Offset(f.Offset).Limit(f.Limit).Find(&res).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return res, cnt, nil
+}
+
+// ReplayMessage makes the dead message pending again. It reports false if there is no such dead message.
+func (r *Repo) ReplayMessage(id uint, runAt time.Time) (bool, error) {
+	res := r.db.Model(&outbox.Message{}).
+		Where("id = ? AND status = ?", id, outbox.StatusDead).
+		Updates(map[string]interface{}{
+			"status":     outbox.StatusPending,
+			"attempts":   0,
+			"run_at":     runAt,
+			"updated_at": time.Now(),
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (r *Repo) PruneMessages(before time.Time) error {
+	return r.db.Where("status = ? AND updated_at < ?", outbox.StatusSent, before).
+		Delete(&outbox.Message{}).Error
+}
@@ -0,0 +1,228 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/common/logger"
+	"github.com/ivch/dynasty/common/queue"
+)
+
+const (
+	defaultMaxAttempts = 8
+	defaultBackoff     = 10 * time.Second
+	maxBackoff         = time.Hour
+	messageLease       = 2 * time.Minute
+	defaultRetention   = 7 * 24 * time.Hour
+
+	defaultListLimit = 50
+)
+
+// Handler delivers the payload, the failed message is retried unless the error is Permanent.
+type Handler func(ctx context.Context, payload []byte) error
+
+type Repository interface {
+	ClaimMessage(now time.Time, lease time.Duration) (*Message, error)
+	CompleteMessage(id uint) error
+	RetryMessage(id uint, runAt time.Time, lastErr string) error
+	BuryMessage(id uint, lastErr string) error
+	ListMessages(f *MessageFilter) ([]*Message, int, error)
+	ReplayMessage(id uint, runAt time.Time) (bool, error)
+	// PruneMessages deletes the messages sent before the time.
+	PruneMessages(before time.Time) error
+}
+
+type UserService interface {
+	IsAdmin(ctx context.Context, userID uint) (bool, error)
+}
+
+type Service struct {
+	repo        Repository
+	uSrv        UserService
+	handlers    map[string]Handler
+	maxAttempts int
+	backoff     time.Duration
+	retention   time.Duration
+	log         logger.Logger
+}
+
+// Option configures optional Service parameters.
+type Option func(s *Service)
+
+// WithHandler delivers the messages of the topic with h.
+func WithHandler(topic string, h Handler) Option {
+	return func(s *Service) {
+		s.handlers[topic] = h
+	}
+}
+
+// WithRetries sets the max attempts of the message and the initial delay between them.
+func WithRetries(attempts int, backoff time.Duration) Option {
+	return func(s *Service) {
+		if attempts > 0 {
+			s.maxAttempts = attempts
+		}
+		if backoff > 0 {
+			s.backoff = backoff
+		}
+	}
+}
+
+// WithRetention sets how long the sent messages are kept.
+func WithRetention(d time.Duration) Option {
+	return func(s *Service) {
+		if d > 0 {
+			s.retention = d
+		}
+	}
+}
+
+func New(log logger.Logger, repo Repository, uSrv UserService, opts ...Option) *Service {
+	s := Service{
+		repo:        repo,
+		uSrv:        uSrv,
+		handlers:    make(map[string]Handler),
+		maxAttempts: defaultMaxAttempts,
+		backoff:     defaultBackoff,
+		retention:   defaultRetention,
+		log:         log,
+	}
+
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	return &s
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks the error the message can not be delivered with, so it is dead without retries.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var perr *permanentError
+	return errors.As(err, &perr)
+}
+
+// Run dispatches the due messages until ctx is done, see queue.Work.
+func (s *Service) Run(ctx context.Context, pollInterval time.Duration) {
+	queue.Work(ctx, pollInterval, nil, s.Dispatch, func(err error) {
+		s.log.Error("error dispatching outbox message: %w", err)
+	})
+}
+
+// RunPruning deletes the sent messages older than the retention every interval until ctx is done.
+func (s *Service) RunPruning(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.repo.PruneMessages(time.Now().Add(-s.retention)); err != nil {
+				s.log.Error("error pruning outbox messages: %w", err)
+			}
+		}
+	}
+}
+
+// Dispatch claims the next due message and delivers it. It reports false when there is nothing to deliver.
+func (s *Service) Dispatch(ctx context.Context) (bool, error) {
+	msg, err := s.repo.ClaimMessage(time.Now(), messageLease)
+	if err != nil {
+		return false, err
+	}
+
+	if msg == nil {
+		return false, nil
+	}
+
+	h, ok := s.handlers[msg.Topic]
+	if !ok {
+		return true, s.fail(msg, Permanent(fmt.Errorf("no handler for %q", msg.Topic)))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, messageLease)
+	defer cancel()
+
+	if err := h(ctx, []byte(msg.Payload)); err != nil {
+		return true, s.fail(msg, err)
+	}
+
+	return true, s.repo.CompleteMessage(msg.ID)
+}
+
+// fail schedules the retry of the message or buries it.
+func (s *Service) fail(msg *Message, msgErr error) error {
+	if !IsPermanent(msgErr) && msg.Attempts < s.maxAttempts {
+		backoff := queue.Backoff(s.backoff, maxBackoff, msg.Attempts)
+		s.log.Warn("outbox message %d (%s) failed, retrying in %s: %w", msg.ID, msg.Topic, backoff, msgErr)
+		return s.repo.RetryMessage(msg.ID, time.Now().Add(backoff), msgErr.Error())
+	}
+
+	s.log.Error("outbox message %d (%s) is dead: %w", msg.ID, msg.Topic, msgErr)
+	return s.repo.BuryMessage(msg.ID, msgErr.Error())
+}
+
+// Messages lists the messages for the admin, the dead ones unless the filter says otherwise.
+func (s *Service) Messages(ctx context.Context, adminID uint, f *MessageFilter) ([]*Message, int, error) {
+	if err := s.checkAdmin(ctx, adminID); err != nil {
+		return nil, 0, err
+	}
+
+	if f.Status == "" {
+		f.Status = StatusDead
+	}
+
+	if f.Limit == 0 {
+		f.Limit = defaultListLimit
+	}
+
+	return s.repo.ListMessages(f)
+}
+
+// Replay schedules the dead message to be delivered again with a fresh set of attempts.
+func (s *Service) Replay(ctx context.Context, adminID, id uint) error {
+	if err := s.checkAdmin(ctx, adminID); err != nil {
+		return err
+	}
+
+	ok, err := s.repo.ReplayMessage(id, time.Now())
+	if err != nil {
+		s.log.Error("error replaying outbox message: %w", err)
+		return err
+	}
+
+	if !ok {
+		return errs.OutboxMessageNotDead
+	}
+
+	return nil
+}
+
+func (s *Service) checkAdmin(ctx context.Context, userID uint) error {
+	ok, err := s.uSrv.IsAdmin(ctx, userID)
+	if err != nil {
+		s.log.Error("error checking admin user: %w", err)
+		return errs.UserNotFound
+	}
+
+	if !ok {
+		return errs.InsufficientPermissions
+	}
+
+	return nil
+}
@@ -0,0 +1,267 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/common/logger"
+	"github.com/ivch/dynasty/server/handlers/outbox"
+)
+
+var (
+	defaultLogger *logger.StdLog
+	errTestError  = errors.New("some err")
+)
+
+func TestMain(m *testing.M) {
+	defaultLogger = logger.NewStdLog(logger.WithWriter(io.Discard))
+	os.Exit(m.Run())
+}
+
+func TestService_Dispatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		msg        *outbox.Message
+		claimErr   error
+		handlerErr error
+		wantOK     bool
+		wantErr    bool
+		wantStatus string
+		wantDelay  time.Duration
+	}{
+		{
+			name:     "error claiming",
+			claimErr: errTestError,
+			wantErr:  true,
+		},
+		{
+			name: "empty queue",
+		},
+		{
+			name:       "no handler",
+			msg:        &outbox.Message{ID: 1, Topic: "unknown", Attempts: 1},
+			wantOK:     true,
+			wantStatus: outbox.StatusDead,
+		},
+		{
+			name:       "delivered",
+			msg:        &outbox.Message{ID: 1, Topic: "test", Payload: `{"a":1}`, Attempts: 1},
+			wantOK:     true,
+			wantStatus: outbox.StatusSent,
+		},
+		{
+			name:       "retried with backoff",
+			msg:        &outbox.Message{ID: 1, Topic: "test", Attempts: 3},
+			handlerErr: errTestError,
+			wantOK:     true,
+			wantStatus: outbox.StatusPending,
+			wantDelay:  4 * time.Second,
+		},
+		{
+			name:       "out of attempts",
+			msg:        &outbox.Message{ID: 1, Topic: "test", Attempts: 5},
+			handlerErr: errTestError,
+			wantOK:     true,
+			wantStatus: outbox.StatusDead,
+		},
+		{
+			name:       "permanent error",
+			msg:        &outbox.Message{ID: 1, Topic: "test", Attempts: 1},
+			handlerErr: outbox.Permanent(errTestError),
+			wantOK:     true,
+			wantStatus: outbox.StatusDead,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				status string
+				runAt  time.Time
+			)
+
+			repo := &outbox.RepositoryMock{
+				ClaimMessageFunc: func(_ time.Time, _ time.Duration) (*outbox.Message, error) {
+					return tt.msg, tt.claimErr
+				},
+				CompleteMessageFunc: func(_ uint) error {
+					status = outbox.StatusSent
+					return nil
+				},
+				RetryMessageFunc: func(_ uint, at time.Time, lastErr string) error {
+					status, runAt = outbox.StatusPending, at
+					if lastErr != errTestError.Error() {
+						t.Errorf("RetryMessage() lastErr = %s", lastErr)
+					}
+					return nil
+				},
+				BuryMessageFunc: func(_ uint, _ string) error {
+					status = outbox.StatusDead
+					return nil
+				},
+			}
+
+			handler := func(_ context.Context, payload []byte) error {
+				if tt.handlerErr == nil && string(payload) != tt.msg.Payload {
+					t.Errorf("handler payload = %s", payload)
+				}
+				return tt.handlerErr
+			}
+
+			s := outbox.New(defaultLogger, repo, nil,
+				outbox.WithHandler("test", handler),
+				outbox.WithRetries(5, time.Second),
+			)
+
+			start := time.Now()
+			ok, err := s.Dispatch(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Dispatch() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if ok != tt.wantOK {
+				t.Errorf("Dispatch() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("Dispatch() status = %s, want %s", status, tt.wantStatus)
+			}
+			if tt.wantDelay > 0 && (runAt.Before(start.Add(tt.wantDelay)) || runAt.After(time.Now().Add(tt.wantDelay))) {
+				t.Errorf("Dispatch() retry at %s, want in %s", runAt.Sub(start), tt.wantDelay)
+			}
+		})
+	}
+}
+
+func TestService_Messages(t *testing.T) {
+	tests := []struct {
+		name    string
+		admin   bool
+		userErr error
+		filter  outbox.MessageFilter
+		want    outbox.MessageFilter
+		wantErr error
+	}{
+		{
+			name:    "error no user",
+			userErr: errTestError,
+			wantErr: errs.UserNotFound,
+		},
+		{
+			name:    "error not admin",
+			wantErr: errs.InsufficientPermissions,
+		},
+		{
+			name:  "dead by default",
+			admin: true,
+			want:  outbox.MessageFilter{Status: outbox.StatusDead, Limit: 50},
+		},
+		{
+			name:   "filter",
+			admin:  true,
+			filter: outbox.MessageFilter{Status: outbox.StatusSent, Topic: "test", Offset: 10, Limit: 10},
+			want:   outbox.MessageFilter{Status: outbox.StatusSent, Topic: "test", Offset: 10, Limit: 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uSrv := &outbox.UserServiceMock{
+				IsAdminFunc: func(_ context.Context, _ uint) (bool, error) {
+					return tt.admin, tt.userErr
+				},
+			}
+			repo := &outbox.RepositoryMock{
+				ListMessagesFunc: func(f *outbox.MessageFilter) ([]*outbox.Message, int, error) {
+					if *f != tt.want {
+						t.Errorf("ListMessages() filter = %#v, want %#v", *f, tt.want)
+					}
+					return []*outbox.Message{{ID: 1}}, 1, nil
+				},
+			}
+
+			s := outbox.New(defaultLogger, repo, uSrv)
+			res, cnt, err := s.Messages(context.Background(), 1, &tt.filter)
+			if err != tt.wantErr {
+				t.Errorf("Messages() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && (len(res) != 1 || cnt != 1) {
+				t.Errorf("Messages() got = %v, %d", res, cnt)
+			}
+		})
+	}
+}
+
+func TestService_Replay(t *testing.T) {
+	tests := []struct {
+		name     string
+		admin    bool
+		replayed bool
+		repoErr  error
+		wantErr  error
+	}{
+		{
+			name:    "error not admin",
+			wantErr: errs.InsufficientPermissions,
+		},
+		{
+			name:    "error from repo",
+			admin:   true,
+			repoErr: errTestError,
+			wantErr: errTestError,
+		},
+		{
+			name:    "error not dead",
+			admin:   true,
+			wantErr: errs.OutboxMessageNotDead,
+		},
+		{
+			name:     "ok",
+			admin:    true,
+			replayed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uSrv := &outbox.UserServiceMock{
+				IsAdminFunc: func(_ context.Context, _ uint) (bool, error) {
+					return tt.admin, nil
+				},
+			}
+			repo := &outbox.RepositoryMock{
+				ReplayMessageFunc: func(id uint, _ time.Time) (bool, error) {
+					if id != 7 {
+						t.Errorf("ReplayMessage() id = %d", id)
+					}
+					return tt.replayed, tt.repoErr
+				},
+			}
+
+			s := outbox.New(defaultLogger, repo, uSrv)
+			if err := s.Replay(context.Background(), 1, 7); err != tt.wantErr {
+				t.Errorf("Replay() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewMessage(t *testing.T) {
+	m, err := outbox.NewMessage("test", map[string]string{"a": "b"})
+	if err != nil {
+		t.Fatalf("NewMessage() error = %v", err)
+	}
+
+	if m.Topic != "test" || m.Payload != `{"a":"b"}` || m.Status != outbox.StatusPending || m.RunAt.IsZero() {
+		t.Errorf("NewMessage() got = %#v", m)
+	}
+
+	if _, err := outbox.NewMessage("test", make(chan int)); err == nil {
+		t.Errorf("NewMessage() expected error for the payload which can not be encoded")
+	}
+}
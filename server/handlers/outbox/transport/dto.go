@@ -0,0 +1,15 @@
+package transport
+
+import "github.com/ivch/dynasty/server/handlers/outbox"
+
+type errorResponse struct {
+	Error     string `json:"error"`
+	ErrorCode uint   `json:"error_code"`
+	Ru        string `json:"ru"`
+	Ua        string `json:"ua"`
+}
+
+type messagesResponse struct {
+	Data  []*outbox.Message `json:"data"`
+	Count int               `json:"count"`
+}
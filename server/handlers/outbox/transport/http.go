@@ -0,0 +1,189 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/common/logger"
+	"github.com/ivch/dynasty/server/handlers/outbox"
+	"github.com/ivch/dynasty/server/middlewares"
+)
+
+const maxListLimit = 200
+
+type OutboxService interface {
+	Messages(ctx context.Context, adminID uint, f *outbox.MessageFilter) ([]*outbox.Message, int, error)
+	Replay(ctx context.Context, adminID, id uint) error
+}
+
+type HTTPTransport struct {
+	svc    OutboxService
+	log    logger.Logger
+	router chi.Router
+}
+
+func (h *HTTPTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.router.ServeHTTP(w, r)
+}
+
+// NewHTTPTransport returns a new instance of HTTPTransport.
+func NewHTTPTransport(log logger.Logger, svc OutboxService, mdl ...func(http.Handler) http.Handler) http.Handler {
+	h := &HTTPTransport{log: log, router: chi.NewRouter().With(mdl...), svc: svc}
+	h.attachRoutes()
+	return h
+}
+
+func (h *HTTPTransport) attachRoutes() {
+	h.router.Get("/v1/admin/messages", h.Messages)
+	h.router.Post("/v1/admin/messages/{id}/replay", h.Replay)
+}
+
+// Messages lists the dead messages, the others are listed with the status query parameter.
+func (h *HTTPTransport) Messages(w http.ResponseWriter, r *http.Request) {
+	adminID, err := getUserID(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
+		return
+	}
+
+	f := outbox.MessageFilter{
+		Status: r.URL.Query().Get("status"),
+		Topic:  r.URL.Query().Get("topic"),
+	}
+
+	switch f.Status {
+	case "", outbox.StatusPending, outbox.StatusRunning, outbox.StatusSent, outbox.StatusDead:
+	default:
+		h.sendError(w, http.StatusBadRequest, errs.BadRequest)
+		return
+	}
+
+	if f.Offset, err = parseUint(r, "offset", 0); err != nil {
+		h.sendError(w, http.StatusBadRequest, errs.BadOffset)
+		return
+	}
+
+	if f.Limit, err = parseUint(r, "limit", maxListLimit); err != nil {
+		h.sendError(w, http.StatusBadRequest, errs.BadLimit)
+		return
+	}
+
+	res, count, err := h.svc.Messages(r.Context(), adminID, &f)
+	if err != nil {
+		if err == errs.InsufficientPermissions {
+			h.sendError(w, http.StatusForbidden, err)
+			return
+		}
+		h.sendError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if res == nil {
+		res = []*outbox.Message{}
+	}
+
+	h.sendHTTPResponse(r.Context(), w, messagesResponse{Data: res, Count: count})
+}
+
+func (h *HTTPTransport) Replay(w http.ResponseWriter, r *http.Request) {
+	adminID, err := getUserID(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id == 0 {
+		h.sendError(w, http.StatusBadRequest, errs.BadRequest)
+		return
+	}
+
+	if err := h.svc.Replay(r.Context(), adminID, uint(id)); err != nil {
+		switch err {
+		case errs.InsufficientPermissions:
+			h.sendError(w, http.StatusForbidden, err)
+		case errs.OutboxMessageNotDead:
+			h.sendError(w, http.StatusNotFound, err)
+		default:
+			h.sendError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	h.sendHTTPResponse(r.Context(), w, nil)
+}
+
+// parseUint returns the optional query parameter, zero if it is not set.
+func parseUint(r *http.Request, name string, maxValue uint64) (uint, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.ParseUint(v, 10, 32)
+	if err != nil || (maxValue > 0 && n > maxValue) {
+		return 0, errs.BadRequest
+	}
+
+	return uint(n), nil
+}
+
+func (h *HTTPTransport) sendHTTPResponse(_ context.Context, w http.ResponseWriter, response interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.log.Debug("failed to send response error: %w", err)
+	}
+}
+
+func (h *HTTPTransport) sendError(w http.ResponseWriter, httpCode int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpCode)
+
+	if err == nil {
+		err = errs.Generic
+	}
+
+	var (
+		ru string
+		ua string
+	)
+
+	if e, ok := err.(errs.SvcError); ok {
+		ru, ua = e.Ru, e.Ua
+	}
+
+	res := errorResponse{
+		ErrorCode: errs.Code(err),
+		Error:     err.Error(),
+		Ru:        ru,
+		Ua:        ua,
+	}
+
+	if err := json.NewEncoder(w).Encode(&res); err != nil {
+		h.log.Debug("failed to send response error: %w", err)
+	}
+}
+
+func getUserID(ctx context.Context) (uint, error) {
+	idStr, ok := middlewares.UserIDFromContext(ctx)
+	if !ok {
+		return 0, errs.EmptyUserID
+	}
+
+	userID, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return 0, errs.BadUserID
+	}
+
+	if userID == 0 {
+		return 0, errs.BadUserID
+	}
+
+	return uint(userID), nil
+}
@@ -0,0 +1,174 @@
+package transport_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/common/logger"
+	"github.com/ivch/dynasty/server/handlers/outbox"
+	"github.com/ivch/dynasty/server/handlers/outbox/transport"
+	"github.com/ivch/dynasty/server/middlewares"
+)
+
+var (
+	defaultLogger *logger.StdLog
+	errTestError  = errors.New("some err")
+)
+
+func TestMain(m *testing.M) {
+	defaultLogger = logger.NewStdLog(logger.WithWriter(io.Discard))
+	os.Exit(m.Run())
+}
+
+func TestHTTP_Messages(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		header   string
+		query    string
+		svc      transport.OutboxService
+		wantErr  bool
+		want     string
+		wantCode int
+	}{
+		{
+			name:     "error no user",
+			wantErr:  true,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "error bad status",
+			header:   "1",
+			query:    "?status=lost",
+			wantErr:  true,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "error bad limit",
+			header:   "1",
+			query:    "?limit=1000",
+			wantErr:  true,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "error not admin",
+			header: "1",
+			svc: &transport.OutboxServiceMock{
+				MessagesFunc: func(_ context.Context, _ uint, _ *outbox.MessageFilter) ([]*outbox.Message, int, error) {
+					return nil, 0, errs.InsufficientPermissions
+				},
+			},
+			wantErr:  true,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:   "ok",
+			header: "1",
+			query:  "?status=dead&topic=users.recovery_email&offset=0&limit=10",
+			svc: &transport.OutboxServiceMock{
+				MessagesFunc: func(_ context.Context, adminID uint, f *outbox.MessageFilter) ([]*outbox.Message, int, error) {
+					if adminID != 1 || f.Status != outbox.StatusDead || f.Topic != "users.recovery_email" || f.Limit != 10 {
+						return nil, 0, errTestError
+					}
+					return []*outbox.Message{{
+						ID:        3,
+						Topic:     "users.recovery_email",
+						Payload:   `{"to":"a"}`,
+						Status:    outbox.StatusDead,
+						Attempts:  8,
+						LastError: "smtp is down",
+						RunAt:     created,
+						CreatedAt: &created,
+					}}, 1, nil
+				},
+			},
+			want:     `{"data":[{"id":3,"topic":"users.recovery_email","status":"dead","attempts":8,"last_error":"smtp is down","run_at":"2024-01-02T03:04:05Z","created_at":"2024-01-02T03:04:05Z","updated_at":null}],"count":1}`,
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := transport.NewHTTPTransport(defaultLogger, tt.svc, middlewares.NewIDCtx(defaultLogger).Middleware)
+			rr := httptest.NewRecorder()
+			rq, _ := http.NewRequest(http.MethodGet, "/v1/admin/messages"+tt.query, nil)
+			rq.Header.Add("X-Auth-User", tt.header)
+			h.ServeHTTP(rr, rq)
+			if rr.Code != tt.wantCode {
+				t.Errorf("Request error. status = %d, wantCode = %d", rr.Code, tt.wantCode)
+			}
+
+			if !tt.wantErr && tt.want != strings.TrimSpace(rr.Body.String()) {
+				t.Errorf("Response error, got = %s, want = %s", rr.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTP_Replay(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		svcErr   error
+		wantCode int
+	}{
+		{
+			name:     "error bad id",
+			id:       "abc",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "error not admin",
+			id:       "3",
+			svcErr:   errs.InsufficientPermissions,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "error not dead",
+			id:       "3",
+			svcErr:   errs.OutboxMessageNotDead,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "error service error",
+			id:       "3",
+			svcErr:   errTestError,
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:     "ok",
+			id:       "3",
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &transport.OutboxServiceMock{
+				ReplayFunc: func(_ context.Context, adminID, id uint) error {
+					if adminID != 1 || id != 3 {
+						return errTestError
+					}
+					return tt.svcErr
+				},
+			}
+			h := transport.NewHTTPTransport(defaultLogger, svc, middlewares.NewIDCtx(defaultLogger).Middleware)
+			rr := httptest.NewRecorder()
+			rq, _ := http.NewRequest(http.MethodPost, "/v1/admin/messages/"+tt.id+"/replay", nil)
+			rq.Header.Add("X-Auth-User", "1")
+			h.ServeHTTP(rr, rq)
+			if rr.Code != tt.wantCode {
+				t.Errorf("Request error. status = %d, wantCode = %d", rr.Code, tt.wantCode)
+			}
+		})
+	}
+}
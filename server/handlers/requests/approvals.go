@@ -0,0 +1,213 @@
+package requests
+
+import (
+	"context"
+	"time"
+
+	"github.com/ivch/dynasty/common/errs"
+)
+
+func (s *Service) GuardCreateApproval(ctx context.Context, r *Approval) (*Approval, error) {
+	guard, err := s.guardByID(ctx, r.GuardID)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := s.uSrv.ApartmentMembers(ctx, r.BuildingID, r.Apartment)
+	if err != nil {
+		s.log.Error("error getting apartment members: %w", err)
+		return nil, err
+	}
+
+	if len(members) == 0 {
+		return nil, errs.UserNotFound
+	}
+
+	a := Approval{
+		GuardID:     guard.ID,
+		BuildingID:  r.BuildingID,
+		Apartment:   r.Apartment,
+		Description: r.Description,
+		Status:      ApprovalPending,
+		ExpiresAt:   time.Now().Add(s.approvalTTL),
+	}
+
+	if err := s.repo.CreateApproval(&a); err != nil {
+		s.log.Error("error creating approval: %w", err)
+		return nil, err
+	}
+
+	for i := range members {
+		s.publishApproval(userTopic(members[i].ID), &a)
+	}
+
+	return &a, nil
+}
+
+func (s *Service) GuardApproval(ctx context.Context, id uint) (*Approval, error) {
+	a, err := s.repo.GetApproval(id)
+	if err != nil {
+		s.log.Error("error getting approval %d: %w", id, err)
+		return nil, err
+	}
+
+	if a.DecidedBy != nil {
+		u, err := s.uSrv.UserByID(ctx, *a.DecidedBy)
+		if err != nil {
+			s.log.Error("error getting approval %d decision maker: %w", id, err)
+			return a, nil
+		}
+		a.DecidedByName = u.FirstName + " " + u.LastName
+	}
+
+	return a, nil
+}
+
+func (s *Service) MyApprovals(ctx context.Context, userID uint) ([]*Approval, error) {
+	u, err := s.uSrv.UserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !u.Active {
+		return nil, errs.UserIsInactive
+	}
+
+	return s.repo.ListPendingApprovals(u.BuildingID, u.Apartment)
+}
+
+func (s *Service) ResolveApproval(ctx context.Context, r *ApprovalDecision) (*Approval, error) {
+	a, err := s.repo.GetApproval(r.ID)
+	if err != nil {
+		s.log.Error("error getting approval %d: %w", r.ID, err)
+		return nil, err
+	}
+
+	u, err := s.uSrv.UserByID(ctx, r.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !u.Active || u.BuildingID != a.BuildingID || u.Apartment != a.Apartment {
+		return nil, errs.InsufficientPermissions
+	}
+
+	if a.Status != ApprovalPending {
+		return nil, errs.ApprovalAlreadyResolved
+	}
+
+	now := time.Now()
+	if now.After(a.ExpiresAt) {
+		return nil, errs.ApprovalExpired
+	}
+
+	a.Status = ApprovalDenied
+	if r.Approve {
+		a.Status = ApprovalApproved
+	}
+	a.DecidedBy = &u.ID
+	a.DecidedAt = &now
+
+	ok, err := s.repo.ResolveApproval(a)
+	if err != nil {
+		s.log.Error("error resolving approval %d: %w", r.ID, err)
+		return nil, err
+	}
+
+	// another member was faster
+	if !ok {
+		return nil, errs.ApprovalAlreadyResolved
+	}
+
+	a.DecidedByName = u.FirstName + " " + u.LastName
+	s.notifyApproval(ctx, a)
+
+	return a, nil
+}
+
+// ExpireApprovals marks all unanswered approvals with passed deadline as expired.
+func (s *Service) ExpireApprovals(ctx context.Context) error {
+	list, err := s.repo.ExpireApprovals(time.Now())
+	if err != nil {
+		return err
+	}
+
+	for i := range list {
+		s.notifyApproval(ctx, list[i])
+	}
+
+	return nil
+}
+
+// RunApprovalsExpiry periodically expires unanswered approvals until ctx is done.
+func (s *Service) RunApprovalsExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.ExpireApprovals(ctx); err != nil {
+				s.log.Error("error expiring approvals: %w", err)
+			}
+		}
+	}
+}
+
+// RunApprovalEvents delivers the approval updates of all the instances to the subscribers of this one until ctx is done.
+func (s *Service) RunApprovalEvents(ctx context.Context) {
+	if s.events == nil {
+		return
+	}
+
+	for {
+		if err := s.events.Listen(ctx, s.hub.publish); err != nil {
+			s.log.Error("error listening to approval events: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(approvalEventsRetry):
+		}
+	}
+}
+
+// publishApproval sends the update to the subscribers of the topic on all the instances.
+func (s *Service) publishApproval(topic string, a *Approval) {
+	if s.events == nil {
+		s.hub.publish(topic, a)
+		return
+	}
+
+	if err := s.events.Publish(topic, a); err != nil {
+		s.log.Error("error publishing approval %d: %w", a.ID, err)
+	}
+}
+
+// SubscribeApproval streams state changes of the given approval.
+func (s *Service) SubscribeApproval(_ context.Context, id uint) (<-chan *Approval, func()) {
+	return s.hub.subscribe(approvalTopic(id))
+}
+
+// SubscribeUserApprovals streams approvals raised or resolved for the apartment of the given user.
+func (s *Service) SubscribeUserApprovals(_ context.Context, userID uint) (<-chan *Approval, func()) {
+	return s.hub.subscribe(userTopic(userID))
+}
+
+// notifyApproval pushes the resolved approval to the guard and the apartment members.
+func (s *Service) notifyApproval(ctx context.Context, a *Approval) {
+	s.publishApproval(approvalTopic(a.ID), a)
+
+	members, err := s.uSrv.ApartmentMembers(ctx, a.BuildingID, a.Apartment)
+	if err != nil {
+		s.log.Error("error getting apartment members: %w", err)
+		return
+	}
+
+	for i := range members {
+		s.publishApproval(userTopic(members[i].ID), a)
+	}
+}
@@ -0,0 +1,378 @@
+package requests_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/server/handlers/requests"
+	"github.com/ivch/dynasty/server/handlers/users"
+)
+
+func apartmentUsers() *requests.UserServiceMock {
+	return &requests.UserServiceMock{
+		UserByIDFunc: func(_ context.Context, id uint) (*users.User, error) {
+			switch id {
+			case 3:
+				return &users.User{ID: 3, Role: users.GuardUserRole}, nil
+			case 7:
+				return &users.User{ID: 7, BuildingID: 1, Apartment: 12, Active: true, FirstName: "John", LastName: "Doe"}, nil
+			case 8:
+				return &users.User{ID: 8, BuildingID: 1, Apartment: 13, Active: true}, nil
+			}
+			return nil, errTestError
+		},
+		ApartmentMembersFunc: func(_ context.Context, _ uint, _ uint) ([]*users.User, error) {
+			return []*users.User{{ID: 7}, {ID: 9}}, nil
+		},
+	}
+}
+
+func TestService_GuardCreateApproval(t *testing.T) {
+	tests := []struct {
+		name    string
+		repo    requests.RequestsRepository
+		uSrv    *requests.UserServiceMock
+		req     *requests.Approval
+		wantErr error
+	}{
+		{
+			name:    "error not a guard",
+			uSrv:    apartmentUsers(),
+			req:     &requests.Approval{GuardID: 7, BuildingID: 1, Apartment: 12},
+			wantErr: errs.InsufficientPermissions,
+		},
+		{
+			name: "error no members",
+			uSrv: &requests.UserServiceMock{
+				UserByIDFunc: apartmentUsers().UserByIDFunc,
+				ApartmentMembersFunc: func(_ context.Context, _ uint, _ uint) ([]*users.User, error) {
+					return nil, nil
+				},
+			},
+			req:     &requests.Approval{GuardID: 3, BuildingID: 1, Apartment: 12},
+			wantErr: errs.UserNotFound,
+		},
+		{
+			name: "error from db",
+			repo: &requests.RequestsRepositoryMock{
+				CreateApprovalFunc: func(_ *requests.Approval) error {
+					return errTestError
+				},
+			},
+			uSrv:    apartmentUsers(),
+			req:     &requests.Approval{GuardID: 3, BuildingID: 1, Apartment: 12},
+			wantErr: errTestError,
+		},
+		{
+			name: "ok",
+			repo: &requests.RequestsRepositoryMock{
+				CreateApprovalFunc: func(a *requests.Approval) error {
+					a.ID = 1
+					return nil
+				},
+			},
+			uSrv: apartmentUsers(),
+			req:  &requests.Approval{GuardID: 3, BuildingID: 1, Apartment: 12, Description: "courier"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := requests.New(defaultLogger, tt.repo, tt.uSrv, nil, "", requests.WithApprovalTTL(time.Minute))
+			updates, unsubscribe := s.SubscribeUserApprovals(context.Background(), 9)
+			defer unsubscribe()
+
+			got, err := s.GuardCreateApproval(context.Background(), tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GuardCreateApproval() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantErr != nil {
+				return
+			}
+
+			if got.Status != requests.ApprovalPending || got.GuardID != 3 || got.Description != "courier" {
+				t.Errorf("GuardCreateApproval() got = %#v", got)
+			}
+
+			if d := time.Until(got.ExpiresAt); d <= 0 || d > time.Minute {
+				t.Errorf("GuardCreateApproval() wrong expiration %s", got.ExpiresAt)
+			}
+
+			select {
+			case a := <-updates:
+				if a.ID != got.ID {
+					t.Errorf("member notified about wrong approval %d", a.ID)
+				}
+			default:
+				t.Error("apartment member was not notified")
+			}
+		})
+	}
+}
+
+func TestService_ResolveApproval(t *testing.T) {
+	pending := func() *requests.Approval {
+		return &requests.Approval{
+			ID:         1,
+			BuildingID: 1,
+			Apartment:  12,
+			Status:     requests.ApprovalPending,
+			ExpiresAt:  time.Now().Add(time.Minute),
+		}
+	}
+
+	tests := []struct {
+		name       string
+		repo       requests.RequestsRepository
+		req        *requests.ApprovalDecision
+		wantErr    error
+		wantStatus string
+	}{
+		{
+			name: "error no approval",
+			repo: &requests.RequestsRepositoryMock{
+				GetApprovalFunc: func(_ uint) (*requests.Approval, error) {
+					return nil, errTestError
+				},
+			},
+			req:     &requests.ApprovalDecision{ID: 1, UserID: 7},
+			wantErr: errTestError,
+		},
+		{
+			name: "error other apartment",
+			repo: &requests.RequestsRepositoryMock{
+				GetApprovalFunc: func(_ uint) (*requests.Approval, error) {
+					return pending(), nil
+				},
+			},
+			req:     &requests.ApprovalDecision{ID: 1, UserID: 8},
+			wantErr: errs.InsufficientPermissions,
+		},
+		{
+			name: "error already resolved",
+			repo: &requests.RequestsRepositoryMock{
+				GetApprovalFunc: func(_ uint) (*requests.Approval, error) {
+					a := pending()
+					a.Status = requests.ApprovalDenied
+					return a, nil
+				},
+			},
+			req:     &requests.ApprovalDecision{ID: 1, UserID: 7},
+			wantErr: errs.ApprovalAlreadyResolved,
+		},
+		{
+			name: "error expired",
+			repo: &requests.RequestsRepositoryMock{
+				GetApprovalFunc: func(_ uint) (*requests.Approval, error) {
+					a := pending()
+					a.ExpiresAt = time.Now().Add(-time.Second)
+					return a, nil
+				},
+			},
+			req:     &requests.ApprovalDecision{ID: 1, UserID: 7},
+			wantErr: errs.ApprovalExpired,
+		},
+		{
+			name: "error another member was first",
+			repo: &requests.RequestsRepositoryMock{
+				GetApprovalFunc: func(_ uint) (*requests.Approval, error) {
+					return pending(), nil
+				},
+				ResolveApprovalFunc: func(_ *requests.Approval) (bool, error) {
+					return false, nil
+				},
+			},
+			req:     &requests.ApprovalDecision{ID: 1, UserID: 7, Approve: true},
+			wantErr: errs.ApprovalAlreadyResolved,
+		},
+		{
+			name: "ok approved",
+			repo: &requests.RequestsRepositoryMock{
+				GetApprovalFunc: func(_ uint) (*requests.Approval, error) {
+					return pending(), nil
+				},
+				ResolveApprovalFunc: func(a *requests.Approval) (bool, error) {
+					return *a.DecidedBy == 7 && a.DecidedAt != nil, nil
+				},
+			},
+			req:        &requests.ApprovalDecision{ID: 1, UserID: 7, Approve: true},
+			wantStatus: requests.ApprovalApproved,
+		},
+		{
+			name: "ok denied",
+			repo: &requests.RequestsRepositoryMock{
+				GetApprovalFunc: func(_ uint) (*requests.Approval, error) {
+					return pending(), nil
+				},
+				ResolveApprovalFunc: func(_ *requests.Approval) (bool, error) {
+					return true, nil
+				},
+			},
+			req:        &requests.ApprovalDecision{ID: 1, UserID: 7},
+			wantStatus: requests.ApprovalDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := requests.New(defaultLogger, tt.repo, apartmentUsers(), nil, "")
+			updates, unsubscribe := s.SubscribeApproval(context.Background(), 1)
+			defer unsubscribe()
+
+			got, err := s.ResolveApproval(context.Background(), tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ResolveApproval() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantErr != nil {
+				return
+			}
+
+			if got.Status != tt.wantStatus || got.DecidedByName != "John Doe" {
+				t.Errorf("ResolveApproval() got = %#v", got)
+			}
+
+			select {
+			case a := <-updates:
+				if a.Status != tt.wantStatus {
+					t.Errorf("guard notified with wrong status %s", a.Status)
+				}
+			default:
+				t.Error("guard was not notified")
+			}
+		})
+	}
+}
+
+func TestService_ExpireApprovals(t *testing.T) {
+	repo := &requests.RequestsRepositoryMock{
+		ExpireApprovalsFunc: func(_ time.Time) ([]*requests.Approval, error) {
+			return []*requests.Approval{{ID: 1, Status: requests.ApprovalExpired}}, nil
+		},
+	}
+
+	s := requests.New(defaultLogger, repo, apartmentUsers(), nil, "")
+	updates, unsubscribe := s.SubscribeApproval(context.Background(), 1)
+	defer unsubscribe()
+
+	if err := s.ExpireApprovals(context.Background()); err != nil {
+		t.Fatalf("ExpireApprovals() error = %v", err)
+	}
+
+	select {
+	case a := <-updates:
+		if a.Status != requests.ApprovalExpired {
+			t.Errorf("guard notified with wrong status %s", a.Status)
+		}
+	default:
+		t.Error("guard was not notified")
+	}
+}
+
+// approvalBus stands in for the database delivering the approval events to every instance.
+type approvalBus struct {
+	mu        sync.Mutex
+	listeners []func(topic string, a *requests.Approval)
+}
+
+func (b *approvalBus) Publish(topic string, a *requests.Approval) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, deliver := range b.listeners {
+		deliver(topic, a)
+	}
+	return nil
+}
+
+func (b *approvalBus) Listen(ctx context.Context, deliver func(topic string, a *requests.Approval)) error {
+	b.mu.Lock()
+	b.listeners = append(b.listeners, deliver)
+	b.mu.Unlock()
+	<-ctx.Done()
+	return nil
+}
+
+func (b *approvalBus) listening() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.listeners)
+}
+
+func TestService_ApprovalEvents(t *testing.T) {
+	var (
+		bus  = &approvalBus{}
+		repo = &requests.RequestsRepositoryMock{
+			ExpireApprovalsFunc: func(_ time.Time) ([]*requests.Approval, error) {
+				return []*requests.Approval{{ID: 1, Status: requests.ApprovalExpired}}, nil
+			},
+		}
+		expiring = requests.New(defaultLogger, repo, apartmentUsers(), nil, "", requests.WithApprovalEvents(bus))
+		serving  = requests.New(defaultLogger, repo, apartmentUsers(), nil, "", requests.WithApprovalEvents(bus))
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go expiring.RunApprovalEvents(ctx)
+	go serving.RunApprovalEvents(ctx)
+	for bus.listening() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	updates, unsubscribe := serving.SubscribeApproval(context.Background(), 1)
+	defer unsubscribe()
+
+	if err := expiring.ExpireApprovals(context.Background()); err != nil {
+		t.Fatalf("ExpireApprovals() error = %v", err)
+	}
+
+	select {
+	case a := <-updates:
+		if a.Status != requests.ApprovalExpired {
+			t.Errorf("guard notified with wrong status %s", a.Status)
+		}
+	case <-time.After(time.Second):
+		t.Error("guard connected to the other instance was not notified")
+	}
+}
+
+func TestService_MyApprovals(t *testing.T) {
+	tests := []struct {
+		name    string
+		userID  uint
+		wantErr bool
+	}{
+		{name: "error no user", userID: 1, wantErr: true},
+		{name: "ok", userID: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &requests.RequestsRepositoryMock{
+				ListPendingApprovalsFunc: func(building uint, apt uint) ([]*requests.Approval, error) {
+					if building != 1 || apt != 12 {
+						return nil, errTestError
+					}
+					return []*requests.Approval{{ID: 1}}, nil
+				},
+			}
+
+			s := requests.New(defaultLogger, repo, apartmentUsers(), nil, "")
+			got, err := s.MyApprovals(context.Background(), tt.userID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MyApprovals() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr && len(got) != 1 {
+				t.Errorf("MyApprovals() got = %#v", got)
+			}
+		})
+	}
+}
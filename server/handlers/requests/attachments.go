@@ -0,0 +1,233 @@
+package requests
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png" // register PNG decoder
+	"net/http"
+
+	"github.com/disintegration/imaging"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	_ "golang.org/x/image/webp" // register WebP decoder
+
+	"github.com/ivch/dynasty/common/errs"
+)
+
+const (
+	ContentTypeJPEG = "image/jpeg"
+	ContentTypePNG  = "image/png"
+	ContentTypeWebP = "image/webp"
+	ContentTypeHEIC = "image/heic"
+	ContentTypePDF  = "application/pdf"
+)
+
+// DefaultAttachmentLimits are used when the allowlist is not configured.
+var DefaultAttachmentLimits = map[string]int64{
+	ContentTypeJPEG: 5 << 20,
+	ContentTypePNG:  5 << 20,
+	ContentTypeWebP: 5 << 20,
+	ContentTypePDF:  10 << 20,
+}
+
+// heicBrands are the ISO BMFF brands used by HEIC/HEIF photos.
+var heicBrands = []string{"heic", "heix", "hevc", "hevx", "heim", "heis", "mif1", "msf1"}
+
+// Attachment is the uploaded file prepared for storage.
+type Attachment struct {
+	File        []byte
+	ContentType string
+	Ext         string
+	Variants    []AttachmentVariant
+}
+
+// AttachmentVariant is the resized copy of the attachment, see ImageVariant.
+type AttachmentVariant struct {
+	ImageVariant
+	ContentType string
+	Data        []byte
+}
+
+// AttachmentHandler prepares the uploaded file of a specific content type for storage.
+type AttachmentHandler interface {
+	Process(file []byte) (*Attachment, error)
+}
+
+// AttachmentHandlerFunc is an adapter allowing ordinary functions to be used as AttachmentHandler.
+type AttachmentHandlerFunc func(file []byte) (*Attachment, error)
+
+func (f AttachmentHandlerFunc) Process(file []byte) (*Attachment, error) {
+	return f(file)
+}
+
+// newAttachmentHandlers builds the pipeline by detected content type, HEIC is not decoded.
+func newAttachmentHandlers(maxImageDimension int, variants []ImageVariant) map[string]AttachmentHandler {
+	img := imageHandler{maxDimension: maxImageDimension, variants: variants}
+	return map[string]AttachmentHandler{
+		ContentTypeJPEG: img,
+		ContentTypePNG:  img,
+		ContentTypeWebP: img,
+		ContentTypePDF:  pdfHandler{thumb: thumbVariant(variants)},
+	}
+}
+
+// checkAttachment checks the file against the allowlist with size limits and returns its content type.
+func (s *Service) checkAttachment(file []byte) (string, error) {
+	fileType, err := checkFileLimits(s.attachmentLimits, file)
+	if err != nil {
+		return "", err
+	}
+
+	if _, ok := s.attachmentHandlers[fileType]; !ok {
+		return "", errs.FileWrongType
+	}
+
+	return fileType, nil
+}
+
+// prepareAttachment checks the file and runs it through the handler of its content type.
+func (s *Service) prepareAttachment(file []byte) (*Attachment, error) {
+	fileType, err := s.checkAttachment(file)
+	if err != nil {
+		return nil, err
+	}
+
+	h := s.attachmentHandlers[fileType]
+
+	a, err := h.Process(file)
+	if errors.Is(err, image.ErrFormat) {
+		return nil, errs.FileWrongType
+	}
+
+	return a, err
+}
+
+// checkFileLimits checks the file against the allowlist with size limits and returns its content type.
+func checkFileLimits(limits map[string]int64, file []byte) (string, error) {
+	fileType := detectContentType(file)
+
+	limit, ok := limits[fileType]
+	if !ok {
+		return "", errs.FileWrongType
+	}
+
+	if int64(len(file)) > limit {
+		return "", errs.FileIsTooBig
+	}
+
+	return fileType, nil
+}
+
+// detectContentType extends http.DetectContentType with HEIC sniffing.
+func detectContentType(file []byte) string {
+	if len(file) >= 12 && string(file[4:8]) == "ftyp" {
+		brand := string(file[8:12])
+		for i := range heicBrands {
+			if brand == heicBrands[i] {
+				return ContentTypeHEIC
+			}
+		}
+	}
+
+	return http.DetectContentType(file)
+}
+
+// imageHandler normalizes uploaded photos and drops their metadata.
+type imageHandler struct {
+	maxDimension int
+	variants     []ImageVariant
+}
+
+func (h imageHandler) Process(file []byte) (*Attachment, error) {
+	img, data, err := normalizeImage(file, h.maxDimension)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Attachment{File: data, ContentType: ContentTypeJPEG, Ext: ".jpg"}
+	for _, v := range h.variants {
+		variant, err := renderVariant(img, v)
+		if err != nil {
+			return nil, err
+		}
+		a.Variants = append(a.Variants, *variant)
+	}
+
+	return a, nil
+}
+
+// normalizeImage decodes the photo, downsizes it to maxDimension and encodes it to jpeg without metadata.
+func normalizeImage(file []byte, maxDimension int) (image.Image, []byte, error) {
+	img, err := imaging.Decode(bytes.NewReader(file), imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if b := img.Bounds(); maxDimension > 0 && (b.Dx() > maxDimension || b.Dy() > maxDimension) {
+		img = imaging.Fit(img, maxDimension, maxDimension, imaging.Lanczos)
+	}
+
+	// flatten transparency on white instead of the black jpeg would give
+	dst := image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+
+	buf := new(bytes.Buffer)
+	if err := jpeg.Encode(buf, dst, &jpeg.Options{Quality: 90}); err != nil {
+		return nil, nil, err
+	}
+
+	return dst, buf.Bytes(), nil
+}
+
+// attachmentExt is the extension of the stored file.
+func attachmentExt(contentType string) string {
+	if contentType == ContentTypePDF {
+		return ".pdf"
+	}
+	return ".jpg"
+}
+
+// pdfHandler stores documents as is with the placeholder thumbnail, the other variants are not rendered.
+type pdfHandler struct {
+	thumb ImageVariant
+}
+
+func (h pdfHandler) Process(file []byte) (*Attachment, error) {
+	thumb, err := renderVariant(documentPlaceholder("PDF"), h.thumb)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Attachment{File: file, ContentType: ContentTypePDF, Ext: ".pdf", Variants: []AttachmentVariant{*thumb}}, nil
+}
+
+// documentPlaceholder draws a placeholder page with the document kind label.
+func documentPlaceholder(label string) image.Image {
+	const size = 128
+	var (
+		dst  = image.NewRGBA(image.Rect(0, 0, size, size))
+		page = image.Rect(size/4, size/8, size*3/4, size*7/8)
+		band = image.Rect(page.Min.X, page.Max.Y-40, page.Max.X, page.Max.Y-20)
+	)
+
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{C: color.RGBA{R: 230, G: 230, B: 230, A: 255}}, image.Point{}, draw.Src)
+	draw.Draw(dst, page, image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, band, &image.Uniform{C: color.RGBA{R: 200, G: 40, B: 40, A: 255}}, image.Point{}, draw.Src)
+
+	face := basicfont.Face7x13
+	d := font.Drawer{Dst: dst, Src: image.White, Face: face}
+	width := d.MeasureString(label)
+	d.Dot = fixed.Point26_6{
+		X: fixed.I(band.Min.X+band.Dx()/2) - width/2,
+		Y: fixed.I(band.Max.Y - (band.Dy()-face.Ascent)/2),
+	}
+	d.DrawString(label)
+
+	return dst
+}
@@ -0,0 +1,224 @@
+package requests_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/jpeg"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/server/handlers/requests"
+)
+
+func TestService_UploadAttachment(t *testing.T) {
+	png, err := os.ReadFile("../../../test_image.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	heic := append([]byte{0, 0, 0, 24}, []byte("ftypheic\x00\x00\x00\x00mif1heic")...)
+	pdf := []byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\ntrailer\n<< /Root 1 0 R >>\n%%EOF\n")
+
+	tests := []struct {
+		name      string
+		file      []byte
+		limits    map[string]int64
+		wantErr   error
+		wantType  string
+		wantExt   string
+		wantBytes []byte
+		wantFiles int
+	}{
+		{
+			name:    "error type not in allowlist",
+			file:    pdf,
+			limits:  map[string]int64{requests.ContentTypeJPEG: 1 << 20},
+			wantErr: errs.FileWrongType,
+		},
+		{
+			name:    "error heic cannot be decoded",
+			file:    heic,
+			limits:  map[string]int64{requests.ContentTypeHEIC: 1 << 20},
+			wantErr: errs.FileWrongType,
+		},
+		{
+			name:    "error too big for its type",
+			file:    png,
+			limits:  map[string]int64{requests.ContentTypePNG: 10},
+			wantErr: errs.FileIsTooBig,
+		},
+		{
+			name:      "ok png converted to jpeg",
+			file:      png,
+			wantType:  requests.ContentTypeJPEG,
+			wantExt:   ".jpg",
+			wantFiles: 4,
+		},
+		{
+			name:      "ok pdf stored as is",
+			file:      pdf,
+			wantType:  requests.ContentTypePDF,
+			wantExt:   ".pdf",
+			wantBytes: pdf,
+			wantFiles: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				q        = &imageQueue{}
+				uploaded = make(map[string]storedObject)
+			)
+
+			s := requests.New(defaultLogger, q.repo(), nil, memStorage(uploaded), "cdnHost", requests.WithAttachmentLimits(tt.limits))
+			res, err := s.UploadImage(context.Background(), &requests.Image{UserID: 1, RequestID: 1, File: tt.file})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UploadImage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				if len(uploaded) != 0 {
+					t.Error("UploadImage() uploaded rejected file")
+				}
+				return
+			}
+
+			if ok, err := s.ProcessImageJob(context.Background()); err != nil || !ok {
+				t.Fatalf("ProcessImageJob() = %v, %v", ok, err)
+			}
+
+			if !strings.HasSuffix(res.URL, tt.wantExt) {
+				t.Errorf("UploadImage() wrong file extension: %s", res.URL)
+			}
+
+			for key, obj := range uploaded {
+				body := obj.body
+				if strings.HasPrefix(key, requests.UploadPathPrefix) {
+					t.Errorf("UploadImage() upload %s is not removed", key)
+					continue
+				}
+				if !strings.HasPrefix(key, requests.ImgPathPrefix) {
+					if obj.opts.ContentType != requests.ContentTypeJPEG {
+						t.Errorf("UploadImage() wrong variant %s type %s", key, obj.opts.ContentType)
+					}
+					if _, err := jpeg.Decode(bytes.NewReader(body)); err != nil {
+						t.Errorf("UploadImage() variant %s is not a jpeg: %v", key, err)
+					}
+					continue
+				}
+
+				if obj.opts.ContentType != tt.wantType {
+					t.Errorf("UploadImage() wrong content type %s, want %s", obj.opts.ContentType, tt.wantType)
+				}
+
+				if tt.wantBytes != nil && !bytes.Equal(body, tt.wantBytes) {
+					t.Error("UploadImage() file was modified")
+				}
+
+				if tt.wantType == requests.ContentTypeJPEG {
+					if _, err := jpeg.Decode(bytes.NewReader(body)); err != nil {
+						t.Errorf("UploadImage() file is not a jpeg: %v", err)
+					}
+				}
+			}
+
+			if len(uploaded) != tt.wantFiles {
+				t.Errorf("UploadImage() uploaded %d objects, want %d", len(uploaded), tt.wantFiles)
+			}
+		})
+	}
+}
+
+// withExif inserts an APP1 segment with the given orientation and a GPS tag right after the JPEG SOI marker.
+func withExif(file []byte, orientation uint16) []byte {
+	tiff := []byte{
+		'M', 'M', 0, 42, 0, 0, 0, 8, // big endian header, IFD0 at 8
+		0, 2, // IFD0 entries
+		0x01, 0x12, 0, 3, 0, 0, 0, 1, byte(orientation >> 8), byte(orientation), 0, 0, // Orientation
+		0x88, 0x25, 0, 4, 0, 0, 0, 1, 0, 0, 0, 38, // GPS IFD pointer
+		0, 0, 0, 0, // no next IFD
+		0, 1, // GPS IFD entries
+		0x00, 0x01, 0, 2, 0, 0, 0, 2, 'N', 0, 0, 0, // GPSLatitudeRef
+		0, 0, 0, 0,
+	}
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	size := len(payload) + 2
+
+	res := append([]byte{}, file[:2]...)
+	res = append(res, 0xFF, 0xE1, byte(size>>8), byte(size))
+	res = append(res, payload...)
+	return append(res, file[2:]...)
+}
+
+func TestService_UploadImageNormalization(t *testing.T) {
+	orig, err := os.ReadFile("../../../test_image.jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		file       []byte
+		maxDim     int
+		wantWidth  int
+		wantHeight int
+	}{
+		{name: "ok without exif", file: orig, wantWidth: 400, wantHeight: 200},
+		{name: "ok rotated by exif", file: withExif(orig, 6), wantWidth: 200, wantHeight: 400},
+		{name: "ok upside down", file: withExif(orig, 3), wantWidth: 400, wantHeight: 200},
+		{name: "ok downsized", file: withExif(orig, 6), maxDim: 100, wantWidth: 50, wantHeight: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				stored, thumb []byte
+				objects       = make(map[string]storedObject)
+			)
+
+			s := requests.New(defaultLogger, (&imageQueue{}).repo(), nil, memStorage(objects), "", requests.WithMaxImageDimension(tt.maxDim))
+			if _, err := s.UploadImage(context.Background(), &requests.Image{UserID: 1, RequestID: 1, File: tt.file}); err != nil {
+				t.Fatalf("UploadImage() error = %v", err)
+			}
+
+			if ok, err := s.ProcessImageJob(context.Background()); err != nil || !ok {
+				t.Fatalf("ProcessImageJob() = %v, %v", ok, err)
+			}
+
+			for key, obj := range objects {
+				switch {
+				case strings.HasPrefix(key, requests.ThumbPathPrefix):
+					thumb = obj.body
+				case strings.HasPrefix(key, requests.ImgPathPrefix):
+					stored = obj.body
+				}
+			}
+
+			if bytes.Contains(stored, []byte("Exif")) {
+				t.Error("UploadImage() stored file still has EXIF metadata")
+			}
+
+			img, err := jpeg.Decode(bytes.NewReader(stored))
+			if err != nil {
+				t.Fatalf("UploadImage() stored file is not a jpeg: %v", err)
+			}
+
+			if b := img.Bounds(); b.Dx() != tt.wantWidth || b.Dy() != tt.wantHeight {
+				t.Errorf("UploadImage() stored %dx%d, want %dx%d", b.Dx(), b.Dy(), tt.wantWidth, tt.wantHeight)
+			}
+
+			cfg, err := jpeg.DecodeConfig(bytes.NewReader(thumb))
+			if err != nil {
+				t.Fatalf("UploadImage() thumb is not a jpeg: %v", err)
+			}
+
+			if cfg.Width != 128 || cfg.Height != 128 {
+				t.Errorf("UploadImage() thumb is %dx%d", cfg.Width, cfg.Height)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package requests
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"strconv"
+
+	"github.com/ivch/dynasty/common"
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/common/logger"
+)
+
+const (
+	AvatarPathPrefix      = "avatar/i/"
+	AvatarThumbPathPrefix = "avatar/t/"
+)
+
+// Avatars stores the profile photos with the image settings of the requests, only the thumbnail is rendered.
+type Avatars struct {
+	store        imageStore
+	limits       map[string]int64
+	maxDimension int
+	thumb        ImageVariant
+}
+
+// NewAvatars takes the same options as the requests service, so both share the pipeline settings.
+func NewAvatars(log logger.Logger, store Storage, cdnHost string, opts ...Option) *Avatars {
+	// the options only fill the settings, no service methods are called
+	settings := Service{
+		storage:           store,
+		cdnHost:           cdnHost,
+		log:               log,
+		attachmentLimits:  DefaultAttachmentLimits,
+		maxImageDimension: defaultMaxImageDimension,
+		imageVariants:     DefaultImageVariants,
+		imageURLTTL:       defaultImageURLTTL,
+	}
+
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	return &Avatars{
+		store:        settings.imageStore(),
+		limits:       settings.attachmentLimits,
+		maxDimension: settings.maxImageDimension,
+		thumb:        thumbVariant(settings.imageVariants),
+	}
+}
+
+// SaveAvatar stores the photo with its thumbnail and returns the file name.
+func (a *Avatars) SaveAvatar(ctx context.Context, userID uint, file []byte) (string, error) {
+	fileType, err := checkFileLimits(a.limits, file)
+	if err != nil {
+		return "", err
+	}
+
+	if fileType == ContentTypePDF {
+		return "", errs.FileWrongType
+	}
+
+	img, data, err := normalizeImage(file, a.maxDimension)
+	if errors.Is(err, image.ErrFormat) {
+		return "", errs.FileWrongType
+	}
+	if err != nil {
+		return "", err
+	}
+
+	thumb, err := renderVariant(img, a.thumb)
+	if err != nil {
+		return "", err
+	}
+
+	filename := fmt.Sprintf("%s:%s.jpg", base64.StdEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(userID), 10))), common.RandomString(25))
+
+	if err := a.store.storage.Put(ctx, AvatarPathPrefix+filename, bytes.NewReader(data), a.store.putOptions(ContentTypeJPEG)); err != nil {
+		return "", fmt.Errorf("failed to upload avatar: %w", err)
+	}
+
+	if err := a.store.storage.Put(ctx, AvatarThumbPathPrefix+filename, bytes.NewReader(thumb.Data), a.store.putOptions(thumb.ContentType)); err != nil {
+		if delErr := a.DeleteAvatar(ctx, filename); delErr != nil {
+			a.store.log.Error("failed to delete avatar: %w", delErr)
+		}
+		return "", fmt.Errorf("failed to upload avatar thumb: %w", err)
+	}
+
+	return filename, nil
+}
+
+// DeleteAvatar removes the photo and its thumbnail, missing files are not an error.
+func (a *Avatars) DeleteAvatar(ctx context.Context, filename string) error {
+	for _, key := range []string{AvatarPathPrefix + filename, AvatarThumbPathPrefix + filename} {
+		if err := a.store.storage.Delete(ctx, key); err != nil {
+			return fmt.Errorf("failed to delete avatar %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// AvatarURLs returns the "img" and "thumb" links of the photo.
+func (a *Avatars) AvatarURLs(ctx context.Context, filename string) map[string]string {
+	return a.store.avatarURLs(ctx, filename)
+}
+
+func (st imageStore) avatarURLs(ctx context.Context, filename string) map[string]string {
+	return map[string]string{
+		"img":   st.url(ctx, AvatarPathPrefix+filename),
+		"thumb": st.url(ctx, AvatarThumbPathPrefix+filename),
+	}
+}
+
+// attachAvatarURLs sets the avatar links of the request authors.
+func (s *Service) attachAvatarURLs(ctx context.Context, reqs ...*Request) {
+	store := s.imageStore()
+	for i := range reqs {
+		if u := reqs[i].User; u != nil && u.Avatar != "" {
+			u.AvatarURL = store.avatarURLs(ctx, u.Avatar)
+		}
+	}
+}
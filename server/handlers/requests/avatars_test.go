@@ -0,0 +1,87 @@
+package requests_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/jpeg"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/common/storage"
+	"github.com/ivch/dynasty/server/handlers/requests"
+)
+
+func TestAvatars_SaveAvatar(t *testing.T) {
+	jpg, err := os.ReadFile("../../../test_image.jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		file    []byte
+		putErr  bool
+		wantErr error
+	}{
+		{name: "error pdf is not a photo", file: []byte("%PDF-1.4\n%%EOF\n"), wantErr: errs.FileWrongType},
+		{name: "error not an image", file: []byte("hello"), wantErr: errs.FileWrongType},
+		{name: "error storage", file: jpg, putErr: true, wantErr: errTestError},
+		{name: "ok", file: jpg},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				objects = make(map[string]storedObject)
+				store   = memStorage(objects)
+			)
+
+			if tt.putErr {
+				store.PutFunc = func(_ context.Context, _ string, _ io.Reader, _ storage.PutOptions) error {
+					return errTestError
+				}
+			}
+
+			a := requests.NewAvatars(defaultLogger, store, "cdnHost")
+			filename, err := a.SaveAvatar(context.Background(), 1, tt.file)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SaveAvatar() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				if len(objects) != 0 {
+					t.Errorf("SaveAvatar() left objects %v", objects)
+				}
+				return
+			}
+
+			if len(objects) != 2 {
+				t.Errorf("SaveAvatar() stored %d objects, want photo and thumb", len(objects))
+			}
+
+			thumb, ok := objects[requests.AvatarThumbPathPrefix+filename]
+			if !ok {
+				t.Fatal("SaveAvatar() thumb is not stored")
+			}
+			if cfg, err := jpeg.DecodeConfig(bytes.NewReader(thumb.body)); err != nil || cfg.Width != 128 || cfg.Height != 128 {
+				t.Errorf("SaveAvatar() wrong thumb: %v", err)
+			}
+
+			urls := a.AvatarURLs(context.Background(), filename)
+			if urls["img"] != "cdnHost/"+requests.AvatarPathPrefix+filename || !strings.HasSuffix(urls["thumb"], requests.AvatarThumbPathPrefix+filename) {
+				t.Errorf("AvatarURLs() = %v", urls)
+			}
+
+			if err := a.DeleteAvatar(context.Background(), filename); err != nil {
+				t.Fatalf("DeleteAvatar() error = %v", err)
+			}
+			if len(objects) != 0 {
+				t.Errorf("DeleteAvatar() left objects %v", objects)
+			}
+		})
+	}
+}
@@ -0,0 +1,340 @@
+package requests
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/server/handlers/users"
+)
+
+// minPhoneDigits is the number of trailing digits used to compare phones.
+const minPhoneDigits = 9
+
+// minNameLength is the shortest name matched, the shorter ones are parts of too many words.
+const minNameLength = 4
+
+// Sizes of the blocklist columns, the phone is stored as digits only.
+const (
+	maxPlateLength  = 20
+	maxNameLength   = 100
+	maxPhoneDigits  = 13
+	maxReasonLength = 1000
+)
+
+// maxPlateWords is the most words the plate is written with, e.g. "AA 1234 BB".
+const maxPlateWords = 3
+
+// phoneSeparators may be written between the digits of one phone number, at most
+// maxPhoneSeparators in a row, e.g. "(050) 111-22-33".
+const (
+	phoneSeparators    = " -()"
+	maxPhoneSeparators = 3
+)
+
+// cyrillicPlateLetters maps cyrillic letters allowed on local plates to their latin twins.
+var cyrillicPlateLetters = map[rune]rune{
+	'А': 'A', 'В': 'B', 'Е': 'E', 'І': 'I', 'К': 'K', 'М': 'M',
+	'Н': 'H', 'О': 'O', 'Р': 'P', 'С': 'C', 'Т': 'T', 'Х': 'X',
+}
+
+func (s *Service) Blocklist(ctx context.Context, adminID uint) ([]*BlocklistEntry, error) {
+	if _, err := s.adminByID(ctx, adminID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListBlocklist()
+}
+
+func (s *Service) CreateBlocklistEntry(ctx context.Context, e *BlocklistEntry) (*BlocklistEntry, error) {
+	if _, err := s.adminByID(ctx, e.CreatedBy); err != nil {
+		return nil, err
+	}
+
+	if err := checkBlocklistEntry(e); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateBlocklistEntry(e); err != nil {
+		s.log.Error("error creating blocklist entry: %w", err)
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (s *Service) UpdateBlocklistEntry(ctx context.Context, adminID uint, e *BlocklistEntry) error {
+	if _, err := s.adminByID(ctx, adminID); err != nil {
+		return err
+	}
+
+	if err := checkBlocklistEntry(e); err != nil {
+		return err
+	}
+
+	if _, err := s.repo.GetBlocklistEntry(e.ID); err != nil {
+		return errs.BlocklistEntryNotFound
+	}
+
+	if err := s.repo.UpdateBlocklistEntry(e, adminID); err != nil {
+		s.log.Error("error updating blocklist entry %d: %w", e.ID, err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteBlocklistEntry(ctx context.Context, adminID, id uint) error {
+	if _, err := s.adminByID(ctx, adminID); err != nil {
+		return err
+	}
+
+	if _, err := s.repo.GetBlocklistEntry(id); err != nil {
+		return errs.BlocklistEntryNotFound
+	}
+
+	if err := s.repo.DeleteBlocklistEntry(id, adminID); err != nil {
+		s.log.Error("error deleting blocklist entry %d: %w", id, err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *Service) BlocklistAudit(ctx context.Context, adminID, entryID uint) ([]*BlocklistAudit, error) {
+	if _, err := s.adminByID(ctx, adminID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListBlocklistAudit(entryID)
+}
+
+// checkBlocklist flags or rejects the request matching the blocklist.
+func (s *Service) checkBlocklist(r *Request) error {
+	list, err := s.repo.ActiveBlocklist(time.Now())
+	if err != nil {
+		// the checkpoint must keep working even if the blocklist is unavailable
+		s.log.Error("error getting blocklist: %w", err)
+		return nil
+	}
+
+	r.Blocklisted = matchBlocklist(list, r.Description)
+	for i := range r.Blocklisted {
+		if r.Blocklisted[i].Reject {
+			return errs.RequestBlocklisted
+		}
+	}
+
+	return nil
+}
+
+// flagBlocklisted marks the requests mentioning active blocklist entries.
+func (s *Service) flagBlocklisted(reqs []*Request) {
+	list, err := s.repo.ActiveBlocklist(time.Now())
+	if err != nil {
+		s.log.Error("error getting blocklist: %w", err)
+		return
+	}
+
+	for i := range reqs {
+		reqs[i].Blocklisted = matchBlocklist(list, reqs[i].Description)
+	}
+}
+
+// adminByID returns the user with the given id if it is an administrator.
+func (s *Service) adminByID(ctx context.Context, id uint) (*users.User, error) {
+	admin, err := s.uSrv.UserByID(ctx, id)
+	if err != nil {
+		s.log.Error("error getting admin user: %w", err)
+		return nil, errs.UserNotFound
+	}
+
+	if admin.Role != users.AdminUserRole {
+		return nil, errs.InsufficientPermissions
+	}
+
+	return admin, nil
+}
+
+// checkBlocklistEntry validates the entry against the blocklist columns and keeps only the digits of the phone.
+func checkBlocklistEntry(e *BlocklistEntry) error {
+	if e.Plate == "" && e.Name == "" && e.Phone == "" {
+		return errs.BlocklistEntryEmpty
+	}
+
+	if e.Name != "" && utf8.RuneCountInString(strings.Join(words(e.Name), "")) < minNameLength {
+		return errs.BlocklistNameTooShort
+	}
+
+	if utf8.RuneCountInString(e.Name) > maxNameLength {
+		return errs.BlocklistNameTooLong
+	}
+
+	if utf8.RuneCountInString(e.Plate) > maxPlateLength {
+		return errs.BlocklistPlateTooLong
+	}
+
+	if utf8.RuneCountInString(e.Reason) > maxReasonLength {
+		return errs.BlocklistReasonTooLong
+	}
+
+	if e.Phone != "" {
+		e.Phone = onlyDigits(e.Phone)
+		if len(e.Phone) < minPhoneDigits || len(e.Phone) > maxPhoneDigits {
+			return errs.BlocklistPhoneWrongLength
+		}
+	}
+
+	return nil
+}
+
+func matchBlocklist(list []*BlocklistEntry, text string) []*BlocklistEntry {
+	if text == "" || len(list) == 0 {
+		return nil
+	}
+
+	var (
+		tokens = words(text)
+		plates = make([]string, len(tokens))
+		lower  = make([]string, len(tokens))
+		digits = digitRuns(text)
+		res    []*BlocklistEntry
+	)
+
+	for i := range tokens {
+		plates[i] = normalizePlate(tokens[i])
+		lower[i] = strings.ToLower(tokens[i])
+	}
+
+	for i := range list {
+		if list[i].matches(plates, lower, digits) {
+			res = append(res, list[i])
+		}
+	}
+
+	return res
+}
+
+// matches reports whether the entry is mentioned in the words of the text.
+func (e *BlocklistEntry) matches(plates, lower, digits []string) bool {
+	if plate := normalizePlate(e.Plate); plate != "" && containsPlate(plates, plate) {
+		return true
+	}
+
+	if name := words(strings.ToLower(e.Name)); utf8.RuneCountInString(strings.Join(name, "")) >= minNameLength &&
+		containsWords(lower, name) {
+		return true
+	}
+
+	phone := onlyDigits(e.Phone)
+	if len(phone) < minPhoneDigits {
+		return false
+	}
+
+	for _, number := range digits {
+		if strings.Contains(number, lastN(phone, minPhoneDigits)) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsPlate reports whether up to maxPlateWords consecutive words make the plate.
+func containsPlate(plates []string, plate string) bool {
+	for i := range plates {
+		joined := ""
+		for j := i; j < len(plates) && j < i+maxPlateWords && len(joined) < len(plate); j++ {
+			if joined += plates[j]; joined == plate {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// containsWords reports whether the text words contain the sub words in a row.
+func containsWords(text, sub []string) bool {
+	for i := 0; i+len(sub) <= len(text); i++ {
+		ok := true
+		for j := range sub {
+			if text[i+j] != sub[j] {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// words splits the text into the words of letters and digits.
+func words(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// normalizePlate keeps only letters and digits of the plate in latin upper case.
+func normalizePlate(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(s) {
+		if l, ok := cyrillicPlateLetters[r]; ok {
+			r = l
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// digitRuns returns the digits of every number in the text, the separators inside the number dropped.
+func digitRuns(s string) []string {
+	var (
+		runs []string
+		run  strings.Builder
+		gap  int
+	)
+
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			run.WriteRune(r)
+			gap = 0
+		case run.Len() > 0 && gap < maxPhoneSeparators && strings.ContainsRune(phoneSeparators, r):
+			gap++
+		case run.Len() > 0:
+			runs = append(runs, run.String())
+			run.Reset()
+			gap = 0
+		}
+	}
+
+	if run.Len() > 0 {
+		runs = append(runs, run.String())
+	}
+
+	return runs
+}
+
+func onlyDigits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func lastN(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[len(s)-n:]
+}
@@ -0,0 +1,351 @@
+package requests_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/server/handlers/requests"
+	"github.com/ivch/dynasty/server/handlers/users"
+)
+
+func emptyBlocklist(_ time.Time) ([]*requests.BlocklistEntry, error) {
+	return nil, nil
+}
+
+func adminUsers() *requests.UserServiceMock {
+	return &requests.UserServiceMock{
+		UserByIDFunc: func(_ context.Context, id uint) (*users.User, error) {
+			switch id {
+			case 1:
+				return &users.User{ID: 1, Role: users.AdminUserRole}, nil
+			case 3:
+				return &users.User{ID: 3, Role: users.GuardUserRole}, nil
+			}
+			return nil, errTestError
+		},
+	}
+}
+
+func TestService_CreateBlocklisted(t *testing.T) {
+	blocklist := []*requests.BlocklistEntry{
+		{ID: 1, Plate: "АА 1234 ВВ", Reason: "damaged the gate"},
+		{ID: 2, Name: "Vasyl Pupkin", Reason: "trespassing"},
+		{ID: 3, Phone: "380501112233", Reason: "spam", Reject: true},
+		{ID: 4, Plate: "AB 1234", Reason: "speeding"},
+		{ID: 5, Name: "Ivan", Reason: "threats"},
+	}
+
+	tests := []struct {
+		name        string
+		description string
+		wantErr     error
+		wantMatches []uint
+	}{
+		{name: "ok no match", description: "Taxi KA 5555 BB"},
+		{name: "ok plate in latin", description: "guest car aa1234bb", wantMatches: []uint{1}},
+		{name: "ok plate with dashes", description: "AA-1234-BB, silver", wantMatches: []uint{1}},
+		{name: "ok name", description: "visitor VASYL PUPKIN at 18:00", wantMatches: []uint{2}},
+		{name: "ok several", description: "vasyl pupkin AA1234BB", wantMatches: []uint{1, 2}},
+		{name: "ok name as word", description: "Ivan, plumber", wantMatches: []uint{5}},
+		{name: "ok no plate inside word", description: "truck XAA1234BBX"},
+		{name: "ok no plate across words", description: "cab 1234 at the gate"},
+		{name: "ok no name inside word", description: "Ivanna with Vasyl Pupkinson"},
+		{name: "ok no name out of order", description: "Pupkin Vasyl"},
+		{name: "ok no phone across numbers", description: "car AA 0501 BB to apt 112233"},
+		{name: "ok no phone across adjacent numbers", description: "apt 0501, code 112233"},
+		{name: "error rejected phone", description: "courier 050 111-22-33", wantErr: errs.RequestBlocklisted},
+		{name: "error rejected phone in parentheses", description: "call +38 (050) 111 22 33", wantErr: errs.RequestBlocklisted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created := false
+			repo := &requests.RequestsRepositoryMock{
+				ListByUserFunc: func(_ *requests.RequestListFilter) ([]*requests.Request, error) {
+					return nil, nil
+				},
+				ActiveBlocklistFunc: func(_ time.Time) ([]*requests.BlocklistEntry, error) {
+					return blocklist, nil
+				},
+				CreateFunc: func(_ *requests.Request, _ requests.EventFunc) error {
+					created = true
+					return nil
+				},
+			}
+
+			s := requests.New(defaultLogger, repo, nil, nil, "")
+			got, err := s.Create(context.Background(), &requests.Request{UserID: 1, Description: tt.description})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Create() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantErr != nil {
+				if created {
+					t.Error("Create() stored rejected request")
+				}
+				return
+			}
+
+			if len(got.Blocklisted) != len(tt.wantMatches) {
+				t.Fatalf("Create() matched %d entries, want %v", len(got.Blocklisted), tt.wantMatches)
+			}
+
+			for i := range tt.wantMatches {
+				if got.Blocklisted[i].ID != tt.wantMatches[i] {
+					t.Errorf("Create() matched entry %d, want %d", got.Blocklisted[i].ID, tt.wantMatches[i])
+				}
+			}
+		})
+	}
+}
+
+func TestService_GuardRequestListBlocklisted(t *testing.T) {
+	repo := &requests.RequestsRepositoryMock{
+		ListForGuardFunc: func(_ *requests.RequestListFilter) ([]*requests.Request, error) {
+			return []*requests.Request{{ID: 1, Description: "taxi"}, {ID: 2, Description: "AA1234BB"}}, nil
+		},
+		CountForGuardFunc: func(_ *requests.RequestListFilter) (int, error) {
+			return 2, nil
+		},
+		ActiveBlocklistFunc: func(_ time.Time) ([]*requests.BlocklistEntry, error) {
+			return []*requests.BlocklistEntry{{ID: 1, Plate: "AA1234BB", Reject: true}}, nil
+		},
+	}
+
+	s := requests.New(defaultLogger, repo, nil, nil, "")
+	got, _, err := s.GuardRequestList(context.Background(), &requests.RequestListFilter{Limit: 2})
+	if err != nil {
+		t.Fatalf("GuardRequestList() error = %v", err)
+	}
+
+	if len(got[0].Blocklisted) != 0 || len(got[1].Blocklisted) != 1 {
+		t.Errorf("GuardRequestList() wrong blocklist flags %v %v", got[0].Blocklisted, got[1].Blocklisted)
+	}
+}
+
+func TestService_CreateBlocklistEntry(t *testing.T) {
+	tests := []struct {
+		name    string
+		repo    requests.RequestsRepository
+		req     *requests.BlocklistEntry
+		wantErr error
+	}{
+		{
+			name:    "error unknown user",
+			req:     &requests.BlocklistEntry{CreatedBy: 2, Plate: "AA1234BB"},
+			wantErr: errs.UserNotFound,
+		},
+		{
+			name:    "error not an admin",
+			req:     &requests.BlocklistEntry{CreatedBy: 3, Plate: "AA1234BB"},
+			wantErr: errs.InsufficientPermissions,
+		},
+		{
+			name:    "error empty entry",
+			req:     &requests.BlocklistEntry{CreatedBy: 1, Reason: "why not"},
+			wantErr: errs.BlocklistEntryEmpty,
+		},
+		{
+			name:    "error name too short",
+			req:     &requests.BlocklistEntry{CreatedBy: 1, Name: "Li"},
+			wantErr: errs.BlocklistNameTooShort,
+		},
+		{
+			name:    "error name too long",
+			req:     &requests.BlocklistEntry{CreatedBy: 1, Name: strings.Repeat("Вася ", 21)},
+			wantErr: errs.BlocklistNameTooLong,
+		},
+		{
+			name:    "error plate too long",
+			req:     &requests.BlocklistEntry{CreatedBy: 1, Plate: "AA 1234 BB AA 1234 BB"},
+			wantErr: errs.BlocklistPlateTooLong,
+		},
+		{
+			name:    "error reason too long",
+			req:     &requests.BlocklistEntry{CreatedBy: 1, Plate: "AA1234BB", Reason: strings.Repeat("a", 1001)},
+			wantErr: errs.BlocklistReasonTooLong,
+		},
+		{
+			name:    "error phone too short",
+			req:     &requests.BlocklistEntry{CreatedBy: 1, Phone: "111-22-33"},
+			wantErr: errs.BlocklistPhoneWrongLength,
+		},
+		{
+			name:    "error phone too long",
+			req:     &requests.BlocklistEntry{CreatedBy: 1, Phone: "+38 (050) 111-22-33-44"},
+			wantErr: errs.BlocklistPhoneWrongLength,
+		},
+		{
+			name: "error from db",
+			repo: &requests.RequestsRepositoryMock{
+				CreateBlocklistEntryFunc: func(_ *requests.BlocklistEntry) error {
+					return errTestError
+				},
+			},
+			req:     &requests.BlocklistEntry{CreatedBy: 1, Plate: "AA1234BB"},
+			wantErr: errTestError,
+		},
+		{
+			name: "ok",
+			repo: &requests.RequestsRepositoryMock{
+				CreateBlocklistEntryFunc: func(e *requests.BlocklistEntry) error {
+					e.ID = 1
+					return nil
+				},
+			},
+			req: &requests.BlocklistEntry{CreatedBy: 1, Plate: "AA1234BB"},
+		},
+		{
+			name: "ok formatted phone",
+			repo: &requests.RequestsRepositoryMock{
+				CreateBlocklistEntryFunc: func(e *requests.BlocklistEntry) error {
+					if e.Phone != "380501112233" {
+						return errTestError
+					}
+					e.ID = 1
+					return nil
+				},
+			},
+			req: &requests.BlocklistEntry{CreatedBy: 1, Phone: "+38 (050) 111-22-33"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := requests.New(defaultLogger, tt.repo, adminUsers(), nil, "")
+			got, err := s.CreateBlocklistEntry(context.Background(), tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CreateBlocklistEntry() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantErr == nil && got.ID != 1 {
+				t.Errorf("CreateBlocklistEntry() got = %#v", got)
+			}
+		})
+	}
+}
+
+func TestService_UpdateBlocklistEntry(t *testing.T) {
+	tests := []struct {
+		name    string
+		repo    requests.RequestsRepository
+		adminID uint
+		req     *requests.BlocklistEntry
+		wantErr error
+	}{
+		{
+			name:    "error not an admin",
+			adminID: 3,
+			req:     &requests.BlocklistEntry{ID: 1, Plate: "AA1234BB"},
+			wantErr: errs.InsufficientPermissions,
+		},
+		{
+			name:    "error empty entry",
+			adminID: 1,
+			req:     &requests.BlocklistEntry{ID: 1},
+			wantErr: errs.BlocklistEntryEmpty,
+		},
+		{
+			name: "error no entry",
+			repo: &requests.RequestsRepositoryMock{
+				GetBlocklistEntryFunc: func(_ uint) (*requests.BlocklistEntry, error) {
+					return nil, errTestError
+				},
+			},
+			adminID: 1,
+			req:     &requests.BlocklistEntry{ID: 1, Plate: "AA1234BB"},
+			wantErr: errs.BlocklistEntryNotFound,
+		},
+		{
+			name: "error from db",
+			repo: &requests.RequestsRepositoryMock{
+				GetBlocklistEntryFunc: func(id uint) (*requests.BlocklistEntry, error) {
+					return &requests.BlocklistEntry{ID: id}, nil
+				},
+				UpdateBlocklistEntryFunc: func(_ *requests.BlocklistEntry, _ uint) error {
+					return errTestError
+				},
+			},
+			adminID: 1,
+			req:     &requests.BlocklistEntry{ID: 1, Plate: "AA1234BB"},
+			wantErr: errTestError,
+		},
+		{
+			name: "ok",
+			repo: &requests.RequestsRepositoryMock{
+				GetBlocklistEntryFunc: func(id uint) (*requests.BlocklistEntry, error) {
+					return &requests.BlocklistEntry{ID: id}, nil
+				},
+				UpdateBlocklistEntryFunc: func(_ *requests.BlocklistEntry, adminID uint) error {
+					if adminID != 1 {
+						return errTestError
+					}
+					return nil
+				},
+			},
+			adminID: 1,
+			req:     &requests.BlocklistEntry{ID: 1, Plate: "AA1234BB"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := requests.New(defaultLogger, tt.repo, adminUsers(), nil, "")
+			if err := s.UpdateBlocklistEntry(context.Background(), tt.adminID, tt.req); !errors.Is(err, tt.wantErr) {
+				t.Errorf("UpdateBlocklistEntry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestService_DeleteBlocklistEntry(t *testing.T) {
+	tests := []struct {
+		name    string
+		repo    requests.RequestsRepository
+		adminID uint
+		wantErr error
+	}{
+		{
+			name:    "error not an admin",
+			adminID: 3,
+			wantErr: errs.InsufficientPermissions,
+		},
+		{
+			name: "error no entry",
+			repo: &requests.RequestsRepositoryMock{
+				GetBlocklistEntryFunc: func(_ uint) (*requests.BlocklistEntry, error) {
+					return nil, errTestError
+				},
+			},
+			adminID: 1,
+			wantErr: errs.BlocklistEntryNotFound,
+		},
+		{
+			name: "ok",
+			repo: &requests.RequestsRepositoryMock{
+				GetBlocklistEntryFunc: func(id uint) (*requests.BlocklistEntry, error) {
+					return &requests.BlocklistEntry{ID: id}, nil
+				},
+				DeleteBlocklistEntryFunc: func(_, _ uint) error {
+					return nil
+				},
+			},
+			adminID: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := requests.New(defaultLogger, tt.repo, adminUsers(), nil, "")
+			if err := s.DeleteBlocklistEntry(context.Background(), tt.adminID, 1); !errors.Is(err, tt.wantErr) {
+				t.Errorf("DeleteBlocklistEntry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/lib/pq"
 
+	"github.com/ivch/dynasty/common/queue"
 	"github.com/ivch/dynasty/server/handlers/users"
 )
 
@@ -14,6 +15,7 @@ type Request struct {
 	Type        string              `json:"type"`
 	Rtype       RequestType         `json:"rtype"`
 	UserID      uint                `json:"user_id" gorm:"user_id"`
+	GuardID     *uint               `json:"guard_id,omitempty" gorm:"guard_id"`
 	Time        int64               `json:"time"`
 	Description string              `json:"description"`
 	Status      string              `json:"status"`
@@ -21,6 +23,7 @@ type Request struct {
 	History     pq.StringArray      `json:"-" gorm:"type:text[]"`
 	ImagesURL   []map[string]string `json:"images" gorm:"-"`
 	User        *users.User         `json:"user,omitempty"`
+	Blocklisted []*BlocklistEntry   `json:"-" gorm:"-"`
 	CreatedAt   *time.Time
 	DeletedAt   *time.Time
 }
@@ -37,6 +40,18 @@ type UpdateRequest struct {
 
 func (Request) TableName() string { return "requests" }
 
+// WalkInRequest is a request created by the guard on behalf of the resident.
+type WalkInRequest struct {
+	GuardID           uint
+	BuildingID        uint
+	Apartment         uint
+	Type              string
+	Rtype             RequestType
+	Time              int64
+	Description       string
+	AwaitConfirmation bool
+}
+
 type RequestListFilter struct {
 	DateFrom  *time.Time `json:"date_from,omitempty"`
 	DateTo    *time.Time `json:"date_to,omitempty"`
@@ -47,15 +62,16 @@ type RequestListFilter struct {
 	Limit     uint       `json:"limit" validate:"required,min=1"`
 	UserID    uint       `json:"user_id,omitempty"`
 	Apartment string     `json:"apartment,omitempty" validate:"omitempty,numeric"`
-	Status    string     `json:"status,omitempty" validate:"oneof=all new closed"`
+	Status    string     `json:"status,omitempty" validate:"oneof=all new pending closed"`
 }
 
 type Image struct {
-	UserID    uint
-	RequestID uint
-	File      []byte
-	URL       string
-	Thumb     string
+	UserID     uint
+	RequestID  uint
+	File       []byte
+	URL        string
+	Thumb      string
+	Processing bool
 }
 
 type HistoryRecord struct {
@@ -68,8 +84,98 @@ func (h *HistoryRecord) String() string {
 	return fmt.Sprintf("%s@%s@%d", h.Time.Format("2006-01-02 15:04"), h.Action, h.UserID)
 }
 
+const (
+	ApprovalPending  = "pending"
+	ApprovalApproved = "approved"
+	ApprovalDenied   = "denied"
+	ApprovalExpired  = "expired"
+)
+
+// Approval is a "visitor at the gate" check raised by the guard for an apartment.
+type Approval struct {
+	ID            uint       `json:"id"`
+	GuardID       uint       `json:"guard_id"`
+	BuildingID    uint       `json:"building_id"`
+	Apartment     uint       `json:"apartment"`
+	Description   string     `json:"description"`
+	Status        string     `json:"status"`
+	DecidedBy     *uint      `json:"decided_by,omitempty"`
+	DecidedByName string     `json:"decided_by_name,omitempty" gorm:"-"`
+	DecidedAt     *time.Time `json:"decided_at,omitempty"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	CreatedAt     *time.Time `json:"created_at,omitempty"`
+}
+
+func (Approval) TableName() string { return "approvals" }
+
+type ApprovalDecision struct {
+	ID      uint
+	UserID  uint
+	Approve bool
+}
+
+const (
+	BlocklistActionCreate = "create"
+	BlocklistActionUpdate = "update"
+	BlocklistActionDelete = "delete"
+)
+
+// BlocklistEntry is a vehicle or person banned from entry by the management.
+type BlocklistEntry struct {
+	ID        uint       `json:"id"`
+	Plate     string     `json:"plate"`
+	Name      string     `json:"name"`
+	Phone     string     `json:"phone"`
+	Reason    string     `json:"reason"`
+	Reject    bool       `json:"reject"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedBy uint       `json:"created_by"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	DeletedAt *time.Time `json:"-"`
+}
+
+func (BlocklistEntry) TableName() string { return "blocklist" }
+
+// BlocklistAudit records who changed the blocklist entry and its state after the change.
+type BlocklistAudit struct {
+	ID        uint
+	EntryID   uint
+	AdminID   uint
+	Action    string
+	Data      string
+	CreatedAt *time.Time
+}
+
+func (BlocklistAudit) TableName() string { return "blocklist_audit" }
+
 type RequestStats struct {
 	Total  int `json:"total"`
 	Open   int `json:"open"`
 	Closed int `json:"closed"`
 }
+
+const (
+	ImageJobPending = queue.StatusPending
+	ImageJobRunning = queue.StatusRunning
+	ImageJobDone    = "done"
+	ImageJobFailed  = "failed"
+)
+
+// ImageJob is the uploaded file waiting to be processed and stored.
+type ImageJob struct {
+	ID          uint
+	RequestID   uint
+	UserID      uint
+	Filename    string
+	ContentType string
+	Status      string
+	Attempts    int
+	LastError   string
+	RunAt       time.Time
+	LockedUntil *time.Time
+	CreatedAt   *time.Time
+	UpdatedAt   *time.Time
+}
+
+func (ImageJob) TableName() string { return "image_jobs" }
@@ -2,9 +2,15 @@ package requests
 
 import (
 	"context"
+	"errors"
+	"strconv"
+
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/server/handlers/outbox"
+	"github.com/ivch/dynasty/server/handlers/users"
 )
 
-func (s *Service) GuardRequestList(_ context.Context, r *RequestListFilter) ([]*Request, int, error) {
+func (s *Service) GuardRequestList(ctx context.Context, r *RequestListFilter) ([]*Request, int, error) {
 	reqs, err := s.repo.ListForGuard(r)
 	if err != nil {
 		return nil, 0, err
@@ -15,18 +21,70 @@ func (s *Service) GuardRequestList(_ context.Context, r *RequestListFilter) ([]*
 		return nil, 0, err
 	}
 
-	for i := range reqs {
-		reqs[i].ImagesURL = make([]map[string]string, len(reqs[i].Images))
-		for j := range reqs[i].Images {
-			reqs[i].ImagesURL[j] = s.buildImageURL(reqs[i].Images[j])
-		}
-	}
+	s.attachImageURLs(ctx, reqs...)
+	s.attachAvatarURLs(ctx, reqs...)
+
+	s.flagBlocklisted(reqs)
 
 	return reqs, cnt, nil
 }
 
 func (s *Service) GuardUpdateRequest(_ context.Context, r *Request) error {
-	return s.repo.UpdateForGuard(r.ID, r.Status)
+	var event EventFunc
+	if s.notifier != nil {
+		event = func(req *Request) (*outbox.Message, error) {
+			return s.notifier.EventMessage(req.UserID, EventRequestStatusChanged, map[string]string{
+				"id":     strconv.FormatUint(uint64(req.ID), 10),
+				"status": req.Status,
+			})
+		}
+	}
+
+	return s.repo.UpdateForGuard(r.ID, r.Status, event)
+}
+
+func (s *Service) GuardCreateRequest(ctx context.Context, r *WalkInRequest) (*Request, error) {
+	guard, err := s.guardByID(ctx, r.GuardID)
+	if err != nil {
+		return nil, err
+	}
+
+	resident, err := s.uSrv.UserByApartment(ctx, r.BuildingID, r.Apartment)
+	if err != nil {
+		return nil, err
+	}
+
+	// the placeholder of the apartment reset by the admin and the inactive account do not live there
+	if resident.Role == users.PredefinedUserRole || !resident.Active {
+		return nil, errs.UserNotFound
+	}
+
+	req := Request{
+		Type:        r.Type,
+		Rtype:       r.Rtype,
+		UserID:      resident.ID,
+		GuardID:     &guard.ID,
+		Time:        r.Time,
+		Description: r.Description,
+		Status:      defaultRequestStatus,
+	}
+
+	normalizeRequestType(&req)
+
+	if r.AwaitConfirmation {
+		req.Status = pendingRequestStatus
+	}
+
+	if err := s.checkBlocklist(&req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(&req, nil); err != nil {
+		s.log.Error("error creating walk-in request: %w", err)
+		return nil, errors.New("failed to create request")
+	}
+
+	return &req, nil
 }
 
 func (s *Service) GuardStats24h(_ context.Context) (*RequestStats, error) {
@@ -42,3 +100,18 @@ func (s *Service) GuardStats24h(_ context.Context) (*RequestStats, error) {
 		Closed: closed,
 	}, nil
 }
+
+// guardByID returns the user with the given id if it is allowed to act as a guard.
+func (s *Service) guardByID(ctx context.Context, id uint) (*users.User, error) {
+	guard, err := s.uSrv.UserByID(ctx, id)
+	if err != nil {
+		s.log.Error("error getting guard user: %w", err)
+		return nil, errs.UserNotFound
+	}
+
+	if guard.Role != users.GuardUserRole && guard.Role != users.AdminUserRole {
+		return nil, errs.InsufficientPermissions
+	}
+
+	return guard, nil
+}
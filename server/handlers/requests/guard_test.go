@@ -2,10 +2,12 @@ package requests_test
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"reflect"
 	"testing"
 
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/server/handlers/outbox"
 	"github.com/ivch/dynasty/server/handlers/requests"
 	"github.com/ivch/dynasty/server/handlers/users"
 )
@@ -69,14 +71,19 @@ func TestService_GuardRequestList(t *testing.T) {
 								Phone:     "1",
 								FirstName: "1",
 								LastName:  "1",
+								Avatar:    "me.jpg",
 							},
 							Images: []string{"a"},
 						},
 					}, nil
 				},
+				ProcessingImagesFunc: func(_ []uint) ([]string, error) {
+					return nil, nil
+				},
 				CountForGuardFunc: func(req *requests.RequestListFilter) (int, error) {
 					return 1, nil
 				},
+				ActiveBlocklistFunc: emptyBlocklist,
 			},
 			req: &requests.RequestListFilter{
 				UserID: 1,
@@ -99,13 +106,15 @@ func TestService_GuardRequestList(t *testing.T) {
 						Phone:     "1",
 						FirstName: "1",
 						LastName:  "1",
+						Avatar:    "me.jpg",
+						AvatarURL: map[string]string{
+							"img":   "cdnHost/" + requests.AvatarPathPrefix + "me.jpg",
+							"thumb": "cdnHost/" + requests.AvatarThumbPathPrefix + "me.jpg",
+						},
 					},
 					Images: []string{"a"},
 					ImagesURL: []map[string]string{
-						{
-							"img":   fmt.Sprintf("cdnHost/%s%s", requests.ImgPathPrefix, "a"),
-							"thumb": fmt.Sprintf("cdnHost/%s%s", requests.ThumbPathPrefix, "a"),
-						},
+						cdnImageURLs("a"),
 					},
 				},
 			},
@@ -115,7 +124,7 @@ func TestService_GuardRequestList(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := requests.New(defaultLogger, tt.repo, nil, "", "cdnHost")
+			s := requests.New(defaultLogger, tt.repo, nil, nil, "cdnHost")
 			got, cnt, err := s.GuardRequestList(context.Background(), tt.req)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GuardRequestList() error = %v, wantErr %v", err, tt.wantErr)
@@ -141,7 +150,7 @@ func TestService_GuardUpdateRequest(t *testing.T) {
 		{
 			name: "error from db",
 			repo: &requests.RequestsRepositoryMock{
-				UpdateForGuardFunc: func(_ uint, _ string) error {
+				UpdateForGuardFunc: func(_ uint, _ string, _ requests.EventFunc) error {
 					return errTestError
 				},
 			},
@@ -154,7 +163,7 @@ func TestService_GuardUpdateRequest(t *testing.T) {
 		{
 			name: "ok",
 			repo: &requests.RequestsRepositoryMock{
-				UpdateForGuardFunc: func(_ uint, _ string) error {
+				UpdateForGuardFunc: func(_ uint, _ string, _ requests.EventFunc) error {
 					return nil
 				},
 			},
@@ -168,7 +177,7 @@ func TestService_GuardUpdateRequest(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := requests.New(defaultLogger, tt.repo, nil, "", "")
+			s := requests.New(defaultLogger, tt.repo, nil, nil, "")
 			err := s.GuardUpdateRequest(context.Background(), tt.req)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GuardUpdateRequest() error = %v, wantErr %v", err, tt.wantErr)
@@ -177,3 +186,211 @@ func TestService_GuardUpdateRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestService_GuardUpdateRequestNotifies(t *testing.T) {
+	var msg *outbox.Message
+	repo := &requests.RequestsRepositoryMock{
+		UpdateForGuardFunc: func(id uint, status string, event requests.EventFunc) error {
+			var err error
+			msg, err = event(&requests.Request{ID: id, UserID: 5, Status: status})
+			return err
+		},
+	}
+	notifier := &requests.NotifierMock{
+		EventMessageFunc: func(_ uint, _ string, _ map[string]string) (*outbox.Message, error) {
+			return &outbox.Message{Topic: "event"}, nil
+		},
+	}
+
+	s := requests.New(defaultLogger, repo, nil, nil, "", requests.WithNotifier(notifier))
+	if err := s.GuardUpdateRequest(context.Background(), &requests.Request{ID: 1, Status: "closed"}); err != nil {
+		t.Fatalf("GuardUpdateRequest() error = %v", err)
+	}
+
+	calls := notifier.EventMessageCalls()
+	if len(calls) != 1 || msg == nil {
+		t.Fatalf("GuardUpdateRequest() notified %d times, want 1", len(calls))
+	}
+
+	want := map[string]string{"id": "1", "status": "closed"}
+	if calls[0].UserID != 5 || calls[0].Event != requests.EventRequestStatusChanged || !reflect.DeepEqual(calls[0].Data, want) {
+		t.Errorf("GuardUpdateRequest() notified %d %s %v", calls[0].UserID, calls[0].Event, calls[0].Data)
+	}
+}
+
+func TestService_GuardCreateRequest(t *testing.T) {
+	guardID := uint(3)
+
+	tests := []struct {
+		name    string
+		repo    requests.RequestsRepository
+		uSrv    requests.UserService
+		req     *requests.WalkInRequest
+		wantErr error
+		want    *requests.Request
+	}{
+		{
+			name: "error no guard",
+			uSrv: &requests.UserServiceMock{
+				UserByIDFunc: func(_ context.Context, _ uint) (*users.User, error) {
+					return nil, errTestError
+				},
+			},
+			req:     &requests.WalkInRequest{GuardID: guardID},
+			wantErr: errs.UserNotFound,
+		},
+		{
+			name: "error not a guard",
+			uSrv: &requests.UserServiceMock{
+				UserByIDFunc: func(_ context.Context, id uint) (*users.User, error) {
+					return &users.User{ID: id, Role: users.DefaultUserRole}, nil
+				},
+			},
+			req:     &requests.WalkInRequest{GuardID: guardID},
+			wantErr: errs.InsufficientPermissions,
+		},
+		{
+			name: "error no resident",
+			uSrv: &requests.UserServiceMock{
+				UserByIDFunc: func(_ context.Context, id uint) (*users.User, error) {
+					return &users.User{ID: id, Role: users.GuardUserRole}, nil
+				},
+				UserByApartmentFunc: func(_ context.Context, _ uint, _ uint) (*users.User, error) {
+					return nil, errs.UserNotFound
+				},
+			},
+			req:     &requests.WalkInRequest{GuardID: guardID, BuildingID: 1, Apartment: 12},
+			wantErr: errs.UserNotFound,
+		},
+		{
+			name: "error placeholder resident",
+			uSrv: &requests.UserServiceMock{
+				UserByIDFunc: func(_ context.Context, id uint) (*users.User, error) {
+					return &users.User{ID: id, Role: users.GuardUserRole}, nil
+				},
+				UserByApartmentFunc: func(_ context.Context, _ uint, _ uint) (*users.User, error) {
+					return &users.User{ID: 7, Role: users.PredefinedUserRole, Active: true}, nil
+				},
+			},
+			req:     &requests.WalkInRequest{GuardID: guardID, BuildingID: 1, Apartment: 12},
+			wantErr: errs.UserNotFound,
+		},
+		{
+			name: "error inactive resident",
+			uSrv: &requests.UserServiceMock{
+				UserByIDFunc: func(_ context.Context, id uint) (*users.User, error) {
+					return &users.User{ID: id, Role: users.GuardUserRole}, nil
+				},
+				UserByApartmentFunc: func(_ context.Context, _ uint, _ uint) (*users.User, error) {
+					return &users.User{ID: 7}, nil
+				},
+			},
+			req:     &requests.WalkInRequest{GuardID: guardID, BuildingID: 1, Apartment: 12},
+			wantErr: errs.UserNotFound,
+		},
+		{
+			name: "error from db",
+			repo: &requests.RequestsRepositoryMock{
+				ActiveBlocklistFunc: emptyBlocklist,
+				CreateFunc: func(_ *requests.Request, _ requests.EventFunc) error {
+					return errTestError
+				},
+			},
+			uSrv: &requests.UserServiceMock{
+				UserByIDFunc: func(_ context.Context, id uint) (*users.User, error) {
+					return &users.User{ID: id, Role: users.GuardUserRole}, nil
+				},
+				UserByApartmentFunc: func(_ context.Context, _ uint, _ uint) (*users.User, error) {
+					return &users.User{ID: 7, Active: true}, nil
+				},
+			},
+			req:     &requests.WalkInRequest{GuardID: guardID, BuildingID: 1, Apartment: 12},
+			wantErr: errors.New("failed to create request"),
+		},
+		{
+			name: "ok",
+			repo: &requests.RequestsRepositoryMock{
+				ActiveBlocklistFunc: emptyBlocklist,
+				CreateFunc: func(req *requests.Request, _ requests.EventFunc) error {
+					req.ID = 1
+					return nil
+				},
+			},
+			uSrv: &requests.UserServiceMock{
+				UserByIDFunc: func(_ context.Context, id uint) (*users.User, error) {
+					return &users.User{ID: id, Role: users.GuardUserRole}, nil
+				},
+				UserByApartmentFunc: func(_ context.Context, _ uint, _ uint) (*users.User, error) {
+					return &users.User{ID: 7, Active: true}, nil
+				},
+			},
+			req: &requests.WalkInRequest{
+				GuardID:     guardID,
+				BuildingID:  1,
+				Apartment:   12,
+				Type:        "guest",
+				Time:        1,
+				Description: "visitor",
+			},
+			want: &requests.Request{
+				ID:          1,
+				Type:        "guest",
+				Rtype:       requests.Guest,
+				UserID:      7,
+				GuardID:     &guardID,
+				Time:        1,
+				Description: "visitor",
+				Status:      "new",
+			},
+		},
+		{
+			name: "ok awaiting confirmation",
+			repo: &requests.RequestsRepositoryMock{
+				ActiveBlocklistFunc: emptyBlocklist,
+				CreateFunc: func(req *requests.Request, _ requests.EventFunc) error {
+					req.ID = 1
+					return nil
+				},
+			},
+			uSrv: &requests.UserServiceMock{
+				UserByIDFunc: func(_ context.Context, id uint) (*users.User, error) {
+					return &users.User{ID: id, Role: users.AdminUserRole}, nil
+				},
+				UserByApartmentFunc: func(_ context.Context, _ uint, _ uint) (*users.User, error) {
+					return &users.User{ID: 7, Active: true}, nil
+				},
+			},
+			req: &requests.WalkInRequest{
+				GuardID:           guardID,
+				BuildingID:        1,
+				Apartment:         12,
+				Rtype:             requests.Delivery,
+				Time:              1,
+				AwaitConfirmation: true,
+			},
+			want: &requests.Request{
+				ID:      1,
+				Type:    "delivery",
+				Rtype:   requests.Delivery,
+				UserID:  7,
+				GuardID: &guardID,
+				Time:    1,
+				Status:  "pending",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := requests.New(defaultLogger, tt.repo, tt.uSrv, nil, "")
+			got, err := s.GuardCreateRequest(context.Background(), tt.req)
+			if (err != nil) != (tt.wantErr != nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Errorf("GuardCreateRequest() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GuardCreateRequest() got = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
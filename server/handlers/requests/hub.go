@@ -0,0 +1,65 @@
+package requests
+
+import (
+	"strconv"
+	"sync"
+)
+
+const hubBufferSize = 8
+
+// hub is an in-process publish/subscribe broker of the approval updates, see ApprovalEvents for the other instances.
+type hub struct {
+	mu   sync.RWMutex
+	subs map[string]map[chan *Approval]struct{}
+}
+
+func newHub() *hub {
+	return &hub{subs: make(map[string]map[chan *Approval]struct{})}
+}
+
+// subscribe returns the updates of the topic and the func releasing them.
+func (h *hub) subscribe(topic string) (<-chan *Approval, func()) {
+	ch := make(chan *Approval, hubBufferSize)
+
+	h.mu.Lock()
+	if _, ok := h.subs[topic]; !ok {
+		h.subs[topic] = make(map[chan *Approval]struct{})
+	}
+	h.subs[topic][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs[topic], ch)
+			if len(h.subs[topic]) == 0 {
+				delete(h.subs, topic)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// publish delivers the update to every subscriber of the topic without blocking.
+func (h *hub) publish(topic string, a *Approval) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for ch := range h.subs[topic] {
+		upd := *a
+		select {
+		case ch <- &upd:
+		default:
+		}
+	}
+}
+
+func approvalTopic(id uint) string {
+	return "approval:" + strconv.FormatUint(uint64(id), 10)
+}
+
+func userTopic(id uint) string {
+	return "user:" + strconv.FormatUint(uint64(id), 10)
+}
@@ -0,0 +1,156 @@
+package requests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/common/queue"
+	"github.com/ivch/dynasty/common/storage"
+)
+
+const (
+	defaultImageJobAttempts = 5
+	defaultImageJobBackoff  = 5 * time.Second
+	maxImageJobBackoff      = 10 * time.Minute
+	imageJobLease           = 2 * time.Minute
+)
+
+// WithImageJobRetries sets the max attempts of an image job and the initial delay between them.
+func WithImageJobRetries(attempts int, backoff time.Duration) Option {
+	return func(s *Service) {
+		if attempts > 0 {
+			s.imageJobAttempts = attempts
+		}
+		if backoff > 0 {
+			s.imageJobBackoff = backoff
+		}
+	}
+}
+
+// RunImageWorkers processes the queued uploads with the given number of workers, see queue.Work.
+func (s *Service) RunImageWorkers(ctx context.Context, workers int, pollInterval time.Duration) {
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.imageWorker(ctx, pollInterval)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Service) imageWorker(ctx context.Context, pollInterval time.Duration) {
+	queue.Work(ctx, pollInterval, s.imageJobsWake, s.ProcessImageJob, func(err error) {
+		s.log.Error("error processing image job: %w", err)
+	})
+}
+
+// wakeImageWorkers lets an idle worker pick up the new job without waiting for the next poll.
+func (s *Service) wakeImageWorkers() {
+	select {
+	case s.imageJobsWake <- struct{}{}:
+	default:
+	}
+}
+
+// ProcessImageJob claims the next due job and processes it. It reports false when the queue is empty.
+func (s *Service) ProcessImageJob(ctx context.Context) (bool, error) {
+	job, err := s.repo.ClaimImageJob(time.Now(), imageJobLease)
+	if err != nil {
+		return false, err
+	}
+
+	if job == nil {
+		return false, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, imageJobLease)
+	defer cancel()
+
+	if err := s.processImageJob(ctx, job); err != nil {
+		return true, s.retryImageJob(ctx, job, err)
+	}
+
+	return true, nil
+}
+
+func (s *Service) processImageJob(ctx context.Context, job *ImageJob) error {
+	uploadPath := s.buildImagePath(UploadPathPrefix, job.Filename)
+
+	rc, err := s.storage.Get(ctx, uploadPath)
+	if err != nil {
+		return fmt.Errorf("failed to get upload: %w", err)
+	}
+	file, err := io.ReadAll(rc)
+	rc.Close() // nolint: errcheck
+	if err != nil {
+		return fmt.Errorf("failed to read upload: %w", err)
+	}
+
+	a, err := s.prepareAttachment(file)
+	if err != nil {
+		return err
+	}
+
+	if err := s.storage.Put(ctx, s.buildImagePath(ImgPathPrefix, job.Filename), bytes.NewReader(a.File), s.imagePutOptions(a.ContentType)); err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+
+	if err := s.putVariants(ctx, job.Filename, a.Variants); err != nil {
+		return err
+	}
+
+	ok, err := s.repo.CompleteImageJob(job.ID)
+	if err != nil {
+		return fmt.Errorf("failed to complete job: %w", err)
+	}
+
+	// the image was deleted while it was processed
+	if !ok {
+		return s.deleteImageFiles(ctx, job.Filename)
+	}
+
+	if err := s.storage.Delete(ctx, uploadPath); err != nil {
+		s.log.Error("failed to delete processed upload %s: %w", uploadPath, err)
+	}
+
+	return nil
+}
+
+// retryImageJob schedules the retry of the failed job or gives it up.
+func (s *Service) retryImageJob(ctx context.Context, job *ImageJob, jobErr error) error {
+	if !isPermanentImageError(jobErr) && job.Attempts < s.imageJobAttempts {
+		backoff := queue.Backoff(s.imageJobBackoff, maxImageJobBackoff, job.Attempts)
+		s.log.Warn("image job %d failed, retrying in %s: %w", job.ID, backoff, jobErr)
+		return s.repo.RetryImageJob(job.ID, time.Now().Add(backoff), jobErr.Error())
+	}
+
+	s.log.Error("image job %d failed: %w", job.ID, jobErr)
+	if err := s.repo.FailImageJob(job, jobErr.Error()); err != nil {
+		return err
+	}
+
+	return s.deleteImageFiles(ctx, job.Filename)
+}
+
+func isPermanentImageError(err error) bool {
+	return errors.Is(err, errs.FileWrongType) || errors.Is(err, errs.FileIsTooBig) || errors.Is(err, storage.ErrNotExist)
+}
+
+func (s *Service) putVariants(ctx context.Context, filename string, variants []AttachmentVariant) error {
+	for _, v := range variants {
+		key := variantKey(v.ImageVariant, filename)
+		if err := s.storage.Put(ctx, key, bytes.NewReader(v.Data), s.imagePutOptions(v.ContentType)); err != nil {
+			return fmt.Errorf("failed to upload %s variant: %w", v.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,287 @@
+package requests_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ivch/dynasty/common/storage"
+	"github.com/ivch/dynasty/server/handlers/requests"
+)
+
+type storedObject struct {
+	body     []byte
+	opts     storage.PutOptions
+	modified time.Time
+}
+
+// memStorage returns the storage keeping objects in the given map.
+func memStorage(objects map[string]storedObject) *requests.StorageMock {
+	var mu sync.Mutex
+	return &requests.StorageMock{
+		PutFunc: func(_ context.Context, key string, body io.Reader, opts storage.PutOptions) error {
+			data, err := io.ReadAll(body)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			objects[key] = storedObject{body: data, opts: opts, modified: time.Now()}
+			return nil
+		},
+		GetFunc: func(_ context.Context, key string) (io.ReadCloser, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			o, ok := objects[key]
+			if !ok {
+				return nil, storage.ErrNotExist
+			}
+			return io.NopCloser(bytes.NewReader(o.body)), nil
+		},
+		DeleteFunc: func(_ context.Context, key string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			delete(objects, key)
+			return nil
+		},
+		ListFunc: func(_ context.Context, prefix string) ([]storage.ObjectInfo, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			var list []storage.ObjectInfo
+			for key, o := range objects {
+				if strings.HasPrefix(key, prefix) {
+					list = append(list, storage.ObjectInfo{Key: key, Size: int64(len(o.body)), LastModified: o.modified})
+				}
+			}
+			return list, nil
+		},
+		PresignFunc: func(_ context.Context, key string, ttl time.Duration) (string, error) {
+			return fmt.Sprintf("https://fra1.example.com/space/%s?expires=%d&signature=sig", key, int(ttl.Seconds())), nil
+		},
+	}
+}
+
+// imageQueue is the repository keeping the image jobs in memory.
+type imageQueue struct {
+	mu        sync.Mutex
+	jobs      []*requests.ImageJob
+	retriedAt time.Time
+	completed bool
+}
+
+func (q *imageQueue) repo() *requests.RequestsRepositoryMock {
+	return &requests.RequestsRepositoryMock{
+		GetRequestByIDAndUserFunc: func(_ uint, _ uint) (*requests.Request, error) {
+			return &requests.Request{}, nil
+		},
+		EnqueueImageFunc: func(job *requests.ImageJob) error {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			job.ID = uint(len(q.jobs) + 1)
+			q.jobs = append(q.jobs, job)
+			return nil
+		},
+		ClaimImageJobFunc: func(now time.Time, _ time.Duration) (*requests.ImageJob, error) {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			for _, job := range q.jobs {
+				if job.Status == requests.ImageJobPending && !job.RunAt.After(now) {
+					job.Status = requests.ImageJobRunning
+					job.Attempts++
+					return job, nil
+				}
+			}
+			return nil, nil
+		},
+		CompleteImageJobFunc: func(id uint) (bool, error) {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			q.jobs[id-1].Status = requests.ImageJobDone
+			return !q.completed, nil
+		},
+		RetryImageJobFunc: func(id uint, runAt time.Time, _ string) error {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			q.jobs[id-1].Status = requests.ImageJobPending
+			q.jobs[id-1].RunAt = runAt
+			q.retriedAt = runAt
+			return nil
+		},
+		FailImageJobFunc: func(job *requests.ImageJob, _ string) error {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			q.jobs[job.ID-1].Status = requests.ImageJobFailed
+			return nil
+		},
+	}
+}
+
+func (q *imageQueue) status(id uint) string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.jobs[id-1].Status
+}
+
+func TestService_ProcessImageJob(t *testing.T) {
+	jpg, err := os.ReadFile("../../../test_image.jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		file        []byte
+		attempts    int
+		putErr      bool
+		lostUpload  bool
+		deleted     bool
+		wantStatus  string
+		wantRetry   time.Duration
+		wantObjects []string
+	}{
+		{
+			name:        "ok",
+			file:        jpg,
+			wantStatus:  requests.ImageJobDone,
+			wantObjects: []string{requests.ImgPathPrefix, requests.ThumbPathPrefix, requests.VariantPathPrefix, requests.VariantPathPrefix},
+		},
+		{
+			name:        "retry failed upload with backoff",
+			file:        jpg,
+			attempts:    1,
+			putErr:      true,
+			wantStatus:  requests.ImageJobPending,
+			wantRetry:   4 * time.Second,
+			wantObjects: []string{requests.UploadPathPrefix, requests.ImgPathPrefix},
+		},
+		{
+			name:       "give up after last attempt",
+			file:       jpg,
+			attempts:   3,
+			putErr:     true,
+			wantStatus: requests.ImageJobFailed,
+		},
+		{
+			name:       "give up lost upload",
+			file:       jpg,
+			lostUpload: true,
+			wantStatus: requests.ImageJobFailed,
+		},
+		{
+			name:       "clean up image deleted while processing",
+			file:       jpg,
+			deleted:    true,
+			wantStatus: requests.ImageJobDone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				q       = &imageQueue{completed: tt.deleted}
+				objects = make(map[string]storedObject)
+				store   = memStorage(objects)
+				put     = store.PutFunc
+			)
+			store.PutFunc = func(ctx context.Context, key string, body io.Reader, opts storage.PutOptions) error {
+				if tt.putErr && strings.HasPrefix(key, requests.ThumbPathPrefix) {
+					return errTestError
+				}
+				return put(ctx, key, body, opts)
+			}
+
+			limits := map[string]int64{requests.ContentTypeJPEG: 1 << 20}
+			s := requests.New(defaultLogger, q.repo(), nil, store, "cdnHost",
+				requests.WithAttachmentLimits(limits), requests.WithImageJobRetries(3, 2*time.Second))
+
+			if _, err := s.UploadImage(context.Background(), &requests.Image{UserID: 1, RequestID: 1, File: tt.file}); err != nil {
+				t.Fatalf("UploadImage() error = %v", err)
+			}
+
+			q.jobs[0].Attempts = tt.attempts
+			if tt.lostUpload {
+				clear(objects)
+			}
+
+			start := time.Now()
+			ok, err := s.ProcessImageJob(context.Background())
+			if err != nil || !ok {
+				t.Fatalf("ProcessImageJob() = %v, %v", ok, err)
+			}
+
+			if st := q.status(1); st != tt.wantStatus {
+				t.Errorf("ProcessImageJob() job status = %s, want %s", st, tt.wantStatus)
+			}
+
+			if tt.wantRetry > 0 {
+				if d := q.retriedAt.Sub(start); d < tt.wantRetry || d > tt.wantRetry+time.Second {
+					t.Errorf("ProcessImageJob() retry in %s, want %s", d, tt.wantRetry)
+				}
+			}
+
+			var prefixes []string
+			for key := range objects {
+				prefixes = append(prefixes, key[:len(requests.ImgPathPrefix)])
+			}
+			if len(prefixes) != len(tt.wantObjects) {
+				t.Fatalf("ProcessImageJob() left objects %v, want %v", prefixes, tt.wantObjects)
+			}
+			for _, want := range tt.wantObjects {
+				if !strings.Contains(strings.Join(prefixes, ","), want) {
+					t.Errorf("ProcessImageJob() left objects %v, want %v", prefixes, tt.wantObjects)
+				}
+			}
+
+			if ok, _ := s.ProcessImageJob(context.Background()); ok {
+				t.Error("ProcessImageJob() claimed a job which is not due")
+			}
+		})
+	}
+}
+
+func TestService_RunImageWorkers(t *testing.T) {
+	jpg, err := os.ReadFile("../../../test_image.jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := &imageQueue{}
+	objects := make(map[string]storedObject)
+	s := requests.New(defaultLogger, q.repo(), nil, memStorage(objects), "cdnHost")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.RunImageWorkers(ctx, 2, time.Hour)
+		close(done)
+	}()
+
+	for i := 0; i < 3; i++ {
+		if _, err := s.UploadImage(context.Background(), &requests.Image{UserID: 1, RequestID: 1, File: jpg}); err != nil {
+			t.Fatalf("UploadImage() error = %v", err)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for id := uint(1); id <= 3; id++ {
+		for q.status(id) != requests.ImageJobDone {
+			if time.Now().After(deadline) {
+				t.Fatalf("RunImageWorkers() job %d is %s", id, q.status(id))
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunImageWorkers() did not stop")
+	}
+}
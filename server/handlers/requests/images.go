@@ -5,22 +5,19 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
-	"image"
-	"image/color"
-	"image/jpeg"
-	"net/http"
-	"path/filepath"
+	"net/url"
+	"path"
 	"strconv"
-
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/s3"
-	"github.com/disintegration/imaging"
+	"time"
 
 	"github.com/ivch/dynasty/common"
 	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/common/logger"
+	"github.com/ivch/dynasty/common/storage"
 )
 
-func (s *Service) UploadImage(_ context.Context, r *Image) (*Image, error) {
+// UploadImage attaches the image to the request and queues it for processing.
+func (s *Service) UploadImage(ctx context.Context, r *Image) (*Image, error) {
 	req, err := s.repo.GetRequestByIDAndUser(r.RequestID, r.UserID)
 	if err != nil {
 		return nil, err
@@ -30,72 +27,54 @@ func (s *Service) UploadImage(_ context.Context, r *Image) (*Image, error) {
 		return nil, errs.TooMuchFiles
 	}
 
-	var (
-		filename     = fmt.Sprintf("%s:%s.jpg", base64.StdEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(r.UserID), 10))), common.RandomString(25))
-		imgPath      = s.buildImagePath(ImgPathPrefix, filename)
-		imgThumbPath = s.buildImagePath(ThumbPathPrefix, filename)
-		fileType     = http.DetectContentType(r.File)
-	)
-
-	if fileType != allowedFileType {
-		return nil, errs.FileWrongType
-	}
-
-	thumb, err := s.createThumbnail(r.File)
+	contentType, err := s.checkAttachment(r.File)
 	if err != nil {
-		s.log.Error("failed to create thumb: %w", err)
+		s.log.Error("failed to check attachment: %w", err)
 		return nil, err
 	}
 
-	if _, err := s.s3Client.PutObject(&s3.PutObjectInput{
-		Bucket:      aws.String(s.s3Space),
-		Key:         aws.String(imgPath),
-		Body:        bytes.NewReader(r.File),
-		ACL:         aws.String(defaultS3ACL),
-		ContentType: aws.String(fileType),
-	}); err != nil {
-		s.log.Error("failed to upload thumb file: %w", err)
-		return nil, err
-	}
+	var (
+		filename   = fmt.Sprintf("%s:%s%s", base64.StdEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(r.UserID), 10))), common.RandomString(25), attachmentExt(contentType))
+		uploadPath = s.buildImagePath(UploadPathPrefix, filename)
+	)
 
-	if _, err := s.s3Client.PutObject(&s3.PutObjectInput{
-		Bucket:      aws.String(s.s3Space),
-		Key:         aws.String(imgThumbPath),
-		Body:        bytes.NewReader(thumb),
-		ACL:         aws.String(defaultS3ACL),
-		ContentType: aws.String(fileType),
-	}); err != nil {
-		// todo this can cause an error because deleteImageFromS3 expects both img and thumb are present
-		if s3err := s.deleteImageFromS3(filename); s3err != nil {
-			return nil, s3err
-		}
+	if err := s.storage.Put(ctx, uploadPath, bytes.NewReader(r.File), storage.PutOptions{ContentType: contentType}); err != nil {
 		s.log.Error("failed to upload file: %w", err)
 		return nil, err
 	}
 
-	if err := s.repo.AddImage(r.UserID, r.RequestID, filename); err != nil {
-		if s3err := s.deleteImageFromS3(imgPath); s3err != nil {
-			s.log.Error("failed to delete file from S3: %w", err)
-			return nil, s3err
+	if err := s.repo.EnqueueImage(&ImageJob{
+		RequestID:   r.RequestID,
+		UserID:      r.UserID,
+		Filename:    filename,
+		ContentType: contentType,
+		Status:      ImageJobPending,
+		RunAt:       time.Now(),
+	}); err != nil {
+		if delErr := s.storage.Delete(ctx, uploadPath); delErr != nil {
+			s.log.Error("failed to delete file from storage: %w", delErr)
 		}
 		return nil, err
 	}
 
-	imgURL := s.buildImageURL(filename)
+	s.wakeImageWorkers()
+
+	imgURL := s.buildImageURL(ctx, filename)
 	r.URL = imgURL["img"]
 	r.Thumb = imgURL["thumb"]
+	r.Processing = true
 
 	return r, nil
 }
 
-func (s *Service) DeleteImage(_ context.Context, r *Image) error {
-	filename := filepath.Base(r.URL)
+func (s *Service) DeleteImage(ctx context.Context, r *Image) error {
+	filename := imageFilename(r.URL)
 
 	if err := s.repo.DeleteImage(r.UserID, r.RequestID, filename); err != nil {
 		return err
 	}
 
-	if err := s.deleteImageFromS3(filename); err != nil {
+	if err := s.deleteImageFiles(ctx, filename); err != nil {
 		if err2 := s.repo.AddImage(r.UserID, r.RequestID, filename); err2 != nil {
 			return err2
 		}
@@ -105,52 +84,132 @@ func (s *Service) DeleteImage(_ context.Context, r *Image) error {
 	return nil
 }
 
-func (s *Service) createThumbnail(file []byte) ([]byte, error) {
-	img, err := imaging.Decode(bytes.NewReader(file))
-	if err != nil {
-		return nil, err
+// buildImageURL returns the links of the image and all its variants by name.
+func (s *Service) buildImageURL(ctx context.Context, filename string) map[string]string {
+	variants := s.fileVariants(filename)
+	urls := make(map[string]string, len(variants)+2)
+	urls["img"] = s.storageURL(ctx, s.buildImagePath(ImgPathPrefix, filename))
+	for _, v := range variants {
+		urls[v.Name] = s.storageURL(ctx, variantKey(v, filename))
 	}
 
-	var (
-		thumb = imaging.Thumbnail(img, 128, 128, imaging.CatmullRom)
-		dst   = imaging.New(128, 128, color.NRGBA{R: 0, G: 0, B: 0, A: 0})
-		buf   = new(bytes.Buffer)
-	)
+	if set := srcset(variants, urls); set != "" {
+		urls[srcsetKey] = set
+	}
 
-	dst = imaging.Paste(dst, thumb, image.Pt(0, 0))
-	if err := jpeg.Encode(buf, dst, nil); err != nil {
-		return nil, err
+	return urls
+}
+
+// fileVariants returns the variants stored for the file, documents only have the thumbnail.
+func (s *Service) fileVariants(filename string) []ImageVariant {
+	if path.Ext(filename) == attachmentExt(ContentTypePDF) {
+		return []ImageVariant{thumbVariant(s.imageVariants)}
+	}
+	return s.imageVariants
+}
+
+// storageURL returns the presigned link to the object for private images, otherwise the CDN one.
+func (s *Service) storageURL(ctx context.Context, key string) string {
+	return s.imageStore().url(ctx, key)
+}
+
+func (s *Service) imagePutOptions(contentType string) storage.PutOptions {
+	return s.imageStore().putOptions(contentType)
+}
+
+// imageStore returns the storage settings of the images shared with the avatars.
+func (s *Service) imageStore() imageStore {
+	return imageStore{
+		storage: s.storage,
+		cdnHost: s.cdnHost,
+		private: s.privateImages,
+		urlTTL:  s.imageURLTTL,
+		log:     s.log,
+	}
+}
+
+// imageStore keeps the images in the storage and builds their links.
+type imageStore struct {
+	storage Storage
+	cdnHost string
+	private bool
+	urlTTL  time.Duration
+	log     logger.Logger
+}
+
+// url returns the presigned link to the object for private images, otherwise the CDN one.
+func (st imageStore) url(ctx context.Context, key string) string {
+	if !st.private {
+		return fmt.Sprintf("%s/%s", st.cdnHost, key)
+	}
+
+	u, err := st.storage.Presign(ctx, key, st.urlTTL)
+	if err != nil {
+		st.log.Error("failed to presign image url %s: %w", key, err)
+		return ""
 	}
 
-	return buf.Bytes(), nil
+	return u
 }
 
-func (s *Service) buildImageURL(filename string) map[string]string {
-	return map[string]string{
-		"img":   fmt.Sprintf("%s/%s", s.cdnHost, s.buildImagePath(ImgPathPrefix, filename)),
-		"thumb": fmt.Sprintf("%s/%s", s.cdnHost, s.buildImagePath(ThumbPathPrefix, filename)),
+func (st imageStore) putOptions(contentType string) storage.PutOptions {
+	return storage.PutOptions{ContentType: contentType, Public: !st.private}
+}
+
+// imageFilename extracts the stored file name from both CDN and presigned image links.
+func imageFilename(link string) string {
+	if u, err := url.Parse(link); err == nil {
+		return path.Base(u.Path)
 	}
+	return path.Base(link)
 }
 
 func (s *Service) buildImagePath(prefix, filename string) string {
 	return fmt.Sprintf("%s%s", prefix, filename)
 }
 
-func (s *Service) deleteImageFromS3(filename string) error {
-	img := s.buildImagePath(ImgPathPrefix, filename)
-	if _, err := s.s3Client.DeleteObject(&s3.DeleteObjectInput{
-		Bucket: aws.String(s.s3Space),
-		Key:    aws.String(img),
-	}); err != nil {
-		return fmt.Errorf("failed to delete image %s: %w", img, err)
+// attachImageURLs sets the image links of the requests, marking the images not processed yet.
+func (s *Service) attachImageURLs(ctx context.Context, reqs ...*Request) {
+	var ids []uint
+	for i := range reqs {
+		if len(reqs[i].Images) > 0 {
+			ids = append(ids, reqs[i].ID)
+		}
 	}
 
-	thumb := s.buildImagePath(ThumbPathPrefix, filename)
-	if _, err := s.s3Client.DeleteObject(&s3.DeleteObjectInput{
-		Bucket: aws.String(s.s3Space),
-		Key:    aws.String(thumb),
-	}); err != nil {
-		return fmt.Errorf("failed to delete image %s: %w", thumb, err)
+	processing := make(map[string]bool)
+	if len(ids) > 0 {
+		files, err := s.repo.ProcessingImages(ids)
+		if err != nil {
+			s.log.Error("failed to get processing images: %w", err)
+		}
+		for i := range files {
+			processing[files[i]] = true
+		}
+	}
+
+	for i := range reqs {
+		reqs[i].ImagesURL = make([]map[string]string, len(reqs[i].Images))
+		for j := range reqs[i].Images {
+			reqs[i].ImagesURL[j] = s.buildImageURL(ctx, reqs[i].Images[j])
+			if processing[reqs[i].Images[j]] {
+				reqs[i].ImagesURL[j]["status"] = imageStatusProcessing
+			}
+		}
+	}
+}
+
+// deleteImageFiles removes the image, its variants and the unprocessed upload, missing files are not an error.
+func (s *Service) deleteImageFiles(ctx context.Context, filename string) error {
+	keys := []string{s.buildImagePath(ImgPathPrefix, filename), s.buildImagePath(UploadPathPrefix, filename)}
+	for _, v := range s.fileVariants(filename) {
+		keys = append(keys, variantKey(v, filename))
+	}
+
+	for _, key := range keys {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			return fmt.Errorf("failed to delete image %s: %w", key, err)
+		}
 	}
 
 	return nil
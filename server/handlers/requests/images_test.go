@@ -4,11 +4,13 @@ import (
 	"context"
 	"io"
 	"log"
+	"net/url"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
-	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/ivch/dynasty/common/storage"
 	"github.com/ivch/dynasty/server/handlers/requests"
 )
 
@@ -33,7 +35,7 @@ func TestService_UploadImage(t *testing.T) {
 	tests := []struct {
 		name    string
 		repo    requests.RequestsRepository
-		s3cli   requests.S3Client
+		store   requests.Storage
 		req     *requests.Image
 		wantErr bool
 		want    *requests.Image
@@ -71,61 +73,17 @@ func TestService_UploadImage(t *testing.T) {
 			req: &requests.Image{
 				UserID:    1,
 				RequestID: 1,
-				File:      loadFile("../../../test_image.png"),
-			},
-			repo: &requests.RequestsRepositoryMock{
-				GetRequestByIDAndUserFunc: func(_ uint, _ uint) (*requests.Request, error) {
-					return &requests.Request{}, nil
-				},
-			},
-			wantErr: true,
-		},
-		{
-			name: "error upload to s3",
-			req: &requests.Image{
-				UserID:    1,
-				RequestID: 1,
-				File:      loadFile("../../../test_image.jpeg"),
-			},
-			repo: &requests.RequestsRepositoryMock{
-				GetRequestByIDAndUserFunc: func(_ uint, _ uint) (*requests.Request, error) {
-					return &requests.Request{}, nil
-				},
-			},
-			s3cli: &requests.S3ClientMock{
-				PutObjectFunc: func(_ *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
-					return nil, errTestError
-				},
-			},
-			wantErr: true,
-		},
-		{
-			name: "error upload thumb to s3 and delete err",
-			req: &requests.Image{
-				UserID:    1,
-				RequestID: 1,
-				File:      loadFile("../../../test_image.jpeg"),
+				File:      []byte("plain text"),
 			},
 			repo: &requests.RequestsRepositoryMock{
 				GetRequestByIDAndUserFunc: func(_ uint, _ uint) (*requests.Request, error) {
 					return &requests.Request{}, nil
 				},
 			},
-			s3cli: &requests.S3ClientMock{
-				PutObjectFunc: func(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
-					if !strings.Contains(*input.Key, requests.ThumbPathPrefix) {
-						return nil, nil
-					}
-					return nil, errTestError
-				},
-				DeleteObjectFunc: func(_ *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
-					return nil, errTestError
-				},
-			},
 			wantErr: true,
 		},
 		{
-			name: "error upload thumb to s3 and no err on delete",
+			name: "error upload to storage",
 			req: &requests.Image{
 				UserID:    1,
 				RequestID: 1,
@@ -136,92 +94,36 @@ func TestService_UploadImage(t *testing.T) {
 					return &requests.Request{}, nil
 				},
 			},
-			s3cli: &requests.S3ClientMock{
-				PutObjectFunc: func(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
-					if !strings.Contains(*input.Key, requests.ThumbPathPrefix) {
-						return nil, nil
-					}
-					return nil, errTestError
-				},
-				DeleteObjectFunc: func(_ *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
-					return nil, nil
-				},
-			},
-			wantErr: true,
-		},
-		{
-			name: "error add image to db + err delete from s3",
-			req: &requests.Image{
-				UserID:    1,
-				RequestID: 1,
-				File:      loadFile("../../../test_image.jpeg"),
-			},
-			s3cli: &requests.S3ClientMock{
-				PutObjectFunc: func(_ *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
-					return nil, nil
-				},
-				DeleteObjectFunc: func(_ *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
-					return nil, errTestError
-				},
-			},
-			repo: &requests.RequestsRepositoryMock{
-				GetRequestByIDAndUserFunc: func(_ uint, _ uint) (*requests.Request, error) {
-					return &requests.Request{}, nil
-				},
-				AddImageFunc: func(_ uint, _ uint, _ string) error {
+			store: &requests.StorageMock{
+				PutFunc: func(_ context.Context, _ string, _ io.Reader, _ storage.PutOptions) error {
 					return errTestError
 				},
 			},
 			wantErr: true,
 		},
 		{
-			name: "error add image to db + err delete thumb from s3",
+			name: "error enqueue image",
 			req: &requests.Image{
 				UserID:    1,
 				RequestID: 1,
 				File:      loadFile("../../../test_image.jpeg"),
 			},
-			s3cli: &requests.S3ClientMock{
-				PutObjectFunc: func(_ *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
-					return nil, nil
+			store: &requests.StorageMock{
+				PutFunc: func(_ context.Context, _ string, _ io.Reader, _ storage.PutOptions) error {
+					return nil
 				},
-				DeleteObjectFunc: func(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
-					if !strings.Contains(*input.Key, requests.ThumbPathPrefix) {
-						return nil, nil
+				DeleteFunc: func(_ context.Context, key string) error {
+					if !strings.HasPrefix(key, requests.UploadPathPrefix) {
+						t.Errorf("UploadImage() deleted %s", key)
 					}
-					return nil, errTestError
-				},
-			},
-			repo: &requests.RequestsRepositoryMock{
-				GetRequestByIDAndUserFunc: func(_ uint, _ uint) (*requests.Request, error) {
-					return &requests.Request{}, nil
-				},
-				AddImageFunc: func(_ uint, _ uint, _ string) error {
-					return errTestError
-				},
-			},
-			wantErr: true,
-		},
-		{
-			name: "error add image to db",
-			req: &requests.Image{
-				UserID:    1,
-				RequestID: 1,
-				File:      loadFile("../../../test_image.jpeg"),
-			},
-			s3cli: &requests.S3ClientMock{
-				PutObjectFunc: func(_ *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
-					return nil, nil
-				},
-				DeleteObjectFunc: func(_ *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
-					return nil, nil
+					return nil
 				},
 			},
 			repo: &requests.RequestsRepositoryMock{
 				GetRequestByIDAndUserFunc: func(_ uint, _ uint) (*requests.Request, error) {
 					return &requests.Request{}, nil
 				},
-				AddImageFunc: func(_ uint, _ uint, _ string) error {
+				EnqueueImageFunc: func(_ *requests.ImageJob) error {
 					return errTestError
 				},
 			},
@@ -234,16 +136,22 @@ func TestService_UploadImage(t *testing.T) {
 				RequestID: 1,
 				File:      loadFile("../../../test_image.jpeg"),
 			},
-			s3cli: &requests.S3ClientMock{
-				PutObjectFunc: func(_ *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
-					return nil, nil
+			store: &requests.StorageMock{
+				PutFunc: func(_ context.Context, key string, _ io.Reader, opts storage.PutOptions) error {
+					if !strings.HasPrefix(key, requests.UploadPathPrefix) || opts.Public {
+						t.Errorf("UploadImage() stored %s with %+v", key, opts)
+					}
+					return nil
 				},
 			},
 			repo: &requests.RequestsRepositoryMock{
 				GetRequestByIDAndUserFunc: func(_ uint, _ uint) (*requests.Request, error) {
 					return &requests.Request{}, nil
 				},
-				AddImageFunc: func(_ uint, _ uint, _ string) error {
+				EnqueueImageFunc: func(job *requests.ImageJob) error {
+					if job.Status != requests.ImageJobPending || job.ContentType != requests.ContentTypeJPEG || !strings.HasSuffix(job.Filename, ".jpg") {
+						t.Errorf("UploadImage() enqueued %+v", job)
+					}
 					return nil
 				},
 			},
@@ -257,7 +165,7 @@ func TestService_UploadImage(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := requests.New(defaultLogger, tt.repo, tt.s3cli, "", "cdnHost")
+			s := requests.New(defaultLogger, tt.repo, nil, tt.store, "cdnHost")
 			res, err := s.UploadImage(context.Background(), tt.req)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("UploadImage() error = %v, wantErr %v", err, tt.wantErr)
@@ -278,6 +186,10 @@ func TestService_UploadImage(t *testing.T) {
 				t.Errorf("UploadImage() error = wrong thumb path: %s", res.Thumb)
 				return
 			}
+
+			if !tt.wantErr && !res.Processing {
+				t.Error("UploadImage() error = image is not processing")
+			}
 		})
 	}
 }
@@ -286,7 +198,7 @@ func TestService_DeleteImage(t *testing.T) {
 	tests := []struct {
 		name    string
 		repo    requests.RequestsRepository
-		s3cli   requests.S3Client
+		store   requests.Storage
 		req     *requests.Image
 		wantErr bool
 	}{
@@ -319,9 +231,9 @@ func TestService_DeleteImage(t *testing.T) {
 					return errTestError
 				},
 			},
-			s3cli: &requests.S3ClientMock{
-				DeleteObjectFunc: func(_ *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
-					return nil, errTestError
+			store: &requests.StorageMock{
+				DeleteFunc: func(_ context.Context, _ string) error {
+					return errTestError
 				},
 			},
 			wantErr: true,
@@ -341,9 +253,9 @@ func TestService_DeleteImage(t *testing.T) {
 					return nil
 				},
 			},
-			s3cli: &requests.S3ClientMock{
-				DeleteObjectFunc: func(_ *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
-					return nil, errTestError
+			store: &requests.StorageMock{
+				DeleteFunc: func(_ context.Context, _ string) error {
+					return errTestError
 				},
 			},
 			wantErr: true,
@@ -360,9 +272,9 @@ func TestService_DeleteImage(t *testing.T) {
 					return nil
 				},
 			},
-			s3cli: &requests.S3ClientMock{
-				DeleteObjectFunc: func(_ *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
-					return nil, nil
+			store: &requests.StorageMock{
+				DeleteFunc: func(_ context.Context, _ string) error {
+					return nil
 				},
 			},
 			wantErr: false,
@@ -371,7 +283,7 @@ func TestService_DeleteImage(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := requests.New(defaultLogger, tt.repo, tt.s3cli, "", "")
+			s := requests.New(defaultLogger, tt.repo, nil, tt.store, "")
 			err := s.DeleteImage(context.Background(), tt.req)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("DeleteImage() error = %v, wantErr %v", err, tt.wantErr)
@@ -380,3 +292,65 @@ func TestService_DeleteImage(t *testing.T) {
 		})
 	}
 }
+
+func TestService_PrivateImages(t *testing.T) {
+	var (
+		q       = &imageQueue{}
+		objects = make(map[string]storedObject)
+		deleted string
+	)
+
+	repo := q.repo()
+	repo.DeleteImageFunc = func(_ uint, _ uint, filename string) error {
+		deleted = filename
+		return nil
+	}
+
+	s := requests.New(defaultLogger, repo, nil, memStorage(objects), "cdnHost", requests.WithPrivateImages(10*time.Minute))
+
+	file, err := os.ReadFile("../../../test_image.jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := s.UploadImage(context.Background(), &requests.Image{UserID: 1, RequestID: 1, File: file})
+	if err != nil {
+		t.Fatalf("UploadImage() error = %v", err)
+	}
+
+	if ok, err := s.ProcessImageJob(context.Background()); err != nil || !ok {
+		t.Fatalf("ProcessImageJob() = %v, %v", ok, err)
+	}
+
+	if len(objects) != 4 {
+		t.Errorf("UploadImage() stored %d objects", len(objects))
+	}
+	for key, obj := range objects {
+		if obj.opts.Public {
+			t.Errorf("UploadImage() uploaded public object %s", key)
+		}
+	}
+
+	for _, link := range []string{res.URL, res.Thumb} {
+		u, err := url.Parse(link)
+		if err != nil {
+			t.Fatalf("UploadImage() bad link %s: %v", link, err)
+		}
+
+		if strings.Contains(link, "cdnHost") || u.Query().Get("expires") != "600" || u.Query().Get("signature") == "" {
+			t.Errorf("UploadImage() link is not presigned: %s", link)
+		}
+	}
+
+	if err := s.DeleteImage(context.Background(), &requests.Image{UserID: 1, RequestID: 1, URL: res.URL}); err != nil {
+		t.Fatalf("DeleteImage() error = %v", err)
+	}
+
+	if !strings.HasSuffix(deleted, ".jpg") || strings.Contains(deleted, "?") {
+		t.Errorf("DeleteImage() deleted wrong file %s", deleted)
+	}
+
+	if len(objects) != 0 {
+		t.Errorf("DeleteImage() left %d objects", len(objects))
+	}
+}
@@ -4,8 +4,13 @@
 package requests
 
 import (
-	"github.com/aws/aws-sdk-go/service/s3"
+	"context"
+	"github.com/ivch/dynasty/common/storage"
+	"github.com/ivch/dynasty/server/handlers/outbox"
+	"github.com/ivch/dynasty/server/handlers/users"
+	"io"
 	"sync"
+	"time"
 )
 
 // Ensure, that RequestsRepositoryMock does implement RequestsRepository.
@@ -18,37 +23,97 @@ var _ RequestsRepository = &RequestsRepositoryMock{}
 //
 //		// make and configure a mocked RequestsRepository
 //		mockedRequestsRepository := &RequestsRepositoryMock{
+//			ActiveBlocklistFunc: func(now time.Time) ([]*BlocklistEntry, error) {
+//				panic("mock out the ActiveBlocklist method")
+//			},
 //			AddImageFunc: func(userID uint, requestID uint, filename string) error {
 //				panic("mock out the AddImage method")
 //			},
+//			AvatarFilenamesFunc: func() ([]string, error) {
+//				panic("mock out the AvatarFilenames method")
+//			},
+//			ClaimImageJobFunc: func(now time.Time, lease time.Duration) (*ImageJob, error) {
+//				panic("mock out the ClaimImageJob method")
+//			},
+//			CompleteImageJobFunc: func(id uint) (bool, error) {
+//				panic("mock out the CompleteImageJob method")
+//			},
 //			CountForGuardFunc: func(req *RequestListFilter) (int, error) {
 //				panic("mock out the CountForGuard method")
 //			},
-//			CreateFunc: func(req *Request) error {
+//			CreateFunc: func(req *Request, event EventFunc) error {
 //				panic("mock out the Create method")
 //			},
+//			CreateApprovalFunc: func(a *Approval) error {
+//				panic("mock out the CreateApproval method")
+//			},
+//			CreateBlocklistEntryFunc: func(e *BlocklistEntry) error {
+//				panic("mock out the CreateBlocklistEntry method")
+//			},
 //			DeleteFunc: func(id uint, userID uint) error {
 //				panic("mock out the Delete method")
 //			},
+//			DeleteBlocklistEntryFunc: func(id uint, adminID uint) error {
+//				panic("mock out the DeleteBlocklistEntry method")
+//			},
 //			DeleteImageFunc: func(userID uint, requestID uint, filename string) error {
 //				panic("mock out the DeleteImage method")
 //			},
+//			EnqueueImageFunc: func(job *ImageJob) error {
+//				panic("mock out the EnqueueImage method")
+//			},
+//			ExpireApprovalsFunc: func(now time.Time) ([]*Approval, error) {
+//				panic("mock out the ExpireApprovals method")
+//			},
+//			FailImageJobFunc: func(job *ImageJob, lastErr string) error {
+//				panic("mock out the FailImageJob method")
+//			},
+//			GetApprovalFunc: func(id uint) (*Approval, error) {
+//				panic("mock out the GetApproval method")
+//			},
+//			GetBlocklistEntryFunc: func(id uint) (*BlocklistEntry, error) {
+//				panic("mock out the GetBlocklistEntry method")
+//			},
 //			GetRequestByIDAndUserFunc: func(id uint, userID uint) (*Request, error) {
 //				panic("mock out the GetRequestByIDAndUser method")
 //			},
 //			GetStats24hFunc: func() (int, int, int, error) {
 //				panic("mock out the GetStats24h method")
 //			},
+//			ImageFilenamesFunc: func() ([]string, error) {
+//				panic("mock out the ImageFilenames method")
+//			},
+//			ListBlocklistFunc: func() ([]*BlocklistEntry, error) {
+//				panic("mock out the ListBlocklist method")
+//			},
+//			ListBlocklistAuditFunc: func(entryID uint) ([]*BlocklistAudit, error) {
+//				panic("mock out the ListBlocklistAudit method")
+//			},
 //			ListByUserFunc: func(r *RequestListFilter) ([]*Request, error) {
 //				panic("mock out the ListByUser method")
 //			},
 //			ListForGuardFunc: func(req *RequestListFilter) ([]*Request, error) {
 //				panic("mock out the ListForGuard method")
 //			},
+//			ListPendingApprovalsFunc: func(buildingID uint, apartment uint) ([]*Approval, error) {
+//				panic("mock out the ListPendingApprovals method")
+//			},
+//			ProcessingImagesFunc: func(requestIDs []uint) ([]string, error) {
+//				panic("mock out the ProcessingImages method")
+//			},
+//			ResolveApprovalFunc: func(a *Approval) (bool, error) {
+//				panic("mock out the ResolveApproval method")
+//			},
+//			RetryImageJobFunc: func(id uint, runAt time.Time, lastErr string) error {
+//				panic("mock out the RetryImageJob method")
+//			},
 //			UpdateFunc: func(update *UpdateRequest) error {
 //				panic("mock out the Update method")
 //			},
-//			UpdateForGuardFunc: func(id uint, status string) error {
+//			UpdateBlocklistEntryFunc: func(e *BlocklistEntry, adminID uint) error {
+//				panic("mock out the UpdateBlocklistEntry method")
+//			},
+//			UpdateForGuardFunc: func(id uint, status string, event EventFunc) error {
 //				panic("mock out the UpdateForGuard method")
 //			},
 //		}
@@ -58,41 +123,106 @@ var _ RequestsRepository = &RequestsRepositoryMock{}
 //
 //	}
 type RequestsRepositoryMock struct {
+	// ActiveBlocklistFunc mocks the ActiveBlocklist method.
+	ActiveBlocklistFunc func(now time.Time) ([]*BlocklistEntry, error)
+
 	// AddImageFunc mocks the AddImage method.
 	AddImageFunc func(userID uint, requestID uint, filename string) error
 
+	// AvatarFilenamesFunc mocks the AvatarFilenames method.
+	AvatarFilenamesFunc func() ([]string, error)
+
+	// ClaimImageJobFunc mocks the ClaimImageJob method.
+	ClaimImageJobFunc func(now time.Time, lease time.Duration) (*ImageJob, error)
+
+	// CompleteImageJobFunc mocks the CompleteImageJob method.
+	CompleteImageJobFunc func(id uint) (bool, error)
+
 	// CountForGuardFunc mocks the CountForGuard method.
 	CountForGuardFunc func(req *RequestListFilter) (int, error)
 
 	// CreateFunc mocks the Create method.
-	CreateFunc func(req *Request) error
+	CreateFunc func(req *Request, event EventFunc) error
+
+	// CreateApprovalFunc mocks the CreateApproval method.
+	CreateApprovalFunc func(a *Approval) error
+
+	// CreateBlocklistEntryFunc mocks the CreateBlocklistEntry method.
+	CreateBlocklistEntryFunc func(e *BlocklistEntry) error
 
 	// DeleteFunc mocks the Delete method.
 	DeleteFunc func(id uint, userID uint) error
 
+	// DeleteBlocklistEntryFunc mocks the DeleteBlocklistEntry method.
+	DeleteBlocklistEntryFunc func(id uint, adminID uint) error
+
 	// DeleteImageFunc mocks the DeleteImage method.
 	DeleteImageFunc func(userID uint, requestID uint, filename string) error
 
+	// EnqueueImageFunc mocks the EnqueueImage method.
+	EnqueueImageFunc func(job *ImageJob) error
+
+	// ExpireApprovalsFunc mocks the ExpireApprovals method.
+	ExpireApprovalsFunc func(now time.Time) ([]*Approval, error)
+
+	// FailImageJobFunc mocks the FailImageJob method.
+	FailImageJobFunc func(job *ImageJob, lastErr string) error
+
+	// GetApprovalFunc mocks the GetApproval method.
+	GetApprovalFunc func(id uint) (*Approval, error)
+
+	// GetBlocklistEntryFunc mocks the GetBlocklistEntry method.
+	GetBlocklistEntryFunc func(id uint) (*BlocklistEntry, error)
+
 	// GetRequestByIDAndUserFunc mocks the GetRequestByIDAndUser method.
 	GetRequestByIDAndUserFunc func(id uint, userID uint) (*Request, error)
 
 	// GetStats24hFunc mocks the GetStats24h method.
 	GetStats24hFunc func() (int, int, int, error)
 
+	// ImageFilenamesFunc mocks the ImageFilenames method.
+	ImageFilenamesFunc func() ([]string, error)
+
+	// ListBlocklistFunc mocks the ListBlocklist method.
+	ListBlocklistFunc func() ([]*BlocklistEntry, error)
+
+	// ListBlocklistAuditFunc mocks the ListBlocklistAudit method.
+	ListBlocklistAuditFunc func(entryID uint) ([]*BlocklistAudit, error)
+
 	// ListByUserFunc mocks the ListByUser method.
 	ListByUserFunc func(r *RequestListFilter) ([]*Request, error)
 
 	// ListForGuardFunc mocks the ListForGuard method.
 	ListForGuardFunc func(req *RequestListFilter) ([]*Request, error)
 
+	// ListPendingApprovalsFunc mocks the ListPendingApprovals method.
+	ListPendingApprovalsFunc func(buildingID uint, apartment uint) ([]*Approval, error)
+
+	// ProcessingImagesFunc mocks the ProcessingImages method.
+	ProcessingImagesFunc func(requestIDs []uint) ([]string, error)
+
+	// ResolveApprovalFunc mocks the ResolveApproval method.
+	ResolveApprovalFunc func(a *Approval) (bool, error)
+
+	// RetryImageJobFunc mocks the RetryImageJob method.
+	RetryImageJobFunc func(id uint, runAt time.Time, lastErr string) error
+
 	// UpdateFunc mocks the Update method.
 	UpdateFunc func(update *UpdateRequest) error
 
+	// UpdateBlocklistEntryFunc mocks the UpdateBlocklistEntry method.
+	UpdateBlocklistEntryFunc func(e *BlocklistEntry, adminID uint) error
+
 	// UpdateForGuardFunc mocks the UpdateForGuard method.
-	UpdateForGuardFunc func(id uint, status string) error
+	UpdateForGuardFunc func(id uint, status string, event EventFunc) error
 
 	// calls tracks calls to the methods.
 	calls struct {
+		// ActiveBlocklist holds details about calls to the ActiveBlocklist method.
+		ActiveBlocklist []struct {
+			// Now is the now argument value.
+			Now time.Time
+		}
 		// AddImage holds details about calls to the AddImage method.
 		AddImage []struct {
 			// UserID is the userID argument value.
@@ -102,6 +232,21 @@ type RequestsRepositoryMock struct {
 			// Filename is the filename argument value.
 			Filename string
 		}
+		// AvatarFilenames holds details about calls to the AvatarFilenames method.
+		AvatarFilenames []struct {
+		}
+		// ClaimImageJob holds details about calls to the ClaimImageJob method.
+		ClaimImageJob []struct {
+			// Now is the now argument value.
+			Now time.Time
+			// Lease is the lease argument value.
+			Lease time.Duration
+		}
+		// CompleteImageJob holds details about calls to the CompleteImageJob method.
+		CompleteImageJob []struct {
+			// ID is the id argument value.
+			ID uint
+		}
 		// CountForGuard holds details about calls to the CountForGuard method.
 		CountForGuard []struct {
 			// Req is the req argument value.
@@ -111,6 +256,18 @@ type RequestsRepositoryMock struct {
 		Create []struct {
 			// Req is the req argument value.
 			Req *Request
+			// Event is the event argument value.
+			Event EventFunc
+		}
+		// CreateApproval holds details about calls to the CreateApproval method.
+		CreateApproval []struct {
+			// A is the a argument value.
+			A *Approval
+		}
+		// CreateBlocklistEntry holds details about calls to the CreateBlocklistEntry method.
+		CreateBlocklistEntry []struct {
+			// E is the e argument value.
+			E *BlocklistEntry
 		}
 		// Delete holds details about calls to the Delete method.
 		Delete []struct {
@@ -119,6 +276,13 @@ type RequestsRepositoryMock struct {
 			// UserID is the userID argument value.
 			UserID uint
 		}
+		// DeleteBlocklistEntry holds details about calls to the DeleteBlocklistEntry method.
+		DeleteBlocklistEntry []struct {
+			// ID is the id argument value.
+			ID uint
+			// AdminID is the adminID argument value.
+			AdminID uint
+		}
 		// DeleteImage holds details about calls to the DeleteImage method.
 		DeleteImage []struct {
 			// UserID is the userID argument value.
@@ -128,6 +292,33 @@ type RequestsRepositoryMock struct {
 			// Filename is the filename argument value.
 			Filename string
 		}
+		// EnqueueImage holds details about calls to the EnqueueImage method.
+		EnqueueImage []struct {
+			// Job is the job argument value.
+			Job *ImageJob
+		}
+		// ExpireApprovals holds details about calls to the ExpireApprovals method.
+		ExpireApprovals []struct {
+			// Now is the now argument value.
+			Now time.Time
+		}
+		// FailImageJob holds details about calls to the FailImageJob method.
+		FailImageJob []struct {
+			// Job is the job argument value.
+			Job *ImageJob
+			// LastErr is the lastErr argument value.
+			LastErr string
+		}
+		// GetApproval holds details about calls to the GetApproval method.
+		GetApproval []struct {
+			// ID is the id argument value.
+			ID uint
+		}
+		// GetBlocklistEntry holds details about calls to the GetBlocklistEntry method.
+		GetBlocklistEntry []struct {
+			// ID is the id argument value.
+			ID uint
+		}
 		// GetRequestByIDAndUser holds details about calls to the GetRequestByIDAndUser method.
 		GetRequestByIDAndUser []struct {
 			// ID is the id argument value.
@@ -138,6 +329,17 @@ type RequestsRepositoryMock struct {
 		// GetStats24h holds details about calls to the GetStats24h method.
 		GetStats24h []struct {
 		}
+		// ImageFilenames holds details about calls to the ImageFilenames method.
+		ImageFilenames []struct {
+		}
+		// ListBlocklist holds details about calls to the ListBlocklist method.
+		ListBlocklist []struct {
+		}
+		// ListBlocklistAudit holds details about calls to the ListBlocklistAudit method.
+		ListBlocklistAudit []struct {
+			// EntryID is the entryID argument value.
+			EntryID uint
+		}
 		// ListByUser holds details about calls to the ListByUser method.
 		ListByUser []struct {
 			// R is the r argument value.
@@ -148,32 +350,119 @@ type RequestsRepositoryMock struct {
 			// Req is the req argument value.
 			Req *RequestListFilter
 		}
+		// ListPendingApprovals holds details about calls to the ListPendingApprovals method.
+		ListPendingApprovals []struct {
+			// BuildingID is the buildingID argument value.
+			BuildingID uint
+			// Apartment is the apartment argument value.
+			Apartment uint
+		}
+		// ProcessingImages holds details about calls to the ProcessingImages method.
+		ProcessingImages []struct {
+			// RequestIDs is the requestIDs argument value.
+			RequestIDs []uint
+		}
+		// ResolveApproval holds details about calls to the ResolveApproval method.
+		ResolveApproval []struct {
+			// A is the a argument value.
+			A *Approval
+		}
+		// RetryImageJob holds details about calls to the RetryImageJob method.
+		RetryImageJob []struct {
+			// ID is the id argument value.
+			ID uint
+			// RunAt is the runAt argument value.
+			RunAt time.Time
+			// LastErr is the lastErr argument value.
+			LastErr string
+		}
 		// Update holds details about calls to the Update method.
 		Update []struct {
 			// Update is the update argument value.
 			Update *UpdateRequest
 		}
+		// UpdateBlocklistEntry holds details about calls to the UpdateBlocklistEntry method.
+		UpdateBlocklistEntry []struct {
+			// E is the e argument value.
+			E *BlocklistEntry
+			// AdminID is the adminID argument value.
+			AdminID uint
+		}
 		// UpdateForGuard holds details about calls to the UpdateForGuard method.
 		UpdateForGuard []struct {
 			// ID is the id argument value.
 			ID uint
 			// Status is the status argument value.
 			Status string
+			// Event is the event argument value.
+			Event EventFunc
 		}
 	}
+	lockActiveBlocklist       sync.RWMutex
 	lockAddImage              sync.RWMutex
+	lockAvatarFilenames       sync.RWMutex
+	lockClaimImageJob         sync.RWMutex
+	lockCompleteImageJob      sync.RWMutex
 	lockCountForGuard         sync.RWMutex
 	lockCreate                sync.RWMutex
+	lockCreateApproval        sync.RWMutex
+	lockCreateBlocklistEntry  sync.RWMutex
 	lockDelete                sync.RWMutex
+	lockDeleteBlocklistEntry  sync.RWMutex
 	lockDeleteImage           sync.RWMutex
+	lockEnqueueImage          sync.RWMutex
+	lockExpireApprovals       sync.RWMutex
+	lockFailImageJob          sync.RWMutex
+	lockGetApproval           sync.RWMutex
+	lockGetBlocklistEntry     sync.RWMutex
 	lockGetRequestByIDAndUser sync.RWMutex
 	lockGetStats24h           sync.RWMutex
+	lockImageFilenames        sync.RWMutex
+	lockListBlocklist         sync.RWMutex
+	lockListBlocklistAudit    sync.RWMutex
 	lockListByUser            sync.RWMutex
 	lockListForGuard          sync.RWMutex
+	lockListPendingApprovals  sync.RWMutex
+	lockProcessingImages      sync.RWMutex
+	lockResolveApproval       sync.RWMutex
+	lockRetryImageJob         sync.RWMutex
 	lockUpdate                sync.RWMutex
+	lockUpdateBlocklistEntry  sync.RWMutex
 	lockUpdateForGuard        sync.RWMutex
 }
 
+// ActiveBlocklist calls ActiveBlocklistFunc.
+func (mock *RequestsRepositoryMock) ActiveBlocklist(now time.Time) ([]*BlocklistEntry, error) {
+	if mock.ActiveBlocklistFunc == nil {
+		panic("RequestsRepositoryMock.ActiveBlocklistFunc: method is nil but RequestsRepository.ActiveBlocklist was just called")
+	}
+	callInfo := struct {
+		Now time.Time
+	}{
+		Now: now,
+	}
+	mock.lockActiveBlocklist.Lock()
+	mock.calls.ActiveBlocklist = append(mock.calls.ActiveBlocklist, callInfo)
+	mock.lockActiveBlocklist.Unlock()
+	return mock.ActiveBlocklistFunc(now)
+}
+
+// ActiveBlocklistCalls gets all the calls that were made to ActiveBlocklist.
+// Check the length with:
+//
+//	len(mockedRequestsRepository.ActiveBlocklistCalls())
+func (mock *RequestsRepositoryMock) ActiveBlocklistCalls() []struct {
+	Now time.Time
+} {
+	var calls []struct {
+		Now time.Time
+	}
+	mock.lockActiveBlocklist.RLock()
+	calls = mock.calls.ActiveBlocklist
+	mock.lockActiveBlocklist.RUnlock()
+	return calls
+}
+
 // AddImage calls AddImageFunc.
 func (mock *RequestsRepositoryMock) AddImage(userID uint, requestID uint, filename string) error {
 	if mock.AddImageFunc == nil {
@@ -214,6 +503,101 @@ func (mock *RequestsRepositoryMock) AddImageCalls() []struct {
 	return calls
 }
 
+// AvatarFilenames calls AvatarFilenamesFunc.
+func (mock *RequestsRepositoryMock) AvatarFilenames() ([]string, error) {
+	if mock.AvatarFilenamesFunc == nil {
+		panic("RequestsRepositoryMock.AvatarFilenamesFunc: method is nil but RequestsRepository.AvatarFilenames was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockAvatarFilenames.Lock()
+	mock.calls.AvatarFilenames = append(mock.calls.AvatarFilenames, callInfo)
+	mock.lockAvatarFilenames.Unlock()
+	return mock.AvatarFilenamesFunc()
+}
+
+// AvatarFilenamesCalls gets all the calls that were made to AvatarFilenames.
+// Check the length with:
+//
+//	len(mockedRequestsRepository.AvatarFilenamesCalls())
+func (mock *RequestsRepositoryMock) AvatarFilenamesCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockAvatarFilenames.RLock()
+	calls = mock.calls.AvatarFilenames
+	mock.lockAvatarFilenames.RUnlock()
+	return calls
+}
+
+// ClaimImageJob calls ClaimImageJobFunc.
+func (mock *RequestsRepositoryMock) ClaimImageJob(now time.Time, lease time.Duration) (*ImageJob, error) {
+	if mock.ClaimImageJobFunc == nil {
+		panic("RequestsRepositoryMock.ClaimImageJobFunc: method is nil but RequestsRepository.ClaimImageJob was just called")
+	}
+	callInfo := struct {
+		Now   time.Time
+		Lease time.Duration
+	}{
+		Now:   now,
+		Lease: lease,
+	}
+	mock.lockClaimImageJob.Lock()
+	mock.calls.ClaimImageJob = append(mock.calls.ClaimImageJob, callInfo)
+	mock.lockClaimImageJob.Unlock()
+	return mock.ClaimImageJobFunc(now, lease)
+}
+
+// ClaimImageJobCalls gets all the calls that were made to ClaimImageJob.
+// Check the length with:
+//
+//	len(mockedRequestsRepository.ClaimImageJobCalls())
+func (mock *RequestsRepositoryMock) ClaimImageJobCalls() []struct {
+	Now   time.Time
+	Lease time.Duration
+} {
+	var calls []struct {
+		Now   time.Time
+		Lease time.Duration
+	}
+	mock.lockClaimImageJob.RLock()
+	calls = mock.calls.ClaimImageJob
+	mock.lockClaimImageJob.RUnlock()
+	return calls
+}
+
+// CompleteImageJob calls CompleteImageJobFunc.
+func (mock *RequestsRepositoryMock) CompleteImageJob(id uint) (bool, error) {
+	if mock.CompleteImageJobFunc == nil {
+		panic("RequestsRepositoryMock.CompleteImageJobFunc: method is nil but RequestsRepository.CompleteImageJob was just called")
+	}
+	callInfo := struct {
+		ID uint
+	}{
+		ID: id,
+	}
+	mock.lockCompleteImageJob.Lock()
+	mock.calls.CompleteImageJob = append(mock.calls.CompleteImageJob, callInfo)
+	mock.lockCompleteImageJob.Unlock()
+	return mock.CompleteImageJobFunc(id)
+}
+
+// CompleteImageJobCalls gets all the calls that were made to CompleteImageJob.
+// Check the length with:
+//
+//	len(mockedRequestsRepository.CompleteImageJobCalls())
+func (mock *RequestsRepositoryMock) CompleteImageJobCalls() []struct {
+	ID uint
+} {
+	var calls []struct {
+		ID uint
+	}
+	mock.lockCompleteImageJob.RLock()
+	calls = mock.calls.CompleteImageJob
+	mock.lockCompleteImageJob.RUnlock()
+	return calls
+}
+
 // CountForGuard calls CountForGuardFunc.
 func (mock *RequestsRepositoryMock) CountForGuard(req *RequestListFilter) (int, error) {
 	if mock.CountForGuardFunc == nil {
@@ -247,19 +631,21 @@ func (mock *RequestsRepositoryMock) CountForGuardCalls() []struct {
 }
 
 // Create calls CreateFunc.
-func (mock *RequestsRepositoryMock) Create(req *Request) error {
+func (mock *RequestsRepositoryMock) Create(req *Request, event EventFunc) error {
 	if mock.CreateFunc == nil {
 		panic("RequestsRepositoryMock.CreateFunc: method is nil but RequestsRepository.Create was just called")
 	}
 	callInfo := struct {
-		Req *Request
+		Req   *Request
+		Event EventFunc
 	}{
-		Req: req,
+		Req:   req,
+		Event: event,
 	}
 	mock.lockCreate.Lock()
 	mock.calls.Create = append(mock.calls.Create, callInfo)
 	mock.lockCreate.Unlock()
-	return mock.CreateFunc(req)
+	return mock.CreateFunc(req, event)
 }
 
 // CreateCalls gets all the calls that were made to Create.
@@ -267,10 +653,12 @@ func (mock *RequestsRepositoryMock) Create(req *Request) error {
 //
 //	len(mockedRequestsRepository.CreateCalls())
 func (mock *RequestsRepositoryMock) CreateCalls() []struct {
-	Req *Request
+	Req   *Request
+	Event EventFunc
 } {
 	var calls []struct {
-		Req *Request
+		Req   *Request
+		Event EventFunc
 	}
 	mock.lockCreate.RLock()
 	calls = mock.calls.Create
@@ -278,6 +666,70 @@ func (mock *RequestsRepositoryMock) CreateCalls() []struct {
 	return calls
 }
 
+// CreateApproval calls CreateApprovalFunc.
+func (mock *RequestsRepositoryMock) CreateApproval(a *Approval) error {
+	if mock.CreateApprovalFunc == nil {
+		panic("RequestsRepositoryMock.CreateApprovalFunc: method is nil but RequestsRepository.CreateApproval was just called")
+	}
+	callInfo := struct {
+		A *Approval
+	}{
+		A: a,
+	}
+	mock.lockCreateApproval.Lock()
+	mock.calls.CreateApproval = append(mock.calls.CreateApproval, callInfo)
+	mock.lockCreateApproval.Unlock()
+	return mock.CreateApprovalFunc(a)
+}
+
+// CreateApprovalCalls gets all the calls that were made to CreateApproval.
+// Check the length with:
+//
+//	len(mockedRequestsRepository.CreateApprovalCalls())
+func (mock *RequestsRepositoryMock) CreateApprovalCalls() []struct {
+	A *Approval
+} {
+	var calls []struct {
+		A *Approval
+	}
+	mock.lockCreateApproval.RLock()
+	calls = mock.calls.CreateApproval
+	mock.lockCreateApproval.RUnlock()
+	return calls
+}
+
+// CreateBlocklistEntry calls CreateBlocklistEntryFunc.
+func (mock *RequestsRepositoryMock) CreateBlocklistEntry(e *BlocklistEntry) error {
+	if mock.CreateBlocklistEntryFunc == nil {
+		panic("RequestsRepositoryMock.CreateBlocklistEntryFunc: method is nil but RequestsRepository.CreateBlocklistEntry was just called")
+	}
+	callInfo := struct {
+		E *BlocklistEntry
+	}{
+		E: e,
+	}
+	mock.lockCreateBlocklistEntry.Lock()
+	mock.calls.CreateBlocklistEntry = append(mock.calls.CreateBlocklistEntry, callInfo)
+	mock.lockCreateBlocklistEntry.Unlock()
+	return mock.CreateBlocklistEntryFunc(e)
+}
+
+// CreateBlocklistEntryCalls gets all the calls that were made to CreateBlocklistEntry.
+// Check the length with:
+//
+//	len(mockedRequestsRepository.CreateBlocklistEntryCalls())
+func (mock *RequestsRepositoryMock) CreateBlocklistEntryCalls() []struct {
+	E *BlocklistEntry
+} {
+	var calls []struct {
+		E *BlocklistEntry
+	}
+	mock.lockCreateBlocklistEntry.RLock()
+	calls = mock.calls.CreateBlocklistEntry
+	mock.lockCreateBlocklistEntry.RUnlock()
+	return calls
+}
+
 // Delete calls DeleteFunc.
 func (mock *RequestsRepositoryMock) Delete(id uint, userID uint) error {
 	if mock.DeleteFunc == nil {
@@ -314,6 +766,42 @@ func (mock *RequestsRepositoryMock) DeleteCalls() []struct {
 	return calls
 }
 
+// DeleteBlocklistEntry calls DeleteBlocklistEntryFunc.
+func (mock *RequestsRepositoryMock) DeleteBlocklistEntry(id uint, adminID uint) error {
+	if mock.DeleteBlocklistEntryFunc == nil {
+		panic("RequestsRepositoryMock.DeleteBlocklistEntryFunc: method is nil but RequestsRepository.DeleteBlocklistEntry was just called")
+	}
+	callInfo := struct {
+		ID      uint
+		AdminID uint
+	}{
+		ID:      id,
+		AdminID: adminID,
+	}
+	mock.lockDeleteBlocklistEntry.Lock()
+	mock.calls.DeleteBlocklistEntry = append(mock.calls.DeleteBlocklistEntry, callInfo)
+	mock.lockDeleteBlocklistEntry.Unlock()
+	return mock.DeleteBlocklistEntryFunc(id, adminID)
+}
+
+// DeleteBlocklistEntryCalls gets all the calls that were made to DeleteBlocklistEntry.
+// Check the length with:
+//
+//	len(mockedRequestsRepository.DeleteBlocklistEntryCalls())
+func (mock *RequestsRepositoryMock) DeleteBlocklistEntryCalls() []struct {
+	ID      uint
+	AdminID uint
+} {
+	var calls []struct {
+		ID      uint
+		AdminID uint
+	}
+	mock.lockDeleteBlocklistEntry.RLock()
+	calls = mock.calls.DeleteBlocklistEntry
+	mock.lockDeleteBlocklistEntry.RUnlock()
+	return calls
+}
+
 // DeleteImage calls DeleteImageFunc.
 func (mock *RequestsRepositoryMock) DeleteImage(userID uint, requestID uint, filename string) error {
 	if mock.DeleteImageFunc == nil {
@@ -354,6 +842,170 @@ func (mock *RequestsRepositoryMock) DeleteImageCalls() []struct {
 	return calls
 }
 
+// EnqueueImage calls EnqueueImageFunc.
+func (mock *RequestsRepositoryMock) EnqueueImage(job *ImageJob) error {
+	if mock.EnqueueImageFunc == nil {
+		panic("RequestsRepositoryMock.EnqueueImageFunc: method is nil but RequestsRepository.EnqueueImage was just called")
+	}
+	callInfo := struct {
+		Job *ImageJob
+	}{
+		Job: job,
+	}
+	mock.lockEnqueueImage.Lock()
+	mock.calls.EnqueueImage = append(mock.calls.EnqueueImage, callInfo)
+	mock.lockEnqueueImage.Unlock()
+	return mock.EnqueueImageFunc(job)
+}
+
+// EnqueueImageCalls gets all the calls that were made to EnqueueImage.
+// Check the length with:
+//
+//	len(mockedRequestsRepository.EnqueueImageCalls())
+func (mock *RequestsRepositoryMock) EnqueueImageCalls() []struct {
+	Job *ImageJob
+} {
+	var calls []struct {
+		Job *ImageJob
+	}
+	mock.lockEnqueueImage.RLock()
+	calls = mock.calls.EnqueueImage
+	mock.lockEnqueueImage.RUnlock()
+	return calls
+}
+
+// ExpireApprovals calls ExpireApprovalsFunc.
+func (mock *RequestsRepositoryMock) ExpireApprovals(now time.Time) ([]*Approval, error) {
+	if mock.ExpireApprovalsFunc == nil {
+		panic("RequestsRepositoryMock.ExpireApprovalsFunc: method is nil but RequestsRepository.ExpireApprovals was just called")
+	}
+	callInfo := struct {
+		Now time.Time
+	}{
+		Now: now,
+	}
+	mock.lockExpireApprovals.Lock()
+	mock.calls.ExpireApprovals = append(mock.calls.ExpireApprovals, callInfo)
+	mock.lockExpireApprovals.Unlock()
+	return mock.ExpireApprovalsFunc(now)
+}
+
+// ExpireApprovalsCalls gets all the calls that were made to ExpireApprovals.
+// Check the length with:
+//
+//	len(mockedRequestsRepository.ExpireApprovalsCalls())
+func (mock *RequestsRepositoryMock) ExpireApprovalsCalls() []struct {
+	Now time.Time
+} {
+	var calls []struct {
+		Now time.Time
+	}
+	mock.lockExpireApprovals.RLock()
+	calls = mock.calls.ExpireApprovals
+	mock.lockExpireApprovals.RUnlock()
+	return calls
+}
+
+// FailImageJob calls FailImageJobFunc.
+func (mock *RequestsRepositoryMock) FailImageJob(job *ImageJob, lastErr string) error {
+	if mock.FailImageJobFunc == nil {
+		panic("RequestsRepositoryMock.FailImageJobFunc: method is nil but RequestsRepository.FailImageJob was just called")
+	}
+	callInfo := struct {
+		Job     *ImageJob
+		LastErr string
+	}{
+		Job:     job,
+		LastErr: lastErr,
+	}
+	mock.lockFailImageJob.Lock()
+	mock.calls.FailImageJob = append(mock.calls.FailImageJob, callInfo)
+	mock.lockFailImageJob.Unlock()
+	return mock.FailImageJobFunc(job, lastErr)
+}
+
+// FailImageJobCalls gets all the calls that were made to FailImageJob.
+// Check the length with:
+//
+//	len(mockedRequestsRepository.FailImageJobCalls())
+func (mock *RequestsRepositoryMock) FailImageJobCalls() []struct {
+	Job     *ImageJob
+	LastErr string
+} {
+	var calls []struct {
+		Job     *ImageJob
+		LastErr string
+	}
+	mock.lockFailImageJob.RLock()
+	calls = mock.calls.FailImageJob
+	mock.lockFailImageJob.RUnlock()
+	return calls
+}
+
+// GetApproval calls GetApprovalFunc.
+func (mock *RequestsRepositoryMock) GetApproval(id uint) (*Approval, error) {
+	if mock.GetApprovalFunc == nil {
+		panic("RequestsRepositoryMock.GetApprovalFunc: method is nil but RequestsRepository.GetApproval was just called")
+	}
+	callInfo := struct {
+		ID uint
+	}{
+		ID: id,
+	}
+	mock.lockGetApproval.Lock()
+	mock.calls.GetApproval = append(mock.calls.GetApproval, callInfo)
+	mock.lockGetApproval.Unlock()
+	return mock.GetApprovalFunc(id)
+}
+
+// GetApprovalCalls gets all the calls that were made to GetApproval.
+// Check the length with:
+//
+//	len(mockedRequestsRepository.GetApprovalCalls())
+func (mock *RequestsRepositoryMock) GetApprovalCalls() []struct {
+	ID uint
+} {
+	var calls []struct {
+		ID uint
+	}
+	mock.lockGetApproval.RLock()
+	calls = mock.calls.GetApproval
+	mock.lockGetApproval.RUnlock()
+	return calls
+}
+
+// GetBlocklistEntry calls GetBlocklistEntryFunc.
+func (mock *RequestsRepositoryMock) GetBlocklistEntry(id uint) (*BlocklistEntry, error) {
+	if mock.GetBlocklistEntryFunc == nil {
+		panic("RequestsRepositoryMock.GetBlocklistEntryFunc: method is nil but RequestsRepository.GetBlocklistEntry was just called")
+	}
+	callInfo := struct {
+		ID uint
+	}{
+		ID: id,
+	}
+	mock.lockGetBlocklistEntry.Lock()
+	mock.calls.GetBlocklistEntry = append(mock.calls.GetBlocklistEntry, callInfo)
+	mock.lockGetBlocklistEntry.Unlock()
+	return mock.GetBlocklistEntryFunc(id)
+}
+
+// GetBlocklistEntryCalls gets all the calls that were made to GetBlocklistEntry.
+// Check the length with:
+//
+//	len(mockedRequestsRepository.GetBlocklistEntryCalls())
+func (mock *RequestsRepositoryMock) GetBlocklistEntryCalls() []struct {
+	ID uint
+} {
+	var calls []struct {
+		ID uint
+	}
+	mock.lockGetBlocklistEntry.RLock()
+	calls = mock.calls.GetBlocklistEntry
+	mock.lockGetBlocklistEntry.RUnlock()
+	return calls
+}
+
 // GetRequestByIDAndUser calls GetRequestByIDAndUserFunc.
 func (mock *RequestsRepositoryMock) GetRequestByIDAndUser(id uint, userID uint) (*Request, error) {
 	if mock.GetRequestByIDAndUserFunc == nil {
@@ -417,31 +1069,117 @@ func (mock *RequestsRepositoryMock) GetStats24hCalls() []struct {
 	return calls
 }
 
-// ListByUser calls ListByUserFunc.
-func (mock *RequestsRepositoryMock) ListByUser(r *RequestListFilter) ([]*Request, error) {
-	if mock.ListByUserFunc == nil {
-		panic("RequestsRepositoryMock.ListByUserFunc: method is nil but RequestsRepository.ListByUser was just called")
+// ImageFilenames calls ImageFilenamesFunc.
+func (mock *RequestsRepositoryMock) ImageFilenames() ([]string, error) {
+	if mock.ImageFilenamesFunc == nil {
+		panic("RequestsRepositoryMock.ImageFilenamesFunc: method is nil but RequestsRepository.ImageFilenames was just called")
 	}
 	callInfo := struct {
-		R *RequestListFilter
-	}{
-		R: r,
-	}
-	mock.lockListByUser.Lock()
-	mock.calls.ListByUser = append(mock.calls.ListByUser, callInfo)
-	mock.lockListByUser.Unlock()
-	return mock.ListByUserFunc(r)
+	}{}
+	mock.lockImageFilenames.Lock()
+	mock.calls.ImageFilenames = append(mock.calls.ImageFilenames, callInfo)
+	mock.lockImageFilenames.Unlock()
+	return mock.ImageFilenamesFunc()
 }
 
-// ListByUserCalls gets all the calls that were made to ListByUser.
+// ImageFilenamesCalls gets all the calls that were made to ImageFilenames.
 // Check the length with:
 //
-//	len(mockedRequestsRepository.ListByUserCalls())
-func (mock *RequestsRepositoryMock) ListByUserCalls() []struct {
-	R *RequestListFilter
+//	len(mockedRequestsRepository.ImageFilenamesCalls())
+func (mock *RequestsRepositoryMock) ImageFilenamesCalls() []struct {
 } {
 	var calls []struct {
-		R *RequestListFilter
+	}
+	mock.lockImageFilenames.RLock()
+	calls = mock.calls.ImageFilenames
+	mock.lockImageFilenames.RUnlock()
+	return calls
+}
+
+// ListBlocklist calls ListBlocklistFunc.
+func (mock *RequestsRepositoryMock) ListBlocklist() ([]*BlocklistEntry, error) {
+	if mock.ListBlocklistFunc == nil {
+		panic("RequestsRepositoryMock.ListBlocklistFunc: method is nil but RequestsRepository.ListBlocklist was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockListBlocklist.Lock()
+	mock.calls.ListBlocklist = append(mock.calls.ListBlocklist, callInfo)
+	mock.lockListBlocklist.Unlock()
+	return mock.ListBlocklistFunc()
+}
+
+// ListBlocklistCalls gets all the calls that were made to ListBlocklist.
+// Check the length with:
+//
+//	len(mockedRequestsRepository.ListBlocklistCalls())
+func (mock *RequestsRepositoryMock) ListBlocklistCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockListBlocklist.RLock()
+	calls = mock.calls.ListBlocklist
+	mock.lockListBlocklist.RUnlock()
+	return calls
+}
+
+// ListBlocklistAudit calls ListBlocklistAuditFunc.
+func (mock *RequestsRepositoryMock) ListBlocklistAudit(entryID uint) ([]*BlocklistAudit, error) {
+	if mock.ListBlocklistAuditFunc == nil {
+		panic("RequestsRepositoryMock.ListBlocklistAuditFunc: method is nil but RequestsRepository.ListBlocklistAudit was just called")
+	}
+	callInfo := struct {
+		EntryID uint
+	}{
+		EntryID: entryID,
+	}
+	mock.lockListBlocklistAudit.Lock()
+	mock.calls.ListBlocklistAudit = append(mock.calls.ListBlocklistAudit, callInfo)
+	mock.lockListBlocklistAudit.Unlock()
+	return mock.ListBlocklistAuditFunc(entryID)
+}
+
+// ListBlocklistAuditCalls gets all the calls that were made to ListBlocklistAudit.
+// Check the length with:
+//
+//	len(mockedRequestsRepository.ListBlocklistAuditCalls())
+func (mock *RequestsRepositoryMock) ListBlocklistAuditCalls() []struct {
+	EntryID uint
+} {
+	var calls []struct {
+		EntryID uint
+	}
+	mock.lockListBlocklistAudit.RLock()
+	calls = mock.calls.ListBlocklistAudit
+	mock.lockListBlocklistAudit.RUnlock()
+	return calls
+}
+
+// ListByUser calls ListByUserFunc.
+func (mock *RequestsRepositoryMock) ListByUser(r *RequestListFilter) ([]*Request, error) {
+	if mock.ListByUserFunc == nil {
+		panic("RequestsRepositoryMock.ListByUserFunc: method is nil but RequestsRepository.ListByUser was just called")
+	}
+	callInfo := struct {
+		R *RequestListFilter
+	}{
+		R: r,
+	}
+	mock.lockListByUser.Lock()
+	mock.calls.ListByUser = append(mock.calls.ListByUser, callInfo)
+	mock.lockListByUser.Unlock()
+	return mock.ListByUserFunc(r)
+}
+
+// ListByUserCalls gets all the calls that were made to ListByUser.
+// Check the length with:
+//
+//	len(mockedRequestsRepository.ListByUserCalls())
+func (mock *RequestsRepositoryMock) ListByUserCalls() []struct {
+	R *RequestListFilter
+} {
+	var calls []struct {
+		R *RequestListFilter
 	}
 	mock.lockListByUser.RLock()
 	calls = mock.calls.ListByUser
@@ -481,6 +1219,146 @@ func (mock *RequestsRepositoryMock) ListForGuardCalls() []struct {
 	return calls
 }
 
+// ListPendingApprovals calls ListPendingApprovalsFunc.
+func (mock *RequestsRepositoryMock) ListPendingApprovals(buildingID uint, apartment uint) ([]*Approval, error) {
+	if mock.ListPendingApprovalsFunc == nil {
+		panic("RequestsRepositoryMock.ListPendingApprovalsFunc: method is nil but RequestsRepository.ListPendingApprovals was just called")
+	}
+	callInfo := struct {
+		BuildingID uint
+		Apartment  uint
+	}{
+		BuildingID: buildingID,
+		Apartment:  apartment,
+	}
+	mock.lockListPendingApprovals.Lock()
+	mock.calls.ListPendingApprovals = append(mock.calls.ListPendingApprovals, callInfo)
+	mock.lockListPendingApprovals.Unlock()
+	return mock.ListPendingApprovalsFunc(buildingID, apartment)
+}
+
+// ListPendingApprovalsCalls gets all the calls that were made to ListPendingApprovals.
+// Check the length with:
+//
+//	len(mockedRequestsRepository.ListPendingApprovalsCalls())
+func (mock *RequestsRepositoryMock) ListPendingApprovalsCalls() []struct {
+	BuildingID uint
+	Apartment  uint
+} {
+	var calls []struct {
+		BuildingID uint
+		Apartment  uint
+	}
+	mock.lockListPendingApprovals.RLock()
+	calls = mock.calls.ListPendingApprovals
+	mock.lockListPendingApprovals.RUnlock()
+	return calls
+}
+
+// ProcessingImages calls ProcessingImagesFunc.
+func (mock *RequestsRepositoryMock) ProcessingImages(requestIDs []uint) ([]string, error) {
+	if mock.ProcessingImagesFunc == nil {
+		panic("RequestsRepositoryMock.ProcessingImagesFunc: method is nil but RequestsRepository.ProcessingImages was just called")
+	}
+	callInfo := struct {
+		RequestIDs []uint
+	}{
+		RequestIDs: requestIDs,
+	}
+	mock.lockProcessingImages.Lock()
+	mock.calls.ProcessingImages = append(mock.calls.ProcessingImages, callInfo)
+	mock.lockProcessingImages.Unlock()
+	return mock.ProcessingImagesFunc(requestIDs)
+}
+
+// ProcessingImagesCalls gets all the calls that were made to ProcessingImages.
+// Check the length with:
+//
+//	len(mockedRequestsRepository.ProcessingImagesCalls())
+func (mock *RequestsRepositoryMock) ProcessingImagesCalls() []struct {
+	RequestIDs []uint
+} {
+	var calls []struct {
+		RequestIDs []uint
+	}
+	mock.lockProcessingImages.RLock()
+	calls = mock.calls.ProcessingImages
+	mock.lockProcessingImages.RUnlock()
+	return calls
+}
+
+// ResolveApproval calls ResolveApprovalFunc.
+func (mock *RequestsRepositoryMock) ResolveApproval(a *Approval) (bool, error) {
+	if mock.ResolveApprovalFunc == nil {
+		panic("RequestsRepositoryMock.ResolveApprovalFunc: method is nil but RequestsRepository.ResolveApproval was just called")
+	}
+	callInfo := struct {
+		A *Approval
+	}{
+		A: a,
+	}
+	mock.lockResolveApproval.Lock()
+	mock.calls.ResolveApproval = append(mock.calls.ResolveApproval, callInfo)
+	mock.lockResolveApproval.Unlock()
+	return mock.ResolveApprovalFunc(a)
+}
+
+// ResolveApprovalCalls gets all the calls that were made to ResolveApproval.
+// Check the length with:
+//
+//	len(mockedRequestsRepository.ResolveApprovalCalls())
+func (mock *RequestsRepositoryMock) ResolveApprovalCalls() []struct {
+	A *Approval
+} {
+	var calls []struct {
+		A *Approval
+	}
+	mock.lockResolveApproval.RLock()
+	calls = mock.calls.ResolveApproval
+	mock.lockResolveApproval.RUnlock()
+	return calls
+}
+
+// RetryImageJob calls RetryImageJobFunc.
+func (mock *RequestsRepositoryMock) RetryImageJob(id uint, runAt time.Time, lastErr string) error {
+	if mock.RetryImageJobFunc == nil {
+		panic("RequestsRepositoryMock.RetryImageJobFunc: method is nil but RequestsRepository.RetryImageJob was just called")
+	}
+	callInfo := struct {
+		ID      uint
+		RunAt   time.Time
+		LastErr string
+	}{
+		ID:      id,
+		RunAt:   runAt,
+		LastErr: lastErr,
+	}
+	mock.lockRetryImageJob.Lock()
+	mock.calls.RetryImageJob = append(mock.calls.RetryImageJob, callInfo)
+	mock.lockRetryImageJob.Unlock()
+	return mock.RetryImageJobFunc(id, runAt, lastErr)
+}
+
+// RetryImageJobCalls gets all the calls that were made to RetryImageJob.
+// Check the length with:
+//
+//	len(mockedRequestsRepository.RetryImageJobCalls())
+func (mock *RequestsRepositoryMock) RetryImageJobCalls() []struct {
+	ID      uint
+	RunAt   time.Time
+	LastErr string
+} {
+	var calls []struct {
+		ID      uint
+		RunAt   time.Time
+		LastErr string
+	}
+	mock.lockRetryImageJob.RLock()
+	calls = mock.calls.RetryImageJob
+	mock.lockRetryImageJob.RUnlock()
+	return calls
+}
+
 // Update calls UpdateFunc.
 func (mock *RequestsRepositoryMock) Update(update *UpdateRequest) error {
 	if mock.UpdateFunc == nil {
@@ -513,22 +1391,60 @@ func (mock *RequestsRepositoryMock) UpdateCalls() []struct {
 	return calls
 }
 
+// UpdateBlocklistEntry calls UpdateBlocklistEntryFunc.
+func (mock *RequestsRepositoryMock) UpdateBlocklistEntry(e *BlocklistEntry, adminID uint) error {
+	if mock.UpdateBlocklistEntryFunc == nil {
+		panic("RequestsRepositoryMock.UpdateBlocklistEntryFunc: method is nil but RequestsRepository.UpdateBlocklistEntry was just called")
+	}
+	callInfo := struct {
+		E       *BlocklistEntry
+		AdminID uint
+	}{
+		E:       e,
+		AdminID: adminID,
+	}
+	mock.lockUpdateBlocklistEntry.Lock()
+	mock.calls.UpdateBlocklistEntry = append(mock.calls.UpdateBlocklistEntry, callInfo)
+	mock.lockUpdateBlocklistEntry.Unlock()
+	return mock.UpdateBlocklistEntryFunc(e, adminID)
+}
+
+// UpdateBlocklistEntryCalls gets all the calls that were made to UpdateBlocklistEntry.
+// Check the length with:
+//
+//	len(mockedRequestsRepository.UpdateBlocklistEntryCalls())
+func (mock *RequestsRepositoryMock) UpdateBlocklistEntryCalls() []struct {
+	E       *BlocklistEntry
+	AdminID uint
+} {
+	var calls []struct {
+		E       *BlocklistEntry
+		AdminID uint
+	}
+	mock.lockUpdateBlocklistEntry.RLock()
+	calls = mock.calls.UpdateBlocklistEntry
+	mock.lockUpdateBlocklistEntry.RUnlock()
+	return calls
+}
+
 // UpdateForGuard calls UpdateForGuardFunc.
-func (mock *RequestsRepositoryMock) UpdateForGuard(id uint, status string) error {
+func (mock *RequestsRepositoryMock) UpdateForGuard(id uint, status string, event EventFunc) error {
 	if mock.UpdateForGuardFunc == nil {
 		panic("RequestsRepositoryMock.UpdateForGuardFunc: method is nil but RequestsRepository.UpdateForGuard was just called")
 	}
 	callInfo := struct {
 		ID     uint
 		Status string
+		Event  EventFunc
 	}{
 		ID:     id,
 		Status: status,
+		Event:  event,
 	}
 	mock.lockUpdateForGuard.Lock()
 	mock.calls.UpdateForGuard = append(mock.calls.UpdateForGuard, callInfo)
 	mock.lockUpdateForGuard.Unlock()
-	return mock.UpdateForGuardFunc(id, status)
+	return mock.UpdateForGuardFunc(id, status, event)
 }
 
 // UpdateForGuardCalls gets all the calls that were made to UpdateForGuard.
@@ -538,10 +1454,12 @@ func (mock *RequestsRepositoryMock) UpdateForGuard(id uint, status string) error
 func (mock *RequestsRepositoryMock) UpdateForGuardCalls() []struct {
 	ID     uint
 	Status string
+	Event  EventFunc
 } {
 	var calls []struct {
 		ID     uint
 		Status string
+		Event  EventFunc
 	}
 	mock.lockUpdateForGuard.RLock()
 	calls = mock.calls.UpdateForGuard
@@ -549,112 +1467,604 @@ func (mock *RequestsRepositoryMock) UpdateForGuardCalls() []struct {
 	return calls
 }
 
-// Ensure, that S3ClientMock does implement S3Client.
+// Ensure, that UserServiceMock does implement UserService.
 // If this is not the case, regenerate this file with moq.
-var _ S3Client = &S3ClientMock{}
+var _ UserService = &UserServiceMock{}
 
-// S3ClientMock is a mock implementation of S3Client.
+// UserServiceMock is a mock implementation of UserService.
 //
-//	func TestSomethingThatUsesS3Client(t *testing.T) {
+//	func TestSomethingThatUsesUserService(t *testing.T) {
 //
-//		// make and configure a mocked S3Client
-//		mockedS3Client := &S3ClientMock{
-//			DeleteObjectFunc: func(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
-//				panic("mock out the DeleteObject method")
+//		// make and configure a mocked UserService
+//		mockedUserService := &UserServiceMock{
+//			ApartmentMembersFunc: func(ctx context.Context, buildingID uint, apartment uint) ([]*users.User, error) {
+//				panic("mock out the ApartmentMembers method")
+//			},
+//			UserByApartmentFunc: func(ctx context.Context, buildingID uint, apartment uint) (*users.User, error) {
+//				panic("mock out the UserByApartment method")
 //			},
-//			PutObjectFunc: func(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
-//				panic("mock out the PutObject method")
+//			UserByIDFunc: func(ctx context.Context, id uint) (*users.User, error) {
+//				panic("mock out the UserByID method")
 //			},
 //		}
 //
-//		// use mockedS3Client in code that requires S3Client
+//		// use mockedUserService in code that requires UserService
 //		// and then make assertions.
 //
 //	}
-type S3ClientMock struct {
-	// DeleteObjectFunc mocks the DeleteObject method.
-	DeleteObjectFunc func(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error)
+type UserServiceMock struct {
+	// ApartmentMembersFunc mocks the ApartmentMembers method.
+	ApartmentMembersFunc func(ctx context.Context, buildingID uint, apartment uint) ([]*users.User, error)
 
-	// PutObjectFunc mocks the PutObject method.
-	PutObjectFunc func(input *s3.PutObjectInput) (*s3.PutObjectOutput, error)
+	// UserByApartmentFunc mocks the UserByApartment method.
+	UserByApartmentFunc func(ctx context.Context, buildingID uint, apartment uint) (*users.User, error)
+
+	// UserByIDFunc mocks the UserByID method.
+	UserByIDFunc func(ctx context.Context, id uint) (*users.User, error)
 
 	// calls tracks calls to the methods.
 	calls struct {
-		// DeleteObject holds details about calls to the DeleteObject method.
-		DeleteObject []struct {
-			// Input is the input argument value.
-			Input *s3.DeleteObjectInput
+		// ApartmentMembers holds details about calls to the ApartmentMembers method.
+		ApartmentMembers []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// BuildingID is the buildingID argument value.
+			BuildingID uint
+			// Apartment is the apartment argument value.
+			Apartment uint
 		}
-		// PutObject holds details about calls to the PutObject method.
-		PutObject []struct {
-			// Input is the input argument value.
-			Input *s3.PutObjectInput
+		// UserByApartment holds details about calls to the UserByApartment method.
+		UserByApartment []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// BuildingID is the buildingID argument value.
+			BuildingID uint
+			// Apartment is the apartment argument value.
+			Apartment uint
+		}
+		// UserByID holds details about calls to the UserByID method.
+		UserByID []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ID is the id argument value.
+			ID uint
 		}
 	}
-	lockDeleteObject sync.RWMutex
-	lockPutObject    sync.RWMutex
+	lockApartmentMembers sync.RWMutex
+	lockUserByApartment  sync.RWMutex
+	lockUserByID         sync.RWMutex
 }
 
-// DeleteObject calls DeleteObjectFunc.
-func (mock *S3ClientMock) DeleteObject(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
-	if mock.DeleteObjectFunc == nil {
-		panic("S3ClientMock.DeleteObjectFunc: method is nil but S3Client.DeleteObject was just called")
+// ApartmentMembers calls ApartmentMembersFunc.
+func (mock *UserServiceMock) ApartmentMembers(ctx context.Context, buildingID uint, apartment uint) ([]*users.User, error) {
+	if mock.ApartmentMembersFunc == nil {
+		panic("UserServiceMock.ApartmentMembersFunc: method is nil but UserService.ApartmentMembers was just called")
 	}
 	callInfo := struct {
-		Input *s3.DeleteObjectInput
+		Ctx        context.Context
+		BuildingID uint
+		Apartment  uint
 	}{
-		Input: input,
+		Ctx:        ctx,
+		BuildingID: buildingID,
+		Apartment:  apartment,
 	}
-	mock.lockDeleteObject.Lock()
-	mock.calls.DeleteObject = append(mock.calls.DeleteObject, callInfo)
-	mock.lockDeleteObject.Unlock()
-	return mock.DeleteObjectFunc(input)
+	mock.lockApartmentMembers.Lock()
+	mock.calls.ApartmentMembers = append(mock.calls.ApartmentMembers, callInfo)
+	mock.lockApartmentMembers.Unlock()
+	return mock.ApartmentMembersFunc(ctx, buildingID, apartment)
 }
 
-// DeleteObjectCalls gets all the calls that were made to DeleteObject.
+// ApartmentMembersCalls gets all the calls that were made to ApartmentMembers.
 // Check the length with:
 //
-//	len(mockedS3Client.DeleteObjectCalls())
-func (mock *S3ClientMock) DeleteObjectCalls() []struct {
-	Input *s3.DeleteObjectInput
+//	len(mockedUserService.ApartmentMembersCalls())
+func (mock *UserServiceMock) ApartmentMembersCalls() []struct {
+	Ctx        context.Context
+	BuildingID uint
+	Apartment  uint
 } {
 	var calls []struct {
-		Input *s3.DeleteObjectInput
+		Ctx        context.Context
+		BuildingID uint
+		Apartment  uint
+	}
+	mock.lockApartmentMembers.RLock()
+	calls = mock.calls.ApartmentMembers
+	mock.lockApartmentMembers.RUnlock()
+	return calls
+}
+
+// UserByApartment calls UserByApartmentFunc.
+func (mock *UserServiceMock) UserByApartment(ctx context.Context, buildingID uint, apartment uint) (*users.User, error) {
+	if mock.UserByApartmentFunc == nil {
+		panic("UserServiceMock.UserByApartmentFunc: method is nil but UserService.UserByApartment was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		BuildingID uint
+		Apartment  uint
+	}{
+		Ctx:        ctx,
+		BuildingID: buildingID,
+		Apartment:  apartment,
+	}
+	mock.lockUserByApartment.Lock()
+	mock.calls.UserByApartment = append(mock.calls.UserByApartment, callInfo)
+	mock.lockUserByApartment.Unlock()
+	return mock.UserByApartmentFunc(ctx, buildingID, apartment)
+}
+
+// UserByApartmentCalls gets all the calls that were made to UserByApartment.
+// Check the length with:
+//
+//	len(mockedUserService.UserByApartmentCalls())
+func (mock *UserServiceMock) UserByApartmentCalls() []struct {
+	Ctx        context.Context
+	BuildingID uint
+	Apartment  uint
+} {
+	var calls []struct {
+		Ctx        context.Context
+		BuildingID uint
+		Apartment  uint
+	}
+	mock.lockUserByApartment.RLock()
+	calls = mock.calls.UserByApartment
+	mock.lockUserByApartment.RUnlock()
+	return calls
+}
+
+// UserByID calls UserByIDFunc.
+func (mock *UserServiceMock) UserByID(ctx context.Context, id uint) (*users.User, error) {
+	if mock.UserByIDFunc == nil {
+		panic("UserServiceMock.UserByIDFunc: method is nil but UserService.UserByID was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		ID  uint
+	}{
+		Ctx: ctx,
+		ID:  id,
+	}
+	mock.lockUserByID.Lock()
+	mock.calls.UserByID = append(mock.calls.UserByID, callInfo)
+	mock.lockUserByID.Unlock()
+	return mock.UserByIDFunc(ctx, id)
+}
+
+// UserByIDCalls gets all the calls that were made to UserByID.
+// Check the length with:
+//
+//	len(mockedUserService.UserByIDCalls())
+func (mock *UserServiceMock) UserByIDCalls() []struct {
+	Ctx context.Context
+	ID  uint
+} {
+	var calls []struct {
+		Ctx context.Context
+		ID  uint
+	}
+	mock.lockUserByID.RLock()
+	calls = mock.calls.UserByID
+	mock.lockUserByID.RUnlock()
+	return calls
+}
+
+// Ensure, that StorageMock does implement Storage.
+// If this is not the case, regenerate this file with moq.
+var _ Storage = &StorageMock{}
+
+// StorageMock is a mock implementation of Storage.
+//
+//	func TestSomethingThatUsesStorage(t *testing.T) {
+//
+//		// make and configure a mocked Storage
+//		mockedStorage := &StorageMock{
+//			DeleteFunc: func(ctx context.Context, key string) error {
+//				panic("mock out the Delete method")
+//			},
+//			GetFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+//				panic("mock out the Get method")
+//			},
+//			ListFunc: func(ctx context.Context, prefix string) ([]storage.ObjectInfo, error) {
+//				panic("mock out the List method")
+//			},
+//			PresignFunc: func(ctx context.Context, key string, ttl time.Duration) (string, error) {
+//				panic("mock out the Presign method")
+//			},
+//			PutFunc: func(ctx context.Context, key string, body io.Reader, opts storage.PutOptions) error {
+//				panic("mock out the Put method")
+//			},
+//		}
+//
+//		// use mockedStorage in code that requires Storage
+//		// and then make assertions.
+//
+//	}
+type StorageMock struct {
+	// DeleteFunc mocks the Delete method.
+	DeleteFunc func(ctx context.Context, key string) error
+
+	// GetFunc mocks the Get method.
+	GetFunc func(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// ListFunc mocks the List method.
+	ListFunc func(ctx context.Context, prefix string) ([]storage.ObjectInfo, error)
+
+	// PresignFunc mocks the Presign method.
+	PresignFunc func(ctx context.Context, key string, ttl time.Duration) (string, error)
+
+	// PutFunc mocks the Put method.
+	PutFunc func(ctx context.Context, key string, body io.Reader, opts storage.PutOptions) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Delete holds details about calls to the Delete method.
+		Delete []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Key is the key argument value.
+			Key string
+		}
+		// Get holds details about calls to the Get method.
+		Get []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Key is the key argument value.
+			Key string
+		}
+		// List holds details about calls to the List method.
+		List []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Prefix is the prefix argument value.
+			Prefix string
+		}
+		// Presign holds details about calls to the Presign method.
+		Presign []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Key is the key argument value.
+			Key string
+			// TTL is the ttl argument value.
+			TTL time.Duration
+		}
+		// Put holds details about calls to the Put method.
+		Put []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Key is the key argument value.
+			Key string
+			// Body is the body argument value.
+			Body io.Reader
+			// Opts is the opts argument value.
+			Opts storage.PutOptions
+		}
+	}
+	lockDelete  sync.RWMutex
+	lockGet     sync.RWMutex
+	lockList    sync.RWMutex
+	lockPresign sync.RWMutex
+	lockPut     sync.RWMutex
+}
+
+// Delete calls DeleteFunc.
+func (mock *StorageMock) Delete(ctx context.Context, key string) error {
+	if mock.DeleteFunc == nil {
+		panic("StorageMock.DeleteFunc: method is nil but Storage.Delete was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Key string
+	}{
+		Ctx: ctx,
+		Key: key,
+	}
+	mock.lockDelete.Lock()
+	mock.calls.Delete = append(mock.calls.Delete, callInfo)
+	mock.lockDelete.Unlock()
+	return mock.DeleteFunc(ctx, key)
+}
+
+// DeleteCalls gets all the calls that were made to Delete.
+// Check the length with:
+//
+//	len(mockedStorage.DeleteCalls())
+func (mock *StorageMock) DeleteCalls() []struct {
+	Ctx context.Context
+	Key string
+} {
+	var calls []struct {
+		Ctx context.Context
+		Key string
+	}
+	mock.lockDelete.RLock()
+	calls = mock.calls.Delete
+	mock.lockDelete.RUnlock()
+	return calls
+}
+
+// Get calls GetFunc.
+func (mock *StorageMock) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if mock.GetFunc == nil {
+		panic("StorageMock.GetFunc: method is nil but Storage.Get was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Key string
+	}{
+		Ctx: ctx,
+		Key: key,
+	}
+	mock.lockGet.Lock()
+	mock.calls.Get = append(mock.calls.Get, callInfo)
+	mock.lockGet.Unlock()
+	return mock.GetFunc(ctx, key)
+}
+
+// GetCalls gets all the calls that were made to Get.
+// Check the length with:
+//
+//	len(mockedStorage.GetCalls())
+func (mock *StorageMock) GetCalls() []struct {
+	Ctx context.Context
+	Key string
+} {
+	var calls []struct {
+		Ctx context.Context
+		Key string
+	}
+	mock.lockGet.RLock()
+	calls = mock.calls.Get
+	mock.lockGet.RUnlock()
+	return calls
+}
+
+// List calls ListFunc.
+func (mock *StorageMock) List(ctx context.Context, prefix string) ([]storage.ObjectInfo, error) {
+	if mock.ListFunc == nil {
+		panic("StorageMock.ListFunc: method is nil but Storage.List was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Prefix string
+	}{
+		Ctx:    ctx,
+		Prefix: prefix,
+	}
+	mock.lockList.Lock()
+	mock.calls.List = append(mock.calls.List, callInfo)
+	mock.lockList.Unlock()
+	return mock.ListFunc(ctx, prefix)
+}
+
+// ListCalls gets all the calls that were made to List.
+// Check the length with:
+//
+//	len(mockedStorage.ListCalls())
+func (mock *StorageMock) ListCalls() []struct {
+	Ctx    context.Context
+	Prefix string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Prefix string
+	}
+	mock.lockList.RLock()
+	calls = mock.calls.List
+	mock.lockList.RUnlock()
+	return calls
+}
+
+// Presign calls PresignFunc.
+func (mock *StorageMock) Presign(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if mock.PresignFunc == nil {
+		panic("StorageMock.PresignFunc: method is nil but Storage.Presign was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Key string
+		TTL time.Duration
+	}{
+		Ctx: ctx,
+		Key: key,
+		TTL: ttl,
+	}
+	mock.lockPresign.Lock()
+	mock.calls.Presign = append(mock.calls.Presign, callInfo)
+	mock.lockPresign.Unlock()
+	return mock.PresignFunc(ctx, key, ttl)
+}
+
+// PresignCalls gets all the calls that were made to Presign.
+// Check the length with:
+//
+//	len(mockedStorage.PresignCalls())
+func (mock *StorageMock) PresignCalls() []struct {
+	Ctx context.Context
+	Key string
+	TTL time.Duration
+} {
+	var calls []struct {
+		Ctx context.Context
+		Key string
+		TTL time.Duration
+	}
+	mock.lockPresign.RLock()
+	calls = mock.calls.Presign
+	mock.lockPresign.RUnlock()
+	return calls
+}
+
+// Put calls PutFunc.
+func (mock *StorageMock) Put(ctx context.Context, key string, body io.Reader, opts storage.PutOptions) error {
+	if mock.PutFunc == nil {
+		panic("StorageMock.PutFunc: method is nil but Storage.Put was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		Key  string
+		Body io.Reader
+		Opts storage.PutOptions
+	}{
+		Ctx:  ctx,
+		Key:  key,
+		Body: body,
+		Opts: opts,
+	}
+	mock.lockPut.Lock()
+	mock.calls.Put = append(mock.calls.Put, callInfo)
+	mock.lockPut.Unlock()
+	return mock.PutFunc(ctx, key, body, opts)
+}
+
+// PutCalls gets all the calls that were made to Put.
+// Check the length with:
+//
+//	len(mockedStorage.PutCalls())
+func (mock *StorageMock) PutCalls() []struct {
+	Ctx  context.Context
+	Key  string
+	Body io.Reader
+	Opts storage.PutOptions
+} {
+	var calls []struct {
+		Ctx  context.Context
+		Key  string
+		Body io.Reader
+		Opts storage.PutOptions
+	}
+	mock.lockPut.RLock()
+	calls = mock.calls.Put
+	mock.lockPut.RUnlock()
+	return calls
+}
+
+// Ensure, that NotifierMock does implement Notifier.
+// If this is not the case, regenerate this file with moq.
+var _ Notifier = &NotifierMock{}
+
+// NotifierMock is a mock implementation of Notifier.
+//
+//	func TestSomethingThatUsesNotifier(t *testing.T) {
+//
+//		// make and configure a mocked Notifier
+//		mockedNotifier := &NotifierMock{
+//			EventMessageFunc: func(userID uint, event string, data map[string]string) (*outbox.Message, error) {
+//				panic("mock out the EventMessage method")
+//			},
+//			GuardEventMessageFunc: func(event string, data map[string]string) (*outbox.Message, error) {
+//				panic("mock out the GuardEventMessage method")
+//			},
+//		}
+//
+//		// use mockedNotifier in code that requires Notifier
+//		// and then make assertions.
+//
+//	}
+type NotifierMock struct {
+	// EventMessageFunc mocks the EventMessage method.
+	EventMessageFunc func(userID uint, event string, data map[string]string) (*outbox.Message, error)
+
+	// GuardEventMessageFunc mocks the GuardEventMessage method.
+	GuardEventMessageFunc func(event string, data map[string]string) (*outbox.Message, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// EventMessage holds details about calls to the EventMessage method.
+		EventMessage []struct {
+			// UserID is the userID argument value.
+			UserID uint
+			// Event is the event argument value.
+			Event string
+			// Data is the data argument value.
+			Data map[string]string
+		}
+		// GuardEventMessage holds details about calls to the GuardEventMessage method.
+		GuardEventMessage []struct {
+			// Event is the event argument value.
+			Event string
+			// Data is the data argument value.
+			Data map[string]string
+		}
+	}
+	lockEventMessage      sync.RWMutex
+	lockGuardEventMessage sync.RWMutex
+}
+
+// EventMessage calls EventMessageFunc.
+func (mock *NotifierMock) EventMessage(userID uint, event string, data map[string]string) (*outbox.Message, error) {
+	if mock.EventMessageFunc == nil {
+		panic("NotifierMock.EventMessageFunc: method is nil but Notifier.EventMessage was just called")
+	}
+	callInfo := struct {
+		UserID uint
+		Event  string
+		Data   map[string]string
+	}{
+		UserID: userID,
+		Event:  event,
+		Data:   data,
+	}
+	mock.lockEventMessage.Lock()
+	mock.calls.EventMessage = append(mock.calls.EventMessage, callInfo)
+	mock.lockEventMessage.Unlock()
+	return mock.EventMessageFunc(userID, event, data)
+}
+
+// EventMessageCalls gets all the calls that were made to EventMessage.
+// Check the length with:
+//
+//	len(mockedNotifier.EventMessageCalls())
+func (mock *NotifierMock) EventMessageCalls() []struct {
+	UserID uint
+	Event  string
+	Data   map[string]string
+} {
+	var calls []struct {
+		UserID uint
+		Event  string
+		Data   map[string]string
 	}
-	mock.lockDeleteObject.RLock()
-	calls = mock.calls.DeleteObject
-	mock.lockDeleteObject.RUnlock()
+	mock.lockEventMessage.RLock()
+	calls = mock.calls.EventMessage
+	mock.lockEventMessage.RUnlock()
 	return calls
 }
 
-// PutObject calls PutObjectFunc.
-func (mock *S3ClientMock) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
-	if mock.PutObjectFunc == nil {
-		panic("S3ClientMock.PutObjectFunc: method is nil but S3Client.PutObject was just called")
+// GuardEventMessage calls GuardEventMessageFunc.
+func (mock *NotifierMock) GuardEventMessage(event string, data map[string]string) (*outbox.Message, error) {
+	if mock.GuardEventMessageFunc == nil {
+		panic("NotifierMock.GuardEventMessageFunc: method is nil but Notifier.GuardEventMessage was just called")
 	}
 	callInfo := struct {
-		Input *s3.PutObjectInput
+		Event string
+		Data  map[string]string
 	}{
-		Input: input,
+		Event: event,
+		Data:  data,
 	}
-	mock.lockPutObject.Lock()
-	mock.calls.PutObject = append(mock.calls.PutObject, callInfo)
-	mock.lockPutObject.Unlock()
-	return mock.PutObjectFunc(input)
+	mock.lockGuardEventMessage.Lock()
+	mock.calls.GuardEventMessage = append(mock.calls.GuardEventMessage, callInfo)
+	mock.lockGuardEventMessage.Unlock()
+	return mock.GuardEventMessageFunc(event, data)
 }
 
-// PutObjectCalls gets all the calls that were made to PutObject.
+// GuardEventMessageCalls gets all the calls that were made to GuardEventMessage.
 // Check the length with:
 //
-//	len(mockedS3Client.PutObjectCalls())
-func (mock *S3ClientMock) PutObjectCalls() []struct {
-	Input *s3.PutObjectInput
+//	len(mockedNotifier.GuardEventMessageCalls())
+func (mock *NotifierMock) GuardEventMessageCalls() []struct {
+	Event string
+	Data  map[string]string
 } {
 	var calls []struct {
-		Input *s3.PutObjectInput
+		Event string
+		Data  map[string]string
 	}
-	mock.lockPutObject.RLock()
-	calls = mock.calls.PutObject
-	mock.lockPutObject.RUnlock()
+	mock.lockGuardEventMessage.RLock()
+	calls = mock.calls.GuardEventMessage
+	mock.lockGuardEventMessage.RUnlock()
 	return calls
 }
@@ -0,0 +1,197 @@
+package requests
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"path"
+	"time"
+
+	"github.com/disintegration/imaging"
+)
+
+// DefaultOrphanMinAge protects the files of the requests being saved right now from deletion.
+const DefaultOrphanMinAge = 24 * time.Hour
+
+// ReconcileOptions configures the reconciliation of the stored images with the requests.
+type ReconcileOptions struct {
+	// DeleteOrphans removes the stored files no request or user refers to, otherwise they are only reported.
+	DeleteOrphans bool
+	// MinAge is how old the orphan has to be to be deleted.
+	MinAge time.Duration
+}
+
+// ReconcileReport lists the mismatches found between the storage and the requests.
+type ReconcileReport struct {
+	Checked             int
+	Orphans             []string
+	Deleted             int
+	MissingVariants     []string
+	RegeneratedVariants int
+	MissingImages       []string
+}
+
+func (r *ReconcileReport) String() string {
+	return fmt.Sprintf("checked %d files: %d orphans (%d deleted), %d missing variants (%d regenerated), %d missing images",
+		r.Checked, len(r.Orphans), r.Deleted, len(r.MissingVariants), r.RegeneratedVariants, len(r.MissingImages))
+}
+
+// ReconcileImages compares the stored images and their variants with the requests, and the avatars with the users.
+func (s *Service) ReconcileImages(ctx context.Context, opts ReconcileOptions) (*ReconcileReport, error) {
+	files, err := s.repo.ImageFilenames()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get request files: %w", err)
+	}
+
+	avatars, err := s.repo.AvatarFilenames()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get avatar files: %w", err)
+	}
+
+	// all the keys the files of the requests and the avatars may have
+	known := make(map[string]bool)
+	for i := range files {
+		known[s.buildImagePath(ImgPathPrefix, files[i])] = true
+		known[s.buildImagePath(UploadPathPrefix, files[i])] = true
+		for _, v := range s.fileVariants(files[i]) {
+			known[variantKey(v, files[i])] = true
+		}
+	}
+	for i := range avatars {
+		known[AvatarPathPrefix+avatars[i]] = true
+		known[AvatarThumbPathPrefix+avatars[i]] = true
+	}
+
+	var (
+		report    ReconcileReport
+		stored    = make(map[string]bool)
+		orphanAge = time.Now().Add(-opts.MinAge)
+	)
+
+	for _, prefix := range []string{ImgPathPrefix, ThumbPathPrefix, VariantPathPrefix, UploadPathPrefix, AvatarPathPrefix, AvatarThumbPathPrefix} {
+		objects, err := s.storage.List(ctx, prefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", prefix, err)
+		}
+
+		for _, o := range objects {
+			stored[o.Key] = true
+			report.Checked++
+
+			if known[o.Key] || o.LastModified.After(orphanAge) {
+				continue
+			}
+
+			report.Orphans = append(report.Orphans, o.Key)
+			if !opts.DeleteOrphans {
+				continue
+			}
+
+			if err := s.storage.Delete(ctx, o.Key); err != nil {
+				s.log.Error("failed to delete orphan %s: %w", o.Key, err)
+				continue
+			}
+			report.Deleted++
+		}
+	}
+
+	for _, filename := range files {
+		// the image is still being processed
+		if stored[s.buildImagePath(UploadPathPrefix, filename)] {
+			continue
+		}
+
+		if !stored[s.buildImagePath(ImgPathPrefix, filename)] {
+			report.MissingImages = append(report.MissingImages, s.buildImagePath(ImgPathPrefix, filename))
+			continue
+		}
+
+		var missing []ImageVariant
+		for _, v := range s.fileVariants(filename) {
+			if key := variantKey(v, filename); !stored[key] {
+				missing = append(missing, v)
+				report.MissingVariants = append(report.MissingVariants, key)
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		n, err := s.regenerateVariants(ctx, filename, missing)
+		if err != nil {
+			s.log.Error("failed to regenerate variants of %s: %w", filename, err)
+		}
+		report.RegeneratedVariants += n
+	}
+
+	return &report, nil
+}
+
+// RunImageReconciliation reconciles the images every interval until ctx is done.
+func (s *Service) RunImageReconciliation(ctx context.Context, interval time.Duration, opts ReconcileOptions) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := s.ReconcileImages(ctx, opts)
+			if err != nil {
+				s.log.Error("error reconciling images: %w", err)
+				continue
+			}
+			s.log.Info("images reconciled: %s", report)
+		}
+	}
+}
+
+// regenerateVariants renders the variants from the stored image and returns how many were saved.
+func (s *Service) regenerateVariants(ctx context.Context, filename string, variants []ImageVariant) (int, error) {
+	var (
+		img image.Image
+		err error
+	)
+
+	if path.Ext(filename) == attachmentExt(ContentTypePDF) {
+		img = documentPlaceholder("PDF")
+	} else {
+		img, err = s.storedImage(ctx, s.buildImagePath(ImgPathPrefix, filename))
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var saved int
+	for _, v := range variants {
+		variant, err := renderVariant(img, v)
+		if err != nil {
+			return saved, err
+		}
+
+		if err := s.putVariants(ctx, filename, []AttachmentVariant{*variant}); err != nil {
+			return saved, err
+		}
+		saved++
+	}
+
+	return saved, nil
+}
+
+func (s *Service) storedImage(ctx context.Context, key string) (image.Image, error) {
+	rc, err := s.storage.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close() // nolint: errcheck
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	return imaging.Decode(bytes.NewReader(data))
+}
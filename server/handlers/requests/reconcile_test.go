@@ -0,0 +1,150 @@
+package requests_test
+
+import (
+	"bytes"
+	"context"
+	"image/jpeg"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ivch/dynasty/server/handlers/requests"
+)
+
+func TestService_ReconcileImages(t *testing.T) {
+	jpg, err := os.ReadFile("../../../test_image.jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		old    = time.Now().Add(-48 * time.Hour)
+		recent = time.Now()
+	)
+
+	tests := []struct {
+		name          string
+		opts          requests.ReconcileOptions
+		wantOrphans   []string
+		wantDeleted   int
+		wantVariants  []string
+		wantMissing   []string
+		wantRemaining []string
+	}{
+		{
+			name: "report only",
+			opts: requests.ReconcileOptions{MinAge: 24 * time.Hour},
+			wantOrphans: []string{
+				"avatar/i/old.jpg", "avatar/t/old.jpg",
+				"req/i/orphan.jpg", "req/t/orphan.jpg", "req/u/failed.jpg", "req/v/small/orphan.jpg",
+			},
+			wantVariants: []string{"req/t/a.jpg", "req/t/doc.pdf", "req/v/large/a.jpg", "req/v/small/a.jpg"},
+			wantMissing:  []string{"req/i/lost.jpg"},
+			wantRemaining: []string{
+				"avatar/i/old.jpg", "avatar/i/u.jpg", "avatar/t/old.jpg", "avatar/t/u.jpg",
+				"req/i/a.jpg", "req/i/doc.pdf", "req/i/orphan.jpg", "req/i/recent.jpg",
+				"req/t/a.jpg", "req/t/doc.pdf", "req/t/orphan.jpg",
+				"req/u/failed.jpg", "req/u/processing.jpg",
+				"req/v/large/a.jpg", "req/v/small/a.jpg", "req/v/small/orphan.jpg",
+			},
+		},
+		{
+			name: "delete orphans",
+			opts: requests.ReconcileOptions{DeleteOrphans: true, MinAge: 24 * time.Hour},
+			wantOrphans: []string{
+				"avatar/i/old.jpg", "avatar/t/old.jpg",
+				"req/i/orphan.jpg", "req/t/orphan.jpg", "req/u/failed.jpg", "req/v/small/orphan.jpg",
+			},
+			wantDeleted:  6,
+			wantVariants: []string{"req/t/a.jpg", "req/t/doc.pdf", "req/v/large/a.jpg", "req/v/small/a.jpg"},
+			wantMissing:  []string{"req/i/lost.jpg"},
+			wantRemaining: []string{
+				"avatar/i/u.jpg", "avatar/t/u.jpg",
+				"req/i/a.jpg", "req/i/doc.pdf", "req/i/recent.jpg",
+				"req/t/a.jpg", "req/t/doc.pdf",
+				"req/u/processing.jpg",
+				"req/v/large/a.jpg", "req/v/small/a.jpg",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := map[string]storedObject{
+				"req/i/a.jpg":            {body: jpg, modified: old},
+				"req/i/doc.pdf":          {body: []byte("%PDF-1.4"), modified: old},
+				"req/i/orphan.jpg":       {body: jpg, modified: old},
+				"req/t/orphan.jpg":       {body: jpg, modified: old},
+				"req/v/small/orphan.jpg": {body: jpg, modified: old},
+				"req/i/recent.jpg":       {body: jpg, modified: recent},
+				"req/u/failed.jpg":       {body: jpg, modified: old},
+				"req/u/processing.jpg":   {body: jpg, modified: recent},
+				"avatar/i/u.jpg":         {body: jpg, modified: old},
+				"avatar/t/u.jpg":         {body: jpg, modified: old},
+				"avatar/i/old.jpg":       {body: jpg, modified: old},
+				"avatar/t/old.jpg":       {body: jpg, modified: old},
+			}
+			repo := &requests.RequestsRepositoryMock{
+				ImageFilenamesFunc: func() ([]string, error) {
+					return []string{"a.jpg", "doc.pdf", "lost.jpg", "processing.jpg"}, nil
+				},
+				AvatarFilenamesFunc: func() ([]string, error) {
+					return []string{"u.jpg"}, nil
+				},
+			}
+
+			s := requests.New(defaultLogger, repo, nil, memStorage(objects), "cdnHost")
+			report, err := s.ReconcileImages(context.Background(), tt.opts)
+			if err != nil {
+				t.Fatalf("ReconcileImages() error = %v", err)
+			}
+
+			sort.Strings(report.Orphans)
+			sort.Strings(report.MissingVariants)
+			if !reflect.DeepEqual(report.Orphans, tt.wantOrphans) {
+				t.Errorf("ReconcileImages() orphans = %v, want %v", report.Orphans, tt.wantOrphans)
+			}
+			if report.Deleted != tt.wantDeleted {
+				t.Errorf("ReconcileImages() deleted = %d, want %d", report.Deleted, tt.wantDeleted)
+			}
+			if !reflect.DeepEqual(report.MissingVariants, tt.wantVariants) || report.RegeneratedVariants != len(tt.wantVariants) {
+				t.Errorf("ReconcileImages() missing variants = %v (%d regenerated), want %v",
+					report.MissingVariants, report.RegeneratedVariants, tt.wantVariants)
+			}
+			if !reflect.DeepEqual(report.MissingImages, tt.wantMissing) {
+				t.Errorf("ReconcileImages() missing images = %v, want %v", report.MissingImages, tt.wantMissing)
+			}
+
+			var remaining []string
+			for key := range objects {
+				remaining = append(remaining, key)
+			}
+			sort.Strings(remaining)
+			if !reflect.DeepEqual(remaining, tt.wantRemaining) {
+				t.Errorf("ReconcileImages() left %v, want %v", remaining, tt.wantRemaining)
+			}
+
+			for key, width := range map[string]int{"req/t/a.jpg": 128, "req/v/small/a.jpg": 400, "req/t/doc.pdf": 128} {
+				cfg, err := jpeg.DecodeConfig(bytes.NewReader(objects[key].body))
+				if err != nil || cfg.Width != width {
+					t.Errorf("ReconcileImages() regenerated wrong variant %s: %v", key, err)
+				}
+			}
+		})
+	}
+}
+
+func TestService_ReconcileImagesError(t *testing.T) {
+	repo := &requests.RequestsRepositoryMock{
+		ImageFilenamesFunc: func() ([]string, error) {
+			return nil, errTestError
+		},
+	}
+
+	s := requests.New(defaultLogger, repo, nil, memStorage(map[string]storedObject{}), "cdnHost")
+	if _, err := s.ReconcileImages(context.Background(), requests.ReconcileOptions{}); err == nil {
+		t.Error("ReconcileImages() expected error")
+	}
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/ivch/dynasty/server/handlers/requests"
+)
+
+func (r *Requests) CreateApproval(a *requests.Approval) error {
+	return r.db.Create(a).Error
+}
+
+func (r *Requests) GetApproval(id uint) (*requests.Approval, error) {
+	var a requests.Approval
+	if err := r.db.Where("id = ?", id).First(&a).Error; err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *Requests) ListPendingApprovals(buildingID, apartment uint) ([]*requests.Approval, error) {
+	var list []*requests.Approval
+	if err := r.db.Where("building_id = ? AND apartment = ? AND status = ? AND expires_at > ?",
+		buildingID, apartment, requests.ApprovalPending, time.Now()).
+		Order("created_at desc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ResolveApproval stores the decision only if the approval is still pending.
+func (r *Requests) ResolveApproval(a *requests.Approval) (bool, error) {
+	res := r.db.Model(&requests.Approval{}).
+		Where("id = ? AND status = ? AND expires_at > ?", a.ID, requests.ApprovalPending, a.DecidedAt).
+		Updates(map[string]interface{}{
+			"status":     a.Status,
+			"decided_by": a.DecidedBy,
+			"decided_at": a.DecidedAt,
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (r *Requests) ExpireApprovals(now time.Time) ([]*requests.Approval, error) {
+	var list []*requests.Approval
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Set("gorm:query_option", "FOR UPDATE SKIP LOCKED").
+			Where("status = ? AND expires_at <= ?", requests.ApprovalPending, now).
+			Find(&list).Error; err != nil {
+			return err
+		}
+
+		if len(list) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(list))
+		for i := range list {
+			ids[i] = list[i].ID
+			list[i].Status = requests.ApprovalExpired
+		}
+
+		return tx.Model(&requests.Approval{}).Where("id IN (?)", ids).
+			Update("status", requests.ApprovalExpired).Error
+	})
+
+	return list, err
+}
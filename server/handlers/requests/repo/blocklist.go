@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/ivch/dynasty/server/handlers/requests"
+)
+
+func (r *Requests) ListBlocklist() ([]*requests.BlocklistEntry, error) {
+	var list []*requests.BlocklistEntry
+	if err := r.db.Order("created_at desc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *Requests) ActiveBlocklist(now time.Time) ([]*requests.BlocklistEntry, error) {
+	var list []*requests.BlocklistEntry
+	if err := r.db.Where("expires_at IS NULL OR expires_at > ?", now).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *Requests) GetBlocklistEntry(id uint) (*requests.BlocklistEntry, error) {
+	var e requests.BlocklistEntry
+	if err := r.db.Where("id = ?", id).First(&e).Error; err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *Requests) CreateBlocklistEntry(e *requests.BlocklistEntry) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(e).Error; err != nil {
+			return err
+		}
+		return writeBlocklistAudit(tx, requests.BlocklistActionCreate, e.CreatedBy, e)
+	})
+}
+
+func (r *Requests) UpdateBlocklistEntry(e *requests.BlocklistEntry, adminID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&requests.BlocklistEntry{ID: e.ID}).Updates(map[string]interface{}{
+			"plate":      e.Plate,
+			"name":       e.Name,
+			"phone":      e.Phone,
+			"reason":     e.Reason,
+			"reject":     e.Reject,
+			"expires_at": e.ExpiresAt,
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("id = ?", e.ID).First(e).Error; err != nil {
+			return err
+		}
+
+		return writeBlocklistAudit(tx, requests.BlocklistActionUpdate, adminID, e)
+	})
+}
+
+func (r *Requests) DeleteBlocklistEntry(id, adminID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var e requests.BlocklistEntry
+		if err := tx.Where("id = ?", id).First(&e).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&e).Error; err != nil {
+			return err
+		}
+
+		return writeBlocklistAudit(tx, requests.BlocklistActionDelete, adminID, &e)
+	})
+}
+
+func (r *Requests) ListBlocklistAudit(entryID uint) ([]*requests.BlocklistAudit, error) {
+	var list []*requests.BlocklistAudit
+	if err := r.db.Where("entry_id = ?", entryID).Order("created_at desc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// writeBlocklistAudit stores the snapshot of the entry after the change made by the admin.
+func writeBlocklistAudit(tx *gorm.DB, action string, adminID uint, e *requests.BlocklistEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&requests.BlocklistAudit{
+		EntryID: e.ID,
+		AdminID: adminID,
+		Action:  action,
+		Data:    string(data),
+	}).Error
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+
+	"github.com/ivch/dynasty/server/handlers/requests"
+)
+
+const (
+	approvalsChannel          = "approvals"
+	listenerMinReconnect      = time.Second
+	listenerMaxReconnect      = time.Minute
+	listenerKeepaliveInterval = 90 * time.Second
+)
+
+// ApprovalEvents fans the approval updates out to all the instances through PostgreSQL LISTEN/NOTIFY.
+type ApprovalEvents struct {
+	db  *gorm.DB
+	dsn string
+}
+
+type approvalEvent struct {
+	Topic    string             `json:"topic"`
+	Approval *requests.Approval `json:"approval"`
+}
+
+// NewApprovalEvents publishes with db and listens on a dedicated connection to dsn.
+func NewApprovalEvents(db *gorm.DB, dsn string) *ApprovalEvents {
+	return &ApprovalEvents{db: db, dsn: dsn}
+}
+
+func (e *ApprovalEvents) Publish(topic string, a *requests.Approval) error {
+	payload, err := json.Marshal(approvalEvent{Topic: topic, Approval: a})
+	if err != nil {
+		return err
+	}
+	return e.db.Exec("SELECT pg_notify(?, ?)", approvalsChannel, string(payload)).Error
+}
+
+// Listen delivers the updates published by every instance, this one included, until ctx is done.
+// The updates published while the connection is lost are missed.
+func (e *ApprovalEvents) Listen(ctx context.Context, deliver func(topic string, a *requests.Approval)) error {
+	l := pq.NewListener(e.dsn, listenerMinReconnect, listenerMaxReconnect, nil)
+	defer l.Close() // nolint: errcheck
+
+	if err := l.Listen(approvalsChannel); err != nil {
+		return fmt.Errorf("failed to listen to %s: %w", approvalsChannel, err)
+	}
+
+	ticker := time.NewTicker(listenerKeepaliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			go l.Ping() // nolint: errcheck
+		case n := <-l.Notify:
+			// nil tells the connection was re-established
+			if n == nil {
+				continue
+			}
+
+			var ev approvalEvent
+			if err := json.Unmarshal([]byte(n.Extra), &ev); err != nil || ev.Approval == nil {
+				continue
+			}
+			deliver(ev.Topic, ev.Approval)
+		}
+	}
+}
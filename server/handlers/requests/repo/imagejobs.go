@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/ivch/dynasty/common/queue"
+	"github.com/ivch/dynasty/server/handlers/requests"
+)
+
+// EnqueueImage attaches the image to the request and queues its processing.
+func (r *Requests) EnqueueImage(job *requests.ImageJob) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := r.updateRequestHistory(tx, job.RequestID, &requests.HistoryRecord{
+			Time:   time.Now(),
+			UserID: job.UserID,
+			Action: "uploaded image: " + job.Filename,
+		}); err != nil {
+			return err
+		}
+
+		if err := tx.Table(requests.Request{}.TableName()).
+			Where("id = ? and user_id = ?", job.RequestID, job.UserID).
+			Update("images", gorm.Expr("array_append(images, ?)", job.Filename)).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(job).Error
+	})
+}
+
+func (r *Requests) ClaimImageJob(now time.Time, lease time.Duration) (*requests.ImageJob, error) {
+	var job requests.ImageJob
+	ok, err := queue.Claim(r.db, &job, now, lease)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// CompleteImageJob marks the job done, false if it is gone.
+func (r *Requests) CompleteImageJob(id uint) (bool, error) {
+	return queue.Release(r.db, &requests.ImageJob{}, id, map[string]interface{}{
+		"status": requests.ImageJobDone,
+	})
+}
+
+func (r *Requests) RetryImageJob(id uint, runAt time.Time, lastErr string) error {
+	return queue.Retry(r.db, &requests.ImageJob{}, id, runAt, lastErr)
+}
+
+// FailImageJob gives the job up and detaches its image from the request.
+func (r *Requests) FailImageJob(job *requests.ImageJob, lastErr string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&requests.ImageJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+			"status":       requests.ImageJobFailed,
+			"last_error":   lastErr,
+			"locked_until": nil,
+			"updated_at":   time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := r.updateRequestHistory(tx, job.RequestID, &requests.HistoryRecord{
+			Time:   time.Now(),
+			UserID: job.UserID,
+			Action: "failed to process image: " + job.Filename,
+		}); err != nil {
+			return err
+		}
+
+		return tx.Table(requests.Request{}.TableName()).
+			Where("id = ?", job.RequestID).
+			Update("images", gorm.Expr("array_remove(images, ?)", job.Filename)).Error
+	})
+}
+
+// ProcessingImages returns the files of the given requests which are not processed yet.
+func (r *Requests) ProcessingImages(requestIDs []uint) ([]string, error) {
+	var files []string
+	if err := r.db.Model(&requests.ImageJob{}).
+		Where("request_id IN (?) AND status IN (?)", requestIDs, []string{requests.ImageJobPending, requests.ImageJobRunning}).
+		Pluck("filename", &files).Error; err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// AvatarFilenames returns the profile photos of the users, they share the storage with the request images.
+func (r *Requests) AvatarFilenames() ([]string, error) {
+	var files []string
+	if err := r.db.Table("users").Where("avatar <> ''").Pluck("avatar", &files).Error; err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// ImageFilenames returns the files of all the requests.
+func (r *Requests) ImageFilenames() ([]string, error) {
+	var files []string
+	if err := r.db.Model(&requests.Request{}).Pluck("unnest(images)", &files).Error; err != nil {
+		return nil, err
+	}
+	return files, nil
+}
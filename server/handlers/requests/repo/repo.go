@@ -6,6 +6,7 @@ import (
 
 	"github.com/jinzhu/gorm"
 
+	outboxRepo "github.com/ivch/dynasty/server/handlers/outbox/repo"
 	"github.com/ivch/dynasty/server/handlers/requests"
 )
 
@@ -26,6 +27,13 @@ func (r *Requests) Delete(id, userID uint) error {
 		}); err != nil {
 			return err
 		}
+
+		if err := tx.Where("request_id = ? AND status IN (?)",
+			id, []string{requests.ImageJobPending, requests.ImageJobRunning}).
+			Delete(&requests.ImageJob{}).Error; err != nil {
+			return err
+		}
+
 		return tx.Where("id = ? AND user_id = ?", id, userID).Delete(&requests.Request{}).Error
 	})
 }
@@ -71,8 +79,14 @@ func (r *Requests) Update(req *requests.UpdateRequest) error {
 	})
 }
 
-func (r *Requests) Create(req *requests.Request) error {
-	return r.db.Create(req).Error
+// Create stores the request together with the message of its event in the outbox.
+func (r *Requests) Create(req *requests.Request, event requests.EventFunc) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(req).Error; err != nil {
+			return err
+		}
+		return addEvent(tx, req, event)
+	})
 }
 
 func (r *Requests) ListForGuard(req *requests.RequestListFilter) ([]*requests.Request, error) {
@@ -95,7 +109,8 @@ func (r *Requests) CountForGuard(req *requests.RequestListFilter) (int, error) {
 	return count, nil
 }
 
-func (r *Requests) UpdateForGuard(id uint, status string) error {
+// UpdateForGuard changes the status together with the message of its event in the outbox.
+func (r *Requests) UpdateForGuard(id uint, status string, event requests.EventFunc) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
 		if err := r.updateRequestHistory(tx, id, &requests.HistoryRecord{
 			Time:   time.Now(),
@@ -104,7 +119,18 @@ func (r *Requests) UpdateForGuard(id uint, status string) error {
 		}); err != nil {
 			return err
 		}
-		return tx.Model(&requests.Request{}).Where("id = ?", id).Update("status", status).Error
+		if err := tx.Model(&requests.Request{}).Where("id = ?", id).Update("status", status).Error; err != nil {
+			return err
+		}
+		if event == nil {
+			return nil
+		}
+
+		var req requests.Request
+		if err := tx.Where("id = ?", id).First(&req).Error; err != nil {
+			return err
+		}
+		return addEvent(tx, &req, event)
 	})
 }
 
@@ -120,6 +146,10 @@ func (r *Requests) ListByUser(req *requests.RequestListFilter) ([]*requests.Requ
 		q = q.Where("time <= ? ", req.DateTo.Unix())
 	}
 
+	if req.Status != "" && req.Status != "all" {
+		q = q.Where("status = ?", req.Status)
+	}
+
 	if err := q.Order("time desc").Limit(req.Limit).Offset(req.Offset).Find(&reqs).Error; err != nil {
 		return nil, err
 	}
@@ -177,12 +207,33 @@ func (r *Requests) DeleteImage(userID, requestID uint, filename string) error {
 		}); err != nil {
 			return err
 		}
+
+		// drop the unfinished processing, the worker cleans up after it
+		if err := tx.Where("request_id = ? AND filename = ? AND status IN (?)",
+			requestID, filename, []string{requests.ImageJobPending, requests.ImageJobRunning}).
+			Delete(&requests.ImageJob{}).Error; err != nil {
+			return err
+		}
+
 		return tx.Table(requests.Request{}.TableName()).
 			Where("id = ? and user_id = ?", requestID, userID).
 			Update("images", gorm.Expr("array_remove(images, ?)", filename)).Error
 	})
 }
 
+// addEvent stores the message of the request event with tx, the transaction of the request change.
+func addEvent(tx *gorm.DB, req *requests.Request, event requests.EventFunc) error {
+	if event == nil {
+		return nil
+	}
+
+	msg, err := event(req)
+	if err != nil || msg == nil {
+		return err
+	}
+	return outboxRepo.Add(tx, msg)
+}
+
 func (r *Requests) updateRequestHistory(tx *gorm.DB, requestID uint, rec fmt.Stringer) error {
 	return tx.Table(requests.Request{}.TableName()).
 		Where("id = ?", requestID).
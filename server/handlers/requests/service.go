@@ -3,12 +3,15 @@ package requests
 import (
 	"context"
 	"errors"
+	"io"
+	"strconv"
 	"time"
 
-	"github.com/aws/aws-sdk-go/service/s3"
-
 	"github.com/ivch/dynasty/common/errs"
 	"github.com/ivch/dynasty/common/logger"
+	"github.com/ivch/dynasty/common/storage"
+	"github.com/ivch/dynasty/server/handlers/outbox"
+	"github.com/ivch/dynasty/server/handlers/users"
 )
 
 type RequestType int
@@ -20,13 +23,23 @@ const (
 	Delivery
 	Cargo
 
-	defaultRequestStatus = "new"
-	allowedFileType      = "image/jpeg"
-	requestsPerDay       = 20
-	filesPerRequest      = 3
-	ImgPathPrefix        = "req/i/"
-	ThumbPathPrefix      = "req/t/"
-	defaultS3ACL         = "public-read"
+	defaultRequestStatus     = "new"
+	pendingRequestStatus     = "pending"
+	requestsPerDay           = 20
+	filesPerRequest          = 3
+	ImgPathPrefix            = "req/i/"
+	ThumbPathPrefix          = "req/t/"
+	UploadPathPrefix         = "req/u/"
+	imageStatusProcessing    = "processing"
+	defaultApprovalTTL       = 2 * time.Minute
+	approvalEventsRetry      = 5 * time.Second
+	defaultMaxImageDimension = 2048
+	defaultImageURLTTL       = time.Hour
+
+	// EventRequestStatusChanged tells the author the guard changed the status of the request.
+	EventRequestStatusChanged = "request_status_changed"
+	// EventKPPRequestCreated tells the guards a resident created the request for the checkpoint.
+	EventKPPRequestCreated = "kpp_request_created"
 )
 
 var (
@@ -65,55 +78,203 @@ var (
 )
 
 type RequestsRepository interface {
-	Create(req *Request) error
+	Create(req *Request, event EventFunc) error
 	GetRequestByIDAndUser(id, userID uint) (*Request, error)
 	Update(update *UpdateRequest) error
 	Delete(id, userID uint) error
 	ListByUser(r *RequestListFilter) ([]*Request, error)
 	ListForGuard(req *RequestListFilter) ([]*Request, error)
-	UpdateForGuard(id uint, status string) error
+	UpdateForGuard(id uint, status string, event EventFunc) error
 	CountForGuard(req *RequestListFilter) (int, error)
 	AddImage(userID, requestID uint, filename string) error
 	DeleteImage(userID, requestID uint, filename string) error
 	GetStats24h() (total, open, closed int, err error)
+
+	EnqueueImage(job *ImageJob) error
+	ClaimImageJob(now time.Time, lease time.Duration) (*ImageJob, error)
+	CompleteImageJob(id uint) (bool, error)
+	RetryImageJob(id uint, runAt time.Time, lastErr string) error
+	FailImageJob(job *ImageJob, lastErr string) error
+	ProcessingImages(requestIDs []uint) ([]string, error)
+	ImageFilenames() ([]string, error)
+	AvatarFilenames() ([]string, error)
+
+	CreateApproval(a *Approval) error
+	GetApproval(id uint) (*Approval, error)
+	ListPendingApprovals(buildingID, apartment uint) ([]*Approval, error)
+	ResolveApproval(a *Approval) (bool, error)
+	ExpireApprovals(now time.Time) ([]*Approval, error)
+
+	ListBlocklist() ([]*BlocklistEntry, error)
+	ActiveBlocklist(now time.Time) ([]*BlocklistEntry, error)
+	GetBlocklistEntry(id uint) (*BlocklistEntry, error)
+	CreateBlocklistEntry(e *BlocklistEntry) error
+	UpdateBlocklistEntry(e *BlocklistEntry, adminID uint) error
+	DeleteBlocklistEntry(id, adminID uint) error
+	ListBlocklistAudit(entryID uint) ([]*BlocklistAudit, error)
+}
+
+type UserService interface {
+	UserByID(ctx context.Context, id uint) (*users.User, error)
+	UserByApartment(ctx context.Context, buildingID, apartment uint) (*users.User, error)
+	ApartmentMembers(ctx context.Context, buildingID, apartment uint) ([]*users.User, error)
 }
 
-type S3Client interface {
-	PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error)
-	DeleteObject(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error)
+// Notifier builds the outbox messages of the events delivered to the users through their notification channels.
+type Notifier interface {
+	EventMessage(userID uint, event string, data map[string]string) (*outbox.Message, error)
+	GuardEventMessage(event string, data map[string]string) (*outbox.Message, error)
+}
+
+// ApprovalEvents fans the approval updates out to the subscribers connected to all the instances.
+type ApprovalEvents interface {
+	Publish(topic string, a *Approval) error
+	Listen(ctx context.Context, deliver func(topic string, a *Approval)) error
+}
+
+// EventFunc returns the outbox message of the stored request, nil if there is nothing to tell.
+// The repository stores it in the same transaction as the request.
+type EventFunc func(r *Request) (*outbox.Message, error)
+
+type Storage interface {
+	Put(ctx context.Context, key string, body io.Reader, opts storage.PutOptions) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	List(ctx context.Context, prefix string) ([]storage.ObjectInfo, error)
+	Delete(ctx context.Context, key string) error
+	Presign(ctx context.Context, key string, ttl time.Duration) (string, error)
 }
 
 type Service struct {
-	repo     RequestsRepository
-	s3Client S3Client
-	s3Space  string
-	cdnHost  string
-	log      logger.Logger
+	repo        RequestsRepository
+	uSrv        UserService
+	storage     Storage
+	cdnHost     string
+	approvalTTL time.Duration
+	hub         *hub
+	events      ApprovalEvents
+	log         logger.Logger
+
+	attachmentLimits   map[string]int64
+	attachmentHandlers map[string]AttachmentHandler
+	maxImageDimension  int
+	imageVariants      []ImageVariant
+
+	privateImages bool
+	imageURLTTL   time.Duration
+
+	notifier Notifier
+
+	imageJobsWake    chan struct{}
+	imageJobAttempts int
+	imageJobBackoff  time.Duration
+}
+
+// Option configures optional Service parameters.
+type Option func(s *Service)
+
+// WithAttachmentLimits sets the allowed attachment content types with their max sizes in bytes.
+func WithAttachmentLimits(limits map[string]int64) Option {
+	return func(s *Service) {
+		if len(limits) > 0 {
+			s.attachmentLimits = limits
+		}
+	}
 }
 
-func New(log logger.Logger, repo RequestsRepository, s3Client S3Client, s3Space, cdnHost string) *Service {
-	s := Service{repo: repo, s3Space: s3Space, s3Client: s3Client, cdnHost: cdnHost, log: log}
+// WithMaxImageDimension sets the max width and height in pixels uploaded photos are downsized to.
+func WithMaxImageDimension(px int) Option {
+	return func(s *Service) {
+		if px > 0 {
+			s.maxImageDimension = px
+		}
+	}
+}
+
+// WithImageVariants sets the resized copies rendered for every uploaded image, see ParseImageVariants.
+func WithImageVariants(variants []ImageVariant) Option {
+	return func(s *Service) {
+		if len(variants) > 0 {
+			s.imageVariants = variants
+		}
+	}
+}
+
+// WithPrivateImages makes new images private and their links presigned, valid for ttl.
+func WithPrivateImages(ttl time.Duration) Option {
+	return func(s *Service) {
+		s.privateImages = true
+		if ttl > 0 {
+			s.imageURLTTL = ttl
+		}
+	}
+}
+
+// WithNotifier sends the request events to the notifier.
+func WithNotifier(n Notifier) Option {
+	return func(s *Service) {
+		s.notifier = n
+	}
+}
+
+// WithApprovalEvents delivers the approval updates through the events, so the subscribers
+// connected to the other instances get them too. Without it they stay in the instance.
+func WithApprovalEvents(e ApprovalEvents) Option {
+	return func(s *Service) {
+		s.events = e
+	}
+}
+
+// WithApprovalTTL sets how long a visitor approval waits for the residents' answer.
+func WithApprovalTTL(ttl time.Duration) Option {
+	return func(s *Service) {
+		if ttl > 0 {
+			s.approvalTTL = ttl
+		}
+	}
+}
+
+func New(log logger.Logger, repo RequestsRepository, uSrv UserService, store Storage, cdnHost string, opts ...Option) *Service {
+	s := Service{
+		repo:        repo,
+		uSrv:        uSrv,
+		storage:     store,
+		cdnHost:     cdnHost,
+		approvalTTL: defaultApprovalTTL,
+		hub:         newHub(),
+		log:         log,
+
+		attachmentLimits:  DefaultAttachmentLimits,
+		maxImageDimension: defaultMaxImageDimension,
+		imageVariants:     DefaultImageVariants,
+		imageURLTTL:       defaultImageURLTTL,
+
+		imageJobsWake:    make(chan struct{}, 1),
+		imageJobAttempts: defaultImageJobAttempts,
+		imageJobBackoff:  defaultImageJobBackoff,
+	}
+
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	s.attachmentHandlers = newAttachmentHandlers(s.maxImageDimension, s.imageVariants)
 
 	return &s
 }
 
-func (s *Service) Get(_ context.Context, r *Request) (*Request, error) {
+func (s *Service) Get(ctx context.Context, r *Request) (*Request, error) {
 	req, err := s.repo.GetRequestByIDAndUser(r.ID, r.UserID)
 	if err != nil {
 		s.log.Error("error finding request: %w", err)
 		return nil, err
 	}
 	// todo: make separate requests for user data
-	req.ImagesURL = make([]map[string]string, len(req.Images))
-
-	for i := range req.Images {
-		req.ImagesURL[i] = s.buildImageURL(req.Images[i])
-	}
+	s.attachImageURLs(ctx, req)
 
 	return req, nil
 }
 
-func (s *Service) Delete(_ context.Context, r *Request) error {
+func (s *Service) Delete(ctx context.Context, r *Request) error {
 	req, err := s.repo.GetRequestByIDAndUser(r.ID, r.UserID)
 	if err != nil {
 		s.log.Error("failed to delete request %d: %w", r.ID, err)
@@ -121,7 +282,7 @@ func (s *Service) Delete(_ context.Context, r *Request) error {
 	}
 
 	for i := range req.Images {
-		if err := s.deleteImageFromS3(req.Images[i]); err != nil {
+		if err := s.deleteImageFiles(ctx, req.Images[i]); err != nil {
 			s.log.Error("error deleting image for request %d: %w", r.ID, err)
 		}
 	}
@@ -129,6 +290,26 @@ func (s *Service) Delete(_ context.Context, r *Request) error {
 	return s.repo.Delete(r.ID, r.UserID)
 }
 
+func (s *Service) Confirm(_ context.Context, r *Request) error {
+	req, err := s.repo.GetRequestByIDAndUser(r.ID, r.UserID)
+	if err != nil {
+		s.log.Error("error finding request: %w", err)
+		return err
+	}
+
+	if req.Status != pendingRequestStatus {
+		return errs.RequestNotPending
+	}
+
+	status := defaultRequestStatus
+
+	return s.repo.Update(&UpdateRequest{
+		ID:     r.ID,
+		UserID: r.UserID,
+		Status: &status,
+	})
+}
+
 func (s *Service) Update(_ context.Context, r *UpdateRequest) error {
 	_, err := s.repo.GetRequestByIDAndUser(r.ID, r.UserID)
 	if err != nil {
@@ -155,23 +336,18 @@ func (s *Service) Update(_ context.Context, r *UpdateRequest) error {
 	return s.repo.Update(r)
 }
 
-func (s *Service) My(_ context.Context, r *RequestListFilter) ([]*Request, error) {
+func (s *Service) My(ctx context.Context, r *RequestListFilter) ([]*Request, error) {
 	reqs, err := s.repo.ListByUser(r)
 	if err != nil {
 		return nil, err
 	}
 
-	for i := range reqs {
-		reqs[i].ImagesURL = make([]map[string]string, len(reqs[i].Images))
-		for j := range reqs[i].Images {
-			reqs[i].ImagesURL[j] = s.buildImageURL(reqs[i].Images[j])
-		}
-	}
+	s.attachImageURLs(ctx, reqs...)
 
 	return reqs, nil
 }
 
-func (s *Service) Create(_ context.Context, r *Request) (*Request, error) {
+func (s *Service) Create(ctx context.Context, r *Request) (*Request, error) {
 	dateFrom := time.Now().Add(-24 * time.Hour)
 	list, err := s.repo.ListByUser(&RequestListFilter{
 		DateFrom: &dateFrom,
@@ -180,19 +356,7 @@ func (s *Service) Create(_ context.Context, r *Request) (*Request, error) {
 		UserID:   r.UserID,
 	})
 
-	// backward compatibility
-	if r.Type != "" {
-		if _, ok := oldRequestTypes[r.Type]; ok {
-			r.Rtype = oldRequestTypes[r.Type]
-		}
-	}
-
-	if r.Rtype != 0 {
-		if _, ok := newRequestTypes[r.Rtype]; ok {
-			r.Type = newRequestTypes[r.Rtype]["key"]
-		}
-	}
-	// end backward compatibility
+	normalizeRequestType(r)
 
 	if err != nil {
 		return nil, err
@@ -202,9 +366,13 @@ func (s *Service) Create(_ context.Context, r *Request) (*Request, error) {
 		return nil, errs.RequestPerDayLimitExceeded
 	}
 
+	if err := s.checkBlocklist(r); err != nil {
+		return nil, err
+	}
+
 	r.Status = defaultRequestStatus
 
-	if err := s.repo.Create(r); err != nil {
+	if err := s.repo.Create(r, s.guardsEvent(ctx, r)); err != nil {
 		s.log.Error("error creating request: %w", err)
 		return nil, errors.New("failed to create request")
 	}
@@ -212,6 +380,57 @@ func (s *Service) Create(_ context.Context, r *Request) (*Request, error) {
 	return r, nil
 }
 
+// guardsEvent tells the guards about the new request for the checkpoint.
+func (s *Service) guardsEvent(ctx context.Context, r *Request) EventFunc {
+	if s.notifier == nil || !isKPPType(r.Rtype) {
+		return nil
+	}
+
+	data := map[string]string{
+		"type":        newRequestTypes[r.Rtype]["key"],
+		"description": r.Description,
+	}
+
+	u, err := s.uSrv.UserByID(ctx, r.UserID)
+	if err != nil {
+		s.log.Error("error getting request author to notify guards: %w", err)
+	} else {
+		data["apartment"] = strconv.FormatUint(uint64(u.Apartment), 10)
+		data["building"] = u.Building.Name
+	}
+
+	return func(r *Request) (*outbox.Message, error) {
+		data["id"] = strconv.FormatUint(uint64(r.ID), 10)
+		return s.notifier.GuardEventMessage(EventKPPRequestCreated, data)
+	}
+}
+
+// isKPPType tells whether the request of the type is handled at the checkpoint.
+func isKPPType(t RequestType) bool {
+	switch t {
+	case Guest, Taxi, Delivery, Cargo:
+		return true
+	}
+	return false
+}
+
+// normalizeRequestType fills both legacy and numeric types of the request.
+func normalizeRequestType(r *Request) {
+	// backward compatibility
+	if r.Type != "" {
+		if _, ok := oldRequestTypes[r.Type]; ok {
+			r.Rtype = oldRequestTypes[r.Type]
+		}
+	}
+
+	if r.Rtype != 0 {
+		if _, ok := newRequestTypes[r.Rtype]; ok {
+			r.Type = newRequestTypes[r.Rtype]["key"]
+		}
+	}
+	// end backward compatibility
+}
+
 func GetRequestTypes() map[RequestType]map[string]string {
 	return newRequestTypes
 }
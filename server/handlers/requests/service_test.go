@@ -3,16 +3,15 @@ package requests_test
 import (
 	"context"
 	"errors"
-	"fmt"
 	"io"
 	"os"
 	"reflect"
 	"testing"
 
-	"github.com/aws/aws-sdk-go/service/s3"
-
 	"github.com/ivch/dynasty/common/logger"
+	"github.com/ivch/dynasty/server/handlers/outbox"
 	"github.com/ivch/dynasty/server/handlers/requests"
+	"github.com/ivch/dynasty/server/handlers/users"
 )
 
 var (
@@ -25,6 +24,17 @@ func TestMain(m *testing.M) {
 	os.Exit(m.Run())
 }
 
+// cdnImageURLs are the links of the image with the default variants.
+func cdnImageURLs(filename string) map[string]string {
+	return map[string]string{
+		"img":    "cdnHost/req/i/" + filename,
+		"thumb":  "cdnHost/req/t/" + filename,
+		"small":  "cdnHost/req/v/small/" + filename + ".jpg",
+		"large":  "cdnHost/req/v/large/" + filename + ".jpg",
+		"srcset": "cdnHost/req/v/small/" + filename + ".jpg 480w, cdnHost/req/v/large/" + filename + ".jpg 1280w",
+	}
+}
+
 func TestService_Get(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -57,9 +67,15 @@ func TestService_Get(t *testing.T) {
 						Time:        1,
 						Description: "1",
 						Status:      "1",
-						Images:      []string{"a"},
+						Images:      []string{"a", "b"},
 					}, nil
 				},
+				ProcessingImagesFunc: func(ids []uint) ([]string, error) {
+					if len(ids) != 1 || ids[0] != 1 {
+						return nil, errTestError
+					}
+					return []string{"b"}, nil
+				},
 			},
 			req: &requests.Request{
 				UserID: 1,
@@ -73,11 +89,16 @@ func TestService_Get(t *testing.T) {
 				Time:        1,
 				Description: "1",
 				Status:      "1",
-				Images:      []string{"a"},
+				Images:      []string{"a", "b"},
 				ImagesURL: []map[string]string{
+					cdnImageURLs("a"),
 					{
-						"img":   fmt.Sprintf("cdnHost/%s%s", requests.ImgPathPrefix, "a"),
-						"thumb": fmt.Sprintf("cdnHost/%s%s", requests.ThumbPathPrefix, "a"),
+						"img":    "cdnHost/req/i/b",
+						"thumb":  "cdnHost/req/t/b",
+						"small":  "cdnHost/req/v/small/b.jpg",
+						"large":  "cdnHost/req/v/large/b.jpg",
+						"srcset": "cdnHost/req/v/small/b.jpg 480w, cdnHost/req/v/large/b.jpg 1280w",
+						"status": "processing",
 					},
 				},
 			},
@@ -86,7 +107,7 @@ func TestService_Get(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := requests.New(defaultLogger, tt.repo, nil, "", "cdnHost")
+			s := requests.New(defaultLogger, tt.repo, nil, nil, "cdnHost")
 			got, err := s.Get(context.Background(), tt.req)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Get() error = %v, wantErr %v", err, tt.wantErr)
@@ -229,7 +250,7 @@ func TestService_Update(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := requests.New(defaultLogger, tt.repo, nil, "", "")
+			s := requests.New(defaultLogger, tt.repo, nil, nil, "")
 			err := s.Update(context.Background(), tt.req)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Update() error = %v, wantErr %v", err, tt.wantErr)
@@ -239,6 +260,58 @@ func TestService_Update(t *testing.T) {
 	}
 }
 
+func TestService_Confirm(t *testing.T) {
+	tests := []struct {
+		name    string
+		repo    requests.RequestsRepository
+		wantErr bool
+	}{
+		{
+			name: "error no request",
+			repo: &requests.RequestsRepositoryMock{
+				GetRequestByIDAndUserFunc: func(_ uint, _ uint) (*requests.Request, error) {
+					return nil, errTestError
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error request is not pending",
+			repo: &requests.RequestsRepositoryMock{
+				GetRequestByIDAndUserFunc: func(_ uint, _ uint) (*requests.Request, error) {
+					return &requests.Request{Status: "new"}, nil
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "ok",
+			repo: &requests.RequestsRepositoryMock{
+				GetRequestByIDAndUserFunc: func(_ uint, _ uint) (*requests.Request, error) {
+					return &requests.Request{Status: "pending"}, nil
+				},
+				UpdateFunc: func(req *requests.UpdateRequest) error {
+					if req.ID != 1 || req.UserID != 1 || *req.Status != "new" {
+						return errTestError
+					}
+					return nil
+				},
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := requests.New(defaultLogger, tt.repo, nil, nil, "")
+			err := s.Confirm(context.Background(), &requests.Request{ID: 1, UserID: 1})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Confirm() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestService_My(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -277,6 +350,9 @@ func TestService_My(t *testing.T) {
 						},
 					}, nil
 				},
+				ProcessingImagesFunc: func(_ []uint) ([]string, error) {
+					return nil, nil
+				},
 			},
 			req: &requests.RequestListFilter{
 				UserID: 1,
@@ -294,10 +370,7 @@ func TestService_My(t *testing.T) {
 					Status:      "1",
 					Images:      []string{"a"},
 					ImagesURL: []map[string]string{
-						{
-							"img":   fmt.Sprintf("cdnHost/%s%s", requests.ImgPathPrefix, "a"),
-							"thumb": fmt.Sprintf("cdnHost/%s%s", requests.ThumbPathPrefix, "a"),
-						},
+						cdnImageURLs("a"),
 					},
 				},
 			},
@@ -306,7 +379,7 @@ func TestService_My(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := requests.New(defaultLogger, tt.repo, nil, "", "cdnHost")
+			s := requests.New(defaultLogger, tt.repo, nil, nil, "cdnHost")
 			got, err := s.My(context.Background(), tt.req)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("My() error = %v, wantErr %v", err, tt.wantErr)
@@ -350,7 +423,8 @@ func TestService_Create(t *testing.T) {
 					res := make([]*requests.Request, 1)
 					return res, nil
 				},
-				CreateFunc: func(_ *requests.Request) error {
+				ActiveBlocklistFunc: emptyBlocklist,
+				CreateFunc: func(_ *requests.Request, _ requests.EventFunc) error {
 					return errTestError
 				},
 			},
@@ -369,7 +443,8 @@ func TestService_Create(t *testing.T) {
 					res := make([]*requests.Request, 1)
 					return res, nil
 				},
-				CreateFunc: func(req *requests.Request) error {
+				ActiveBlocklistFunc: emptyBlocklist,
+				CreateFunc: func(req *requests.Request, _ requests.EventFunc) error {
 					req.ID = 1
 					req.Status = "new"
 					return nil
@@ -388,7 +463,7 @@ func TestService_Create(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := requests.New(defaultLogger, tt.repo, nil, "", "")
+			s := requests.New(defaultLogger, tt.repo, nil, nil, "")
 			got, err := s.Create(context.Background(), tt.req)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Create() error = %v, wantErr %v", err, tt.wantErr)
@@ -402,11 +477,75 @@ func TestService_Create(t *testing.T) {
 	}
 }
 
+func TestService_CreateNotifiesGuards(t *testing.T) {
+	tests := []struct {
+		name  string
+		rtype requests.RequestType
+		want  map[string]string
+	}{
+		{
+			name: "not a checkpoint request",
+		},
+		{
+			name:  "checkpoint request",
+			rtype: requests.Taxi,
+			want:  map[string]string{"id": "1", "type": "taxi", "description": "yellow car", "apartment": "12", "building": "Main"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var msg *outbox.Message
+			repo := &requests.RequestsRepositoryMock{
+				ListByUserFunc: func(_ *requests.RequestListFilter) ([]*requests.Request, error) {
+					return nil, nil
+				},
+				ActiveBlocklistFunc: emptyBlocklist,
+				CreateFunc: func(req *requests.Request, event requests.EventFunc) error {
+					req.ID = 1
+					if event == nil {
+						return nil
+					}
+					var err error
+					msg, err = event(req)
+					return err
+				},
+			}
+			uSrv := &requests.UserServiceMock{
+				UserByIDFunc: func(_ context.Context, id uint) (*users.User, error) {
+					return &users.User{ID: id, Apartment: 12, Building: users.Building{Name: "Main"}}, nil
+				},
+			}
+			notifier := &requests.NotifierMock{
+				GuardEventMessageFunc: func(_ string, _ map[string]string) (*outbox.Message, error) {
+					return &outbox.Message{Topic: "guard_event"}, nil
+				},
+			}
+
+			s := requests.New(defaultLogger, repo, uSrv, nil, "", requests.WithNotifier(notifier))
+			if _, err := s.Create(context.Background(), &requests.Request{Rtype: tt.rtype, UserID: 7, Description: "yellow car"}); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+
+			calls := notifier.GuardEventMessageCalls()
+			if tt.want == nil {
+				if len(calls) != 0 || msg != nil {
+					t.Errorf("Create() notified guards %d times", len(calls))
+				}
+				return
+			}
+			if len(calls) != 1 || msg == nil || calls[0].Event != requests.EventKPPRequestCreated || !reflect.DeepEqual(calls[0].Data, tt.want) {
+				t.Errorf("Create() notified guards %v", calls)
+			}
+		})
+	}
+}
+
 func TestService_Delete(t *testing.T) {
 	tests := []struct {
 		name    string
 		repo    requests.RequestsRepository
-		s3cli   requests.S3Client
+		store   requests.Storage
 		req     *requests.Request
 		wantErr bool
 	}{
@@ -469,9 +608,9 @@ func TestService_Delete(t *testing.T) {
 				UserID: 1,
 				ID:     1,
 			},
-			s3cli: &requests.S3ClientMock{
-				DeleteObjectFunc: func(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
-					return nil, nil
+			store: &requests.StorageMock{
+				DeleteFunc: func(_ context.Context, _ string) error {
+					return nil
 				},
 			},
 			wantErr: true,
@@ -490,9 +629,9 @@ func TestService_Delete(t *testing.T) {
 				UserID: 1,
 				ID:     1,
 			},
-			s3cli: &requests.S3ClientMock{
-				DeleteObjectFunc: func(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
-					return nil, nil
+			store: &requests.StorageMock{
+				DeleteFunc: func(_ context.Context, _ string) error {
+					return nil
 				},
 			},
 			wantErr: false,
@@ -501,7 +640,7 @@ func TestService_Delete(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := requests.New(defaultLogger, tt.repo, tt.s3cli, "", "")
+			s := requests.New(defaultLogger, tt.repo, nil, tt.store, "")
 			err := s.Delete(context.Background(), tt.req)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Delete() error = %v, wantErr %v", err, tt.wantErr)
@@ -0,0 +1,258 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/server/handlers/requests"
+)
+
+const sseKeepAliveInterval = 15 * time.Second
+
+func (h *HTTPTransport) GuardCreateApproval(w http.ResponseWriter, r *http.Request) {
+	guardID, err := getUserID(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
+		return
+	}
+
+	var req GuardCreateApprovalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, http.StatusBadRequest, errs.BadRequest)
+		return
+	}
+
+	req.Sanitize(h.sanitizer)
+
+	if req.BuildingID == 0 {
+		h.sendError(w, http.StatusBadRequest, errs.BuildingEmpty)
+		return
+	}
+
+	if req.Apartment == 0 {
+		h.sendError(w, http.StatusBadRequest, errs.ApartmentEmpty)
+		return
+	}
+
+	res, err := h.svc.GuardCreateApproval(r.Context(), &requests.Approval{
+		GuardID:     guardID,
+		BuildingID:  req.BuildingID,
+		Apartment:   req.Apartment,
+		Description: req.Description,
+	})
+	if err != nil {
+		switch err {
+		case errs.InsufficientPermissions:
+			h.sendError(w, http.StatusForbidden, err)
+		case errs.UserNotFound:
+			h.sendError(w, http.StatusNotFound, err)
+		default:
+			h.sendError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	h.sendHTTPResponse(r.Context(), w, newApprovalResponse(res))
+}
+
+func (h *HTTPTransport) GuardApproval(w http.ResponseWriter, r *http.Request) {
+	id, err := getIDFromQuery(r)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res, err := h.svc.GuardApproval(r.Context(), id)
+	if err != nil {
+		h.sendError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.sendHTTPResponse(r.Context(), w, newApprovalResponse(res))
+}
+
+// GuardApprovalEvents streams the approval to the guard console as server-sent events.
+func (h *HTTPTransport) GuardApprovalEvents(w http.ResponseWriter, r *http.Request) {
+	id, err := getIDFromQuery(r)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// subscribe before reading the current state, so no update is lost in between
+	updates, unsubscribe := h.svc.SubscribeApproval(r.Context(), id)
+	defer unsubscribe()
+
+	current, err := h.svc.GuardApproval(r.Context(), id)
+	if err != nil {
+		h.sendError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.streamApprovals(r.Context(), w, []*requests.Approval{current}, updates, true)
+}
+
+func (h *HTTPTransport) MyApprovals(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
+		return
+	}
+
+	res, err := h.svc.MyApprovals(r.Context(), userID)
+	if err != nil {
+		h.sendError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	result := ListApprovalsResponse{Data: make([]*ApprovalResponse, len(res))}
+	for i := range res {
+		result.Data[i] = newApprovalResponse(res[i])
+	}
+
+	h.sendHTTPResponse(r.Context(), w, result)
+}
+
+// MyApprovalsEvents streams approvals raised or resolved for the user's apartment as server-sent events.
+func (h *HTTPTransport) MyApprovalsEvents(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
+		return
+	}
+
+	updates, unsubscribe := h.svc.SubscribeUserApprovals(r.Context(), userID)
+	defer unsubscribe()
+
+	pending, err := h.svc.MyApprovals(r.Context(), userID)
+	if err != nil {
+		h.sendError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.streamApprovals(r.Context(), w, pending, updates, false)
+}
+
+func (h *HTTPTransport) ResolveApproval(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
+		return
+	}
+
+	id, err := getIDFromQuery(r)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var req ResolveApprovalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, http.StatusBadRequest, errs.BadRequest)
+		return
+	}
+
+	if req.Decision != approvalDecisionApprove && req.Decision != approvalDecisionDeny {
+		h.sendError(w, http.StatusBadRequest, errs.WrongApprovalDecision)
+		return
+	}
+
+	res, err := h.svc.ResolveApproval(r.Context(), &requests.ApprovalDecision{
+		ID:      id,
+		UserID:  userID,
+		Approve: req.Decision == approvalDecisionApprove,
+	})
+	if err != nil {
+		switch err {
+		case errs.InsufficientPermissions:
+			h.sendError(w, http.StatusForbidden, err)
+		case errs.ApprovalAlreadyResolved, errs.ApprovalExpired:
+			h.sendError(w, http.StatusConflict, err)
+		default:
+			h.sendError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	h.sendHTTPResponse(r.Context(), w, newApprovalResponse(res))
+}
+
+func (h *HTTPTransport) streamApprovals(ctx context.Context, w http.ResponseWriter, initial []*requests.Approval, updates <-chan *requests.Approval, stopOnResolve bool) {
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(a *requests.Approval) bool {
+		data, err := json.Marshal(newApprovalResponse(a))
+		if err != nil {
+			h.log.Error("failed to encode approval: %w", err)
+			return false
+		}
+
+		if _, err := fmt.Fprintf(w, "event: approval\ndata: %s\n\n", data); err != nil {
+			return false
+		}
+
+		return rc.Flush() == nil
+	}
+
+	for i := range initial {
+		if !send(initial[i]) {
+			return
+		}
+
+		if stopOnResolve && initial[i].Status != requests.ApprovalPending {
+			return
+		}
+	}
+
+	if err := rc.Flush(); err != nil {
+		h.log.Debug("failed to flush event stream: %w", err)
+		return
+	}
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil || rc.Flush() != nil {
+				return
+			}
+		case a, ok := <-updates:
+			if !ok || !send(a) {
+				return
+			}
+
+			if stopOnResolve && a.Status != requests.ApprovalPending {
+				return
+			}
+		}
+	}
+}
+
+func newApprovalResponse(a *requests.Approval) *ApprovalResponse {
+	return &ApprovalResponse{
+		ID:            a.ID,
+		GuardID:       a.GuardID,
+		BuildingID:    a.BuildingID,
+		Apartment:     a.Apartment,
+		Description:   a.Description,
+		Status:        a.Status,
+		DecidedBy:     a.DecidedBy,
+		DecidedByName: a.DecidedByName,
+		DecidedAt:     a.DecidedAt,
+		ExpiresAt:     a.ExpiresAt,
+		CreatedAt:     a.CreatedAt,
+	}
+}
@@ -0,0 +1,325 @@
+package transport_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/server/handlers/requests"
+	"github.com/ivch/dynasty/server/handlers/requests/transport"
+	"github.com/ivch/dynasty/server/middlewares"
+)
+
+var approvalExpiresAt = time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+
+func TestHTTP_GuardCreateApproval(t *testing.T) {
+	tests := []struct {
+		name     string
+		svc      transport.RequestsService
+		request  string
+		header   string
+		want     string
+		wantCode int
+	}{
+		{
+			name:     "error no guard",
+			request:  "{}",
+			header:   "0",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "error parsing request",
+			request:  "}{",
+			header:   "3",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "error no building",
+			request:  `{"apartment":1}`,
+			header:   "3",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "error no apartment",
+			request:  `{"building_id":1}`,
+			header:   "3",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:    "error not a guard",
+			request: `{"building_id":1,"apartment":12}`,
+			header:  "3",
+			svc: &transport.RequestsServiceMock{
+				GuardCreateApprovalFunc: func(_ context.Context, _ *requests.Approval) (*requests.Approval, error) {
+					return nil, errs.InsufficientPermissions
+				},
+			},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:    "error no residents",
+			request: `{"building_id":1,"apartment":12}`,
+			header:  "3",
+			svc: &transport.RequestsServiceMock{
+				GuardCreateApprovalFunc: func(_ context.Context, _ *requests.Approval) (*requests.Approval, error) {
+					return nil, errs.UserNotFound
+				},
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:    "error service",
+			request: `{"building_id":1,"apartment":12}`,
+			header:  "3",
+			svc: &transport.RequestsServiceMock{
+				GuardCreateApprovalFunc: func(_ context.Context, _ *requests.Approval) (*requests.Approval, error) {
+					return nil, errTestError
+				},
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:    "ok",
+			request: `{"building_id":1,"apartment":12,"description":"<b>courier</b>"}`,
+			header:  "3",
+			svc: &transport.RequestsServiceMock{
+				GuardCreateApprovalFunc: func(_ context.Context, a *requests.Approval) (*requests.Approval, error) {
+					if a.GuardID != 3 || a.BuildingID != 1 || a.Apartment != 12 || a.Description != "courier" {
+						return nil, errTestError
+					}
+					a.ID = 1
+					a.Status = requests.ApprovalPending
+					a.ExpiresAt = approvalExpiresAt
+					return a, nil
+				},
+			},
+			want:     `{"id":1,"guard_id":3,"building_id":1,"apartment":12,"description":"courier","status":"pending","expires_at":"2020-01-01T10:00:00Z"}`,
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := transport.NewHTTPTransport(defaultLogger, tt.svc, defaultPolicy, middlewares.NewIDCtx(defaultLogger).Middleware)
+			rr := httptest.NewRecorder()
+			rq, _ := http.NewRequest(http.MethodPost, "/v1/guard/approval", strings.NewReader(tt.request))
+			rq.Header.Add("X-Auth-User", tt.header)
+			h.ServeHTTP(rr, rq)
+			if rr.Code != tt.wantCode {
+				t.Errorf("Request error. status = %d, expected %v", rr.Code, tt.wantCode)
+			}
+
+			if tt.want != "" && tt.want != strings.TrimSpace(rr.Body.String()) {
+				t.Errorf("Response error, got = %s, want = %s", rr.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTP_ResolveApproval(t *testing.T) {
+	tests := []struct {
+		name     string
+		svc      transport.RequestsService
+		request  string
+		header   string
+		want     string
+		wantCode int
+	}{
+		{
+			name:     "error no user",
+			request:  `{"decision":"approve"}`,
+			header:   "0",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "error parsing request",
+			request:  "}{",
+			header:   "7",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "error wrong decision",
+			request:  `{"decision":"maybe"}`,
+			header:   "7",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:    "error other apartment",
+			request: `{"decision":"approve"}`,
+			header:  "7",
+			svc: &transport.RequestsServiceMock{
+				ResolveApprovalFunc: func(_ context.Context, _ *requests.ApprovalDecision) (*requests.Approval, error) {
+					return nil, errs.InsufficientPermissions
+				},
+			},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:    "error already resolved",
+			request: `{"decision":"approve"}`,
+			header:  "7",
+			svc: &transport.RequestsServiceMock{
+				ResolveApprovalFunc: func(_ context.Context, _ *requests.ApprovalDecision) (*requests.Approval, error) {
+					return nil, errs.ApprovalAlreadyResolved
+				},
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:    "error expired",
+			request: `{"decision":"deny"}`,
+			header:  "7",
+			svc: &transport.RequestsServiceMock{
+				ResolveApprovalFunc: func(_ context.Context, _ *requests.ApprovalDecision) (*requests.Approval, error) {
+					return nil, errs.ApprovalExpired
+				},
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:    "error service",
+			request: `{"decision":"deny"}`,
+			header:  "7",
+			svc: &transport.RequestsServiceMock{
+				ResolveApprovalFunc: func(_ context.Context, _ *requests.ApprovalDecision) (*requests.Approval, error) {
+					return nil, errTestError
+				},
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:    "ok",
+			request: `{"decision":"approve"}`,
+			header:  "7",
+			svc: &transport.RequestsServiceMock{
+				ResolveApprovalFunc: func(_ context.Context, d *requests.ApprovalDecision) (*requests.Approval, error) {
+					if d.ID != 1 || d.UserID != 7 || !d.Approve {
+						return nil, errTestError
+					}
+					return &requests.Approval{
+						ID:            1,
+						GuardID:       3,
+						BuildingID:    1,
+						Apartment:     12,
+						Status:        requests.ApprovalApproved,
+						DecidedBy:     &d.UserID,
+						DecidedByName: "John Doe",
+						ExpiresAt:     approvalExpiresAt,
+					}, nil
+				},
+			},
+			want:     `{"id":1,"guard_id":3,"building_id":1,"apartment":12,"status":"approved","decided_by":7,"decided_by_name":"John Doe","expires_at":"2020-01-01T10:00:00Z"}`,
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := transport.NewHTTPTransport(defaultLogger, tt.svc, defaultPolicy, middlewares.NewIDCtx(defaultLogger).Middleware)
+			rr := httptest.NewRecorder()
+			rq, _ := http.NewRequest(http.MethodPost, "/v1/approval/1", strings.NewReader(tt.request))
+			rq.Header.Add("X-Auth-User", tt.header)
+			h.ServeHTTP(rr, rq)
+			if rr.Code != tt.wantCode {
+				t.Errorf("Request error. status = %d, expected %v", rr.Code, tt.wantCode)
+			}
+
+			if tt.want != "" && tt.want != strings.TrimSpace(rr.Body.String()) {
+				t.Errorf("Response error, got = %s, want = %s", rr.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTP_MyApprovals(t *testing.T) {
+	tests := []struct {
+		name     string
+		svc      transport.RequestsService
+		header   string
+		want     string
+		wantCode int
+	}{
+		{
+			name:     "error no user",
+			header:   "0",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "error service",
+			header: "7",
+			svc: &transport.RequestsServiceMock{
+				MyApprovalsFunc: func(_ context.Context, _ uint) ([]*requests.Approval, error) {
+					return nil, errTestError
+				},
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "ok",
+			header: "7",
+			svc: &transport.RequestsServiceMock{
+				MyApprovalsFunc: func(_ context.Context, _ uint) ([]*requests.Approval, error) {
+					return []*requests.Approval{{ID: 1, GuardID: 3, BuildingID: 1, Apartment: 12, Status: requests.ApprovalPending, ExpiresAt: approvalExpiresAt}}, nil
+				},
+			},
+			want:     `{"data":[{"id":1,"guard_id":3,"building_id":1,"apartment":12,"status":"pending","expires_at":"2020-01-01T10:00:00Z"}]}`,
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := transport.NewHTTPTransport(defaultLogger, tt.svc, defaultPolicy, middlewares.NewIDCtx(defaultLogger).Middleware)
+			rr := httptest.NewRecorder()
+			rq, _ := http.NewRequest(http.MethodGet, "/v1/approvals", nil)
+			rq.Header.Add("X-Auth-User", tt.header)
+			h.ServeHTTP(rr, rq)
+			if rr.Code != tt.wantCode {
+				t.Errorf("Request error. status = %d, expected %v", rr.Code, tt.wantCode)
+			}
+
+			if tt.want != "" && tt.want != strings.TrimSpace(rr.Body.String()) {
+				t.Errorf("Response error, got = %s, want = %s", rr.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTP_GuardApprovalEvents(t *testing.T) {
+	updates := make(chan *requests.Approval, 1)
+	updates <- &requests.Approval{ID: 1, Status: requests.ApprovalDenied, ExpiresAt: approvalExpiresAt}
+
+	svc := &transport.RequestsServiceMock{
+		SubscribeApprovalFunc: func(_ context.Context, _ uint) (<-chan *requests.Approval, func()) {
+			return updates, func() {}
+		},
+		GuardApprovalFunc: func(_ context.Context, id uint) (*requests.Approval, error) {
+			return &requests.Approval{ID: id, Status: requests.ApprovalPending, ExpiresAt: approvalExpiresAt}, nil
+		},
+	}
+
+	h := transport.NewHTTPTransport(defaultLogger, svc, defaultPolicy, middlewares.NewIDCtx(defaultLogger).Middleware)
+	rr := httptest.NewRecorder()
+	rq, _ := http.NewRequest(http.MethodGet, "/v1/guard/approval/1/events", nil)
+	h.ServeHTTP(rr, rq)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Request error. status = %d, expected %v", rr.Code, http.StatusOK)
+	}
+
+	if ct := rr.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("wrong content type %s", ct)
+	}
+
+	want := "event: approval\n" +
+		`data: {"id":1,"guard_id":0,"building_id":0,"apartment":0,"status":"pending","expires_at":"2020-01-01T10:00:00Z"}` + "\n\n" +
+		"event: approval\n" +
+		`data: {"id":1,"guard_id":0,"building_id":0,"apartment":0,"status":"denied","expires_at":"2020-01-01T10:00:00Z"}` + "\n\n"
+	if rr.Body.String() != want {
+		t.Errorf("Response error, got = %s, want = %s", rr.Body.String(), want)
+	}
+}
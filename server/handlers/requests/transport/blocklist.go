@@ -0,0 +1,201 @@
+package transport
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/server/handlers/requests"
+)
+
+func (h *HTTPTransport) Blocklist(w http.ResponseWriter, r *http.Request) {
+	adminID, err := getUserID(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
+		return
+	}
+
+	res, err := h.svc.Blocklist(r.Context(), adminID)
+	if err != nil {
+		h.sendBlocklistError(w, err)
+		return
+	}
+
+	result := BlocklistResponse{Data: make([]*BlocklistEntryResponse, len(res))}
+	for i := range res {
+		result.Data[i] = newBlocklistEntryResponse(res[i])
+	}
+
+	h.sendHTTPResponse(r.Context(), w, result)
+}
+
+func (h *HTTPTransport) CreateBlocklistEntry(w http.ResponseWriter, r *http.Request) {
+	adminID, err := getUserID(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
+		return
+	}
+
+	var req BlocklistEntryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, http.StatusBadRequest, errs.BadRequest)
+		return
+	}
+
+	req.Sanitize(h.sanitizer)
+
+	e := newBlocklistEntry(&req)
+	e.CreatedBy = adminID
+
+	res, err := h.svc.CreateBlocklistEntry(r.Context(), e)
+	if err != nil {
+		h.sendBlocklistError(w, err)
+		return
+	}
+
+	h.sendHTTPResponse(r.Context(), w, newBlocklistEntryResponse(res))
+}
+
+func (h *HTTPTransport) UpdateBlocklistEntry(w http.ResponseWriter, r *http.Request) {
+	adminID, err := getUserID(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
+		return
+	}
+
+	id, err := getIDFromQuery(r)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var req BlocklistEntryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, http.StatusBadRequest, errs.BadRequest)
+		return
+	}
+
+	req.Sanitize(h.sanitizer)
+
+	e := newBlocklistEntry(&req)
+	e.ID = id
+
+	if err := h.svc.UpdateBlocklistEntry(r.Context(), adminID, e); err != nil {
+		h.sendBlocklistError(w, err)
+		return
+	}
+
+	h.sendHTTPResponse(r.Context(), w, newBlocklistEntryResponse(e))
+}
+
+func (h *HTTPTransport) DeleteBlocklistEntry(w http.ResponseWriter, r *http.Request) {
+	adminID, err := getUserID(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
+		return
+	}
+
+	id, err := getIDFromQuery(r)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.svc.DeleteBlocklistEntry(r.Context(), adminID, id); err != nil {
+		h.sendBlocklistError(w, err)
+		return
+	}
+
+	h.sendHTTPResponse(r.Context(), w, nil)
+}
+
+func (h *HTTPTransport) BlocklistAudit(w http.ResponseWriter, r *http.Request) {
+	adminID, err := getUserID(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
+		return
+	}
+
+	id, err := getIDFromQuery(r)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res, err := h.svc.BlocklistAudit(r.Context(), adminID, id)
+	if err != nil {
+		h.sendBlocklistError(w, err)
+		return
+	}
+
+	result := BlocklistAuditResponse{Data: make([]*BlocklistAuditRecord, len(res))}
+	for i := range res {
+		result.Data[i] = &BlocklistAuditRecord{
+			AdminID:   res[i].AdminID,
+			Action:    res[i].Action,
+			Entry:     json.RawMessage(res[i].Data),
+			CreatedAt: res[i].CreatedAt,
+		}
+	}
+
+	h.sendHTTPResponse(r.Context(), w, result)
+}
+
+func (h *HTTPTransport) sendBlocklistError(w http.ResponseWriter, err error) {
+	switch err {
+	case errs.InsufficientPermissions, errs.UserNotFound:
+		h.sendError(w, http.StatusForbidden, err)
+	case errs.BlocklistEntryEmpty, errs.BlocklistNameTooShort, errs.BlocklistNameTooLong,
+		errs.BlocklistPlateTooLong, errs.BlocklistPhoneWrongLength, errs.BlocklistReasonTooLong:
+		h.sendError(w, http.StatusBadRequest, err)
+	case errs.BlocklistEntryNotFound:
+		h.sendError(w, http.StatusNotFound, err)
+	default:
+		h.sendError(w, http.StatusInternalServerError, err)
+	}
+}
+
+func newBlocklistEntry(r *BlocklistEntryRequest) *requests.BlocklistEntry {
+	return &requests.BlocklistEntry{
+		Plate:     r.Plate,
+		Name:      r.Name,
+		Phone:     r.Phone,
+		Reason:    r.Reason,
+		Reject:    r.Reject,
+		ExpiresAt: r.ExpiresAt,
+	}
+}
+
+func newBlocklistEntryResponse(e *requests.BlocklistEntry) *BlocklistEntryResponse {
+	return &BlocklistEntryResponse{
+		ID:        e.ID,
+		Plate:     e.Plate,
+		Name:      e.Name,
+		Phone:     e.Phone,
+		Reason:    e.Reason,
+		Reject:    e.Reject,
+		ExpiresAt: e.ExpiresAt,
+		CreatedBy: e.CreatedBy,
+		CreatedAt: e.CreatedAt,
+		UpdatedAt: e.UpdatedAt,
+	}
+}
+
+func newBlocklistWarnings(list []*requests.BlocklistEntry) []*BlocklistWarning {
+	if len(list) == 0 {
+		return nil
+	}
+
+	res := make([]*BlocklistWarning, len(list))
+	for i := range list {
+		res[i] = &BlocklistWarning{
+			EntryID: list[i].ID,
+			Plate:   list[i].Plate,
+			Name:    list[i].Name,
+			Phone:   list[i].Phone,
+			Reason:  list[i].Reason,
+		}
+	}
+
+	return res
+}
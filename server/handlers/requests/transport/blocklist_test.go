@@ -0,0 +1,174 @@
+package transport_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/server/handlers/requests"
+	"github.com/ivch/dynasty/server/handlers/requests/transport"
+	"github.com/ivch/dynasty/server/middlewares"
+)
+
+func TestHTTP_CreateBlocklistEntry(t *testing.T) {
+	tests := []struct {
+		name     string
+		svc      transport.RequestsService
+		request  string
+		header   string
+		want     string
+		wantCode int
+	}{
+		{
+			name:     "error no user",
+			request:  "{}",
+			header:   "0",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "error parsing request",
+			request:  "}{",
+			header:   "1",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:    "error not an admin",
+			request: `{"plate":"AA1234BB"}`,
+			header:  "3",
+			svc: &transport.RequestsServiceMock{
+				CreateBlocklistEntryFunc: func(_ context.Context, _ *requests.BlocklistEntry) (*requests.BlocklistEntry, error) {
+					return nil, errs.InsufficientPermissions
+				},
+			},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:    "error empty entry",
+			request: `{"reason":"<b>why</b>"}`,
+			header:  "1",
+			svc: &transport.RequestsServiceMock{
+				CreateBlocklistEntryFunc: func(_ context.Context, _ *requests.BlocklistEntry) (*requests.BlocklistEntry, error) {
+					return nil, errs.BlocklistEntryEmpty
+				},
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:    "error service",
+			request: `{"plate":"AA1234BB"}`,
+			header:  "1",
+			svc: &transport.RequestsServiceMock{
+				CreateBlocklistEntryFunc: func(_ context.Context, _ *requests.BlocklistEntry) (*requests.BlocklistEntry, error) {
+					return nil, errTestError
+				},
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:    "ok",
+			request: `{"plate":" AA1234BB ","reason":"<b>broke the gate</b>","reject":true}`,
+			header:  "1",
+			svc: &transport.RequestsServiceMock{
+				CreateBlocklistEntryFunc: func(_ context.Context, e *requests.BlocklistEntry) (*requests.BlocklistEntry, error) {
+					if e.CreatedBy != 1 || e.Plate != "AA1234BB" || e.Reason != "broke the gate" || !e.Reject {
+						return nil, errTestError
+					}
+					e.ID = 5
+					return e, nil
+				},
+			},
+			want:     `{"id":5,"plate":"AA1234BB","reason":"broke the gate","reject":true,"created_by":1}`,
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := transport.NewHTTPTransport(defaultLogger, tt.svc, defaultPolicy, middlewares.NewIDCtx(defaultLogger).Middleware)
+			rr := httptest.NewRecorder()
+			rq, _ := http.NewRequest(http.MethodPost, "/v1/admin/blocklist", strings.NewReader(tt.request))
+			rq.Header.Add("X-Auth-User", tt.header)
+			h.ServeHTTP(rr, rq)
+			if rr.Code != tt.wantCode {
+				t.Errorf("Request error. status = %d, expected %v", rr.Code, tt.wantCode)
+			}
+
+			if tt.want != "" && tt.want != strings.TrimSpace(rr.Body.String()) {
+				t.Errorf("Response error, got = %s, want = %s", rr.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTP_DeleteBlocklistEntry(t *testing.T) {
+	tests := []struct {
+		name     string
+		svc      transport.RequestsService
+		header   string
+		wantCode int
+	}{
+		{
+			name:     "error no user",
+			header:   "0",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "error no entry",
+			header: "1",
+			svc: &transport.RequestsServiceMock{
+				DeleteBlocklistEntryFunc: func(_ context.Context, _, _ uint) error {
+					return errs.BlocklistEntryNotFound
+				},
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:   "ok",
+			header: "1",
+			svc: &transport.RequestsServiceMock{
+				DeleteBlocklistEntryFunc: func(_ context.Context, adminID, id uint) error {
+					if adminID != 1 || id != 5 {
+						return errTestError
+					}
+					return nil
+				},
+			},
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := transport.NewHTTPTransport(defaultLogger, tt.svc, defaultPolicy, middlewares.NewIDCtx(defaultLogger).Middleware)
+			rr := httptest.NewRecorder()
+			rq, _ := http.NewRequest(http.MethodDelete, "/v1/admin/blocklist/5", nil)
+			rq.Header.Add("X-Auth-User", tt.header)
+			h.ServeHTTP(rr, rq)
+			if rr.Code != tt.wantCode {
+				t.Errorf("Request error. status = %d, expected %v", rr.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestHTTP_BlocklistAudit(t *testing.T) {
+	svc := &transport.RequestsServiceMock{
+		BlocklistAuditFunc: func(_ context.Context, _, _ uint) ([]*requests.BlocklistAudit, error) {
+			return []*requests.BlocklistAudit{{ID: 1, EntryID: 5, AdminID: 1, Action: requests.BlocklistActionCreate, Data: `{"id":5}`}}, nil
+		},
+	}
+
+	h := transport.NewHTTPTransport(defaultLogger, svc, defaultPolicy, middlewares.NewIDCtx(defaultLogger).Middleware)
+	rr := httptest.NewRecorder()
+	rq, _ := http.NewRequest(http.MethodGet, "/v1/admin/blocklist/5/audit", nil)
+	rq.Header.Add("X-Auth-User", "1")
+	h.ServeHTTP(rr, rq)
+
+	want := `{"data":[{"admin_id":1,"action":"create","entry":{"id":5}}]}`
+	if rr.Code != http.StatusOK || strings.TrimSpace(rr.Body.String()) != want {
+		t.Errorf("Response error, code = %d, got = %s, want = %s", rr.Code, rr.Body.String(), want)
+	}
+}
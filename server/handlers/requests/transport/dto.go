@@ -1,6 +1,8 @@
 package transport
 
 import (
+	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/ivch/dynasty/server/handlers/requests"
@@ -56,6 +58,7 @@ type RequestByIDResponse struct {
 	Type        string              `json:"type"`
 	Rtype       int                 `json:"rtype"`
 	UserID      uint                `json:"user_id"`
+	GuardID     *uint               `json:"guard_id,omitempty"`
 	Time        int64               `json:"time"`
 	Description string              `json:"description"`
 	Status      string              `json:"status"`
@@ -64,8 +67,9 @@ type RequestByIDResponse struct {
 }
 
 type UploadImageResponse struct {
-	Img   string `json:"img"`
-	Thumb string `json:"thumb"`
+	Img    string `json:"img"`
+	Thumb  string `json:"thumb"`
+	Status string `json:"status,omitempty"`
 }
 
 type DeleteImageRequest struct {
@@ -75,6 +79,7 @@ type DeleteImageRequest struct {
 type RequestForGuard struct {
 	ID          uint                 `json:"id"`
 	UserID      uint                 `json:"user_id" gorm:"-"`
+	GuardID     *uint                `json:"guard_id,omitempty"`
 	Type        string               `json:"type"`
 	Rtype       requests.RequestType `json:"rtype"`
 	Time        int64                `json:"time"`
@@ -84,15 +89,126 @@ type RequestForGuard struct {
 	Phone       string               `json:"phone"`
 	Address     string               `json:"address"`
 	Apartment   uint                 `json:"apartment"`
+	Avatar      map[string]string    `json:"avatar,omitempty"`
 	Images      []map[string]string  `json:"images,omitempty"`
+	Warnings    []*BlocklistWarning  `json:"warnings,omitempty"`
 	CreatedAt   *time.Time           `json:"created_at,omitempty"`
 }
 
+// BlocklistWarning tells the guard which blocklist entry the request matches.
+type BlocklistWarning struct {
+	EntryID uint   `json:"entry_id"`
+	Plate   string `json:"plate,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Phone   string `json:"phone,omitempty"`
+	Reason  string `json:"reason"`
+}
+
 type RequestGuardListResponse struct {
 	Data  []*RequestForGuard `json:"data"`
 	Count int                `json:"count"`
 }
 
+type GuardCreateRequest struct {
+	BuildingID        uint                 `json:"building_id"`
+	Apartment         uint                 `json:"apartment"`
+	Type              string               `json:"type"`
+	Rtype             requests.RequestType `json:"rtype"`
+	Time              int64                `json:"time"`
+	Description       string               `json:"description"`
+	AwaitConfirmation bool                 `json:"await_confirmation"`
+}
+
+func (r *GuardCreateRequest) Sanitize(p *bluemonday.Policy) {
+	r.Description = p.Sanitize(r.Description)
+}
+
+type GuardCreateResponse struct {
+	ID       uint                `json:"id"`
+	Warnings []*BlocklistWarning `json:"warnings,omitempty"`
+}
+
+type GuardCreateApprovalRequest struct {
+	BuildingID  uint   `json:"building_id"`
+	Apartment   uint   `json:"apartment"`
+	Description string `json:"description"`
+}
+
+func (r *GuardCreateApprovalRequest) Sanitize(p *bluemonday.Policy) {
+	r.Description = p.Sanitize(r.Description)
+}
+
+const (
+	approvalDecisionApprove = "approve"
+	approvalDecisionDeny    = "deny"
+)
+
+type ResolveApprovalRequest struct {
+	Decision string `json:"decision"`
+}
+
+type ApprovalResponse struct {
+	ID            uint       `json:"id"`
+	GuardID       uint       `json:"guard_id"`
+	BuildingID    uint       `json:"building_id"`
+	Apartment     uint       `json:"apartment"`
+	Description   string     `json:"description,omitempty"`
+	Status        string     `json:"status"`
+	DecidedBy     *uint      `json:"decided_by,omitempty"`
+	DecidedByName string     `json:"decided_by_name,omitempty"`
+	DecidedAt     *time.Time `json:"decided_at,omitempty"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	CreatedAt     *time.Time `json:"created_at,omitempty"`
+}
+
+type ListApprovalsResponse struct {
+	Data []*ApprovalResponse `json:"data"`
+}
+
+type BlocklistEntryRequest struct {
+	Plate     string     `json:"plate"`
+	Name      string     `json:"name"`
+	Phone     string     `json:"phone"`
+	Reason    string     `json:"reason"`
+	Reject    bool       `json:"reject"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+func (r *BlocklistEntryRequest) Sanitize(p *bluemonday.Policy) {
+	r.Plate = strings.TrimSpace(p.Sanitize(r.Plate))
+	r.Name = strings.TrimSpace(p.Sanitize(r.Name))
+	r.Phone = strings.TrimSpace(p.Sanitize(r.Phone))
+	r.Reason = p.Sanitize(r.Reason)
+}
+
+type BlocklistEntryResponse struct {
+	ID        uint       `json:"id"`
+	Plate     string     `json:"plate,omitempty"`
+	Name      string     `json:"name,omitempty"`
+	Phone     string     `json:"phone,omitempty"`
+	Reason    string     `json:"reason"`
+	Reject    bool       `json:"reject"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedBy uint       `json:"created_by"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+type BlocklistResponse struct {
+	Data []*BlocklistEntryResponse `json:"data"`
+}
+
+type BlocklistAuditRecord struct {
+	AdminID   uint            `json:"admin_id"`
+	Action    string          `json:"action"`
+	Entry     json.RawMessage `json:"entry"`
+	CreatedAt *time.Time      `json:"created_at,omitempty"`
+}
+
+type BlocklistAuditResponse struct {
+	Data []*BlocklistAuditRecord `json:"data"`
+}
+
 type GuardUpdateRequest struct {
 	Status string `json:"status"`
 }
@@ -20,6 +20,7 @@ type RequestsService interface {
 	Create(ctx context.Context, r *requests.Request) (*requests.Request, error)
 	Get(ctx context.Context, r *requests.Request) (*requests.Request, error)
 	Update(ctx context.Context, r *requests.UpdateRequest) error
+	Confirm(ctx context.Context, r *requests.Request) error
 	Delete(ctx context.Context, r *requests.Request) error
 	My(ctx context.Context, r *requests.RequestListFilter) ([]*requests.Request, error)
 
@@ -27,12 +28,28 @@ type RequestsService interface {
 	DeleteImage(ctx context.Context, r *requests.Image) error
 
 	GuardRequestList(ctx context.Context, r *requests.RequestListFilter) ([]*requests.Request, int, error)
+	GuardCreateRequest(ctx context.Context, r *requests.WalkInRequest) (*requests.Request, error)
 	GuardUpdateRequest(ctx context.Context, r *requests.Request) error
 	GuardStats24h(ctx context.Context) (*requests.RequestStats, error)
+
+	GuardCreateApproval(ctx context.Context, r *requests.Approval) (*requests.Approval, error)
+	GuardApproval(ctx context.Context, id uint) (*requests.Approval, error)
+	MyApprovals(ctx context.Context, userID uint) ([]*requests.Approval, error)
+	ResolveApproval(ctx context.Context, r *requests.ApprovalDecision) (*requests.Approval, error)
+	SubscribeApproval(ctx context.Context, id uint) (<-chan *requests.Approval, func())
+	SubscribeUserApprovals(ctx context.Context, userID uint) (<-chan *requests.Approval, func())
+
+	Blocklist(ctx context.Context, adminID uint) ([]*requests.BlocklistEntry, error)
+	CreateBlocklistEntry(ctx context.Context, e *requests.BlocklistEntry) (*requests.BlocklistEntry, error)
+	UpdateBlocklistEntry(ctx context.Context, adminID uint, e *requests.BlocklistEntry) error
+	DeleteBlocklistEntry(ctx context.Context, adminID, id uint) error
+	BlocklistAudit(ctx context.Context, adminID, entryID uint) ([]*requests.BlocklistAudit, error)
 }
 
 const (
 	maxUploadSize = 10 << 20 // 10 MB
+
+	imageStatusProcessing = "processing"
 )
 
 type HTTPTransport struct {
@@ -58,14 +75,29 @@ func (h *HTTPTransport) attachRoutes() {
 	h.router.Put("/v1/request/{id}", h.Update)
 	h.router.Get("/v1/request/{id}", h.GetRequestByID)
 	h.router.Delete("/v1/request/{id}", h.Delete)
+	h.router.Put("/v1/request/{id}/confirm", h.Confirm)
 	h.router.Get("/v1/my", h.ListByUser)
 
 	h.router.Post("/v1/request/{id}/file", h.UploadFile)
 	h.router.Delete("/v1/request/{id}/file", h.DeleteFile)
 
 	h.router.Get("/v1/guard/list", h.GuardList)
+	h.router.Post("/v1/guard/request", h.GuardCreateRequest)
 	h.router.Put("/v1/guard/request/{id}", h.GuardUpdateRequest)
 	h.router.Get("/v1/guard/stats24h", h.GuardStats24h)
+
+	h.router.Post("/v1/guard/approval", h.GuardCreateApproval)
+	h.router.Get("/v1/guard/approval/{id}", h.GuardApproval)
+	h.router.Get("/v1/guard/approval/{id}/events", h.GuardApprovalEvents)
+	h.router.Get("/v1/approvals", h.MyApprovals)
+	h.router.Get("/v1/approvals/events", h.MyApprovalsEvents)
+	h.router.Post("/v1/approval/{id}", h.ResolveApproval)
+
+	h.router.Get("/v1/admin/blocklist", h.Blocklist)
+	h.router.Post("/v1/admin/blocklist", h.CreateBlocklistEntry)
+	h.router.Put("/v1/admin/blocklist/{id}", h.UpdateBlocklistEntry)
+	h.router.Delete("/v1/admin/blocklist/{id}", h.DeleteBlocklistEntry)
+	h.router.Get("/v1/admin/blocklist/{id}/audit", h.BlocklistAudit)
 }
 
 func (h *HTTPTransport) Create(w http.ResponseWriter, r *http.Request) {
@@ -100,6 +132,10 @@ func (h *HTTPTransport) Create(w http.ResponseWriter, r *http.Request) {
 
 	res, err := h.svc.Create(r.Context(), &data)
 	if err != nil {
+		if err == errs.RequestBlocklisted {
+			h.sendError(w, http.StatusForbidden, err)
+			return
+		}
 		h.sendError(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -189,6 +225,7 @@ func (h *HTTPTransport) GetRequestByID(w http.ResponseWriter, r *http.Request) {
 		ID:          res.ID,
 		Type:        res.Type,
 		UserID:      res.UserID,
+		GuardID:     res.GuardID,
 		Time:        res.Time,
 		Description: res.Description,
 		Status:      res.Status,
@@ -198,6 +235,36 @@ func (h *HTTPTransport) GetRequestByID(w http.ResponseWriter, r *http.Request) {
 	h.sendHTTPResponse(r.Context(), w, result)
 }
 
+func (h *HTTPTransport) Confirm(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
+		return
+	}
+
+	id, err := getIDFromQuery(r)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	req := requests.Request{
+		ID:     id,
+		UserID: userID,
+	}
+
+	if err := h.svc.Confirm(r.Context(), &req); err != nil {
+		if err == errs.RequestNotPending {
+			h.sendError(w, http.StatusBadRequest, err)
+			return
+		}
+		h.sendError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.sendHTTPResponse(r.Context(), w, nil)
+}
+
 func (h *HTTPTransport) Delete(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserID(r.Context())
 	if err != nil {
@@ -259,6 +326,7 @@ func (h *HTTPTransport) ListByUser(w http.ResponseWriter, r *http.Request) {
 			Type:        res[i].Type,
 			Rtype:       int(res[i].Rtype),
 			UserID:      res[i].UserID,
+			GuardID:     res[i].GuardID,
 			Time:        res[i].Time,
 			Description: res[i].Description,
 			Status:      res[i].Status,
@@ -303,7 +371,8 @@ func (h *HTTPTransport) UploadFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if header.Size > (5 << 20) { // 5Mb
+	// per-type limits are checked by the service
+	if header.Size > maxUploadSize {
 		h.sendError(w, http.StatusBadRequest, errs.FileIsTooBig)
 		return
 	}
@@ -324,7 +393,12 @@ func (h *HTTPTransport) UploadFile(w http.ResponseWriter, r *http.Request) {
 
 	img, err := h.svc.UploadImage(r.Context(), &upload)
 	if err != nil {
-		h.sendError(w, http.StatusInternalServerError, err)
+		switch err {
+		case errs.FileWrongType, errs.FileIsTooBig, errs.TooMuchFiles:
+			h.sendError(w, http.StatusBadRequest, err)
+		default:
+			h.sendError(w, http.StatusInternalServerError, err)
+		}
 		return
 	}
 
@@ -332,6 +406,9 @@ func (h *HTTPTransport) UploadFile(w http.ResponseWriter, r *http.Request) {
 		Img:   img.URL,
 		Thumb: img.Thumb,
 	}
+	if img.Processing {
+		result.Status = imageStatusProcessing
+	}
 
 	h.sendHTTPResponse(r.Context(), w, &result)
 }
@@ -423,6 +500,7 @@ func (h *HTTPTransport) GuardList(w http.ResponseWriter, r *http.Request) {
 		result.Data[i] = &RequestForGuard{
 			ID:          res[i].ID,
 			UserID:      res[i].UserID,
+			GuardID:     res[i].GuardID,
 			Type:        res[i].Type,
 			Rtype:       res[i].Rtype,
 			Time:        res[i].Time,
@@ -432,7 +510,9 @@ func (h *HTTPTransport) GuardList(w http.ResponseWriter, r *http.Request) {
 			Phone:       res[i].User.Phone,
 			Address:     res[i].User.Building.Name + ", " + res[i].User.Entry.Name,
 			Apartment:   res[i].User.Apartment,
+			Avatar:      res[i].User.AvatarURL,
 			Images:      res[i].ImagesURL,
+			Warnings:    newBlocklistWarnings(res[i].Blocklisted),
 			CreatedAt:   res[i].CreatedAt,
 		}
 	}
@@ -440,6 +520,53 @@ func (h *HTTPTransport) GuardList(w http.ResponseWriter, r *http.Request) {
 	h.sendHTTPResponse(r.Context(), w, result)
 }
 
+func (h *HTTPTransport) GuardCreateRequest(w http.ResponseWriter, r *http.Request) {
+	guardID, err := getUserID(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
+		return
+	}
+
+	var req GuardCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, http.StatusBadRequest, errs.BadRequest)
+		return
+	}
+
+	req.Sanitize(h.sanitizer)
+
+	if err := validateGuardCreateRequest(&req); err != nil {
+		h.sendError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	data := requests.WalkInRequest{
+		GuardID:           guardID,
+		BuildingID:        req.BuildingID,
+		Apartment:         req.Apartment,
+		Type:              req.Type,
+		Rtype:             req.Rtype,
+		Time:              req.Time,
+		Description:       req.Description,
+		AwaitConfirmation: req.AwaitConfirmation,
+	}
+
+	res, err := h.svc.GuardCreateRequest(r.Context(), &data)
+	if err != nil {
+		switch err {
+		case errs.InsufficientPermissions, errs.RequestBlocklisted:
+			h.sendError(w, http.StatusForbidden, err)
+		case errs.UserNotFound:
+			h.sendError(w, http.StatusNotFound, err)
+		default:
+			h.sendError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	h.sendHTTPResponse(r.Context(), w, GuardCreateResponse{ID: res.ID, Warnings: newBlocklistWarnings(res.Blocklisted)})
+}
+
 func (h *HTTPTransport) GuardUpdateRequest(w http.ResponseWriter, r *http.Request) {
 	var req GuardUpdateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -550,6 +677,22 @@ func validateCreateRequest(r *RequestCreateRequest) error {
 	return nil
 }
 
+func validateGuardCreateRequest(r *GuardCreateRequest) error {
+	if r.BuildingID == 0 {
+		return errs.BuildingEmpty
+	}
+
+	if r.Apartment == 0 {
+		return errs.ApartmentEmpty
+	}
+
+	return validateCreateRequest(&RequestCreateRequest{
+		Type:  r.Type,
+		Rtype: r.Rtype,
+		Time:  r.Time,
+	})
+}
+
 func validateFilterRequest(r requests.RequestListFilter) error {
 	reqTypes := map[string]struct{}{
 		"taxi":     {},
@@ -574,7 +717,7 @@ func validateFilterRequest(r requests.RequestListFilter) error {
 		}
 	}
 
-	if r.Status != "all" && r.Status != "new" && r.Status != "closed" {
+	if r.Status != "all" && r.Status != "new" && r.Status != "pending" && r.Status != "closed" {
 		return errs.WrongRequestStatus
 	}
 
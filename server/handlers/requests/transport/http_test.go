@@ -18,6 +18,7 @@ import (
 
 	"github.com/microcosm-cc/bluemonday"
 
+	"github.com/ivch/dynasty/common/errs"
 	"github.com/ivch/dynasty/common/logger"
 	"github.com/ivch/dynasty/server/handlers/requests"
 	"github.com/ivch/dynasty/server/handlers/requests/transport"
@@ -435,6 +436,78 @@ func TestHTTP_Delete(t *testing.T) {
 	}
 }
 
+func TestHTTP_Confirm(t *testing.T) {
+	tests := []struct {
+		name     string
+		svc      transport.RequestsService
+		id       string
+		header   string
+		wantCode int
+	}{
+		{
+			name:     "error no user",
+			id:       "1",
+			header:   "0",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "error no id",
+			id:       "0",
+			header:   "1",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "error not pending",
+			id:     "1",
+			header: "1",
+			svc: &transport.RequestsServiceMock{
+				ConfirmFunc: func(_ context.Context, _ *requests.Request) error {
+					return errs.RequestNotPending
+				},
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "error service",
+			id:     "1",
+			header: "1",
+			svc: &transport.RequestsServiceMock{
+				ConfirmFunc: func(_ context.Context, _ *requests.Request) error {
+					return errTestError
+				},
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "ok",
+			id:     "1",
+			header: "1",
+			svc: &transport.RequestsServiceMock{
+				ConfirmFunc: func(_ context.Context, r *requests.Request) error {
+					if r.ID != 1 || r.UserID != 1 {
+						return errTestError
+					}
+					return nil
+				},
+			},
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := transport.NewHTTPTransport(defaultLogger, tt.svc, defaultPolicy, middlewares.NewIDCtx(defaultLogger).Middleware)
+			rr := httptest.NewRecorder()
+			rq, _ := http.NewRequest(http.MethodPut, "/v1/request/"+tt.id+"/confirm", nil)
+			rq.Header.Add("X-Auth-User", tt.header)
+			h.ServeHTTP(rr, rq)
+			if rr.Code != tt.wantCode {
+				t.Errorf("Request error. status = %d, expected %v", rr.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
 func TestHTTP_Get(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -626,6 +699,133 @@ func TestHTTP_GuardUpdate(t *testing.T) {
 	}
 }
 
+func TestHTTP_GuardCreateRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		svc      transport.RequestsService
+		request  string
+		header   string
+		want     string
+		wantCode int
+	}{
+		{
+			name:     "error no guard",
+			request:  "{}",
+			header:   "0",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "error parsing request",
+			request:  "}{",
+			header:   "1",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "error no building",
+			request:  `{"apartment":1,"type":"guest","time":1}`,
+			header:   "1",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "error no apartment",
+			request:  `{"building_id":1,"type":"guest","time":1}`,
+			header:   "1",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "error wrong type",
+			request:  `{"building_id":1,"apartment":1,"type":"noise","time":1}`,
+			header:   "1",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:    "error not a guard",
+			request: `{"building_id":1,"apartment":1,"type":"guest","time":1}`,
+			header:  "1",
+			svc: &transport.RequestsServiceMock{
+				GuardCreateRequestFunc: func(_ context.Context, _ *requests.WalkInRequest) (*requests.Request, error) {
+					return nil, errs.InsufficientPermissions
+				},
+			},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:    "error blocklisted",
+			request: `{"building_id":1,"apartment":1,"type":"guest","time":1}`,
+			header:  "1",
+			svc: &transport.RequestsServiceMock{
+				GuardCreateRequestFunc: func(_ context.Context, _ *requests.WalkInRequest) (*requests.Request, error) {
+					return nil, errs.RequestBlocklisted
+				},
+			},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:    "error no resident",
+			request: `{"building_id":1,"apartment":1,"type":"guest","time":1}`,
+			header:  "1",
+			svc: &transport.RequestsServiceMock{
+				GuardCreateRequestFunc: func(_ context.Context, _ *requests.WalkInRequest) (*requests.Request, error) {
+					return nil, errs.UserNotFound
+				},
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:    "error service",
+			request: `{"building_id":1,"apartment":1,"type":"guest","time":1}`,
+			header:  "1",
+			svc: &transport.RequestsServiceMock{
+				GuardCreateRequestFunc: func(_ context.Context, _ *requests.WalkInRequest) (*requests.Request, error) {
+					return nil, errTestError
+				},
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:    "ok",
+			request: `{"building_id":1,"apartment":12,"rtype":1,"time":1,"description":"<b>abc</b>","await_confirmation":true}`,
+			header:  "3",
+			svc: &transport.RequestsServiceMock{
+				GuardCreateRequestFunc: func(_ context.Context, r *requests.WalkInRequest) (*requests.Request, error) {
+					want := requests.WalkInRequest{
+						GuardID:           3,
+						BuildingID:        1,
+						Apartment:         12,
+						Rtype:             requests.Guest,
+						Time:              1,
+						Description:       "abc",
+						AwaitConfirmation: true,
+					}
+					if !reflect.DeepEqual(*r, want) {
+						return nil, errTestError
+					}
+					return &requests.Request{ID: 5, Blocklisted: []*requests.BlocklistEntry{{ID: 2, Plate: "AA1234BB", Reason: "ban"}}}, nil
+				},
+			},
+			want:     `{"id":5,"warnings":[{"entry_id":2,"plate":"AA1234BB","reason":"ban"}]}`,
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := transport.NewHTTPTransport(defaultLogger, tt.svc, defaultPolicy, middlewares.NewIDCtx(defaultLogger).Middleware)
+			rr := httptest.NewRecorder()
+			rq, _ := http.NewRequest(http.MethodPost, "/v1/guard/request", strings.NewReader(tt.request))
+			rq.Header.Add("X-Auth-User", tt.header)
+			h.ServeHTTP(rr, rq)
+			if rr.Code != tt.wantCode {
+				t.Errorf("Request error. status = %d, expected %v", rr.Code, tt.wantCode)
+			}
+
+			if tt.want != "" && tt.want != strings.TrimSpace(rr.Body.String()) {
+				t.Errorf("Response error, got = %s, want = %s", rr.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
 func TestHTTP_GuardList(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -853,13 +1053,14 @@ func TestHTTP_UploadImage(t *testing.T) {
 			svc: &transport.RequestsServiceMock{
 				UploadImageFunc: func(_ context.Context, _ *requests.Image) (*requests.Image, error) {
 					return &requests.Image{
-						URL:   "path",
-						Thumb: "path",
+						URL:        "path",
+						Thumb:      "path",
+						Processing: true,
 					}, nil
 				},
 			},
 			wantCode: http.StatusOK,
-			want:     `{"img":"path","thumb":"path"}`,
+			want:     `{"img":"path","thumb":"path","status":"processing"}`,
 		},
 	}
 
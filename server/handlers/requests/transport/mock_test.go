@@ -19,18 +19,42 @@ var _ RequestsService = &RequestsServiceMock{}
 //
 //		// make and configure a mocked RequestsService
 //		mockedRequestsService := &RequestsServiceMock{
+//			BlocklistFunc: func(ctx context.Context, adminID uint) ([]*requests.BlocklistEntry, error) {
+//				panic("mock out the Blocklist method")
+//			},
+//			BlocklistAuditFunc: func(ctx context.Context, adminID uint, entryID uint) ([]*requests.BlocklistAudit, error) {
+//				panic("mock out the BlocklistAudit method")
+//			},
+//			ConfirmFunc: func(ctx context.Context, r *requests.Request) error {
+//				panic("mock out the Confirm method")
+//			},
 //			CreateFunc: func(ctx context.Context, r *requests.Request) (*requests.Request, error) {
 //				panic("mock out the Create method")
 //			},
+//			CreateBlocklistEntryFunc: func(ctx context.Context, e *requests.BlocklistEntry) (*requests.BlocklistEntry, error) {
+//				panic("mock out the CreateBlocklistEntry method")
+//			},
 //			DeleteFunc: func(ctx context.Context, r *requests.Request) error {
 //				panic("mock out the Delete method")
 //			},
+//			DeleteBlocklistEntryFunc: func(ctx context.Context, adminID uint, id uint) error {
+//				panic("mock out the DeleteBlocklistEntry method")
+//			},
 //			DeleteImageFunc: func(ctx context.Context, r *requests.Image) error {
 //				panic("mock out the DeleteImage method")
 //			},
 //			GetFunc: func(ctx context.Context, r *requests.Request) (*requests.Request, error) {
 //				panic("mock out the Get method")
 //			},
+//			GuardApprovalFunc: func(ctx context.Context, id uint) (*requests.Approval, error) {
+//				panic("mock out the GuardApproval method")
+//			},
+//			GuardCreateApprovalFunc: func(ctx context.Context, r *requests.Approval) (*requests.Approval, error) {
+//				panic("mock out the GuardCreateApproval method")
+//			},
+//			GuardCreateRequestFunc: func(ctx context.Context, r *requests.WalkInRequest) (*requests.Request, error) {
+//				panic("mock out the GuardCreateRequest method")
+//			},
 //			GuardRequestListFunc: func(ctx context.Context, r *requests.RequestListFilter) ([]*requests.Request, int, error) {
 //				panic("mock out the GuardRequestList method")
 //			},
@@ -43,9 +67,24 @@ var _ RequestsService = &RequestsServiceMock{}
 //			MyFunc: func(ctx context.Context, r *requests.RequestListFilter) ([]*requests.Request, error) {
 //				panic("mock out the My method")
 //			},
+//			MyApprovalsFunc: func(ctx context.Context, userID uint) ([]*requests.Approval, error) {
+//				panic("mock out the MyApprovals method")
+//			},
+//			ResolveApprovalFunc: func(ctx context.Context, r *requests.ApprovalDecision) (*requests.Approval, error) {
+//				panic("mock out the ResolveApproval method")
+//			},
+//			SubscribeApprovalFunc: func(ctx context.Context, id uint) (<-chan *requests.Approval, func()) {
+//				panic("mock out the SubscribeApproval method")
+//			},
+//			SubscribeUserApprovalsFunc: func(ctx context.Context, userID uint) (<-chan *requests.Approval, func()) {
+//				panic("mock out the SubscribeUserApprovals method")
+//			},
 //			UpdateFunc: func(ctx context.Context, r *requests.UpdateRequest) error {
 //				panic("mock out the Update method")
 //			},
+//			UpdateBlocklistEntryFunc: func(ctx context.Context, adminID uint, e *requests.BlocklistEntry) error {
+//				panic("mock out the UpdateBlocklistEntry method")
+//			},
 //			UploadImageFunc: func(ctx context.Context, r *requests.Image) (*requests.Image, error) {
 //				panic("mock out the UploadImage method")
 //			},
@@ -56,18 +95,42 @@ var _ RequestsService = &RequestsServiceMock{}
 //
 //	}
 type RequestsServiceMock struct {
+	// BlocklistFunc mocks the Blocklist method.
+	BlocklistFunc func(ctx context.Context, adminID uint) ([]*requests.BlocklistEntry, error)
+
+	// BlocklistAuditFunc mocks the BlocklistAudit method.
+	BlocklistAuditFunc func(ctx context.Context, adminID uint, entryID uint) ([]*requests.BlocklistAudit, error)
+
+	// ConfirmFunc mocks the Confirm method.
+	ConfirmFunc func(ctx context.Context, r *requests.Request) error
+
 	// CreateFunc mocks the Create method.
 	CreateFunc func(ctx context.Context, r *requests.Request) (*requests.Request, error)
 
+	// CreateBlocklistEntryFunc mocks the CreateBlocklistEntry method.
+	CreateBlocklistEntryFunc func(ctx context.Context, e *requests.BlocklistEntry) (*requests.BlocklistEntry, error)
+
 	// DeleteFunc mocks the Delete method.
 	DeleteFunc func(ctx context.Context, r *requests.Request) error
 
+	// DeleteBlocklistEntryFunc mocks the DeleteBlocklistEntry method.
+	DeleteBlocklistEntryFunc func(ctx context.Context, adminID uint, id uint) error
+
 	// DeleteImageFunc mocks the DeleteImage method.
 	DeleteImageFunc func(ctx context.Context, r *requests.Image) error
 
 	// GetFunc mocks the Get method.
 	GetFunc func(ctx context.Context, r *requests.Request) (*requests.Request, error)
 
+	// GuardApprovalFunc mocks the GuardApproval method.
+	GuardApprovalFunc func(ctx context.Context, id uint) (*requests.Approval, error)
+
+	// GuardCreateApprovalFunc mocks the GuardCreateApproval method.
+	GuardCreateApprovalFunc func(ctx context.Context, r *requests.Approval) (*requests.Approval, error)
+
+	// GuardCreateRequestFunc mocks the GuardCreateRequest method.
+	GuardCreateRequestFunc func(ctx context.Context, r *requests.WalkInRequest) (*requests.Request, error)
+
 	// GuardRequestListFunc mocks the GuardRequestList method.
 	GuardRequestListFunc func(ctx context.Context, r *requests.RequestListFilter) ([]*requests.Request, int, error)
 
@@ -80,14 +143,52 @@ type RequestsServiceMock struct {
 	// MyFunc mocks the My method.
 	MyFunc func(ctx context.Context, r *requests.RequestListFilter) ([]*requests.Request, error)
 
+	// MyApprovalsFunc mocks the MyApprovals method.
+	MyApprovalsFunc func(ctx context.Context, userID uint) ([]*requests.Approval, error)
+
+	// ResolveApprovalFunc mocks the ResolveApproval method.
+	ResolveApprovalFunc func(ctx context.Context, r *requests.ApprovalDecision) (*requests.Approval, error)
+
+	// SubscribeApprovalFunc mocks the SubscribeApproval method.
+	SubscribeApprovalFunc func(ctx context.Context, id uint) (<-chan *requests.Approval, func())
+
+	// SubscribeUserApprovalsFunc mocks the SubscribeUserApprovals method.
+	SubscribeUserApprovalsFunc func(ctx context.Context, userID uint) (<-chan *requests.Approval, func())
+
 	// UpdateFunc mocks the Update method.
 	UpdateFunc func(ctx context.Context, r *requests.UpdateRequest) error
 
+	// UpdateBlocklistEntryFunc mocks the UpdateBlocklistEntry method.
+	UpdateBlocklistEntryFunc func(ctx context.Context, adminID uint, e *requests.BlocklistEntry) error
+
 	// UploadImageFunc mocks the UploadImage method.
 	UploadImageFunc func(ctx context.Context, r *requests.Image) (*requests.Image, error)
 
 	// calls tracks calls to the methods.
 	calls struct {
+		// Blocklist holds details about calls to the Blocklist method.
+		Blocklist []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// AdminID is the adminID argument value.
+			AdminID uint
+		}
+		// BlocklistAudit holds details about calls to the BlocklistAudit method.
+		BlocklistAudit []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// AdminID is the adminID argument value.
+			AdminID uint
+			// EntryID is the entryID argument value.
+			EntryID uint
+		}
+		// Confirm holds details about calls to the Confirm method.
+		Confirm []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// R is the r argument value.
+			R *requests.Request
+		}
 		// Create holds details about calls to the Create method.
 		Create []struct {
 			// Ctx is the ctx argument value.
@@ -95,6 +196,13 @@ type RequestsServiceMock struct {
 			// R is the r argument value.
 			R *requests.Request
 		}
+		// CreateBlocklistEntry holds details about calls to the CreateBlocklistEntry method.
+		CreateBlocklistEntry []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// E is the e argument value.
+			E *requests.BlocklistEntry
+		}
 		// Delete holds details about calls to the Delete method.
 		Delete []struct {
 			// Ctx is the ctx argument value.
@@ -102,6 +210,15 @@ type RequestsServiceMock struct {
 			// R is the r argument value.
 			R *requests.Request
 		}
+		// DeleteBlocklistEntry holds details about calls to the DeleteBlocklistEntry method.
+		DeleteBlocklistEntry []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// AdminID is the adminID argument value.
+			AdminID uint
+			// ID is the id argument value.
+			ID uint
+		}
 		// DeleteImage holds details about calls to the DeleteImage method.
 		DeleteImage []struct {
 			// Ctx is the ctx argument value.
@@ -116,6 +233,27 @@ type RequestsServiceMock struct {
 			// R is the r argument value.
 			R *requests.Request
 		}
+		// GuardApproval holds details about calls to the GuardApproval method.
+		GuardApproval []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ID is the id argument value.
+			ID uint
+		}
+		// GuardCreateApproval holds details about calls to the GuardCreateApproval method.
+		GuardCreateApproval []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// R is the r argument value.
+			R *requests.Approval
+		}
+		// GuardCreateRequest holds details about calls to the GuardCreateRequest method.
+		GuardCreateRequest []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// R is the r argument value.
+			R *requests.WalkInRequest
+		}
 		// GuardRequestList holds details about calls to the GuardRequestList method.
 		GuardRequestList []struct {
 			// Ctx is the ctx argument value.
@@ -142,6 +280,34 @@ type RequestsServiceMock struct {
 			// R is the r argument value.
 			R *requests.RequestListFilter
 		}
+		// MyApprovals holds details about calls to the MyApprovals method.
+		MyApprovals []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// UserID is the userID argument value.
+			UserID uint
+		}
+		// ResolveApproval holds details about calls to the ResolveApproval method.
+		ResolveApproval []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// R is the r argument value.
+			R *requests.ApprovalDecision
+		}
+		// SubscribeApproval holds details about calls to the SubscribeApproval method.
+		SubscribeApproval []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ID is the id argument value.
+			ID uint
+		}
+		// SubscribeUserApprovals holds details about calls to the SubscribeUserApprovals method.
+		SubscribeUserApprovals []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// UserID is the userID argument value.
+			UserID uint
+		}
 		// Update holds details about calls to the Update method.
 		Update []struct {
 			// Ctx is the ctx argument value.
@@ -149,6 +315,15 @@ type RequestsServiceMock struct {
 			// R is the r argument value.
 			R *requests.UpdateRequest
 		}
+		// UpdateBlocklistEntry holds details about calls to the UpdateBlocklistEntry method.
+		UpdateBlocklistEntry []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// AdminID is the adminID argument value.
+			AdminID uint
+			// E is the e argument value.
+			E *requests.BlocklistEntry
+		}
 		// UploadImage holds details about calls to the UploadImage method.
 		UploadImage []struct {
 			// Ctx is the ctx argument value.
@@ -157,16 +332,141 @@ type RequestsServiceMock struct {
 			R *requests.Image
 		}
 	}
-	lockCreate             sync.RWMutex
-	lockDelete             sync.RWMutex
-	lockDeleteImage        sync.RWMutex
-	lockGet                sync.RWMutex
-	lockGuardRequestList   sync.RWMutex
-	lockGuardStats24h      sync.RWMutex
-	lockGuardUpdateRequest sync.RWMutex
-	lockMy                 sync.RWMutex
-	lockUpdate             sync.RWMutex
-	lockUploadImage        sync.RWMutex
+	lockBlocklist              sync.RWMutex
+	lockBlocklistAudit         sync.RWMutex
+	lockConfirm                sync.RWMutex
+	lockCreate                 sync.RWMutex
+	lockCreateBlocklistEntry   sync.RWMutex
+	lockDelete                 sync.RWMutex
+	lockDeleteBlocklistEntry   sync.RWMutex
+	lockDeleteImage            sync.RWMutex
+	lockGet                    sync.RWMutex
+	lockGuardApproval          sync.RWMutex
+	lockGuardCreateApproval    sync.RWMutex
+	lockGuardCreateRequest     sync.RWMutex
+	lockGuardRequestList       sync.RWMutex
+	lockGuardStats24h          sync.RWMutex
+	lockGuardUpdateRequest     sync.RWMutex
+	lockMy                     sync.RWMutex
+	lockMyApprovals            sync.RWMutex
+	lockResolveApproval        sync.RWMutex
+	lockSubscribeApproval      sync.RWMutex
+	lockSubscribeUserApprovals sync.RWMutex
+	lockUpdate                 sync.RWMutex
+	lockUpdateBlocklistEntry   sync.RWMutex
+	lockUploadImage            sync.RWMutex
+}
+
+// Blocklist calls BlocklistFunc.
+func (mock *RequestsServiceMock) Blocklist(ctx context.Context, adminID uint) ([]*requests.BlocklistEntry, error) {
+	if mock.BlocklistFunc == nil {
+		panic("RequestsServiceMock.BlocklistFunc: method is nil but RequestsService.Blocklist was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		AdminID uint
+	}{
+		Ctx:     ctx,
+		AdminID: adminID,
+	}
+	mock.lockBlocklist.Lock()
+	mock.calls.Blocklist = append(mock.calls.Blocklist, callInfo)
+	mock.lockBlocklist.Unlock()
+	return mock.BlocklistFunc(ctx, adminID)
+}
+
+// BlocklistCalls gets all the calls that were made to Blocklist.
+// Check the length with:
+//
+//	len(mockedRequestsService.BlocklistCalls())
+func (mock *RequestsServiceMock) BlocklistCalls() []struct {
+	Ctx     context.Context
+	AdminID uint
+} {
+	var calls []struct {
+		Ctx     context.Context
+		AdminID uint
+	}
+	mock.lockBlocklist.RLock()
+	calls = mock.calls.Blocklist
+	mock.lockBlocklist.RUnlock()
+	return calls
+}
+
+// BlocklistAudit calls BlocklistAuditFunc.
+func (mock *RequestsServiceMock) BlocklistAudit(ctx context.Context, adminID uint, entryID uint) ([]*requests.BlocklistAudit, error) {
+	if mock.BlocklistAuditFunc == nil {
+		panic("RequestsServiceMock.BlocklistAuditFunc: method is nil but RequestsService.BlocklistAudit was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		AdminID uint
+		EntryID uint
+	}{
+		Ctx:     ctx,
+		AdminID: adminID,
+		EntryID: entryID,
+	}
+	mock.lockBlocklistAudit.Lock()
+	mock.calls.BlocklistAudit = append(mock.calls.BlocklistAudit, callInfo)
+	mock.lockBlocklistAudit.Unlock()
+	return mock.BlocklistAuditFunc(ctx, adminID, entryID)
+}
+
+// BlocklistAuditCalls gets all the calls that were made to BlocklistAudit.
+// Check the length with:
+//
+//	len(mockedRequestsService.BlocklistAuditCalls())
+func (mock *RequestsServiceMock) BlocklistAuditCalls() []struct {
+	Ctx     context.Context
+	AdminID uint
+	EntryID uint
+} {
+	var calls []struct {
+		Ctx     context.Context
+		AdminID uint
+		EntryID uint
+	}
+	mock.lockBlocklistAudit.RLock()
+	calls = mock.calls.BlocklistAudit
+	mock.lockBlocklistAudit.RUnlock()
+	return calls
+}
+
+// Confirm calls ConfirmFunc.
+func (mock *RequestsServiceMock) Confirm(ctx context.Context, r *requests.Request) error {
+	if mock.ConfirmFunc == nil {
+		panic("RequestsServiceMock.ConfirmFunc: method is nil but RequestsService.Confirm was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		R   *requests.Request
+	}{
+		Ctx: ctx,
+		R:   r,
+	}
+	mock.lockConfirm.Lock()
+	mock.calls.Confirm = append(mock.calls.Confirm, callInfo)
+	mock.lockConfirm.Unlock()
+	return mock.ConfirmFunc(ctx, r)
+}
+
+// ConfirmCalls gets all the calls that were made to Confirm.
+// Check the length with:
+//
+//	len(mockedRequestsService.ConfirmCalls())
+func (mock *RequestsServiceMock) ConfirmCalls() []struct {
+	Ctx context.Context
+	R   *requests.Request
+} {
+	var calls []struct {
+		Ctx context.Context
+		R   *requests.Request
+	}
+	mock.lockConfirm.RLock()
+	calls = mock.calls.Confirm
+	mock.lockConfirm.RUnlock()
+	return calls
 }
 
 // Create calls CreateFunc.
@@ -205,6 +505,42 @@ func (mock *RequestsServiceMock) CreateCalls() []struct {
 	return calls
 }
 
+// CreateBlocklistEntry calls CreateBlocklistEntryFunc.
+func (mock *RequestsServiceMock) CreateBlocklistEntry(ctx context.Context, e *requests.BlocklistEntry) (*requests.BlocklistEntry, error) {
+	if mock.CreateBlocklistEntryFunc == nil {
+		panic("RequestsServiceMock.CreateBlocklistEntryFunc: method is nil but RequestsService.CreateBlocklistEntry was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		E   *requests.BlocklistEntry
+	}{
+		Ctx: ctx,
+		E:   e,
+	}
+	mock.lockCreateBlocklistEntry.Lock()
+	mock.calls.CreateBlocklistEntry = append(mock.calls.CreateBlocklistEntry, callInfo)
+	mock.lockCreateBlocklistEntry.Unlock()
+	return mock.CreateBlocklistEntryFunc(ctx, e)
+}
+
+// CreateBlocklistEntryCalls gets all the calls that were made to CreateBlocklistEntry.
+// Check the length with:
+//
+//	len(mockedRequestsService.CreateBlocklistEntryCalls())
+func (mock *RequestsServiceMock) CreateBlocklistEntryCalls() []struct {
+	Ctx context.Context
+	E   *requests.BlocklistEntry
+} {
+	var calls []struct {
+		Ctx context.Context
+		E   *requests.BlocklistEntry
+	}
+	mock.lockCreateBlocklistEntry.RLock()
+	calls = mock.calls.CreateBlocklistEntry
+	mock.lockCreateBlocklistEntry.RUnlock()
+	return calls
+}
+
 // Delete calls DeleteFunc.
 func (mock *RequestsServiceMock) Delete(ctx context.Context, r *requests.Request) error {
 	if mock.DeleteFunc == nil {
@@ -241,6 +577,46 @@ func (mock *RequestsServiceMock) DeleteCalls() []struct {
 	return calls
 }
 
+// DeleteBlocklistEntry calls DeleteBlocklistEntryFunc.
+func (mock *RequestsServiceMock) DeleteBlocklistEntry(ctx context.Context, adminID uint, id uint) error {
+	if mock.DeleteBlocklistEntryFunc == nil {
+		panic("RequestsServiceMock.DeleteBlocklistEntryFunc: method is nil but RequestsService.DeleteBlocklistEntry was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		AdminID uint
+		ID      uint
+	}{
+		Ctx:     ctx,
+		AdminID: adminID,
+		ID:      id,
+	}
+	mock.lockDeleteBlocklistEntry.Lock()
+	mock.calls.DeleteBlocklistEntry = append(mock.calls.DeleteBlocklistEntry, callInfo)
+	mock.lockDeleteBlocklistEntry.Unlock()
+	return mock.DeleteBlocklistEntryFunc(ctx, adminID, id)
+}
+
+// DeleteBlocklistEntryCalls gets all the calls that were made to DeleteBlocklistEntry.
+// Check the length with:
+//
+//	len(mockedRequestsService.DeleteBlocklistEntryCalls())
+func (mock *RequestsServiceMock) DeleteBlocklistEntryCalls() []struct {
+	Ctx     context.Context
+	AdminID uint
+	ID      uint
+} {
+	var calls []struct {
+		Ctx     context.Context
+		AdminID uint
+		ID      uint
+	}
+	mock.lockDeleteBlocklistEntry.RLock()
+	calls = mock.calls.DeleteBlocklistEntry
+	mock.lockDeleteBlocklistEntry.RUnlock()
+	return calls
+}
+
 // DeleteImage calls DeleteImageFunc.
 func (mock *RequestsServiceMock) DeleteImage(ctx context.Context, r *requests.Image) error {
 	if mock.DeleteImageFunc == nil {
@@ -313,6 +689,114 @@ func (mock *RequestsServiceMock) GetCalls() []struct {
 	return calls
 }
 
+// GuardApproval calls GuardApprovalFunc.
+func (mock *RequestsServiceMock) GuardApproval(ctx context.Context, id uint) (*requests.Approval, error) {
+	if mock.GuardApprovalFunc == nil {
+		panic("RequestsServiceMock.GuardApprovalFunc: method is nil but RequestsService.GuardApproval was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		ID  uint
+	}{
+		Ctx: ctx,
+		ID:  id,
+	}
+	mock.lockGuardApproval.Lock()
+	mock.calls.GuardApproval = append(mock.calls.GuardApproval, callInfo)
+	mock.lockGuardApproval.Unlock()
+	return mock.GuardApprovalFunc(ctx, id)
+}
+
+// GuardApprovalCalls gets all the calls that were made to GuardApproval.
+// Check the length with:
+//
+//	len(mockedRequestsService.GuardApprovalCalls())
+func (mock *RequestsServiceMock) GuardApprovalCalls() []struct {
+	Ctx context.Context
+	ID  uint
+} {
+	var calls []struct {
+		Ctx context.Context
+		ID  uint
+	}
+	mock.lockGuardApproval.RLock()
+	calls = mock.calls.GuardApproval
+	mock.lockGuardApproval.RUnlock()
+	return calls
+}
+
+// GuardCreateApproval calls GuardCreateApprovalFunc.
+func (mock *RequestsServiceMock) GuardCreateApproval(ctx context.Context, r *requests.Approval) (*requests.Approval, error) {
+	if mock.GuardCreateApprovalFunc == nil {
+		panic("RequestsServiceMock.GuardCreateApprovalFunc: method is nil but RequestsService.GuardCreateApproval was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		R   *requests.Approval
+	}{
+		Ctx: ctx,
+		R:   r,
+	}
+	mock.lockGuardCreateApproval.Lock()
+	mock.calls.GuardCreateApproval = append(mock.calls.GuardCreateApproval, callInfo)
+	mock.lockGuardCreateApproval.Unlock()
+	return mock.GuardCreateApprovalFunc(ctx, r)
+}
+
+// GuardCreateApprovalCalls gets all the calls that were made to GuardCreateApproval.
+// Check the length with:
+//
+//	len(mockedRequestsService.GuardCreateApprovalCalls())
+func (mock *RequestsServiceMock) GuardCreateApprovalCalls() []struct {
+	Ctx context.Context
+	R   *requests.Approval
+} {
+	var calls []struct {
+		Ctx context.Context
+		R   *requests.Approval
+	}
+	mock.lockGuardCreateApproval.RLock()
+	calls = mock.calls.GuardCreateApproval
+	mock.lockGuardCreateApproval.RUnlock()
+	return calls
+}
+
+// GuardCreateRequest calls GuardCreateRequestFunc.
+func (mock *RequestsServiceMock) GuardCreateRequest(ctx context.Context, r *requests.WalkInRequest) (*requests.Request, error) {
+	if mock.GuardCreateRequestFunc == nil {
+		panic("RequestsServiceMock.GuardCreateRequestFunc: method is nil but RequestsService.GuardCreateRequest was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		R   *requests.WalkInRequest
+	}{
+		Ctx: ctx,
+		R:   r,
+	}
+	mock.lockGuardCreateRequest.Lock()
+	mock.calls.GuardCreateRequest = append(mock.calls.GuardCreateRequest, callInfo)
+	mock.lockGuardCreateRequest.Unlock()
+	return mock.GuardCreateRequestFunc(ctx, r)
+}
+
+// GuardCreateRequestCalls gets all the calls that were made to GuardCreateRequest.
+// Check the length with:
+//
+//	len(mockedRequestsService.GuardCreateRequestCalls())
+func (mock *RequestsServiceMock) GuardCreateRequestCalls() []struct {
+	Ctx context.Context
+	R   *requests.WalkInRequest
+} {
+	var calls []struct {
+		Ctx context.Context
+		R   *requests.WalkInRequest
+	}
+	mock.lockGuardCreateRequest.RLock()
+	calls = mock.calls.GuardCreateRequest
+	mock.lockGuardCreateRequest.RUnlock()
+	return calls
+}
+
 // GuardRequestList calls GuardRequestListFunc.
 func (mock *RequestsServiceMock) GuardRequestList(ctx context.Context, r *requests.RequestListFilter) ([]*requests.Request, int, error) {
 	if mock.GuardRequestListFunc == nil {
@@ -453,6 +937,150 @@ func (mock *RequestsServiceMock) MyCalls() []struct {
 	return calls
 }
 
+// MyApprovals calls MyApprovalsFunc.
+func (mock *RequestsServiceMock) MyApprovals(ctx context.Context, userID uint) ([]*requests.Approval, error) {
+	if mock.MyApprovalsFunc == nil {
+		panic("RequestsServiceMock.MyApprovalsFunc: method is nil but RequestsService.MyApprovals was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		UserID uint
+	}{
+		Ctx:    ctx,
+		UserID: userID,
+	}
+	mock.lockMyApprovals.Lock()
+	mock.calls.MyApprovals = append(mock.calls.MyApprovals, callInfo)
+	mock.lockMyApprovals.Unlock()
+	return mock.MyApprovalsFunc(ctx, userID)
+}
+
+// MyApprovalsCalls gets all the calls that were made to MyApprovals.
+// Check the length with:
+//
+//	len(mockedRequestsService.MyApprovalsCalls())
+func (mock *RequestsServiceMock) MyApprovalsCalls() []struct {
+	Ctx    context.Context
+	UserID uint
+} {
+	var calls []struct {
+		Ctx    context.Context
+		UserID uint
+	}
+	mock.lockMyApprovals.RLock()
+	calls = mock.calls.MyApprovals
+	mock.lockMyApprovals.RUnlock()
+	return calls
+}
+
+// ResolveApproval calls ResolveApprovalFunc.
+func (mock *RequestsServiceMock) ResolveApproval(ctx context.Context, r *requests.ApprovalDecision) (*requests.Approval, error) {
+	if mock.ResolveApprovalFunc == nil {
+		panic("RequestsServiceMock.ResolveApprovalFunc: method is nil but RequestsService.ResolveApproval was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		R   *requests.ApprovalDecision
+	}{
+		Ctx: ctx,
+		R:   r,
+	}
+	mock.lockResolveApproval.Lock()
+	mock.calls.ResolveApproval = append(mock.calls.ResolveApproval, callInfo)
+	mock.lockResolveApproval.Unlock()
+	return mock.ResolveApprovalFunc(ctx, r)
+}
+
+// ResolveApprovalCalls gets all the calls that were made to ResolveApproval.
+// Check the length with:
+//
+//	len(mockedRequestsService.ResolveApprovalCalls())
+func (mock *RequestsServiceMock) ResolveApprovalCalls() []struct {
+	Ctx context.Context
+	R   *requests.ApprovalDecision
+} {
+	var calls []struct {
+		Ctx context.Context
+		R   *requests.ApprovalDecision
+	}
+	mock.lockResolveApproval.RLock()
+	calls = mock.calls.ResolveApproval
+	mock.lockResolveApproval.RUnlock()
+	return calls
+}
+
+// SubscribeApproval calls SubscribeApprovalFunc.
+func (mock *RequestsServiceMock) SubscribeApproval(ctx context.Context, id uint) (<-chan *requests.Approval, func()) {
+	if mock.SubscribeApprovalFunc == nil {
+		panic("RequestsServiceMock.SubscribeApprovalFunc: method is nil but RequestsService.SubscribeApproval was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		ID  uint
+	}{
+		Ctx: ctx,
+		ID:  id,
+	}
+	mock.lockSubscribeApproval.Lock()
+	mock.calls.SubscribeApproval = append(mock.calls.SubscribeApproval, callInfo)
+	mock.lockSubscribeApproval.Unlock()
+	return mock.SubscribeApprovalFunc(ctx, id)
+}
+
+// SubscribeApprovalCalls gets all the calls that were made to SubscribeApproval.
+// Check the length with:
+//
+//	len(mockedRequestsService.SubscribeApprovalCalls())
+func (mock *RequestsServiceMock) SubscribeApprovalCalls() []struct {
+	Ctx context.Context
+	ID  uint
+} {
+	var calls []struct {
+		Ctx context.Context
+		ID  uint
+	}
+	mock.lockSubscribeApproval.RLock()
+	calls = mock.calls.SubscribeApproval
+	mock.lockSubscribeApproval.RUnlock()
+	return calls
+}
+
+// SubscribeUserApprovals calls SubscribeUserApprovalsFunc.
+func (mock *RequestsServiceMock) SubscribeUserApprovals(ctx context.Context, userID uint) (<-chan *requests.Approval, func()) {
+	if mock.SubscribeUserApprovalsFunc == nil {
+		panic("RequestsServiceMock.SubscribeUserApprovalsFunc: method is nil but RequestsService.SubscribeUserApprovals was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		UserID uint
+	}{
+		Ctx:    ctx,
+		UserID: userID,
+	}
+	mock.lockSubscribeUserApprovals.Lock()
+	mock.calls.SubscribeUserApprovals = append(mock.calls.SubscribeUserApprovals, callInfo)
+	mock.lockSubscribeUserApprovals.Unlock()
+	return mock.SubscribeUserApprovalsFunc(ctx, userID)
+}
+
+// SubscribeUserApprovalsCalls gets all the calls that were made to SubscribeUserApprovals.
+// Check the length with:
+//
+//	len(mockedRequestsService.SubscribeUserApprovalsCalls())
+func (mock *RequestsServiceMock) SubscribeUserApprovalsCalls() []struct {
+	Ctx    context.Context
+	UserID uint
+} {
+	var calls []struct {
+		Ctx    context.Context
+		UserID uint
+	}
+	mock.lockSubscribeUserApprovals.RLock()
+	calls = mock.calls.SubscribeUserApprovals
+	mock.lockSubscribeUserApprovals.RUnlock()
+	return calls
+}
+
 // Update calls UpdateFunc.
 func (mock *RequestsServiceMock) Update(ctx context.Context, r *requests.UpdateRequest) error {
 	if mock.UpdateFunc == nil {
@@ -489,6 +1117,46 @@ func (mock *RequestsServiceMock) UpdateCalls() []struct {
 	return calls
 }
 
+// UpdateBlocklistEntry calls UpdateBlocklistEntryFunc.
+func (mock *RequestsServiceMock) UpdateBlocklistEntry(ctx context.Context, adminID uint, e *requests.BlocklistEntry) error {
+	if mock.UpdateBlocklistEntryFunc == nil {
+		panic("RequestsServiceMock.UpdateBlocklistEntryFunc: method is nil but RequestsService.UpdateBlocklistEntry was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		AdminID uint
+		E       *requests.BlocklistEntry
+	}{
+		Ctx:     ctx,
+		AdminID: adminID,
+		E:       e,
+	}
+	mock.lockUpdateBlocklistEntry.Lock()
+	mock.calls.UpdateBlocklistEntry = append(mock.calls.UpdateBlocklistEntry, callInfo)
+	mock.lockUpdateBlocklistEntry.Unlock()
+	return mock.UpdateBlocklistEntryFunc(ctx, adminID, e)
+}
+
+// UpdateBlocklistEntryCalls gets all the calls that were made to UpdateBlocklistEntry.
+// Check the length with:
+//
+//	len(mockedRequestsService.UpdateBlocklistEntryCalls())
+func (mock *RequestsServiceMock) UpdateBlocklistEntryCalls() []struct {
+	Ctx     context.Context
+	AdminID uint
+	E       *requests.BlocklistEntry
+} {
+	var calls []struct {
+		Ctx     context.Context
+		AdminID uint
+		E       *requests.BlocklistEntry
+	}
+	mock.lockUpdateBlocklistEntry.RLock()
+	calls = mock.calls.UpdateBlocklistEntry
+	mock.lockUpdateBlocklistEntry.RUnlock()
+	return calls
+}
+
 // UploadImage calls UploadImageFunc.
 func (mock *RequestsServiceMock) UploadImage(ctx context.Context, r *requests.Image) (*requests.Image, error) {
 	if mock.UploadImageFunc == nil {
@@ -0,0 +1,193 @@
+package requests
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	ImageFormatJPEG = "jpeg"
+
+	// ThumbVariant is the required variant shown in the lists, stored under ThumbPathPrefix.
+	ThumbVariant = "thumb"
+	// VariantPathPrefix keeps the other variants as req/v/{name}/{file}.
+	VariantPathPrefix = "req/v/"
+
+	variantJPEGQuality = 85
+	srcsetKey          = "srcset"
+)
+
+// ImageVariant is the resized copy of the uploaded image.
+type ImageVariant struct {
+	Name   string
+	Width  int
+	Height int
+	Format string
+}
+
+func (v ImageVariant) square() bool { return v.Height > 0 }
+
+// DefaultImageVariants are used when the variants are not configured.
+var DefaultImageVariants = []ImageVariant{
+	{Name: ThumbVariant, Width: 128, Height: 128, Format: ImageFormatJPEG},
+	{Name: "small", Width: 480, Format: ImageFormatJPEG},
+	{Name: "large", Width: 1280, Format: ImageFormatJPEG},
+}
+
+// ImageEncoder writes the image in its format.
+type ImageEncoder struct {
+	ContentType string
+	Ext         string
+	Encode      func(w io.Writer, img image.Image) error
+}
+
+var (
+	encodersMu    sync.RWMutex
+	imageEncoders = map[string]ImageEncoder{
+		ImageFormatJPEG: {
+			ContentType: ContentTypeJPEG,
+			Ext:         ".jpg",
+			Encode: func(w io.Writer, img image.Image) error {
+				return jpeg.Encode(w, img, &jpeg.Options{Quality: variantJPEGQuality})
+			},
+		},
+	}
+)
+
+// RegisterImageEncoder makes the format available for the image variants.
+func RegisterImageEncoder(format string, e ImageEncoder) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	imageEncoders[format] = e
+}
+
+func imageEncoder(format string) (ImageEncoder, bool) {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	e, ok := imageEncoders[format]
+	return e, ok
+}
+
+// ParseImageVariants parses the variants list, e.g. "thumb:128x128:jpeg,large:1280:jpeg".
+func ParseImageVariants(s string) ([]ImageVariant, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var (
+		variants []ImageVariant
+		names    = make(map[string]bool)
+	)
+
+	for _, item := range strings.Split(s, ",") {
+		parts := strings.Split(strings.TrimSpace(item), ":")
+		if len(parts) != 3 || parts[0] == "" || strings.Contains(parts[0], "/") {
+			return nil, fmt.Errorf("bad image variant %q", item)
+		}
+
+		v := ImageVariant{Name: parts[0], Format: parts[2]}
+		if names[v.Name] {
+			return nil, fmt.Errorf("duplicate image variant %q", v.Name)
+		}
+		names[v.Name] = true
+
+		w, h, square := strings.Cut(parts[1], "x")
+		width, err := strconv.Atoi(w)
+		if err != nil || width <= 0 {
+			return nil, fmt.Errorf("bad image variant size %q", item)
+		}
+		v.Width = width
+
+		if square {
+			if v.Height, err = strconv.Atoi(h); err != nil || v.Height <= 0 {
+				return nil, fmt.Errorf("bad image variant size %q", item)
+			}
+		}
+
+		if _, ok := imageEncoder(v.Format); !ok {
+			return nil, fmt.Errorf("image variant %q: no encoder registered for %q", v.Name, v.Format)
+		}
+
+		variants = append(variants, v)
+	}
+
+	if !names[ThumbVariant] {
+		return nil, fmt.Errorf("image variant %q is required", ThumbVariant)
+	}
+
+	return variants, nil
+}
+
+// variantKey is the storage key of the image variant.
+func variantKey(v ImageVariant, filename string) string {
+	if v.Name == ThumbVariant {
+		return ThumbPathPrefix + filename
+	}
+
+	ext := path.Ext(filename)
+	if e, ok := imageEncoder(v.Format); ok {
+		ext = e.Ext
+	}
+
+	return VariantPathPrefix + v.Name + "/" + strings.TrimSuffix(filename, path.Ext(filename)) + ext
+}
+
+// renderVariant resizes the image for the variant, never upscaling it.
+func renderVariant(img image.Image, v ImageVariant) (*AttachmentVariant, error) {
+	e, ok := imageEncoder(v.Format)
+	if !ok {
+		return nil, fmt.Errorf("no encoder registered for %q", v.Format)
+	}
+
+	switch {
+	case v.square():
+		img = imaging.Fill(img, v.Width, v.Height, imaging.Center, imaging.CatmullRom)
+	case img.Bounds().Dx() > v.Width:
+		img = imaging.Resize(img, v.Width, 0, imaging.Lanczos)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := e.Encode(buf, img); err != nil {
+		return nil, err
+	}
+
+	return &AttachmentVariant{ImageVariant: v, ContentType: e.ContentType, Data: buf.Bytes()}, nil
+}
+
+func thumbVariant(variants []ImageVariant) ImageVariant {
+	for _, v := range variants {
+		if v.Name == ThumbVariant {
+			return v
+		}
+	}
+	return DefaultImageVariants[0]
+}
+
+// srcset lists the links of the width-based variants for the responsive images.
+func srcset(variants []ImageVariant, urls map[string]string) string {
+	var widths []ImageVariant
+	for _, v := range variants {
+		if !v.square() && urls[v.Name] != "" {
+			widths = append(widths, v)
+		}
+	}
+
+	sort.Slice(widths, func(i, j int) bool { return widths[i].Width < widths[j].Width })
+
+	items := make([]string, len(widths))
+	for i, v := range widths {
+		items[i] = fmt.Sprintf("%s %dw", urls[v.Name], v.Width)
+	}
+
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,134 @@
+package requests_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/ivch/dynasty/server/handlers/requests"
+)
+
+func TestParseImageVariants(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    []requests.ImageVariant
+		wantErr bool
+	}{
+		{name: "empty", in: ""},
+		{
+			name: "ok",
+			in:   "thumb:64x64:jpeg, large:1280:jpeg",
+			want: []requests.ImageVariant{
+				{Name: "thumb", Width: 64, Height: 64, Format: requests.ImageFormatJPEG},
+				{Name: "large", Width: 1280, Format: requests.ImageFormatJPEG},
+			},
+		},
+		{name: "error thumb is required", in: "large:1280:jpeg", wantErr: true},
+		{name: "error bad size", in: "thumb:0x64:jpeg", wantErr: true},
+		{name: "error bad item", in: "thumb:64x64", wantErr: true},
+		{name: "error duplicate", in: "thumb:64x64:jpeg,thumb:128x128:jpeg", wantErr: true},
+		{name: "error encoder not registered", in: "thumb:64x64:jpeg,large:1280:webp", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := requests.ParseImageVariants(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseImageVariants() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseImageVariants() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestService_UploadImageVariants(t *testing.T) {
+	requests.RegisterImageEncoder("png", requests.ImageEncoder{
+		ContentType: requests.ContentTypePNG,
+		Ext:         ".png",
+		Encode: func(w io.Writer, img image.Image) error {
+			return png.Encode(w, img)
+		},
+	})
+
+	variants, err := requests.ParseImageVariants("thumb:64x32:jpeg,small:100:jpeg,large:1280:png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.ReadFile("../../../test_image.jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		objects = make(map[string]storedObject)
+		repo    = (&imageQueue{}).repo()
+	)
+	s := requests.New(defaultLogger, repo, nil, memStorage(objects), "cdnHost", requests.WithImageVariants(variants))
+
+	res, err := s.UploadImage(context.Background(), &requests.Image{UserID: 1, RequestID: 1, File: file})
+	if err != nil {
+		t.Fatalf("UploadImage() error = %v", err)
+	}
+
+	if ok, err := s.ProcessImageJob(context.Background()); err != nil || !ok {
+		t.Fatalf("ProcessImageJob() = %v, %v", ok, err)
+	}
+
+	filename := res.URL[len("cdnHost/"+requests.ImgPathPrefix):]
+	base := filename[:len(filename)-len(".jpg")]
+
+	tests := []struct {
+		key         string
+		contentType string
+		width       int
+		height      int
+	}{
+		{key: requests.ThumbPathPrefix + filename, contentType: requests.ContentTypeJPEG, width: 64, height: 32},
+		{key: requests.VariantPathPrefix + "small/" + base + ".jpg", contentType: requests.ContentTypeJPEG, width: 100, height: 50},
+		// not upscaled
+		{key: requests.VariantPathPrefix + "large/" + base + ".png", contentType: requests.ContentTypePNG, width: 400, height: 200},
+	}
+
+	for _, tt := range tests {
+		obj, ok := objects[tt.key]
+		if !ok {
+			t.Errorf("ProcessImageJob() variant %s is not stored", tt.key)
+			continue
+		}
+
+		if obj.opts.ContentType != tt.contentType {
+			t.Errorf("ProcessImageJob() variant %s type %s, want %s", tt.key, obj.opts.ContentType, tt.contentType)
+		}
+
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(obj.body))
+		if err != nil || cfg.Width != tt.width || cfg.Height != tt.height {
+			t.Errorf("ProcessImageJob() variant %s is %dx%d (%v), want %dx%d", tt.key, cfg.Width, cfg.Height, err, tt.width, tt.height)
+		}
+	}
+
+	repo.GetRequestByIDAndUserFunc = func(id uint, userID uint) (*requests.Request, error) {
+		return &requests.Request{ID: id, UserID: userID, Images: []string{filename}}, nil
+	}
+	repo.ProcessingImagesFunc = func(_ []uint) ([]string, error) {
+		return nil, nil
+	}
+	req, err := s.Get(context.Background(), &requests.Request{ID: 1, UserID: 1})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	wantSrcset := "cdnHost/" + requests.VariantPathPrefix + "small/" + base + ".jpg 100w, " +
+		"cdnHost/" + requests.VariantPathPrefix + "large/" + base + ".png 1280w"
+	if got := req.ImagesURL[0]["srcset"]; got != wantSrcset {
+		t.Errorf("Get() srcset = %s, want %s", got, wantSrcset)
+	}
+}
@@ -3,6 +3,8 @@ package users
 import "time"
 
 const (
+	AdminUserRole      = 1
+	GuardUserRole      = 3
 	DefaultUserRole    = 4
 	PredefinedUserRole = 5
 )
@@ -23,6 +25,9 @@ type User struct {
 	Active     bool   `json:"active" gorm:"active"`
 	RegCode    string `json:"-"`
 	ParentID   *uint  `json:"-"`
+	Avatar     string `json:"-"`
+	// AvatarURL holds the "img" and "thumb" links of the profile photo.
+	AvatarURL map[string]string `json:"avatar,omitempty" gorm:"-"`
 }
 
 type UserUpdate struct {
@@ -35,6 +40,7 @@ type UserUpdate struct {
 	LastName    *string `json:"last_name,omitempty"`
 	Active      *bool   `json:"active" gorm:"active"`
 	Role        *uint   `json:"role,omitempty" gorm:"role"`
+	Avatar      *string `json:"-"`
 }
 
 func (User) TableName() string { return "users" }
@@ -64,3 +70,9 @@ type PasswordRecovery struct {
 }
 
 func (PasswordRecovery) TableName() string { return "password_recovery" }
+
+// RecoveryEmail is the payload of the password recovery email in the outbox.
+type RecoveryEmail struct {
+	UserID uint   `json:"user_id"`
+	Lang   string `json:"lang,omitempty"`
+}
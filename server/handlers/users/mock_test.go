@@ -4,6 +4,8 @@
 package users
 
 import (
+	"context"
+	"github.com/ivch/dynasty/server/handlers/outbox"
 	"sync"
 )
 
@@ -23,7 +25,7 @@ var _ UserRepository = &UserRepositoryMock{}
 //			CountRecoveryCodesByUserIn24hFunc: func(userID uint) (int, error) {
 //				panic("mock out the CountRecoveryCodesByUserIn24h method")
 //			},
-//			CreateRecoverCodeFunc: func(c *PasswordRecovery) error {
+//			CreateRecoverCodeFunc: func(c *PasswordRecovery, email *outbox.Message) error {
 //				panic("mock out the CreateRecoverCode method")
 //			},
 //			CreateUserFunc: func(user *User) error {
@@ -53,10 +55,13 @@ var _ UserRepository = &UserRepositoryMock{}
 //			GetUserByPhoneFunc: func(phone string) (*User, error) {
 //				panic("mock out the GetUserByPhone method")
 //			},
-//			ResetPasswordFunc: func(codeID uint, req *UserUpdate) error {
+//			LastRecoveryCodeFunc: func(userID uint) (*PasswordRecovery, error) {
+//				panic("mock out the LastRecoveryCode method")
+//			},
+//			ResetPasswordFunc: func(codeID uint, req *UserUpdate, msgs ...*outbox.Message) error {
 //				panic("mock out the ResetPassword method")
 //			},
-//			UpdateUserFunc: func(u *UserUpdate) error {
+//			UpdateUserFunc: func(u *UserUpdate, msgs ...*outbox.Message) error {
 //				panic("mock out the UpdateUser method")
 //			},
 //			UseRegCodeFunc: func(code string) error {
@@ -79,7 +84,7 @@ type UserRepositoryMock struct {
 	CountRecoveryCodesByUserIn24hFunc func(userID uint) (int, error)
 
 	// CreateRecoverCodeFunc mocks the CreateRecoverCode method.
-	CreateRecoverCodeFunc func(c *PasswordRecovery) error
+	CreateRecoverCodeFunc func(c *PasswordRecovery, email *outbox.Message) error
 
 	// CreateUserFunc mocks the CreateUser method.
 	CreateUserFunc func(user *User) error
@@ -108,11 +113,14 @@ type UserRepositoryMock struct {
 	// GetUserByPhoneFunc mocks the GetUserByPhone method.
 	GetUserByPhoneFunc func(phone string) (*User, error)
 
+	// LastRecoveryCodeFunc mocks the LastRecoveryCode method.
+	LastRecoveryCodeFunc func(userID uint) (*PasswordRecovery, error)
+
 	// ResetPasswordFunc mocks the ResetPassword method.
-	ResetPasswordFunc func(codeID uint, req *UserUpdate) error
+	ResetPasswordFunc func(codeID uint, req *UserUpdate, msgs ...*outbox.Message) error
 
 	// UpdateUserFunc mocks the UpdateUser method.
-	UpdateUserFunc func(u *UserUpdate) error
+	UpdateUserFunc func(u *UserUpdate, msgs ...*outbox.Message) error
 
 	// UseRegCodeFunc mocks the UseRegCode method.
 	UseRegCodeFunc func(code string) error
@@ -138,6 +146,8 @@ type UserRepositoryMock struct {
 		CreateRecoverCode []struct {
 			// C is the c argument value.
 			C *PasswordRecovery
+			// Email is the email argument value.
+			Email *outbox.Message
 		}
 		// CreateUser holds details about calls to the CreateUser method.
 		CreateUser []struct {
@@ -184,17 +194,26 @@ type UserRepositoryMock struct {
 			// Phone is the phone argument value.
 			Phone string
 		}
+		// LastRecoveryCode holds details about calls to the LastRecoveryCode method.
+		LastRecoveryCode []struct {
+			// UserID is the userID argument value.
+			UserID uint
+		}
 		// ResetPassword holds details about calls to the ResetPassword method.
 		ResetPassword []struct {
 			// CodeID is the codeID argument value.
 			CodeID uint
 			// Req is the req argument value.
 			Req *UserUpdate
+			// Msgs is the msgs argument value.
+			Msgs []*outbox.Message
 		}
 		// UpdateUser holds details about calls to the UpdateUser method.
 		UpdateUser []struct {
 			// U is the u argument value.
 			U *UserUpdate
+			// Msgs is the msgs argument value.
+			Msgs []*outbox.Message
 		}
 		// UseRegCode holds details about calls to the UseRegCode method.
 		UseRegCode []struct {
@@ -219,6 +238,7 @@ type UserRepositoryMock struct {
 	lockGetUserByEmail                sync.RWMutex
 	lockGetUserByID                   sync.RWMutex
 	lockGetUserByPhone                sync.RWMutex
+	lockLastRecoveryCode              sync.RWMutex
 	lockResetPassword                 sync.RWMutex
 	lockUpdateUser                    sync.RWMutex
 	lockUseRegCode                    sync.RWMutex
@@ -294,19 +314,21 @@ func (mock *UserRepositoryMock) CountRecoveryCodesByUserIn24hCalls() []struct {
 }
 
 // CreateRecoverCode calls CreateRecoverCodeFunc.
-func (mock *UserRepositoryMock) CreateRecoverCode(c *PasswordRecovery) error {
+func (mock *UserRepositoryMock) CreateRecoverCode(c *PasswordRecovery, email *outbox.Message) error {
 	if mock.CreateRecoverCodeFunc == nil {
 		panic("UserRepositoryMock.CreateRecoverCodeFunc: method is nil but UserRepository.CreateRecoverCode was just called")
 	}
 	callInfo := struct {
-		C *PasswordRecovery
+		C     *PasswordRecovery
+		Email *outbox.Message
 	}{
-		C: c,
+		C:     c,
+		Email: email,
 	}
 	mock.lockCreateRecoverCode.Lock()
 	mock.calls.CreateRecoverCode = append(mock.calls.CreateRecoverCode, callInfo)
 	mock.lockCreateRecoverCode.Unlock()
-	return mock.CreateRecoverCodeFunc(c)
+	return mock.CreateRecoverCodeFunc(c, email)
 }
 
 // CreateRecoverCodeCalls gets all the calls that were made to CreateRecoverCode.
@@ -314,10 +336,12 @@ func (mock *UserRepositoryMock) CreateRecoverCode(c *PasswordRecovery) error {
 //
 //	len(mockedUserRepository.CreateRecoverCodeCalls())
 func (mock *UserRepositoryMock) CreateRecoverCodeCalls() []struct {
-	C *PasswordRecovery
+	C     *PasswordRecovery
+	Email *outbox.Message
 } {
 	var calls []struct {
-		C *PasswordRecovery
+		C     *PasswordRecovery
+		Email *outbox.Message
 	}
 	mock.lockCreateRecoverCode.RLock()
 	calls = mock.calls.CreateRecoverCode
@@ -612,22 +636,56 @@ func (mock *UserRepositoryMock) GetUserByPhoneCalls() []struct {
 	return calls
 }
 
+// LastRecoveryCode calls LastRecoveryCodeFunc.
+func (mock *UserRepositoryMock) LastRecoveryCode(userID uint) (*PasswordRecovery, error) {
+	if mock.LastRecoveryCodeFunc == nil {
+		panic("UserRepositoryMock.LastRecoveryCodeFunc: method is nil but UserRepository.LastRecoveryCode was just called")
+	}
+	callInfo := struct {
+		UserID uint
+	}{
+		UserID: userID,
+	}
+	mock.lockLastRecoveryCode.Lock()
+	mock.calls.LastRecoveryCode = append(mock.calls.LastRecoveryCode, callInfo)
+	mock.lockLastRecoveryCode.Unlock()
+	return mock.LastRecoveryCodeFunc(userID)
+}
+
+// LastRecoveryCodeCalls gets all the calls that were made to LastRecoveryCode.
+// Check the length with:
+//
+//	len(mockedUserRepository.LastRecoveryCodeCalls())
+func (mock *UserRepositoryMock) LastRecoveryCodeCalls() []struct {
+	UserID uint
+} {
+	var calls []struct {
+		UserID uint
+	}
+	mock.lockLastRecoveryCode.RLock()
+	calls = mock.calls.LastRecoveryCode
+	mock.lockLastRecoveryCode.RUnlock()
+	return calls
+}
+
 // ResetPassword calls ResetPasswordFunc.
-func (mock *UserRepositoryMock) ResetPassword(codeID uint, req *UserUpdate) error {
+func (mock *UserRepositoryMock) ResetPassword(codeID uint, req *UserUpdate, msgs ...*outbox.Message) error {
 	if mock.ResetPasswordFunc == nil {
 		panic("UserRepositoryMock.ResetPasswordFunc: method is nil but UserRepository.ResetPassword was just called")
 	}
 	callInfo := struct {
 		CodeID uint
 		Req    *UserUpdate
+		Msgs   []*outbox.Message
 	}{
 		CodeID: codeID,
 		Req:    req,
+		Msgs:   msgs,
 	}
 	mock.lockResetPassword.Lock()
 	mock.calls.ResetPassword = append(mock.calls.ResetPassword, callInfo)
 	mock.lockResetPassword.Unlock()
-	return mock.ResetPasswordFunc(codeID, req)
+	return mock.ResetPasswordFunc(codeID, req, msgs...)
 }
 
 // ResetPasswordCalls gets all the calls that were made to ResetPassword.
@@ -637,10 +695,12 @@ func (mock *UserRepositoryMock) ResetPassword(codeID uint, req *UserUpdate) erro
 func (mock *UserRepositoryMock) ResetPasswordCalls() []struct {
 	CodeID uint
 	Req    *UserUpdate
+	Msgs   []*outbox.Message
 } {
 	var calls []struct {
 		CodeID uint
 		Req    *UserUpdate
+		Msgs   []*outbox.Message
 	}
 	mock.lockResetPassword.RLock()
 	calls = mock.calls.ResetPassword
@@ -649,19 +709,21 @@ func (mock *UserRepositoryMock) ResetPasswordCalls() []struct {
 }
 
 // UpdateUser calls UpdateUserFunc.
-func (mock *UserRepositoryMock) UpdateUser(u *UserUpdate) error {
+func (mock *UserRepositoryMock) UpdateUser(u *UserUpdate, msgs ...*outbox.Message) error {
 	if mock.UpdateUserFunc == nil {
 		panic("UserRepositoryMock.UpdateUserFunc: method is nil but UserRepository.UpdateUser was just called")
 	}
 	callInfo := struct {
-		U *UserUpdate
+		U    *UserUpdate
+		Msgs []*outbox.Message
 	}{
-		U: u,
+		U:    u,
+		Msgs: msgs,
 	}
 	mock.lockUpdateUser.Lock()
 	mock.calls.UpdateUser = append(mock.calls.UpdateUser, callInfo)
 	mock.lockUpdateUser.Unlock()
-	return mock.UpdateUserFunc(u)
+	return mock.UpdateUserFunc(u, msgs...)
 }
 
 // UpdateUserCalls gets all the calls that were made to UpdateUser.
@@ -669,10 +731,12 @@ func (mock *UserRepositoryMock) UpdateUser(u *UserUpdate) error {
 //
 //	len(mockedUserRepository.UpdateUserCalls())
 func (mock *UserRepositoryMock) UpdateUserCalls() []struct {
-	U *UserUpdate
+	U    *UserUpdate
+	Msgs []*outbox.Message
 } {
 	var calls []struct {
-		U *UserUpdate
+		U    *UserUpdate
+		Msgs []*outbox.Message
 	}
 	mock.lockUpdateUser.RLock()
 	calls = mock.calls.UpdateUser
@@ -754,7 +818,7 @@ var _ MailSender = &MailSenderMock{}
 //
 //		// make and configure a mocked MailSender
 //		mockedMailSender := &MailSenderMock{
-//			SendRecoveryCodeEmailFunc: func(to string, username string, code string) error {
+//			SendRecoveryCodeEmailFunc: func(to string, username string, code string, lang string) error {
 //				panic("mock out the SendRecoveryCodeEmail method")
 //			},
 //		}
@@ -765,7 +829,7 @@ var _ MailSender = &MailSenderMock{}
 //	}
 type MailSenderMock struct {
 	// SendRecoveryCodeEmailFunc mocks the SendRecoveryCodeEmail method.
-	SendRecoveryCodeEmailFunc func(to string, username string, code string) error
+	SendRecoveryCodeEmailFunc func(to string, username string, code string, lang string) error
 
 	// calls tracks calls to the methods.
 	calls struct {
@@ -777,13 +841,15 @@ type MailSenderMock struct {
 			Username string
 			// Code is the code argument value.
 			Code string
+			// Lang is the lang argument value.
+			Lang string
 		}
 	}
 	lockSendRecoveryCodeEmail sync.RWMutex
 }
 
 // SendRecoveryCodeEmail calls SendRecoveryCodeEmailFunc.
-func (mock *MailSenderMock) SendRecoveryCodeEmail(to string, username string, code string) error {
+func (mock *MailSenderMock) SendRecoveryCodeEmail(to string, username string, code string, lang string) error {
 	if mock.SendRecoveryCodeEmailFunc == nil {
 		panic("MailSenderMock.SendRecoveryCodeEmailFunc: method is nil but MailSender.SendRecoveryCodeEmail was just called")
 	}
@@ -791,15 +857,17 @@ func (mock *MailSenderMock) SendRecoveryCodeEmail(to string, username string, co
 		To       string
 		Username string
 		Code     string
+		Lang     string
 	}{
 		To:       to,
 		Username: username,
 		Code:     code,
+		Lang:     lang,
 	}
 	mock.lockSendRecoveryCodeEmail.Lock()
 	mock.calls.SendRecoveryCodeEmail = append(mock.calls.SendRecoveryCodeEmail, callInfo)
 	mock.lockSendRecoveryCodeEmail.Unlock()
-	return mock.SendRecoveryCodeEmailFunc(to, username, code)
+	return mock.SendRecoveryCodeEmailFunc(to, username, code, lang)
 }
 
 // SendRecoveryCodeEmailCalls gets all the calls that were made to SendRecoveryCodeEmail.
@@ -810,14 +878,338 @@ func (mock *MailSenderMock) SendRecoveryCodeEmailCalls() []struct {
 	To       string
 	Username string
 	Code     string
+	Lang     string
 } {
 	var calls []struct {
 		To       string
 		Username string
 		Code     string
+		Lang     string
 	}
 	mock.lockSendRecoveryCodeEmail.RLock()
 	calls = mock.calls.SendRecoveryCodeEmail
 	mock.lockSendRecoveryCodeEmail.RUnlock()
 	return calls
 }
+
+// Ensure, that AvatarStoreMock does implement AvatarStore.
+// If this is not the case, regenerate this file with moq.
+var _ AvatarStore = &AvatarStoreMock{}
+
+// AvatarStoreMock is a mock implementation of AvatarStore.
+//
+//	func TestSomethingThatUsesAvatarStore(t *testing.T) {
+//
+//		// make and configure a mocked AvatarStore
+//		mockedAvatarStore := &AvatarStoreMock{
+//			AvatarURLsFunc: func(ctx context.Context, filename string) map[string]string {
+//				panic("mock out the AvatarURLs method")
+//			},
+//			DeleteAvatarFunc: func(ctx context.Context, filename string) error {
+//				panic("mock out the DeleteAvatar method")
+//			},
+//			SaveAvatarFunc: func(ctx context.Context, userID uint, file []byte) (string, error) {
+//				panic("mock out the SaveAvatar method")
+//			},
+//		}
+//
+//		// use mockedAvatarStore in code that requires AvatarStore
+//		// and then make assertions.
+//
+//	}
+type AvatarStoreMock struct {
+	// AvatarURLsFunc mocks the AvatarURLs method.
+	AvatarURLsFunc func(ctx context.Context, filename string) map[string]string
+
+	// DeleteAvatarFunc mocks the DeleteAvatar method.
+	DeleteAvatarFunc func(ctx context.Context, filename string) error
+
+	// SaveAvatarFunc mocks the SaveAvatar method.
+	SaveAvatarFunc func(ctx context.Context, userID uint, file []byte) (string, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// AvatarURLs holds details about calls to the AvatarURLs method.
+		AvatarURLs []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Filename is the filename argument value.
+			Filename string
+		}
+		// DeleteAvatar holds details about calls to the DeleteAvatar method.
+		DeleteAvatar []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Filename is the filename argument value.
+			Filename string
+		}
+		// SaveAvatar holds details about calls to the SaveAvatar method.
+		SaveAvatar []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// UserID is the userID argument value.
+			UserID uint
+			// File is the file argument value.
+			File []byte
+		}
+	}
+	lockAvatarURLs   sync.RWMutex
+	lockDeleteAvatar sync.RWMutex
+	lockSaveAvatar   sync.RWMutex
+}
+
+// AvatarURLs calls AvatarURLsFunc.
+func (mock *AvatarStoreMock) AvatarURLs(ctx context.Context, filename string) map[string]string {
+	if mock.AvatarURLsFunc == nil {
+		panic("AvatarStoreMock.AvatarURLsFunc: method is nil but AvatarStore.AvatarURLs was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		Filename string
+	}{
+		Ctx:      ctx,
+		Filename: filename,
+	}
+	mock.lockAvatarURLs.Lock()
+	mock.calls.AvatarURLs = append(mock.calls.AvatarURLs, callInfo)
+	mock.lockAvatarURLs.Unlock()
+	return mock.AvatarURLsFunc(ctx, filename)
+}
+
+// AvatarURLsCalls gets all the calls that were made to AvatarURLs.
+// Check the length with:
+//
+//	len(mockedAvatarStore.AvatarURLsCalls())
+func (mock *AvatarStoreMock) AvatarURLsCalls() []struct {
+	Ctx      context.Context
+	Filename string
+} {
+	var calls []struct {
+		Ctx      context.Context
+		Filename string
+	}
+	mock.lockAvatarURLs.RLock()
+	calls = mock.calls.AvatarURLs
+	mock.lockAvatarURLs.RUnlock()
+	return calls
+}
+
+// DeleteAvatar calls DeleteAvatarFunc.
+func (mock *AvatarStoreMock) DeleteAvatar(ctx context.Context, filename string) error {
+	if mock.DeleteAvatarFunc == nil {
+		panic("AvatarStoreMock.DeleteAvatarFunc: method is nil but AvatarStore.DeleteAvatar was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		Filename string
+	}{
+		Ctx:      ctx,
+		Filename: filename,
+	}
+	mock.lockDeleteAvatar.Lock()
+	mock.calls.DeleteAvatar = append(mock.calls.DeleteAvatar, callInfo)
+	mock.lockDeleteAvatar.Unlock()
+	return mock.DeleteAvatarFunc(ctx, filename)
+}
+
+// DeleteAvatarCalls gets all the calls that were made to DeleteAvatar.
+// Check the length with:
+//
+//	len(mockedAvatarStore.DeleteAvatarCalls())
+func (mock *AvatarStoreMock) DeleteAvatarCalls() []struct {
+	Ctx      context.Context
+	Filename string
+} {
+	var calls []struct {
+		Ctx      context.Context
+		Filename string
+	}
+	mock.lockDeleteAvatar.RLock()
+	calls = mock.calls.DeleteAvatar
+	mock.lockDeleteAvatar.RUnlock()
+	return calls
+}
+
+// SaveAvatar calls SaveAvatarFunc.
+func (mock *AvatarStoreMock) SaveAvatar(ctx context.Context, userID uint, file []byte) (string, error) {
+	if mock.SaveAvatarFunc == nil {
+		panic("AvatarStoreMock.SaveAvatarFunc: method is nil but AvatarStore.SaveAvatar was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		UserID uint
+		File   []byte
+	}{
+		Ctx:    ctx,
+		UserID: userID,
+		File:   file,
+	}
+	mock.lockSaveAvatar.Lock()
+	mock.calls.SaveAvatar = append(mock.calls.SaveAvatar, callInfo)
+	mock.lockSaveAvatar.Unlock()
+	return mock.SaveAvatarFunc(ctx, userID, file)
+}
+
+// SaveAvatarCalls gets all the calls that were made to SaveAvatar.
+// Check the length with:
+//
+//	len(mockedAvatarStore.SaveAvatarCalls())
+func (mock *AvatarStoreMock) SaveAvatarCalls() []struct {
+	Ctx    context.Context
+	UserID uint
+	File   []byte
+} {
+	var calls []struct {
+		Ctx    context.Context
+		UserID uint
+		File   []byte
+	}
+	mock.lockSaveAvatar.RLock()
+	calls = mock.calls.SaveAvatar
+	mock.lockSaveAvatar.RUnlock()
+	return calls
+}
+
+// Ensure, that NotifierMock does implement Notifier.
+// If this is not the case, regenerate this file with moq.
+var _ Notifier = &NotifierMock{}
+
+// NotifierMock is a mock implementation of Notifier.
+//
+//	func TestSomethingThatUsesNotifier(t *testing.T) {
+//
+//		// make and configure a mocked Notifier
+//		mockedNotifier := &NotifierMock{
+//			EventMessageFunc: func(userID uint, event string, data map[string]string) (*outbox.Message, error) {
+//				panic("mock out the EventMessage method")
+//			},
+//		}
+//
+//		// use mockedNotifier in code that requires Notifier
+//		// and then make assertions.
+//
+//	}
+type NotifierMock struct {
+	// EventMessageFunc mocks the EventMessage method.
+	EventMessageFunc func(userID uint, event string, data map[string]string) (*outbox.Message, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// EventMessage holds details about calls to the EventMessage method.
+		EventMessage []struct {
+			// UserID is the userID argument value.
+			UserID uint
+			// Event is the event argument value.
+			Event string
+			// Data is the data argument value.
+			Data map[string]string
+		}
+	}
+	lockEventMessage sync.RWMutex
+}
+
+// EventMessage calls EventMessageFunc.
+func (mock *NotifierMock) EventMessage(userID uint, event string, data map[string]string) (*outbox.Message, error) {
+	if mock.EventMessageFunc == nil {
+		panic("NotifierMock.EventMessageFunc: method is nil but Notifier.EventMessage was just called")
+	}
+	callInfo := struct {
+		UserID uint
+		Event  string
+		Data   map[string]string
+	}{
+		UserID: userID,
+		Event:  event,
+		Data:   data,
+	}
+	mock.lockEventMessage.Lock()
+	mock.calls.EventMessage = append(mock.calls.EventMessage, callInfo)
+	mock.lockEventMessage.Unlock()
+	return mock.EventMessageFunc(userID, event, data)
+}
+
+// EventMessageCalls gets all the calls that were made to EventMessage.
+// Check the length with:
+//
+//	len(mockedNotifier.EventMessageCalls())
+func (mock *NotifierMock) EventMessageCalls() []struct {
+	UserID uint
+	Event  string
+	Data   map[string]string
+} {
+	var calls []struct {
+		UserID uint
+		Event  string
+		Data   map[string]string
+	}
+	mock.lockEventMessage.RLock()
+	calls = mock.calls.EventMessage
+	mock.lockEventMessage.RUnlock()
+	return calls
+}
+
+// Ensure, that LoginUnlockerMock does implement LoginUnlocker.
+// If this is not the case, regenerate this file with moq.
+var _ LoginUnlocker = &LoginUnlockerMock{}
+
+// LoginUnlockerMock is a mock implementation of LoginUnlocker.
+//
+//	func TestSomethingThatUsesLoginUnlocker(t *testing.T) {
+//
+//		// make and configure a mocked LoginUnlocker
+//		mockedLoginUnlocker := &LoginUnlockerMock{
+//			UnlockFunc: func(phone string) error {
+//				panic("mock out the Unlock method")
+//			},
+//		}
+//
+//		// use mockedLoginUnlocker in code that requires LoginUnlocker
+//		// and then make assertions.
+//
+//	}
+type LoginUnlockerMock struct {
+	// UnlockFunc mocks the Unlock method.
+	UnlockFunc func(phone string) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Unlock holds details about calls to the Unlock method.
+		Unlock []struct {
+			// Phone is the phone argument value.
+			Phone string
+		}
+	}
+	lockUnlock sync.RWMutex
+}
+
+// Unlock calls UnlockFunc.
+func (mock *LoginUnlockerMock) Unlock(phone string) error {
+	if mock.UnlockFunc == nil {
+		panic("LoginUnlockerMock.UnlockFunc: method is nil but LoginUnlocker.Unlock was just called")
+	}
+	callInfo := struct {
+		Phone string
+	}{
+		Phone: phone,
+	}
+	mock.lockUnlock.Lock()
+	mock.calls.Unlock = append(mock.calls.Unlock, callInfo)
+	mock.lockUnlock.Unlock()
+	return mock.UnlockFunc(phone)
+}
+
+// UnlockCalls gets all the calls that were made to Unlock.
+// Check the length with:
+//
+//	len(mockedLoginUnlocker.UnlockCalls())
+func (mock *LoginUnlockerMock) UnlockCalls() []struct {
+	Phone string
+} {
+	var calls []struct {
+		Phone string
+	}
+	mock.lockUnlock.RLock()
+	calls = mock.calls.Unlock
+	mock.lockUnlock.RUnlock()
+	return calls
+}
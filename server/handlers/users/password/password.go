@@ -0,0 +1,178 @@
+// Package password hashes and validates the passwords of the users.
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AlgBcrypt   = "bcrypt"
+	AlgArgon2id = "argon2id"
+
+	// MinBcryptCost is the lowest cost the new bcrypt hashes are made with.
+	MinBcryptCost = 12
+
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+var (
+	// ErrMismatch is returned for the wrong password.
+	ErrMismatch = errors.New("password mismatch")
+	// ErrUnknownHash is returned for the hash of the unknown algorithm or the malformed one.
+	ErrUnknownHash = errors.New("unknown password hash")
+)
+
+// Params configure the new hashes. Argon2Memory is in KiB.
+type Params struct {
+	Algorithm     string
+	BcryptCost    int
+	Argon2Time    uint32
+	Argon2Memory  uint32
+	Argon2Threads uint8
+}
+
+// DefaultParams are bcrypt with the min cost, the argon2id defaults follow RFC 9106.
+var DefaultParams = Params{
+	Algorithm:     AlgBcrypt,
+	BcryptCost:    MinBcryptCost,
+	Argon2Time:    3,
+	Argon2Memory:  64 * 1024,
+	Argon2Threads: 2,
+}
+
+type Hasher struct {
+	params Params
+}
+
+// New returns the hasher with params, the zero ones are taken from DefaultParams.
+func New(p Params) (*Hasher, error) {
+	if p.Algorithm == "" {
+		p.Algorithm = DefaultParams.Algorithm
+	}
+	if p.BcryptCost == 0 {
+		p.BcryptCost = DefaultParams.BcryptCost
+	}
+	if p.Argon2Time == 0 {
+		p.Argon2Time = DefaultParams.Argon2Time
+	}
+	if p.Argon2Memory == 0 {
+		p.Argon2Memory = DefaultParams.Argon2Memory
+	}
+	if p.Argon2Threads == 0 {
+		p.Argon2Threads = DefaultParams.Argon2Threads
+	}
+
+	switch p.Algorithm {
+	case AlgBcrypt:
+		if p.BcryptCost < MinBcryptCost || p.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost must be from %d to %d", MinBcryptCost, bcrypt.MaxCost)
+		}
+	case AlgArgon2id:
+	default:
+		return nil, fmt.Errorf("unsupported password hash algorithm %q", p.Algorithm)
+	}
+
+	return &Hasher{params: p}, nil
+}
+
+// Hash hashes the password with the current params.
+func (h *Hasher) Hash(pwd string) (string, error) {
+	if h.params.Algorithm == AlgArgon2id {
+		salt := make([]byte, argon2SaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		return encodeArgon2(h.params, salt, argon2.IDKey([]byte(pwd), salt,
+			h.params.Argon2Time, h.params.Argon2Memory, h.params.Argon2Threads, argon2KeyLen)), nil
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(pwd), h.params.BcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Verify checks the password against the hash of any supported algorithm and params.
+func (h *Hasher) Verify(hash, pwd string) error {
+	if strings.HasPrefix(hash, "$"+AlgArgon2id+"$") {
+		p, salt, key, err := decodeArgon2(hash)
+		if err != nil {
+			return err
+		}
+		// #nosec G115 -- the key length is checked when decoded
+		got := argon2.IDKey([]byte(pwd), salt, p.Argon2Time, p.Argon2Memory, p.Argon2Threads, uint32(len(key)))
+		if subtle.ConstantTimeCompare(got, key) != 1 {
+			return ErrMismatch
+		}
+		return nil
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(pwd)); err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrMismatch
+		}
+		return fmt.Errorf("%w: %s", ErrUnknownHash, err)
+	}
+	return nil
+}
+
+// NeedsRehash tells the hash was made with other algorithm or params than the current ones.
+func (h *Hasher) NeedsRehash(hash string) bool {
+	if strings.HasPrefix(hash, "$"+AlgArgon2id+"$") {
+		p, _, key, err := decodeArgon2(hash)
+		return err != nil || h.params.Algorithm != AlgArgon2id || len(key) != argon2KeyLen ||
+			p.Argon2Time != h.params.Argon2Time || p.Argon2Memory != h.params.Argon2Memory ||
+			p.Argon2Threads != h.params.Argon2Threads
+	}
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || h.params.Algorithm != AlgBcrypt || cost != h.params.BcryptCost
+}
+
+// encodeArgon2 encodes the hash in the PHC string format, e.g. $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>.
+func encodeArgon2(p Params, salt, key []byte) string {
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", AlgArgon2id, argon2.Version,
+		p.Argon2Memory, p.Argon2Time, p.Argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func decodeArgon2(hash string) (Params, []byte, []byte, error) {
+	var p Params
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, ErrUnknownHash
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Argon2Memory, &p.Argon2Time, &p.Argon2Threads); err != nil ||
+		p.Argon2Time == 0 || p.Argon2Threads == 0 {
+		return p, nil, nil, ErrUnknownHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrUnknownHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, ErrUnknownHash
+	}
+
+	p.Algorithm = AlgArgon2id
+	return p, salt, key, nil
+}
@@ -0,0 +1,196 @@
+package password_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/ivch/dynasty/server/handlers/users/password"
+)
+
+var argon2Params = password.Params{Algorithm: password.AlgArgon2id, Argon2Time: 1, Argon2Memory: 1024, Argon2Threads: 1}
+
+func newHasher(t *testing.T, p password.Params) *password.Hasher {
+	t.Helper()
+
+	h, err := password.New(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return h
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  password.Params
+		wantErr bool
+	}{
+		{
+			name: "ok defaults",
+		},
+		{
+			name:   "ok argon2id",
+			params: argon2Params,
+		},
+		{
+			name:    "error bcrypt cost too low",
+			params:  password.Params{Algorithm: password.AlgBcrypt, BcryptCost: bcrypt.DefaultCost},
+			wantErr: true,
+		},
+		{
+			name:    "error bcrypt cost too high",
+			params:  password.Params{BcryptCost: bcrypt.MaxCost + 1},
+			wantErr: true,
+		},
+		{
+			name:    "error unknown algorithm",
+			params:  password.Params{Algorithm: "md5"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := password.New(tt.params); (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHasher_Verify(t *testing.T) {
+	bcryptHasher := newHasher(t, password.DefaultParams)
+	argon2Hasher := newHasher(t, argon2Params)
+
+	bcryptHash, err := bcryptHasher.Hash("kyiv-gates-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	argon2Hash, err := argon2Hasher.Hash("kyiv-gates-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy, err := bcrypt.GenerateFromPassword([]byte("kyiv-gates-42"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(argon2Hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Errorf("Hash() argon2id = %s", argon2Hash)
+	}
+	if cost, err := bcrypt.Cost([]byte(bcryptHash)); err != nil || cost != password.MinBcryptCost {
+		t.Errorf("Hash() bcrypt cost = %d, %v", cost, err)
+	}
+
+	tests := []struct {
+		name     string
+		hash     string
+		password string
+		wantErr  error
+	}{
+		{
+			name:     "ok bcrypt",
+			hash:     bcryptHash,
+			password: "kyiv-gates-42",
+		},
+		{
+			name:     "ok argon2id",
+			hash:     argon2Hash,
+			password: "kyiv-gates-42",
+		},
+		{
+			name:     "ok legacy bcrypt",
+			hash:     string(legacy),
+			password: "kyiv-gates-42",
+		},
+		{
+			name:     "error bcrypt mismatch",
+			hash:     bcryptHash,
+			password: "kyiv-gates-43",
+			wantErr:  password.ErrMismatch,
+		},
+		{
+			name:     "error argon2id mismatch",
+			hash:     argon2Hash,
+			password: "kyiv-gates-43",
+			wantErr:  password.ErrMismatch,
+		},
+		{
+			name:     "error malformed argon2id",
+			hash:     "$argon2id$v=19$m=1024,t=1$c2FsdA$a2V5",
+			password: "kyiv-gates-42",
+			wantErr:  password.ErrUnknownHash,
+		},
+		{
+			name:     "error unsupported version",
+			hash:     strings.Replace(argon2Hash, "$argon2id$v=19$", "$argon2id$v=16$", 1),
+			password: "kyiv-gates-42",
+			wantErr:  password.ErrUnknownHash,
+		},
+		{
+			name:     "error plain text",
+			hash:     "kyiv-gates-42",
+			password: "kyiv-gates-42",
+			wantErr:  password.ErrUnknownHash,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// every hasher verifies the hashes of any algorithm
+			for _, h := range []*password.Hasher{bcryptHasher, argon2Hasher} {
+				if err := h.Verify(tt.hash, tt.password); !errors.Is(err, tt.wantErr) || (tt.wantErr == nil) != (err == nil) {
+					t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestHasher_NeedsRehash(t *testing.T) {
+	current := newHasher(t, password.DefaultParams)
+	currentArgon2 := newHasher(t, argon2Params)
+	stronger := argon2Params
+	stronger.Argon2Time = 2
+	strongerArgon2 := newHasher(t, stronger)
+
+	bcryptHash, err := current.Hash("kyiv-gates-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	argon2Hash, err := currentArgon2.Hash("kyiv-gates-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy, err := bcrypt.GenerateFromPassword([]byte("kyiv-gates-42"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		hasher *password.Hasher
+		hash   string
+		want   bool
+	}{
+		{name: "bcrypt current", hasher: current, hash: bcryptHash, want: false},
+		{name: "bcrypt legacy cost", hasher: current, hash: string(legacy), want: true},
+		{name: "bcrypt to argon2id", hasher: currentArgon2, hash: bcryptHash, want: true},
+		{name: "argon2id current", hasher: currentArgon2, hash: argon2Hash, want: false},
+		{name: "argon2id outdated params", hasher: strongerArgon2, hash: argon2Hash, want: true},
+		{name: "argon2id to bcrypt", hasher: current, hash: argon2Hash, want: true},
+		{name: "unknown hash", hasher: current, hash: "plain", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hasher.NeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
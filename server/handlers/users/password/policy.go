@@ -0,0 +1,61 @@
+package password
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ivch/dynasty/common/errs"
+)
+
+const (
+	MinLength = 8
+	// MaxLength is in bytes, bcrypt ignores the rest of the longer passwords.
+	MaxLength = 72
+)
+
+// common are the most used passwords of the allowed length.
+var common = map[string]struct{}{
+	"12345678": {}, "123456789": {}, "1234567890": {}, "0987654321": {}, "87654321": {}, "12341234": {},
+	"11223344": {}, "12344321": {}, "123123123": {}, "147258369": {}, "qwertyui": {}, "qwertyuiop": {},
+	"qwerty123": {}, "qwerty12": {}, "1q2w3e4r": {}, "1q2w3e4r5t": {}, "1qaz2wsx": {}, "zaq12wsx": {},
+	"asdfghjk": {}, "asdfghjkl": {}, "zxcvbnm1": {}, "password": {}, "password1": {}, "password123": {},
+	"passw0rd": {}, "iloveyou": {}, "sunshine": {}, "football": {}, "baseball": {}, "princess": {},
+	"superman": {}, "abc12345": {}, "abcd1234": {}, "qazwsxedc": {}, "trustno1": {}, "welcome1": {},
+	"letmein1": {}, "admin123": {}, "administrator": {}, "йцукенгш": {}, "пароль123": {}, "dynasty1": {},
+}
+
+// Validate checks the password is strong enough and has no personal data.
+func Validate(pwd string, personal ...string) error {
+	if utf8.RuneCountInString(pwd) < MinLength {
+		return errs.PasswordTooShort
+	}
+
+	if len(pwd) > MaxLength {
+		return errs.PasswordTooLong
+	}
+
+	lower := strings.ToLower(pwd)
+	if _, ok := common[lower]; ok {
+		return errs.PasswordTooWeak
+	}
+
+	first, _ := utf8.DecodeRuneInString(pwd)
+	if strings.Trim(pwd, string(first)) == "" {
+		return errs.PasswordTooWeak
+	}
+
+	for _, p := range personal {
+		if p = strings.ToLower(strings.TrimPrefix(p, "+")); p == "" {
+			continue
+		}
+		// the local part of the email is the guessable one
+		if i := strings.IndexByte(p, '@'); i > 0 {
+			p = p[:i]
+		}
+		if len(p) >= 4 && strings.Contains(lower, p) {
+			return errs.PasswordTooWeak
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package password_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/server/handlers/users/password"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		personal []string
+		wantErr  error
+	}{
+		{
+			name:     "ok",
+			password: "kyiv-gates-42",
+			personal: []string{"380671234567", "john.doe@mail.com"},
+		},
+		{
+			name:     "ok multibyte",
+			password: "ключ-від-воріт",
+		},
+		{
+			name:     "ok short personal data ignored",
+			password: "kyiv-gates-42",
+			personal: []string{"", "ky@mail.com"},
+		},
+		{
+			name:     "error too short",
+			password: "k1-gate",
+			wantErr:  errs.PasswordTooShort,
+		},
+		{
+			name:     "error too long",
+			password: strings.Repeat("ключ", 10),
+			wantErr:  errs.PasswordTooLong,
+		},
+		{
+			name:     "error common",
+			password: "Password1",
+			wantErr:  errs.PasswordTooWeak,
+		},
+		{
+			name:     "error repeated character",
+			password: "aaaaaaaaaa",
+			wantErr:  errs.PasswordTooWeak,
+		},
+		{
+			name:     "error contains phone",
+			password: "my380671234567",
+			personal: []string{"+380671234567"},
+			wantErr:  errs.PasswordTooWeak,
+		},
+		{
+			name:     "error contains email name",
+			password: "John.Doe-2024",
+			personal: []string{"john.doe@mail.com"},
+			wantErr:  errs.PasswordTooWeak,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := password.Validate(tt.password, tt.personal...); err != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/jinzhu/gorm"
 
 	"github.com/ivch/dynasty/common/errs"
+	"github.com/ivch/dynasty/server/handlers/outbox"
+	outboxRepo "github.com/ivch/dynasty/server/handlers/outbox/repo"
 	"github.com/ivch/dynasty/server/handlers/users"
 )
 
@@ -27,9 +29,15 @@ func (r *Repo) DeleteUser(u *users.User) error {
 	return r.db.Delete(u).Error
 }
 
-func (r *Repo) UpdateUser(req *users.UserUpdate) error {
-	update := prepareUpdateQuery(req)
-	return r.db.Table(users.User{}.TableName()).Where("id = ?", req.ID).Updates(update).Error
+// UpdateUser updates the user together with the messages of the change in the outbox.
+func (r *Repo) UpdateUser(req *users.UserUpdate, msgs ...*outbox.Message) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		update := prepareUpdateQuery(req)
+		if err := tx.Table(users.User{}.TableName()).Where("id = ?", req.ID).Updates(update).Error; err != nil {
+			return err
+		}
+		return outboxRepo.Add(tx, msgs...)
+	})
 }
 
 func (r *Repo) GetUserByID(id uint) (*users.User, error) {
@@ -113,8 +121,14 @@ func (r *Repo) FindUserByApartment(building uint, apt uint) (*users.User, error)
 	return &u, nil
 }
 
-func (r *Repo) CreateRecoverCode(c *users.PasswordRecovery) error {
-	return r.db.Create(c).Error
+// CreateRecoverCode stores the code together with its email in the outbox.
+func (r *Repo) CreateRecoverCode(c *users.PasswordRecovery, email *outbox.Message) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(c).Error; err != nil {
+			return err
+		}
+		return outboxRepo.Add(tx, email)
+	})
 }
 
 func (r *Repo) GetRecoveryCode(c *users.PasswordRecovery) (*users.PasswordRecovery, error) {
@@ -126,6 +140,19 @@ func (r *Repo) GetRecoveryCode(c *users.PasswordRecovery) (*users.PasswordRecove
 	return &code, nil
 }
 
+// LastRecoveryCode returns the latest active code of the user, nil when there is none.
+func (r *Repo) LastRecoveryCode(userID uint) (*users.PasswordRecovery, error) {
+	var code users.PasswordRecovery
+	if err := r.db.Where("user_id = ? AND active = ?", userID, true).
+		Order("created_at desc").First(&code).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &code, nil
+}
+
 func (r *Repo) CountRecoveryCodesByUserIn24h(userID uint) (int, error) {
 	var count int
 	from := time.Now().Add(-24 * time.Hour)
@@ -138,13 +165,17 @@ func (r *Repo) CountRecoveryCodesByUserIn24h(userID uint) (int, error) {
 	return count, nil
 }
 
-func (r *Repo) ResetPassword(codeID uint, req *users.UserUpdate) error {
+// ResetPassword updates the user and uses the code together with the messages of the change in the outbox.
+func (r *Repo) ResetPassword(codeID uint, req *users.UserUpdate, msgs ...*outbox.Message) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
 		update := prepareUpdateQuery(req)
 		if err := tx.Table(users.User{}.TableName()).Where("id = ?", req.ID).Updates(update).Error; err != nil {
 			return err
 		}
-		return tx.Model(users.PasswordRecovery{}).Where("id = ?", codeID).Update("active", "false").Error
+		if err := tx.Model(users.PasswordRecovery{}).Where("id = ?", codeID).Update("active", "false").Error; err != nil {
+			return err
+		}
+		return outboxRepo.Add(tx, msgs...)
 	})
 }
 
@@ -206,5 +237,9 @@ func prepareUpdateQuery(req *users.UserUpdate) map[string]interface{} {
 		update["role"] = *req.Role
 	}
 
+	if req.Avatar != nil {
+		update["avatar"] = *req.Avatar
+	}
+
 	return update
 }
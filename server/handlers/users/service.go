@@ -2,15 +2,17 @@ package users
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
-	"golang.org/x/crypto/bcrypt"
-
 	"github.com/ivch/dynasty/common"
 	"github.com/ivch/dynasty/common/errs"
 	"github.com/ivch/dynasty/common/logger"
+	"github.com/ivch/dynasty/server/handlers/outbox"
+	"github.com/ivch/dynasty/server/handlers/users/password"
 )
 
 type UserRepository interface {
@@ -18,22 +20,52 @@ type UserRepository interface {
 	GetUserByPhone(phone string) (*User, error)
 	GetUserByEmail(email string) (*User, error)
 	CreateUser(user *User) error
-	UpdateUser(u *UserUpdate) error
+	UpdateUser(u *UserUpdate, msgs ...*outbox.Message) error
 	DeleteUser(u *User) error
 	ValidateRegCode(code string) error
 	UseRegCode(code string) error
 	GetRegCode() (string, error)
 	GetFamilyMembers(ownerID uint) ([]*User, error)
 	FindUserByApartment(building uint, apt uint) (*User, error)
-	CreateRecoverCode(c *PasswordRecovery) error
+	CreateRecoverCode(c *PasswordRecovery, email *outbox.Message) error
 	CountRecoveryCodesByUserIn24h(userID uint) (int, error)
 	GetRecoveryCode(c *PasswordRecovery) (*PasswordRecovery, error)
-	ResetPassword(codeID uint, req *UserUpdate) error
+	LastRecoveryCode(userID uint) (*PasswordRecovery, error)
+	ResetPassword(codeID uint, req *UserUpdate, msgs ...*outbox.Message) error
 	AdminResetApartment(targetID uint, placeholder *User) (string, error)
 }
 
 type MailSender interface {
-	SendRecoveryCodeEmail(to, username, code string) error
+	SendRecoveryCodeEmail(to, username, code, lang string) error
+}
+
+// TopicRecoveryEmail is the outbox topic of the password recovery emails.
+const TopicRecoveryEmail = "users.recovery_email"
+
+const recoveryCodeTTL = 3 * time.Hour
+
+const (
+	// EventFamilyMemberJoined tells the master account the family member has registered.
+	EventFamilyMemberJoined = "family_member_joined"
+	// EventPasswordChanged tells the user the password was changed or reset.
+	EventPasswordChanged = "password_changed"
+)
+
+// Notifier builds the outbox messages of the events delivered to the users through their notification channels.
+type Notifier interface {
+	EventMessage(userID uint, event string, data map[string]string) (*outbox.Message, error)
+}
+
+// LoginUnlocker lifts the lockout of the login after too many failed attempts.
+type LoginUnlocker interface {
+	Unlock(phone string) error
+}
+
+// AvatarStore keeps the profile photos.
+type AvatarStore interface {
+	SaveAvatar(ctx context.Context, userID uint, file []byte) (string, error)
+	DeleteAvatar(ctx context.Context, filename string) error
+	AvatarURLs(ctx context.Context, filename string) map[string]string
 }
 
 type Service struct {
@@ -41,10 +73,45 @@ type Service struct {
 	membersLimit  int
 	verifyRegCode bool
 	email         MailSender
+	avatars       AvatarStore
+	notifier      Notifier
+	unlocker      LoginUnlocker
+	passwords     *password.Hasher
 	log           logger.Logger
 }
 
-func New(log logger.Logger, repo UserRepository, verifyRegCode bool, membersLimit int, email MailSender) *Service {
+// Option configures optional Service parameters.
+type Option func(s *Service)
+
+// WithAvatars enables the profile photos.
+func WithAvatars(store AvatarStore) Option {
+	return func(s *Service) {
+		s.avatars = store
+	}
+}
+
+// WithNotifier sends the user events to the notifier.
+func WithNotifier(n Notifier) Option {
+	return func(s *Service) {
+		s.notifier = n
+	}
+}
+
+// WithLoginUnlocker lifts the login lockout of the user once the password is reset.
+func WithLoginUnlocker(u LoginUnlocker) Option {
+	return func(s *Service) {
+		s.unlocker = u
+	}
+}
+
+// WithPasswordHasher hashes the passwords with h, bcrypt with password.MinBcryptCost by default.
+func WithPasswordHasher(h *password.Hasher) Option {
+	return func(s *Service) {
+		s.passwords = h
+	}
+}
+
+func New(log logger.Logger, repo UserRepository, verifyRegCode bool, membersLimit int, email MailSender, opts ...Option) *Service {
 	s := Service{
 		repo:          repo,
 		membersLimit:  membersLimit,
@@ -53,19 +120,46 @@ func New(log logger.Logger, repo UserRepository, verifyRegCode bool, membersLimi
 		email:         email,
 	}
 
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	if s.passwords == nil {
+		s.passwords, _ = password.New(password.DefaultParams) // nolint: errcheck
+	}
+
 	return &s
 }
 
-func (s *Service) UserByID(_ context.Context, id uint) (*User, error) {
+func (s *Service) UserByID(ctx context.Context, id uint) (*User, error) {
 	u, err := s.repo.GetUserByID(id)
 	if err != nil {
 		s.log.Error("error getting user from db: %w", err)
 		return nil, err
 	}
+
+	if s.avatars != nil && u.Avatar != "" {
+		u.AvatarURL = s.avatars.AvatarURLs(ctx, u.Avatar)
+	}
+
+	return u, nil
+}
+
+func (s *Service) UserByApartment(_ context.Context, buildingID, apartment uint) (*User, error) {
+	u, err := s.repo.FindUserByApartment(buildingID, apartment)
+	if err != nil {
+		s.log.Error("error getting user by apt: %w", err)
+		return nil, err
+	}
+
+	if u == nil {
+		return nil, errs.UserNotFound
+	}
+
 	return u, nil
 }
 
-func (s *Service) UserByPhoneAndPassword(_ context.Context, phone, password string) (*User, error) {
+func (s *Service) UserByPhoneAndPassword(_ context.Context, phone, pwd string) (*User, error) {
 	u, err := s.repo.GetUserByPhone(phone)
 	if err != nil {
 		s.log.Error("error getting user from db: %w", err)
@@ -76,11 +170,13 @@ func (s *Service) UserByPhoneAndPassword(_ context.Context, phone, password stri
 		return nil, errs.UserNotFound
 	}
 
-	if err := comparePasswords(u.Password, password); err != nil {
+	if err := s.comparePasswords(u.Password, pwd); err != nil {
 		s.log.Error("error comparing hash: %w", err)
 		return nil, err
 	}
 
+	s.rehash(u, pwd)
+
 	return u, nil
 }
 
@@ -125,7 +221,7 @@ func (s *Service) Register(ctx context.Context, r *User) (*User, error) {
 		}
 	}
 
-	pwd, err := HashAndSalt(r.Password)
+	pwd, err := s.passwords.Hash(r.Password)
 	if err != nil {
 		s.log.Error("error hashing password: %w", err)
 		return nil, err
@@ -203,12 +299,21 @@ func (s *Service) Update(ctx context.Context, r *UserUpdate) error {
 		return errs.InvalidCredentials
 	}
 
-	if err := comparePasswords(u.Password, *r.Password); err != nil {
+	if err := s.comparePasswords(u.Password, *r.Password); err != nil {
 		return errs.InvalidCredentials
 	}
 
+	personal := []string{u.Phone, u.Email}
+	if r.Email != nil {
+		personal = append(personal, *r.Email)
+	}
+
+	if err := password.Validate(*r.NewPassword, personal...); err != nil {
+		return err
+	}
+
 	// todo in case of password change delete current user session and invalidate refresh token
-	pwd, err := HashAndSalt(*r.NewPassword)
+	pwd, err := s.passwords.Hash(*r.NewPassword)
 	if err != nil {
 		s.log.Error("error hashing password: %w", err)
 		return err
@@ -216,10 +321,16 @@ func (s *Service) Update(ctx context.Context, r *UserUpdate) error {
 
 	r.Password = &pwd
 
-	return s.repo.UpdateUser(r)
+	msgs, err := s.events(r.ID, EventPasswordChanged, nil)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.UpdateUser(r, msgs...)
 }
 
-func (s *Service) RecoveryCode(_ context.Context, r *User) error {
+// RecoveryCode sends the password recovery code to the user by email in the given language.
+func (s *Service) RecoveryCode(_ context.Context, r *User, lang string) error {
 	u, err := s.repo.GetUserByPhone(r.Phone)
 	if err != nil {
 		return err
@@ -249,16 +360,41 @@ func (s *Service) RecoveryCode(_ context.Context, r *User) error {
 		Active: true,
 	}
 
-	if err := s.repo.CreateRecoverCode(&code); err != nil {
+	// the email is sent by the outbox dispatcher, so the code is never stored without it
+	email, err := outbox.NewMessage(TopicRecoveryEmail, &RecoveryEmail{
+		UserID: u.ID,
+		Lang:   lang,
+	})
+	if err != nil {
 		return err
 	}
 
-	if err := s.email.SendRecoveryCodeEmail(u.Email, fmt.Sprintf("%s %s", u.FirstName, u.LastName), code.Code); err != nil {
-		s.log.Error("error sending recovery email: %w", err)
+	return s.repo.CreateRecoverCode(&code, email)
+}
+
+// SendRecoveryEmail is the outbox handler of TopicRecoveryEmail.
+func (s *Service) SendRecoveryEmail(_ context.Context, payload []byte) error {
+	var e RecoveryEmail
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return outbox.Permanent(err)
+	}
+
+	u, err := s.repo.GetUserByID(e.UserID)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	c, err := s.repo.LastRecoveryCode(u.ID)
+	if err != nil {
+		return err
+	}
+
+	// the code is used or outdated, the email is of no use anymore
+	if c == nil || time.Now().After(c.CreatedAt.Add(recoveryCodeTTL)) {
+		return outbox.Permanent(errors.New("no active recovery code"))
+	}
+
+	return s.email.SendRecoveryCodeEmail(u.Email, fmt.Sprintf("%s %s", u.FirstName, u.LastName), c.Code, e.Lang)
 }
 
 func (s *Service) ResetPassword(ctx context.Context, code string, r *UserUpdate) error {
@@ -270,18 +406,23 @@ func (s *Service) ResetPassword(ctx context.Context, code string, r *UserUpdate)
 		return errs.BadRecoveryCode
 	}
 
-	if time.Now().After(c.CreatedAt.Add(3 * time.Hour)) {
+	if time.Now().After(c.CreatedAt.Add(recoveryCodeTTL)) {
 		return errs.RecoveryCodeOutdated
 	}
 
 	r.ID = c.UserID
 
-	if _, err := s.repo.GetUserByID(r.ID); err != nil {
+	u, err := s.repo.GetUserByID(r.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := password.Validate(*r.NewPassword, u.Phone, u.Email); err != nil {
 		return err
 	}
 
 	// todo in case of password change delete current user session and invalidate refresh token
-	pwd, err := HashAndSalt(*r.NewPassword)
+	pwd, err := s.passwords.Hash(*r.NewPassword)
 	if err != nil {
 		s.log.Error("error hashing password: %w", err)
 		return err
@@ -289,17 +430,42 @@ func (s *Service) ResetPassword(ctx context.Context, code string, r *UserUpdate)
 
 	r.Password = &pwd
 
-	return s.repo.ResetPassword(c.ID, r)
+	msgs, err := s.events(r.ID, EventPasswordChanged, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.ResetPassword(c.ID, r, msgs...); err != nil {
+		return err
+	}
+
+	// the owner has proven the access to the email, so the guessing of the old password is over
+	if s.unlocker != nil {
+		if err := s.unlocker.Unlock(u.Phone); err != nil {
+			s.log.Error("failed to unlock login: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) IsAdmin(_ context.Context, id uint) (bool, error) {
+	u, err := s.repo.GetUserByID(id)
+	if err != nil {
+		return false, err
+	}
+
+	return u.Role == AdminUserRole, nil
 }
 
-func (s *Service) AdminResetApartment(_ context.Context, adminID, buildingID, apartmentNumber uint) (string, error) {
+func (s *Service) AdminResetApartment(ctx context.Context, adminID, buildingID, apartmentNumber uint) (string, error) {
 	admin, err := s.repo.GetUserByID(adminID)
 	if err != nil {
 		s.log.Error("error getting admin user: %w", err)
 		return "", errs.UserNotFound
 	}
 
-	if admin.Role != 1 {
+	if admin.Role != AdminUserRole {
 		return "", errs.InsufficientPermissions
 	}
 
@@ -322,20 +488,65 @@ func (s *Service) AdminResetApartment(_ context.Context, adminID, buildingID, ap
 		Active:     false,
 	}
 
-	return s.repo.AdminResetApartment(target.ID, &placeholder)
-}
+	// family members are deleted together with the master account
+	var members []*User
+	if s.avatars != nil {
+		if members, err = s.repo.GetFamilyMembers(target.ID); err != nil {
+			s.log.Error("error getting family members: %w", err)
+			return "", err
+		}
+	}
 
-func HashAndSalt(pwd string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(pwd), bcrypt.MinCost)
+	code, err := s.repo.AdminResetApartment(target.ID, &placeholder)
 	if err != nil {
 		return "", err
 	}
-	return string(hash), nil
+
+	s.deleteAvatar(ctx, target.Avatar)
+	for i := range members {
+		s.deleteAvatar(ctx, members[i].Avatar)
+	}
+
+	return code, nil
+}
+
+// events returns the outbox message of the user event, none without the notifier.
+func (s *Service) events(userID uint, event string, data map[string]string) ([]*outbox.Message, error) {
+	if s.notifier == nil {
+		return nil, nil
+	}
+
+	msg, err := s.notifier.EventMessage(userID, event, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return []*outbox.Message{msg}, nil
+}
+
+// rehash updates the outdated hash of the password, the login goes on if it fails.
+func (s *Service) rehash(u *User, pwd string) {
+	if !s.passwords.NeedsRehash(u.Password) {
+		return
+	}
+
+	hash, err := s.passwords.Hash(pwd)
+	if err != nil {
+		s.log.Error("error hashing password: %w", err)
+		return
+	}
+
+	if err := s.repo.UpdateUser(&UserUpdate{ID: u.ID, Password: &hash}); err != nil {
+		s.log.Error("error rehashing password: %w", err)
+		return
+	}
+
+	u.Password = hash
 }
 
-func comparePasswords(p1, p2 string) error {
-	if err := bcrypt.CompareHashAndPassword([]byte(p1), []byte(p2)); err != nil {
-		if err == bcrypt.ErrMismatchedHashAndPassword {
+func (s *Service) comparePasswords(hash, pwd string) error {
+	if err := s.passwords.Verify(hash, pwd); err != nil {
+		if errors.Is(err, password.ErrMismatch) {
 			return errs.InvalidCredentials
 		}
 		return err
@@ -0,0 +1,76 @@
+package users
+
+import (
+	"context"
+	"errors"
+)
+
+var errAvatarsDisabled = errors.New("avatars are not configured")
+
+// UploadAvatar replaces the profile photo of the user.
+func (s *Service) UploadAvatar(ctx context.Context, userID uint, file []byte) (*User, error) {
+	if s.avatars == nil {
+		return nil, errAvatarsDisabled
+	}
+
+	u, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		s.log.Error("error getting user from db: %w", err)
+		return nil, err
+	}
+
+	filename, err := s.avatars.SaveAvatar(ctx, userID, file)
+	if err != nil {
+		s.log.Error("error saving avatar: %w", err)
+		return nil, err
+	}
+
+	if err := s.repo.UpdateUser(&UserUpdate{ID: userID, Avatar: &filename}); err != nil {
+		s.deleteAvatar(ctx, filename)
+		return nil, err
+	}
+
+	s.deleteAvatar(ctx, u.Avatar)
+
+	u.Avatar = filename
+	u.AvatarURL = s.avatars.AvatarURLs(ctx, filename)
+
+	return u, nil
+}
+
+// DeleteAvatar removes the profile photo of the user.
+func (s *Service) DeleteAvatar(ctx context.Context, userID uint) error {
+	if s.avatars == nil {
+		return errAvatarsDisabled
+	}
+
+	u, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		s.log.Error("error getting user from db: %w", err)
+		return err
+	}
+
+	if u.Avatar == "" {
+		return nil
+	}
+
+	empty := ""
+	if err := s.repo.UpdateUser(&UserUpdate{ID: userID, Avatar: &empty}); err != nil {
+		return err
+	}
+
+	s.deleteAvatar(ctx, u.Avatar)
+
+	return nil
+}
+
+// deleteAvatar removes the stored photo, the file left on failure is collected by the image reconciliation.
+func (s *Service) deleteAvatar(ctx context.Context, filename string) {
+	if s.avatars == nil || filename == "" {
+		return
+	}
+
+	if err := s.avatars.DeleteAvatar(ctx, filename); err != nil {
+		s.log.Error("error deleting avatar %s: %w", filename, err)
+	}
+}
@@ -0,0 +1,202 @@
+package users_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/ivch/dynasty/server/handlers/outbox"
+	"github.com/ivch/dynasty/server/handlers/users"
+)
+
+func avatarStore(deleted *[]string) *users.AvatarStoreMock {
+	return &users.AvatarStoreMock{
+		SaveAvatarFunc: func(_ context.Context, _ uint, _ []byte) (string, error) {
+			return "new.jpg", nil
+		},
+		DeleteAvatarFunc: func(_ context.Context, filename string) error {
+			*deleted = append(*deleted, filename)
+			return nil
+		},
+		AvatarURLsFunc: func(_ context.Context, filename string) map[string]string {
+			return map[string]string{"img": "i/" + filename, "thumb": "t/" + filename}
+		},
+	}
+}
+
+func TestService_UploadAvatar(t *testing.T) {
+	tests := []struct {
+		name        string
+		repo        *users.UserRepositoryMock
+		saveErr     bool
+		wantErr     bool
+		want        *users.User
+		wantDeleted []string
+	}{
+		{
+			name: "error getting user",
+			repo: &users.UserRepositoryMock{
+				GetUserByIDFunc: func(_ uint) (*users.User, error) {
+					return nil, errTestError
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error saving avatar",
+			repo: &users.UserRepositoryMock{
+				GetUserByIDFunc: func(id uint) (*users.User, error) {
+					return &users.User{ID: id}, nil
+				},
+			},
+			saveErr: true,
+			wantErr: true,
+		},
+		{
+			name: "error updating user removes new avatar",
+			repo: &users.UserRepositoryMock{
+				GetUserByIDFunc: func(id uint) (*users.User, error) {
+					return &users.User{ID: id, Avatar: "old.jpg"}, nil
+				},
+				UpdateUserFunc: func(_ *users.UserUpdate, _ ...*outbox.Message) error {
+					return errTestError
+				},
+			},
+			wantErr:     true,
+			wantDeleted: []string{"new.jpg"},
+		},
+		{
+			name: "ok replaces old avatar",
+			repo: &users.UserRepositoryMock{
+				GetUserByIDFunc: func(id uint) (*users.User, error) {
+					return &users.User{ID: id, Avatar: "old.jpg"}, nil
+				},
+				UpdateUserFunc: func(u *users.UserUpdate, _ ...*outbox.Message) error {
+					if u.Avatar == nil || *u.Avatar != "new.jpg" {
+						t.Errorf("UploadAvatar() wrong update %v", u.Avatar)
+					}
+					return nil
+				},
+			},
+			want: &users.User{
+				ID:        1,
+				Avatar:    "new.jpg",
+				AvatarURL: map[string]string{"img": "i/new.jpg", "thumb": "t/new.jpg"},
+			},
+			wantDeleted: []string{"old.jpg"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				deleted []string
+				store   = avatarStore(&deleted)
+			)
+
+			if tt.saveErr {
+				store.SaveAvatarFunc = func(_ context.Context, _ uint, _ []byte) (string, error) {
+					return "", errTestError
+				}
+			}
+
+			s := users.New(defaultLogger, tt.repo, false, 0, nil, users.WithAvatars(store))
+			got, err := s.UploadAvatar(context.Background(), 1, []byte("photo"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UploadAvatar() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UploadAvatar() got = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(deleted, tt.wantDeleted) {
+				t.Errorf("UploadAvatar() deleted = %v, want %v", deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestService_DeleteAvatar(t *testing.T) {
+	tests := []struct {
+		name        string
+		avatar      string
+		updateErr   bool
+		wantErr     bool
+		wantDeleted []string
+	}{
+		{name: "ok no avatar"},
+		{name: "error updating user", avatar: "old.jpg", updateErr: true, wantErr: true},
+		{name: "ok", avatar: "old.jpg", wantDeleted: []string{"old.jpg"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deleted []string
+
+			repo := &users.UserRepositoryMock{
+				GetUserByIDFunc: func(id uint) (*users.User, error) {
+					return &users.User{ID: id, Avatar: tt.avatar}, nil
+				},
+				UpdateUserFunc: func(u *users.UserUpdate, _ ...*outbox.Message) error {
+					if tt.updateErr {
+						return errTestError
+					}
+					if u.Avatar == nil || *u.Avatar != "" {
+						t.Errorf("DeleteAvatar() wrong update %v", u.Avatar)
+					}
+					return nil
+				},
+			}
+
+			s := users.New(defaultLogger, repo, false, 0, nil, users.WithAvatars(avatarStore(&deleted)))
+			if err := s.DeleteAvatar(context.Background(), 1); (err != nil) != tt.wantErr {
+				t.Fatalf("DeleteAvatar() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(deleted, tt.wantDeleted) {
+				t.Errorf("DeleteAvatar() deleted = %v, want %v", deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestService_AvatarDeletedWithUser(t *testing.T) {
+	owner := uint(2)
+
+	var deleted []string
+	repo := &users.UserRepositoryMock{
+		GetUserByIDFunc: func(id uint) (*users.User, error) {
+			if id == 1 {
+				return &users.User{ID: 1, Role: users.AdminUserRole}, nil
+			}
+			return &users.User{ID: id, ParentID: &owner, Avatar: "member.jpg"}, nil
+		},
+		DeleteUserFunc: func(_ *users.User) error {
+			return nil
+		},
+		FindUserByApartmentFunc: func(_ uint, _ uint) (*users.User, error) {
+			return &users.User{ID: owner, Avatar: "owner.jpg"}, nil
+		},
+		GetFamilyMembersFunc: func(_ uint) ([]*users.User, error) {
+			return []*users.User{{ID: 3, Avatar: "member.jpg"}, {ID: 4}}, nil
+		},
+		AdminResetApartmentFunc: func(_ uint, _ *users.User) (string, error) {
+			return "code", nil
+		},
+	}
+
+	s := users.New(defaultLogger, repo, false, 0, nil, users.WithAvatars(avatarStore(&deleted)))
+
+	if err := s.DeleteFamilyMember(context.Background(), owner, 3); err != nil {
+		t.Fatalf("DeleteFamilyMember() error = %v", err)
+	}
+	if !reflect.DeepEqual(deleted, []string{"member.jpg"}) {
+		t.Errorf("DeleteFamilyMember() deleted = %v", deleted)
+	}
+
+	deleted = nil
+	if _, err := s.AdminResetApartment(context.Background(), 1, 1, 1); err != nil {
+		t.Fatalf("AdminResetApartment() error = %v", err)
+	}
+	if !reflect.DeepEqual(deleted, []string{"owner.jpg", "member.jpg"}) {
+		t.Errorf("AdminResetApartment() deleted = %v", deleted)
+	}
+}
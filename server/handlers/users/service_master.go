@@ -2,6 +2,7 @@ package users
 
 import (
 	"context"
+	"strings"
 
 	"github.com/ivch/dynasty/common/errs"
 )
@@ -69,7 +70,38 @@ func (s *Service) ListFamilyMembers(_ context.Context, id uint) ([]*User, error)
 	return list, nil
 }
 
-func (s *Service) DeleteFamilyMember(_ context.Context, ownerID, memberID uint) error {
+// ApartmentMembers returns the active master account of the apartment together with its active family members.
+func (s *Service) ApartmentMembers(_ context.Context, buildingID, apartment uint) ([]*User, error) {
+	owner, err := s.repo.FindUserByApartment(buildingID, apartment)
+	if err != nil {
+		s.log.Error("error getting user by apt: %w", err)
+		return nil, err
+	}
+
+	if owner == nil || owner.Role == PredefinedUserRole {
+		return nil, nil
+	}
+
+	members, err := s.repo.GetFamilyMembers(owner.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*User, 0, len(members)+1)
+	if owner.Active {
+		res = append(res, owner)
+	}
+
+	for i := range members {
+		if members[i].Active {
+			res = append(res, members[i])
+		}
+	}
+
+	return res, nil
+}
+
+func (s *Service) DeleteFamilyMember(ctx context.Context, ownerID, memberID uint) error {
 	member, err := s.repo.GetUserByID(memberID)
 	if err != nil {
 		return err
@@ -79,10 +111,16 @@ func (s *Service) DeleteFamilyMember(_ context.Context, ownerID, memberID uint)
 		return errs.FamilyMemberWrongOwner
 	}
 
-	return s.repo.DeleteUser(member)
+	if err := s.repo.DeleteUser(member); err != nil {
+		return err
+	}
+
+	s.deleteAvatar(ctx, member.Avatar)
+
+	return nil
 }
 
-func (s *Service) registerFamilyMember(_ context.Context, request *User, member *User) (*User, error) {
+func (s *Service) registerFamilyMember(ctx context.Context, request *User, member *User) (*User, error) {
 	if request.RegCode != member.RegCode {
 		return nil, errs.RegCodeWrong
 	}
@@ -101,7 +139,7 @@ func (s *Service) registerFamilyMember(_ context.Context, request *User, member
 		return nil, errs.FamilyMemberWrongAddress
 	}
 
-	pwd, err := HashAndSalt(request.Password)
+	pwd, err := s.passwords.Hash(request.Password)
 	if err != nil {
 		s.log.Error("error hashing password: %w", err)
 		return nil, err
@@ -118,7 +156,15 @@ func (s *Service) registerFamilyMember(_ context.Context, request *User, member
 		Active:    &member.Active,
 	}
 
-	if err := s.repo.UpdateUser(&update); err != nil {
+	msgs, err := s.events(parent.ID, EventFamilyMemberJoined, map[string]string{
+		"member": strings.TrimSpace(request.FirstName + " " + request.LastName),
+		"phone":  member.Phone,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateUser(&update, msgs...); err != nil {
 		return nil, err
 	}
 
@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/ivch/dynasty/server/handlers/outbox"
 	"github.com/ivch/dynasty/server/handlers/users"
 )
 
@@ -198,6 +199,79 @@ func TestService_ListFamilyMembers(t *testing.T) {
 	}
 }
 
+func TestService_ApartmentMembers(t *testing.T) {
+	tests := []struct {
+		name    string
+		repo    users.UserRepository
+		wantErr bool
+		want    []*users.User
+	}{
+		{
+			name: "error getting owner",
+			repo: &users.UserRepositoryMock{
+				FindUserByApartmentFunc: func(_ uint, _ uint) (*users.User, error) {
+					return nil, errTestError
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "no owner",
+			repo: &users.UserRepositoryMock{
+				FindUserByApartmentFunc: func(_ uint, _ uint) (*users.User, error) {
+					return nil, nil
+				},
+			},
+		},
+		{
+			name: "predefined owner",
+			repo: &users.UserRepositoryMock{
+				FindUserByApartmentFunc: func(_ uint, _ uint) (*users.User, error) {
+					return &users.User{ID: 1, Role: users.PredefinedUserRole}, nil
+				},
+			},
+		},
+		{
+			name: "error getting members",
+			repo: &users.UserRepositoryMock{
+				FindUserByApartmentFunc: func(_ uint, _ uint) (*users.User, error) {
+					return &users.User{ID: 1, Active: true}, nil
+				},
+				GetFamilyMembersFunc: func(_ uint) ([]*users.User, error) {
+					return nil, errTestError
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "ok only active",
+			repo: &users.UserRepositoryMock{
+				FindUserByApartmentFunc: func(_ uint, _ uint) (*users.User, error) {
+					return &users.User{ID: 1, Active: true}, nil
+				},
+				GetFamilyMembersFunc: func(_ uint) ([]*users.User, error) {
+					return []*users.User{{ID: 2, Active: true}, {ID: 3}}, nil
+				},
+			},
+			want: []*users.User{{ID: 1, Active: true}, {ID: 2, Active: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := users.New(defaultLogger, tt.repo, false, 0, nil)
+			got, err := s.ApartmentMembers(context.Background(), 1, 1)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ApartmentMembers() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+				t.Errorf("ApartmentMembers() got = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestService_AddFamilyMember(t *testing.T) {
 	type fields struct {
 		verifyRegCode bool
@@ -558,7 +632,7 @@ func TestService_familyMemberRegister(t *testing.T) {
 					GetUserByIDFunc: func(_ uint) (*users.User, error) {
 						return &users.User{}, nil
 					},
-					UpdateUserFunc: func(_ *users.UserUpdate) error {
+					UpdateUserFunc: func(_ *users.UserUpdate, _ ...*outbox.Message) error {
 						return errTestError
 					},
 				},
@@ -585,7 +659,7 @@ func TestService_familyMemberRegister(t *testing.T) {
 					GetUserByIDFunc: func(_ uint) (*users.User, error) {
 						return &users.User{}, nil
 					},
-					UpdateUserFunc: func(u *users.UserUpdate) error {
+					UpdateUserFunc: func(u *users.UserUpdate, _ ...*outbox.Message) error {
 						return nil
 					},
 				},
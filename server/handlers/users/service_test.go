@@ -10,8 +10,13 @@ import (
 	"testing"
 	"time"
 
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/ivch/dynasty/common/errs"
 	"github.com/ivch/dynasty/common/logger"
+	"github.com/ivch/dynasty/server/handlers/outbox"
 	"github.com/ivch/dynasty/server/handlers/users"
+	"github.com/ivch/dynasty/server/handlers/users/password"
 )
 
 var (
@@ -303,7 +308,7 @@ func TestService_Register(t *testing.T) {
 					FindUserByApartmentFunc: func(_ uint, _ uint) (*users.User, error) {
 						return &users.User{Role: users.PredefinedUserRole, RegCode: "abc"}, nil
 					},
-					UpdateUserFunc: func(_ *users.UserUpdate) error {
+					UpdateUserFunc: func(_ *users.UserUpdate, _ ...*outbox.Message) error {
 						return errTestError
 					},
 				},
@@ -325,7 +330,7 @@ func TestService_Register(t *testing.T) {
 					FindUserByApartmentFunc: func(_ uint, _ uint) (*users.User, error) {
 						return &users.User{ID: 1, Role: users.PredefinedUserRole, RegCode: "abc"}, nil
 					},
-					UpdateUserFunc: func(_ *users.UserUpdate) error {
+					UpdateUserFunc: func(_ *users.UserUpdate, _ ...*outbox.Message) error {
 						return nil
 					},
 				},
@@ -361,6 +366,12 @@ func TestService_Register(t *testing.T) {
 }
 
 func TestService_UserByPhoneAndPassword(t *testing.T) {
+	current := hashPassword(t, "1")
+	legacy, err := bcrypt.GenerateFromPassword([]byte("1"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	type params struct {
 		verifyRegCode bool
 		maxMembers    int
@@ -373,11 +384,12 @@ func TestService_UserByPhoneAndPassword(t *testing.T) {
 	}
 
 	tests := []struct {
-		name    string
-		params  params
-		input   input
-		wantErr bool
-		want    *users.User
+		name       string
+		params     params
+		input      input
+		wantErr    bool
+		want       *users.User
+		wantRehash bool
 	}{
 		{
 			name: "error no user",
@@ -400,8 +412,7 @@ func TestService_UserByPhoneAndPassword(t *testing.T) {
 			params: params{
 				repo: &users.UserRepositoryMock{
 					GetUserByPhoneFunc: func(_ string) (*users.User, error) {
-						p, _ := users.HashAndSalt("1")
-						return &users.User{Password: p}, nil
+						return &users.User{Password: current}, nil
 					},
 				},
 			},
@@ -417,8 +428,7 @@ func TestService_UserByPhoneAndPassword(t *testing.T) {
 			params: params{
 				repo: &users.UserRepositoryMock{
 					GetUserByPhoneFunc: func(_ string) (*users.User, error) {
-						p, _ := users.HashAndSalt("1")
-						return &users.User{ID: 1, FirstName: "a", LastName: "b", Role: 1, Password: p}, nil
+						return &users.User{ID: 1, FirstName: "a", LastName: "b", Role: 1, Password: current}, nil
 					},
 				},
 			},
@@ -434,6 +444,47 @@ func TestService_UserByPhoneAndPassword(t *testing.T) {
 				Role:      1,
 			},
 		},
+		{
+			name: "ok legacy hash rehashed",
+			params: params{
+				repo: &users.UserRepositoryMock{
+					GetUserByPhoneFunc: func(_ string) (*users.User, error) {
+						return &users.User{ID: 1, Password: string(legacy)}, nil
+					},
+					UpdateUserFunc: func(u *users.UserUpdate, _ ...*outbox.Message) error {
+						if cost, err := bcrypt.Cost([]byte(*u.Password)); u.ID != 1 || err != nil || cost != password.MinBcryptCost {
+							return errTestError
+						}
+						return nil
+					},
+				},
+			},
+			input: input{
+				phone:    "1",
+				password: "1",
+			},
+			want:       &users.User{ID: 1},
+			wantRehash: true,
+		},
+		{
+			name: "ok rehash failed",
+			params: params{
+				repo: &users.UserRepositoryMock{
+					GetUserByPhoneFunc: func(_ string) (*users.User, error) {
+						return &users.User{ID: 1, Password: string(legacy)}, nil
+					},
+					UpdateUserFunc: func(_ *users.UserUpdate, _ ...*outbox.Message) error {
+						return errTestError
+					},
+				},
+			},
+			input: input{
+				phone:    "1",
+				password: "1",
+			},
+			want:       &users.User{ID: 1},
+			wantRehash: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -451,6 +502,9 @@ func TestService_UserByPhoneAndPassword(t *testing.T) {
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("UserByPhoneAndPassword() got = %#v, want %#v", got, tt.want)
 			}
+			if repo := tt.params.repo.(*users.UserRepositoryMock); (len(repo.UpdateUserCalls()) == 1) != tt.wantRehash {
+				t.Errorf("UserByPhoneAndPassword() rehash calls = %d, want rehash %v", len(repo.UpdateUserCalls()), tt.wantRehash)
+			}
 		})
 	}
 }
@@ -549,7 +603,60 @@ func TestService_UserByID(t *testing.T) {
 	}
 }
 
+func TestService_UserByApartment(t *testing.T) {
+	tests := []struct {
+		name    string
+		repo    users.UserRepository
+		wantErr error
+		want    *users.User
+	}{
+		{
+			name: "error from db",
+			repo: &users.UserRepositoryMock{
+				FindUserByApartmentFunc: func(_ uint, _ uint) (*users.User, error) {
+					return nil, errTestError
+				},
+			},
+			wantErr: errTestError,
+		},
+		{
+			name: "error no user",
+			repo: &users.UserRepositoryMock{
+				FindUserByApartmentFunc: func(_ uint, _ uint) (*users.User, error) {
+					return nil, nil
+				},
+			},
+			wantErr: errs.UserNotFound,
+		},
+		{
+			name: "ok",
+			repo: &users.UserRepositoryMock{
+				FindUserByApartmentFunc: func(building uint, apt uint) (*users.User, error) {
+					return &users.User{ID: 1, BuildingID: building, Apartment: apt}, nil
+				},
+			},
+			want: &users.User{ID: 1, BuildingID: 1, Apartment: 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := users.New(defaultLogger, tt.repo, false, 0, nil)
+			got, err := s.UserByApartment(context.Background(), 1, 10)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("UserByApartment() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UserByApartment() got = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_ServiceUpdate(t *testing.T) {
+	testPass := hashPassword(t, "1")
+
 	type params struct {
 		verifyRegCode bool
 		maxMembers    int
@@ -557,10 +664,11 @@ func Test_ServiceUpdate(t *testing.T) {
 	}
 
 	tests := []struct {
-		name    string
-		params  params
-		input   *users.UserUpdate
-		wantErr bool
+		name         string
+		params       params
+		input        *users.UserUpdate
+		wantErr      bool
+		wantNotified bool
 	}{
 		{
 			name: "error finding user",
@@ -619,7 +727,7 @@ func Test_ServiceUpdate(t *testing.T) {
 							Password: "1",
 						}, nil
 					},
-					UpdateUserFunc: func(u *users.UserUpdate) error {
+					UpdateUserFunc: func(u *users.UserUpdate, _ ...*outbox.Message) error {
 						if u.NewPassword != nil {
 							if u.Password == u.NewPassword {
 								return errTestError
@@ -648,7 +756,7 @@ func Test_ServiceUpdate(t *testing.T) {
 					GetUserByIDFunc: func(_ uint) (*users.User, error) {
 						return nil, nil
 					},
-					UpdateUserFunc: func(u *users.UserUpdate) error {
+					UpdateUserFunc: func(u *users.UserUpdate, _ ...*outbox.Message) error {
 						return nil
 					},
 				},
@@ -663,12 +771,11 @@ func Test_ServiceUpdate(t *testing.T) {
 			params: params{
 				repo: &users.UserRepositoryMock{
 					GetUserByIDFunc: func(_ uint) (*users.User, error) {
-						testPass, _ := users.HashAndSalt("1")
 						return &users.User{
 							Password: testPass,
 						}, nil
 					},
-					UpdateUserFunc: func(u *users.UserUpdate) error {
+					UpdateUserFunc: func(u *users.UserUpdate, _ ...*outbox.Message) error {
 						if u.NewPassword != nil {
 							if u.Password == u.NewPassword {
 								return errTestError
@@ -686,19 +793,56 @@ func Test_ServiceUpdate(t *testing.T) {
 			input: &users.UserUpdate{
 				ID:          1,
 				Password:    func(s string) *string { return &s }("1"),
-				NewPassword: func(s string) *string { return &s }("3"),
+				NewPassword: func(s string) *string { return &s }("kyiv-gates-42"),
 			},
-			wantErr: false,
+			wantErr:      false,
+			wantNotified: true,
+		},
+		{
+			name: "error new password contains email",
+			params: params{
+				repo: &users.UserRepositoryMock{
+					GetUserByIDFunc: func(_ uint) (*users.User, error) {
+						return &users.User{
+							Phone:    "380001112233",
+							Email:    "jane.doe@example.com",
+							Password: testPass,
+						}, nil
+					},
+				},
+			},
+			input: &users.UserUpdate{
+				ID:          1,
+				Password:    func(s string) *string { return &s }("1"),
+				NewPassword: func(s string) *string { return &s }("Jane.Doe-2024"),
+			},
+			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := users.New(defaultLogger, tt.params.repo, tt.params.verifyRegCode, tt.params.maxMembers, nil)
+			notifier := &users.NotifierMock{
+				EventMessageFunc: func(_ uint, event string, _ map[string]string) (*outbox.Message, error) {
+					return &outbox.Message{Topic: event}, nil
+				},
+			}
+			s := users.New(defaultLogger, tt.params.repo, tt.params.verifyRegCode, tt.params.maxMembers, nil, users.WithNotifier(notifier))
 			err := s.Update(context.Background(), tt.input)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Update() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+
+			// the event is stored by the repository together with the update
+			var notified bool
+			if repo, ok := tt.params.repo.(*users.UserRepositoryMock); ok && err == nil {
+				for _, c := range repo.UpdateUserCalls() {
+					notified = notified || (len(c.Msgs) == 1 && c.Msgs[0].Topic == users.EventPasswordChanged)
+				}
+			}
+			if notified != tt.wantNotified {
+				t.Errorf("Update() notified = %v, wantNotified %v", notified, tt.wantNotified)
+			}
 		})
 	}
 }
@@ -813,36 +957,7 @@ func Test_ServiceRecovery(t *testing.T) {
 					CountRecoveryCodesByUserIn24hFunc: func(_ uint) (int, error) {
 						return 2, nil
 					},
-					CreateRecoverCodeFunc: func(_ *users.PasswordRecovery) error {
-						return errTestError
-					},
-				},
-			},
-			input: &users.User{
-				Phone: "1",
-				Email: "a",
-			},
-			wantErr: true,
-		},
-		{
-			name: "error send email",
-			params: params{
-				repo: &users.UserRepositoryMock{
-					GetUserByPhoneFunc: func(_ string) (*users.User, error) {
-						return &users.User{
-							ID:    1,
-							Email: "a",
-						}, nil
-					},
-					CountRecoveryCodesByUserIn24hFunc: func(_ uint) (int, error) {
-						return 2, nil
-					},
-					CreateRecoverCodeFunc: func(_ *users.PasswordRecovery) error {
-						return nil
-					},
-				},
-				email: &users.MailSenderMock{
-					SendRecoveryCodeEmailFunc: func(_, _, _ string) error {
+					CreateRecoverCodeFunc: func(_ *users.PasswordRecovery, _ *outbox.Message) error {
 						return errTestError
 					},
 				},
@@ -859,19 +974,20 @@ func Test_ServiceRecovery(t *testing.T) {
 				repo: &users.UserRepositoryMock{
 					GetUserByPhoneFunc: func(_ string) (*users.User, error) {
 						return &users.User{
-							ID:    1,
-							Email: "a",
+							ID:        1,
+							Email:     "a",
+							FirstName: "John",
+							LastName:  "Doe",
 						}, nil
 					},
 					CountRecoveryCodesByUserIn24hFunc: func(_ uint) (int, error) {
 						return 2, nil
 					},
-					CreateRecoverCodeFunc: func(_ *users.PasswordRecovery) error {
-						return nil
-					},
-				},
-				email: &users.MailSenderMock{
-					SendRecoveryCodeEmailFunc: func(_, _, _ string) error {
+					CreateRecoverCodeFunc: func(c *users.PasswordRecovery, email *outbox.Message) error {
+						want := `{"user_id":1,"lang":"en"}`
+						if email.Topic != users.TopicRecoveryEmail || email.Payload != want {
+							return errTestError
+						}
 						return nil
 					},
 				},
@@ -886,7 +1002,7 @@ func Test_ServiceRecovery(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := users.New(defaultLogger, tt.params.repo, tt.params.verifyRegCode, tt.params.maxMembers, tt.params.email)
-			err := s.RecoveryCode(context.Background(), tt.input)
+			err := s.RecoveryCode(context.Background(), tt.input, "en")
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Update() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -895,11 +1011,93 @@ func Test_ServiceRecovery(t *testing.T) {
 	}
 }
 
+func Test_ServiceSendRecoveryEmail(t *testing.T) {
+	now := time.Now()
+	outdated := now.Add(-4 * time.Hour)
+	tests := []struct {
+		name          string
+		payload       string
+		code          *users.PasswordRecovery
+		codeErr       error
+		sendErr       error
+		wantErr       bool
+		wantPermanent bool
+	}{
+		{
+			name:          "error bad payload",
+			payload:       `{`,
+			wantErr:       true,
+			wantPermanent: true,
+		},
+		{
+			name:    "error repo",
+			payload: `{"user_id":1,"lang":"ua"}`,
+			codeErr: errTestError,
+			wantErr: true,
+		},
+		{
+			name:          "error no active code",
+			payload:       `{"user_id":1,"lang":"ua"}`,
+			wantErr:       true,
+			wantPermanent: true,
+		},
+		{
+			name:          "error outdated code",
+			payload:       `{"user_id":1,"lang":"ua"}`,
+			code:          &users.PasswordRecovery{ID: 1, UserID: 1, Code: "CODE", CreatedAt: &outdated, Active: true},
+			wantErr:       true,
+			wantPermanent: true,
+		},
+		{
+			name:    "error send email",
+			payload: `{"user_id":1,"lang":"ua"}`,
+			code:    &users.PasswordRecovery{ID: 1, UserID: 1, Code: "CODE", CreatedAt: &now, Active: true},
+			sendErr: errTestError,
+			wantErr: true,
+		},
+		{
+			name:    "ok",
+			payload: `{"user_id":1,"lang":"ua"}`,
+			code:    &users.PasswordRecovery{ID: 1, UserID: 1, Code: "CODE", CreatedAt: &now, Active: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &users.UserRepositoryMock{
+				GetUserByIDFunc: func(id uint) (*users.User, error) {
+					return &users.User{ID: id, Email: "a", FirstName: "John", LastName: "Doe"}, nil
+				},
+				LastRecoveryCodeFunc: func(_ uint) (*users.PasswordRecovery, error) {
+					return tt.code, tt.codeErr
+				},
+			}
+			email := &users.MailSenderMock{
+				SendRecoveryCodeEmailFunc: func(to, username, code, lang string) error {
+					if to != "a" || username != "John Doe" || code != "CODE" || lang != "ua" {
+						return errTestError
+					}
+					return tt.sendErr
+				},
+			}
+			s := users.New(defaultLogger, repo, false, 0, email)
+			err := s.SendRecoveryEmail(context.Background(), []byte(tt.payload))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SendRecoveryEmail() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if outbox.IsPermanent(err) != tt.wantPermanent {
+				t.Errorf("SendRecoveryEmail() error = %v, wantPermanent %v", err, tt.wantPermanent)
+			}
+		})
+	}
+}
+
 func Test_ServiceResetPassword(t *testing.T) {
 	type params struct {
 		verifyRegCode bool
 		maxMembers    int
 		repo          users.UserRepository
+		unlocker      users.LoginUnlocker
 	}
 
 	type input struct {
@@ -963,6 +1161,29 @@ func Test_ServiceResetPassword(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "error password contains phone",
+			params: params{
+				repo: &users.UserRepositoryMock{
+					GetRecoveryCodeFunc: func(_ *users.PasswordRecovery) (*users.PasswordRecovery, error) {
+						return &users.PasswordRecovery{
+							UserID:    1,
+							CreatedAt: func(t time.Time) *time.Time { return &t }(time.Now()),
+						}, nil
+					},
+					GetUserByIDFunc: func(id uint) (*users.User, error) {
+						return &users.User{ID: id, Phone: "380001112233", Email: "jane@example.com"}, nil
+					},
+				},
+			},
+			input: input{
+				code: "1",
+				u: &users.UserUpdate{
+					NewPassword: func(s string) *string { return &s }("gate380001112233"),
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "error on reset password",
 			params: params{
@@ -973,10 +1194,10 @@ func Test_ServiceResetPassword(t *testing.T) {
 							CreatedAt: func(t time.Time) *time.Time { return &t }(time.Now()),
 						}, nil
 					},
-					GetUserByIDFunc: func(_ uint) (*users.User, error) {
-						return nil, nil
+					GetUserByIDFunc: func(id uint) (*users.User, error) {
+						return &users.User{ID: id, Phone: "380001112233", Email: "jane@example.com"}, nil
 					},
-					ResetPasswordFunc: func(_ uint, _ *users.UserUpdate) error {
+					ResetPasswordFunc: func(_ uint, _ *users.UserUpdate, _ ...*outbox.Message) error {
 						return errTestError
 					},
 				},
@@ -984,7 +1205,7 @@ func Test_ServiceResetPassword(t *testing.T) {
 			input: input{
 				code: "1",
 				u: &users.UserUpdate{
-					NewPassword: func(s string) *string { return &s }("1"),
+					NewPassword: func(s string) *string { return &s }("kyiv-gates-42"),
 				},
 			},
 			wantErr: true,
@@ -999,10 +1220,10 @@ func Test_ServiceResetPassword(t *testing.T) {
 							CreatedAt: func(t time.Time) *time.Time { return &t }(time.Now()),
 						}, nil
 					},
-					GetUserByIDFunc: func(_ uint) (*users.User, error) {
-						return nil, nil
+					GetUserByIDFunc: func(id uint) (*users.User, error) {
+						return &users.User{ID: id, Phone: "380001112233", Email: "jane@example.com"}, nil
 					},
-					ResetPasswordFunc: func(_ uint, _ *users.UserUpdate) error {
+					ResetPasswordFunc: func(_ uint, _ *users.UserUpdate, _ ...*outbox.Message) error {
 						return nil
 					},
 				},
@@ -1010,7 +1231,38 @@ func Test_ServiceResetPassword(t *testing.T) {
 			input: input{
 				code: "1",
 				u: &users.UserUpdate{
-					NewPassword: func(s string) *string { return &s }("1"),
+					NewPassword: func(s string) *string { return &s }("kyiv-gates-42"),
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "ok login unlocked",
+			params: params{
+				repo: &users.UserRepositoryMock{
+					GetRecoveryCodeFunc: func(_ *users.PasswordRecovery) (*users.PasswordRecovery, error) {
+						return &users.PasswordRecovery{
+							UserID:    1,
+							CreatedAt: func(t time.Time) *time.Time { return &t }(time.Now()),
+						}, nil
+					},
+					GetUserByIDFunc: func(id uint) (*users.User, error) {
+						return &users.User{ID: id, Phone: "380001112233"}, nil
+					},
+					ResetPasswordFunc: func(_ uint, _ *users.UserUpdate, _ ...*outbox.Message) error {
+						return nil
+					},
+				},
+				unlocker: &users.LoginUnlockerMock{
+					UnlockFunc: func(_ string) error {
+						return nil
+					},
+				},
+			},
+			input: input{
+				code: "1",
+				u: &users.UserUpdate{
+					NewPassword: func(s string) *string { return &s }("kyiv-gates-42"),
 				},
 			},
 			wantErr: false,
@@ -1018,12 +1270,21 @@ func Test_ServiceResetPassword(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := users.New(defaultLogger, tt.params.repo, tt.params.verifyRegCode, tt.params.maxMembers, nil)
+			var opts []users.Option
+			if tt.params.unlocker != nil {
+				opts = append(opts, users.WithLoginUnlocker(tt.params.unlocker))
+			}
+			s := users.New(defaultLogger, tt.params.repo, tt.params.verifyRegCode, tt.params.maxMembers, nil, opts...)
 			err := s.ResetPassword(context.Background(), tt.input.code, tt.input.u)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Update() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if m, ok := tt.params.unlocker.(*users.LoginUnlockerMock); ok {
+				if calls := m.UnlockCalls(); len(calls) != 1 || calls[0].Phone != "380001112233" {
+					t.Errorf("ResetPassword() unlock calls = %v", calls)
+				}
+			}
 		})
 	}
 }
@@ -1120,6 +1381,21 @@ func TestService_AdminResetApartment(t *testing.T) {
 }
 
 func Test_Smth(t *testing.T) {
-	pwd, _ := users.HashAndSalt("testdemopass")
-	fmt.Println(pwd)
+	fmt.Println(hashPassword(t, "testdemopass"))
+}
+
+func hashPassword(t *testing.T, pwd string) string {
+	t.Helper()
+
+	h, err := password.New(password.DefaultParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := h.Hash(pwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return hash
 }
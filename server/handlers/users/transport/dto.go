@@ -9,17 +9,22 @@ import (
 )
 
 type UserByIDResponse struct {
-	ID        uint            `json:"id"`
-	Apartment uint            `json:"apartment"`
-	FirstName string          `json:"first_name"`
-	LastName  string          `json:"last_name"`
-	Phone     string          `json:"phone"`
-	Email     string          `json:"email"`
-	Role      uint            `json:"role,omitempty"`
-	Building  *users.Building `json:"building"`
-	Entry     *users.Entry    `json:"entry,omitempty"`
-	Active    bool            `json:"active" gorm:"active"`
-	ParentID  *uint           `json:"parent_id,omitempty"`
+	ID        uint              `json:"id"`
+	Apartment uint              `json:"apartment"`
+	FirstName string            `json:"first_name"`
+	LastName  string            `json:"last_name"`
+	Phone     string            `json:"phone"`
+	Email     string            `json:"email"`
+	Role      uint              `json:"role,omitempty"`
+	Building  *users.Building   `json:"building"`
+	Entry     *users.Entry      `json:"entry,omitempty"`
+	Active    bool              `json:"active" gorm:"active"`
+	ParentID  *uint             `json:"parent_id,omitempty"`
+	Avatar    map[string]string `json:"avatar,omitempty"`
+}
+
+type avatarResponse struct {
+	Avatar map[string]string `json:"avatar"`
 }
 
 type errorResponse struct {
@@ -101,6 +106,7 @@ func (r *userUpdateRequest) Sanitize(p *bluemonday.Policy) {
 type passwordRecoveryRequest struct {
 	Email string `json:"email,omitempty"`
 	Phone string `json:"phone,omitempty"`
+	Lang  string `json:"lang,omitempty"`
 }
 
 type passwordResetRequest struct {
@@ -3,6 +3,7 @@ package transport
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net"
 	"net/http"
 	"regexp"
@@ -15,6 +16,7 @@ import (
 	"github.com/ivch/dynasty/common/errs"
 	"github.com/ivch/dynasty/common/logger"
 	"github.com/ivch/dynasty/server/handlers/users"
+	"github.com/ivch/dynasty/server/handlers/users/password"
 	"github.com/ivch/dynasty/server/middlewares"
 )
 
@@ -27,11 +29,15 @@ type UsersService interface {
 	AddFamilyMember(ctx context.Context, r *users.User) (*users.User, error)
 	ListFamilyMembers(ctx context.Context, id uint) ([]*users.User, error)
 	DeleteFamilyMember(ctx context.Context, ownerID, memberID uint) error
-	RecoveryCode(ctx context.Context, r *users.User) error
+	RecoveryCode(ctx context.Context, r *users.User, lang string) error
 	ResetPassword(ctx context.Context, code string, r *users.UserUpdate) error
 	AdminResetApartment(ctx context.Context, adminID, buildingID, apartmentNumber uint) (string, error)
+	UploadAvatar(ctx context.Context, userID uint, file []byte) (*users.User, error)
+	DeleteAvatar(ctx context.Context, userID uint) error
 }
 
+const maxAvatarSize = 10 << 20 // 10 MB
+
 type HTTPTransport struct {
 	svc       UsersService
 	log       logger.Logger
@@ -53,6 +59,8 @@ func NewHTTPTransport(log logger.Logger, svc UsersService, p *bluemonday.Policy,
 func (h *HTTPTransport) attachRoutes() {
 	h.router.Get("/v1/user", h.UserByID)
 	h.router.Put("/v1/user", h.Update)
+	h.router.Post("/v1/user/avatar", h.UploadAvatar)
+	h.router.Delete("/v1/user/avatar", h.DeleteAvatar)
 	h.router.Post("/v1/register", h.Register)
 	h.router.Post("/v1/member", h.AddFamilyMember)
 	h.router.Get("/v1/members", h.FamilyMembersList)
@@ -153,17 +161,12 @@ func (h *HTTPTransport) Update(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		if err := validatePassword(*req.NewPassword); err != nil {
-			h.sendError(w, http.StatusBadRequest, err)
-			return
-		}
-
 		data.Password = req.Password
 		data.NewPassword = req.NewPassword
 	}
 
 	if err := h.svc.Update(r.Context(), &data); err != nil {
-		h.sendError(w, http.StatusInternalServerError, err)
+		h.sendError(w, passwordErrorStatus(err), err)
 		return
 	}
 
@@ -200,11 +203,82 @@ func (h *HTTPTransport) UserByID(w http.ResponseWriter, r *http.Request) {
 		Role:      res.Role,
 		Active:    res.Active,
 		ParentID:  res.ParentID,
+		Avatar:    res.AvatarURL,
 	}
 
 	h.sendHTTPResponse(r.Context(), w, result)
 }
 
+func (h *HTTPTransport) UploadAvatar(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxAvatarSize+512)
+	// #nosec G120 -- ParseMultipartForm is bounded by maxAvatarSize (10MB) and MaxBytesReader protects against unbounded requests
+	if err := r.ParseMultipartForm(maxAvatarSize); err != nil {
+		h.log.Error("error parsing file: %w", err)
+		h.sendError(w, http.StatusBadRequest, errs.BadRequest)
+		return
+	}
+	defer func() {
+		if err := r.MultipartForm.RemoveAll(); err != nil {
+			h.log.Error("failed to free multipart resources: %v", err)
+		}
+	}()
+
+	file, header, err := r.FormFile("photo")
+	if err != nil {
+		h.log.Error("error reading file: %w", err)
+		h.sendError(w, http.StatusBadRequest, errs.NoFile)
+		return
+	}
+	defer file.Close() // nolint: errcheck
+
+	// per-type limits are checked by the service
+	if header.Size > maxAvatarSize {
+		h.sendError(w, http.StatusBadRequest, errs.FileIsTooBig)
+		return
+	}
+
+	fileBytes, err := io.ReadAll(file)
+	if err != nil {
+		h.log.Error("failed reading file content: %w", err)
+		h.sendError(w, http.StatusBadRequest, errs.BadRequest)
+		return
+	}
+
+	u, err := h.svc.UploadAvatar(r.Context(), userID, fileBytes)
+	if err != nil {
+		switch err {
+		case errs.FileWrongType, errs.FileIsTooBig:
+			h.sendError(w, http.StatusBadRequest, err)
+		default:
+			h.sendError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	h.sendHTTPResponse(r.Context(), w, avatarResponse{Avatar: u.AvatarURL})
+}
+
+func (h *HTTPTransport) DeleteAvatar(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusUnauthorized, errs.Unauthorized)
+		return
+	}
+
+	if err := h.svc.DeleteAvatar(r.Context(), userID); err != nil {
+		h.sendError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.sendHTTPResponse(r.Context(), w, nil)
+}
+
 func (h *HTTPTransport) DeleteFamilyMember(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserID(r.Context())
 	if err != nil {
@@ -327,7 +401,7 @@ func (h *HTTPTransport) PasswordRecovery(w http.ResponseWriter, r *http.Request)
 	if err := h.svc.RecoveryCode(r.Context(), &users.User{
 		Email: req.Email,
 		Phone: req.Phone,
-	}); err != nil {
+	}, req.Lang); err != nil {
 		h.sendError(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -363,15 +437,10 @@ func (h *HTTPTransport) PasswordReset(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := validatePassword(req.NewPassword); err != nil {
-		h.sendError(w, http.StatusBadRequest, err)
-		return
-	}
-
 	data.NewPassword = &req.NewPassword
 
 	if err := h.svc.ResetPassword(r.Context(), req.Code, &data); err != nil {
-		h.sendError(w, http.StatusInternalServerError, err)
+		h.sendError(w, passwordErrorStatus(err), err)
 		return
 	}
 
@@ -435,6 +504,16 @@ func (h *HTTPTransport) sendHTTPResponse(_ context.Context, w http.ResponseWrite
 	}
 }
 
+// passwordErrorStatus tells the new password rejected by the policy from the failure of the service.
+func passwordErrorStatus(err error) int {
+	switch err {
+	case errs.PasswordTooShort, errs.PasswordTooLong, errs.PasswordTooWeak:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 func (h *HTTPTransport) sendError(w http.ResponseWriter, httpCode int, err error) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(httpCode)
@@ -464,13 +543,6 @@ func (h *HTTPTransport) sendError(w http.ResponseWriter, httpCode int, err error
 	}
 }
 
-func validatePassword(p string) error {
-	if len(p) < 6 {
-		return errs.PasswordTooShort
-	}
-	return nil
-}
-
 func validatePhone(p string) error {
 	if len(p) < 12 || len(p) > 13 {
 		return errs.PhoneWrongLength
@@ -484,7 +556,7 @@ func validatePhone(p string) error {
 }
 
 func validateRegisterRequest(r *userRegisterRequest) error {
-	if err := validatePassword(r.Password); err != nil {
+	if err := password.Validate(r.Password, r.Phone, r.Email); err != nil {
 		return err
 	}
 
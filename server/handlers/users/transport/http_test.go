@@ -1,9 +1,11 @@
 package transport_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -148,76 +150,88 @@ func TestHTTP_Register(t *testing.T) {
 			request: `{"email":"test@test.com","first_name":"John","last_name":"Doe","apartment":1,"password":"1213", "phone":"380671234567","building_id": 2, "code":"1231"}`,
 			wantErr: true,
 		},
+		{
+			name:    "error password too weak",
+			svc:     nil,
+			request: `{"email":"test@test.com","first_name":"John","last_name":"Doe","apartment":1,"password":"qwerty123", "phone":"380671234567","building_id": 2, "code":"1231"}`,
+			wantErr: true,
+		},
+		{
+			name:    "error password contains phone",
+			svc:     nil,
+			request: `{"email":"test@test.com","first_name":"John","last_name":"Doe","apartment":1,"password":"x380671234567", "phone":"380671234567","building_id": 2, "code":"1231"}`,
+			wantErr: true,
+		},
 		{
 			name:    "error to short phone",
 			svc:     nil,
-			request: `{"email":"test@test.com","first_name":"John","phone":"12345","last_name":"Doe","apartment":1,"password":"1213123","building_id": 2, "code":"1231"}`,
+			request: `{"email":"test@test.com","first_name":"John","phone":"12345","last_name":"Doe","apartment":1,"password":"kyiv-gates-42","building_id": 2, "code":"1231"}`,
 			wantErr: true,
 		},
 		{
 			name:    "error wrong phone",
 			svc:     nil,
-			request: `{"email":"test@test.com","first_name":"John","phone":"123asd123asd","last_name":"Doe","apartment":1,"password":"1213123","building_id": 2, "code":"1231"}`,
+			request: `{"email":"test@test.com","first_name":"John","phone":"123asd123asd","last_name":"Doe","apartment":1,"password":"kyiv-gates-42","building_id": 2, "code":"1231"}`,
 			wantErr: true,
 		},
 		{
 			name:    "error invalid first name",
 			svc:     nil,
-			request: `{"email":"test@test.com","last_name":"Doe","apartment":1,"password":"1213123", "phone":"380671234567","building_id": 2, "code":"1231"}`,
+			request: `{"email":"test@test.com","last_name":"Doe","apartment":1,"password":"kyiv-gates-42", "phone":"380671234567","building_id": 2, "code":"1231"}`,
 			wantErr: true,
 		},
 		{
 			name:    "error invalid last name",
 			svc:     nil,
-			request: `{"email":"test@test.com","first_name":"John","apartment":1,"password":"1213123", "phone":"380671234567","building_id": 2, "code":"1231"}`,
+			request: `{"email":"test@test.com","first_name":"John","apartment":1,"password":"kyiv-gates-42", "phone":"380671234567","building_id": 2, "code":"1231"}`,
 			wantErr: true,
 		},
 		{
 			name:    "error invalid building",
 			svc:     nil,
-			request: `{"email":"test@test.com","first_name":"John","last_name":"Doe","apartment":1,"password":"1213123", "phone":"380671234567", "code":"1231"}`,
+			request: `{"email":"test@test.com","first_name":"John","last_name":"Doe","apartment":1,"password":"kyiv-gates-42", "phone":"380671234567", "code":"1231"}`,
 			wantErr: true,
 		},
 		{
 			name:    "error invalid entry",
 			svc:     nil,
-			request: `{"email":"test@test.com","first_name":"John","last_name":"Doe","password":"1213123", "phone":"380671234567","building_id": 2,"code":"1231"}`,
+			request: `{"email":"test@test.com","first_name":"John","last_name":"Doe","password":"kyiv-gates-42", "phone":"380671234567","building_id": 2,"code":"1231"}`,
 			wantErr: true,
 		},
 		{
 			name:    "error invalid apartment",
 			svc:     nil,
-			request: `{"email":"test@test.com","first_name":"John","last_name":"Doe","password":"1213123", "phone":"380671234567","building_id": 2, "entry_id": 1, "code":"1231"}`,
+			request: `{"email":"test@test.com","first_name":"John","last_name":"Doe","password":"kyiv-gates-42", "phone":"380671234567","building_id": 2, "entry_id": 1, "code":"1231"}`,
 			wantErr: true,
 		},
 		{
 			name:    "error invalid apartment #2",
 			svc:     nil,
-			request: `{"email":"test@test.com","first_name":"John","last_name":"Doe","password":"1213123", "phone":"380671234567","building_id": 2, "entry_id": 1, "code":"1231", "apartment":12312312"}`,
+			request: `{"email":"test@test.com","first_name":"John","last_name":"Doe","password":"kyiv-gates-42", "phone":"380671234567","building_id": 2, "entry_id": 1, "code":"1231", "apartment":12312312"}`,
 			wantErr: true,
 		},
 		{
 			name:    "error invalid email",
 			svc:     nil,
-			request: `{"first_name":"John","last_name":"Doe","apartment":1, "entry_id": 1,"password":"1213123", "phone":"380671234567","building_id": 2, "code":"1231"}`,
+			request: `{"first_name":"John","last_name":"Doe","apartment":1, "entry_id": 1,"password":"kyiv-gates-42", "phone":"380671234567","building_id": 2, "code":"1231"}`,
 			wantErr: true,
 		},
 		{
 			name:    "error invalid email#2",
 			svc:     nil,
-			request: `{"email":"testst.com","first_name":"John","last_name":"Doe","apartment":1, "entry_id": 1,"password":"1213123", "phone":"380671234567","building_id": 2, "code":"1231"}`,
+			request: `{"email":"testst.com","first_name":"John","last_name":"Doe","apartment":1, "entry_id": 1,"password":"kyiv-gates-42", "phone":"380671234567","building_id": 2, "code":"1231"}`,
 			wantErr: true,
 		},
 		{
 			name:    "error invalid email#3",
 			svc:     nil,
-			request: `{"email":"te","first_name":"John","last_name":"Doe","apartment":1, "entry_id": 1,"password":"1213123", "phone":"380671234567","building_id": 2, "code":"1231"}`,
+			request: `{"email":"te","first_name":"John","last_name":"Doe","apartment":1, "entry_id": 1,"password":"kyiv-gates-42", "phone":"380671234567","building_id": 2, "code":"1231"}`,
 			wantErr: true,
 		},
 		{
 			name:    "error invalid email#4",
 			svc:     nil,
-			request: `{"email":"test@test.com","first_name":"John","last_name":"Doe","apartment":1, "entry_id": 1,"password":"1213123", "phone":"380671234567","building_id": 2, "code":"1231"}`,
+			request: `{"email":"test@test.com","first_name":"John","last_name":"Doe","apartment":1, "entry_id": 1,"password":"kyiv-gates-42", "phone":"380671234567","building_id": 2, "code":"1231"}`,
 			wantErr: true,
 		},
 		{
@@ -227,7 +241,7 @@ func TestHTTP_Register(t *testing.T) {
 					return nil, errTestError
 				},
 			},
-			request: `{"email":"test@mail.com","first_name":"John","last_name":"Doe","apartment":1, "entry_id": 1,"password":"1213123", "phone":"380671234567","building_id": 2, "code":"1231"}`,
+			request: `{"email":"test@mail.com","first_name":"John","last_name":"Doe","apartment":1, "entry_id": 1,"password":"kyiv-gates-42", "phone":"380671234567","building_id": 2, "code":"1231"}`,
 			wantErr: true,
 		},
 		{
@@ -240,7 +254,7 @@ func TestHTTP_Register(t *testing.T) {
 					}, nil
 				},
 			},
-			request: `{"email":"test@mail.com","first_name":"John", "entry_id": 1,"last_name":"Doe","apartment":1,"password":"1213123", "phone":"+380671234567","building_id": 2, "code":"1231", "entry_id":1}`,
+			request: `{"email":"test@mail.com","first_name":"John", "entry_id": 1,"last_name":"Doe","apartment":1,"password":"kyiv-gates-42", "phone":"+380671234567","building_id": 2, "code":"1231", "entry_id":1}`,
 			wantErr: false,
 			want:    `{"id":1,"phone":"380671234567"}`,
 		},
@@ -626,8 +640,12 @@ func TestHTTP_UpdateUser(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name:    "error invalid password",
-			svc:     nil,
+			name: "error invalid password",
+			svc: &transport.UsersServiceMock{
+				UpdateFunc: func(_ context.Context, _ *users.UserUpdate) error {
+					return errs.PasswordTooShort
+				},
+			},
 			header:  "1",
 			request: `{"new_password":"a", "password":"1", "new_password_confirm":"a"}`,
 			wantErr: true,
@@ -636,14 +654,14 @@ func TestHTTP_UpdateUser(t *testing.T) {
 			name: "error password not updated",
 			svc: &transport.UsersServiceMock{
 				UpdateFunc: func(_ context.Context, req *users.UserUpdate) error {
-					if *req.NewPassword != "1234567" {
+					if *req.NewPassword != "kyiv-gates-42" {
 						return errTestError
 					}
 					return nil
 				},
 			},
 			header:  "1",
-			request: `{"new_password":"1234567", "password":"1", "new_password_confirm":"1234567"}`,
+			request: `{"new_password":"kyiv-gates-42", "password":"1", "new_password_confirm":"kyiv-gates-42"}`,
 			wantErr: false,
 		},
 		{
@@ -726,7 +744,7 @@ func TestHTTPTransport_PasswordRecoveryRequest(t *testing.T) {
 			name:    "error service",
 			request: `{"email":"test@mail.com", "phone":"123456789012"}`,
 			svc: &transport.UsersServiceMock{
-				RecoveryCodeFunc: func(_ context.Context, _ *users.User) error {
+				RecoveryCodeFunc: func(_ context.Context, _ *users.User, _ string) error {
 					return errTestError
 				},
 			},
@@ -736,7 +754,7 @@ func TestHTTPTransport_PasswordRecoveryRequest(t *testing.T) {
 			name:    "ok",
 			request: `{"email":"test@mail.com", "phone":"123456789012"}`,
 			svc: &transport.UsersServiceMock{
-				RecoveryCodeFunc: func(_ context.Context, _ *users.User) error {
+				RecoveryCodeFunc: func(_ context.Context, _ *users.User, _ string) error {
 					return nil
 				},
 			},
@@ -796,14 +814,18 @@ func TestHTTPTransport_PasswordResetRequest(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name:    "error bad password",
-			svc:     nil,
+			name: "error bad password",
+			svc: &transport.UsersServiceMock{
+				ResetPasswordFunc: func(_ context.Context, _ string, _ *users.UserUpdate) error {
+					return errs.PasswordTooShort
+				},
+			},
 			request: `{"code":"1234567890","new_password":"pass", "new_password_confirm":"pass"}`,
 			wantErr: true,
 		},
 		{
 			name:    "error service",
-			request: `{"code":"1234567890","new_password":"passwd", "new_password_confirm":"passwd"}`,
+			request: `{"code":"1234567890","new_password":"kyiv-gates-42", "new_password_confirm":"kyiv-gates-42"}`,
 			svc: &transport.UsersServiceMock{
 				ResetPasswordFunc: func(_ context.Context, _ string, _ *users.UserUpdate) error {
 					return errTestError
@@ -813,7 +835,7 @@ func TestHTTPTransport_PasswordResetRequest(t *testing.T) {
 		},
 		{
 			name:    "ok",
-			request: `{"code":"1234567890","new_password":"passwd", "new_password_confirm":"passwd"}`,
+			request: `{"code":"1234567890","new_password":"kyiv-gates-42", "new_password_confirm":"kyiv-gates-42"}`,
 			svc: &transport.UsersServiceMock{
 				ResetPasswordFunc: func(_ context.Context, _ string, _ *users.UserUpdate) error {
 					return nil
@@ -926,3 +948,118 @@ func TestHTTP_AdminResetApartment(t *testing.T) {
 		})
 	}
 }
+
+func TestHTTP_UploadAvatar(t *testing.T) {
+	tests := []struct {
+		name     string
+		svc      transport.UsersService
+		noFile   bool
+		want     string
+		wantCode int
+	}{
+		{
+			name:     "error no file",
+			noFile:   true,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "error wrong type",
+			svc: &transport.UsersServiceMock{
+				UploadAvatarFunc: func(_ context.Context, _ uint, _ []byte) (*users.User, error) {
+					return nil, errs.FileWrongType
+				},
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "error service",
+			svc: &transport.UsersServiceMock{
+				UploadAvatarFunc: func(_ context.Context, _ uint, _ []byte) (*users.User, error) {
+					return nil, errTestError
+				},
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name: "ok",
+			svc: &transport.UsersServiceMock{
+				UploadAvatarFunc: func(_ context.Context, userID uint, file []byte) (*users.User, error) {
+					if userID != 1 || string(file) != "photo" {
+						return nil, errTestError
+					}
+					return &users.User{AvatarURL: map[string]string{"img": "i", "thumb": "t"}}, nil
+				},
+			},
+			want:     `{"avatar":{"img":"i","thumb":"t"}}`,
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				body   = new(bytes.Buffer)
+				writer = multipart.NewWriter(body)
+			)
+			if !tt.noFile {
+				part, _ := writer.CreateFormFile("photo", "me.jpg")
+				_, _ = part.Write([]byte("photo"))
+			}
+			_ = writer.Close()
+
+			h := transport.NewHTTPTransport(defaultLogger, tt.svc, defaultPolicy, middlewares.NewIDCtx(defaultLogger).Middleware)
+			rr := httptest.NewRecorder()
+			rq, _ := http.NewRequest(http.MethodPost, "/v1/user/avatar", body)
+			rq.Header.Set("Content-Type", writer.FormDataContentType())
+			rq.Header.Add("X-Auth-User", "1")
+			h.ServeHTTP(rr, rq)
+			if rr.Code != tt.wantCode {
+				t.Errorf("Request error. status = %d, wantCode = %d", rr.Code, tt.wantCode)
+			}
+
+			if tt.want != "" && tt.want != strings.TrimSpace(rr.Body.String()) {
+				t.Errorf("Response error, got = %s, want = %s", rr.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTP_DeleteAvatar(t *testing.T) {
+	tests := []struct {
+		name     string
+		svc      transport.UsersService
+		wantCode int
+	}{
+		{
+			name: "error service",
+			svc: &transport.UsersServiceMock{
+				DeleteAvatarFunc: func(_ context.Context, _ uint) error {
+					return errTestError
+				},
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name: "ok",
+			svc: &transport.UsersServiceMock{
+				DeleteAvatarFunc: func(_ context.Context, _ uint) error {
+					return nil
+				},
+			},
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := transport.NewHTTPTransport(defaultLogger, tt.svc, defaultPolicy, middlewares.NewIDCtx(defaultLogger).Middleware)
+			rr := httptest.NewRecorder()
+			rq, _ := http.NewRequest(http.MethodDelete, "/v1/user/avatar", nil)
+			rq.Header.Add("X-Auth-User", "1")
+			h.ServeHTTP(rr, rq)
+			if rr.Code != tt.wantCode {
+				t.Errorf("Request error. status = %d, wantCode = %d", rr.Code, tt.wantCode)
+			}
+		})
+	}
+}
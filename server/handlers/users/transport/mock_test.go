@@ -25,13 +25,16 @@ var _ UsersService = &UsersServiceMock{}
 //			AdminResetApartmentFunc: func(ctx context.Context, adminID uint, buildingID uint, apartmentNumber uint) (string, error) {
 //				panic("mock out the AdminResetApartment method")
 //			},
+//			DeleteAvatarFunc: func(ctx context.Context, userID uint) error {
+//				panic("mock out the DeleteAvatar method")
+//			},
 //			DeleteFamilyMemberFunc: func(ctx context.Context, ownerID uint, memberID uint) error {
 //				panic("mock out the DeleteFamilyMember method")
 //			},
 //			ListFamilyMembersFunc: func(ctx context.Context, id uint) ([]*users.User, error) {
 //				panic("mock out the ListFamilyMembers method")
 //			},
-//			RecoveryCodeFunc: func(ctx context.Context, r *users.User) error {
+//			RecoveryCodeFunc: func(ctx context.Context, r *users.User, lang string) error {
 //				panic("mock out the RecoveryCode method")
 //			},
 //			RegisterFunc: func(ctx context.Context, req *users.User) (*users.User, error) {
@@ -43,6 +46,9 @@ var _ UsersService = &UsersServiceMock{}
 //			UpdateFunc: func(ctx context.Context, req *users.UserUpdate) error {
 //				panic("mock out the Update method")
 //			},
+//			UploadAvatarFunc: func(ctx context.Context, userID uint, file []byte) (*users.User, error) {
+//				panic("mock out the UploadAvatar method")
+//			},
 //			UserByIDFunc: func(ctx context.Context, id uint) (*users.User, error) {
 //				panic("mock out the UserByID method")
 //			},
@@ -59,6 +65,9 @@ type UsersServiceMock struct {
 	// AdminResetApartmentFunc mocks the AdminResetApartment method.
 	AdminResetApartmentFunc func(ctx context.Context, adminID uint, buildingID uint, apartmentNumber uint) (string, error)
 
+	// DeleteAvatarFunc mocks the DeleteAvatar method.
+	DeleteAvatarFunc func(ctx context.Context, userID uint) error
+
 	// DeleteFamilyMemberFunc mocks the DeleteFamilyMember method.
 	DeleteFamilyMemberFunc func(ctx context.Context, ownerID uint, memberID uint) error
 
@@ -66,7 +75,7 @@ type UsersServiceMock struct {
 	ListFamilyMembersFunc func(ctx context.Context, id uint) ([]*users.User, error)
 
 	// RecoveryCodeFunc mocks the RecoveryCode method.
-	RecoveryCodeFunc func(ctx context.Context, r *users.User) error
+	RecoveryCodeFunc func(ctx context.Context, r *users.User, lang string) error
 
 	// RegisterFunc mocks the Register method.
 	RegisterFunc func(ctx context.Context, req *users.User) (*users.User, error)
@@ -77,6 +86,9 @@ type UsersServiceMock struct {
 	// UpdateFunc mocks the Update method.
 	UpdateFunc func(ctx context.Context, req *users.UserUpdate) error
 
+	// UploadAvatarFunc mocks the UploadAvatar method.
+	UploadAvatarFunc func(ctx context.Context, userID uint, file []byte) (*users.User, error)
+
 	// UserByIDFunc mocks the UserByID method.
 	UserByIDFunc func(ctx context.Context, id uint) (*users.User, error)
 
@@ -100,6 +112,13 @@ type UsersServiceMock struct {
 			// ApartmentNumber is the apartmentNumber argument value.
 			ApartmentNumber uint
 		}
+		// DeleteAvatar holds details about calls to the DeleteAvatar method.
+		DeleteAvatar []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// UserID is the userID argument value.
+			UserID uint
+		}
 		// DeleteFamilyMember holds details about calls to the DeleteFamilyMember method.
 		DeleteFamilyMember []struct {
 			// Ctx is the ctx argument value.
@@ -122,6 +141,8 @@ type UsersServiceMock struct {
 			Ctx context.Context
 			// R is the r argument value.
 			R *users.User
+			// Lang is the lang argument value.
+			Lang string
 		}
 		// Register holds details about calls to the Register method.
 		Register []struct {
@@ -146,6 +167,15 @@ type UsersServiceMock struct {
 			// Req is the req argument value.
 			Req *users.UserUpdate
 		}
+		// UploadAvatar holds details about calls to the UploadAvatar method.
+		UploadAvatar []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// UserID is the userID argument value.
+			UserID uint
+			// File is the file argument value.
+			File []byte
+		}
 		// UserByID holds details about calls to the UserByID method.
 		UserByID []struct {
 			// Ctx is the ctx argument value.
@@ -156,12 +186,14 @@ type UsersServiceMock struct {
 	}
 	lockAddFamilyMember     sync.RWMutex
 	lockAdminResetApartment sync.RWMutex
+	lockDeleteAvatar        sync.RWMutex
 	lockDeleteFamilyMember  sync.RWMutex
 	lockListFamilyMembers   sync.RWMutex
 	lockRecoveryCode        sync.RWMutex
 	lockRegister            sync.RWMutex
 	lockResetPassword       sync.RWMutex
 	lockUpdate              sync.RWMutex
+	lockUploadAvatar        sync.RWMutex
 	lockUserByID            sync.RWMutex
 }
 
@@ -245,6 +277,42 @@ func (mock *UsersServiceMock) AdminResetApartmentCalls() []struct {
 	return calls
 }
 
+// DeleteAvatar calls DeleteAvatarFunc.
+func (mock *UsersServiceMock) DeleteAvatar(ctx context.Context, userID uint) error {
+	if mock.DeleteAvatarFunc == nil {
+		panic("UsersServiceMock.DeleteAvatarFunc: method is nil but UsersService.DeleteAvatar was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		UserID uint
+	}{
+		Ctx:    ctx,
+		UserID: userID,
+	}
+	mock.lockDeleteAvatar.Lock()
+	mock.calls.DeleteAvatar = append(mock.calls.DeleteAvatar, callInfo)
+	mock.lockDeleteAvatar.Unlock()
+	return mock.DeleteAvatarFunc(ctx, userID)
+}
+
+// DeleteAvatarCalls gets all the calls that were made to DeleteAvatar.
+// Check the length with:
+//
+//	len(mockedUsersService.DeleteAvatarCalls())
+func (mock *UsersServiceMock) DeleteAvatarCalls() []struct {
+	Ctx    context.Context
+	UserID uint
+} {
+	var calls []struct {
+		Ctx    context.Context
+		UserID uint
+	}
+	mock.lockDeleteAvatar.RLock()
+	calls = mock.calls.DeleteAvatar
+	mock.lockDeleteAvatar.RUnlock()
+	return calls
+}
+
 // DeleteFamilyMember calls DeleteFamilyMemberFunc.
 func (mock *UsersServiceMock) DeleteFamilyMember(ctx context.Context, ownerID uint, memberID uint) error {
 	if mock.DeleteFamilyMemberFunc == nil {
@@ -322,21 +390,23 @@ func (mock *UsersServiceMock) ListFamilyMembersCalls() []struct {
 }
 
 // RecoveryCode calls RecoveryCodeFunc.
-func (mock *UsersServiceMock) RecoveryCode(ctx context.Context, r *users.User) error {
+func (mock *UsersServiceMock) RecoveryCode(ctx context.Context, r *users.User, lang string) error {
 	if mock.RecoveryCodeFunc == nil {
 		panic("UsersServiceMock.RecoveryCodeFunc: method is nil but UsersService.RecoveryCode was just called")
 	}
 	callInfo := struct {
-		Ctx context.Context
-		R   *users.User
+		Ctx  context.Context
+		R    *users.User
+		Lang string
 	}{
-		Ctx: ctx,
-		R:   r,
+		Ctx:  ctx,
+		R:    r,
+		Lang: lang,
 	}
 	mock.lockRecoveryCode.Lock()
 	mock.calls.RecoveryCode = append(mock.calls.RecoveryCode, callInfo)
 	mock.lockRecoveryCode.Unlock()
-	return mock.RecoveryCodeFunc(ctx, r)
+	return mock.RecoveryCodeFunc(ctx, r, lang)
 }
 
 // RecoveryCodeCalls gets all the calls that were made to RecoveryCode.
@@ -344,12 +414,14 @@ func (mock *UsersServiceMock) RecoveryCode(ctx context.Context, r *users.User) e
 //
 //	len(mockedUsersService.RecoveryCodeCalls())
 func (mock *UsersServiceMock) RecoveryCodeCalls() []struct {
-	Ctx context.Context
-	R   *users.User
+	Ctx  context.Context
+	R    *users.User
+	Lang string
 } {
 	var calls []struct {
-		Ctx context.Context
-		R   *users.User
+		Ctx  context.Context
+		R    *users.User
+		Lang string
 	}
 	mock.lockRecoveryCode.RLock()
 	calls = mock.calls.RecoveryCode
@@ -469,6 +541,46 @@ func (mock *UsersServiceMock) UpdateCalls() []struct {
 	return calls
 }
 
+// UploadAvatar calls UploadAvatarFunc.
+func (mock *UsersServiceMock) UploadAvatar(ctx context.Context, userID uint, file []byte) (*users.User, error) {
+	if mock.UploadAvatarFunc == nil {
+		panic("UsersServiceMock.UploadAvatarFunc: method is nil but UsersService.UploadAvatar was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		UserID uint
+		File   []byte
+	}{
+		Ctx:    ctx,
+		UserID: userID,
+		File:   file,
+	}
+	mock.lockUploadAvatar.Lock()
+	mock.calls.UploadAvatar = append(mock.calls.UploadAvatar, callInfo)
+	mock.lockUploadAvatar.Unlock()
+	return mock.UploadAvatarFunc(ctx, userID, file)
+}
+
+// UploadAvatarCalls gets all the calls that were made to UploadAvatar.
+// Check the length with:
+//
+//	len(mockedUsersService.UploadAvatarCalls())
+func (mock *UsersServiceMock) UploadAvatarCalls() []struct {
+	Ctx    context.Context
+	UserID uint
+	File   []byte
+} {
+	var calls []struct {
+		Ctx    context.Context
+		UserID uint
+		File   []byte
+	}
+	mock.lockUploadAvatar.RLock()
+	calls = mock.calls.UploadAvatar
+	mock.lockUploadAvatar.RUnlock()
+	return calls
+}
+
 // UserByID calls UserByIDFunc.
 func (mock *UsersServiceMock) UserByID(ctx context.Context, id uint) (*users.User, error) {
 	if mock.UserByIDFunc == nil {
@@ -14,8 +14,10 @@ func (c contextKey) String() string {
 }
 
 const (
-	UserIDHeader contextKey = "X-Auth-User"
-	UserIDCtxKey contextKey = "userID"
+	UserIDHeader    contextKey = "X-Auth-User"
+	UserIDCtxKey    contextKey = "userID"
+	SessionIDHeader contextKey = "X-Auth-Session"
+	SessionIDCtxKey contextKey = "sessionID"
 )
 
 func NewIDCtx(log logger.Logger) *IDCtx { return &IDCtx{log: log} }
@@ -28,7 +30,10 @@ func (m *IDCtx) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		if v := r.Header.Get(UserIDHeader.String()); v != "" {
-			ctx = context.WithValue(r.Context(), UserIDCtxKey, v)
+			ctx = context.WithValue(ctx, UserIDCtxKey, v)
+		}
+		if v := r.Header.Get(SessionIDHeader.String()); v != "" {
+			ctx = context.WithValue(ctx, SessionIDCtxKey, v)
 		}
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -50,3 +55,8 @@ func UserIDFromContext(ctx context.Context) (string, bool) {
 	id, ok := v.(string)
 	return id, ok
 }
+
+func SessionIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(SessionIDCtxKey).(string)
+	return id, ok
+}
@@ -36,3 +36,9 @@ func (w *Wrapper) WriteHeader(statusCode int) {
 	w.code = statusCode
 	w.w.WriteHeader(statusCode)
 }
+
+// Unwrap returns the original http.ResponseWriter, so http.ResponseController
+// can reach optional interfaces like http.Flusher.
+func (w *Wrapper) Unwrap() http.ResponseWriter {
+	return w.w
+}
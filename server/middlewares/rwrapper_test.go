@@ -23,3 +23,16 @@ func TestNewResponseWrapper(t *testing.T) {
 		t.Error("response recorder invalid header key")
 	}
 }
+
+func TestResponseWrapper_Unwrap(t *testing.T) {
+	w := httptest.NewRecorder()
+	ww := middlewares.NewResponseWrapper(w)
+
+	if ww.Unwrap() != w {
+		t.Error("unwrap returned wrong response writer")
+	}
+
+	if err := http.NewResponseController(ww).Flush(); err != nil {
+		t.Errorf("flush through wrapper failed: %v", err)
+	}
+}